@@ -0,0 +1,9 @@
+// Package api содержит контракты API: OpenAPI спецификацию REST и protobuf определения gRPC
+package api
+
+import _ "embed"
+
+// OpenAPISpec - OpenAPI 3 документ REST API, отдается по /openapi.json и используется для валидации запросов
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
@@ -1,17 +1,39 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"wallet-api/api"
 	"wallet-api/config"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/grpcapi"
 	"wallet-api/internal/handler"
+	"wallet-api/internal/importer"
+	"wallet-api/internal/metrics"
 	"wallet-api/internal/middleware"
+	"wallet-api/internal/models"
+	"wallet-api/internal/openapi"
+	"wallet-api/internal/outbox"
+	"wallet-api/internal/ratelimit"
+	"wallet-api/internal/reconcile"
 	"wallet-api/internal/repository"
+	"wallet-api/internal/scheduler"
 	"wallet-api/internal/service"
+	"wallet-api/internal/snapshot"
+	"wallet-api/internal/stream"
+	"wallet-api/internal/webhook"
+	"wallet-api/pkg/receipt"
+	"wallet-api/utils"
 	"wallet-api/utils/logger"
 
 	_ "github.com/lib/pq"
@@ -48,13 +70,360 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// wallet-api verify-ledger [-wallet <uuid>] проверяет журнал и завершается, не запуская сервер
+	if len(os.Args) > 1 && os.Args[1] == "verify-ledger" {
+		code := runVerifyLedger(db, os.Args[2:])
+		db.Close()
+		os.Exit(code)
+	}
+
 	walletRepo := repository.NewWalletRepository(db)
-	walletService := service.NewWalletService(walletRepo)
+	receiptSigner, receiptKeys, err := newReceiptKeys()
+	if err != nil {
+		logger.GlobalLogger.Error("Ошибка загрузки ключей квитанций: %v", err)
+		log.Fatal(err)
+	}
+	walletService := service.NewWalletService(walletRepo).WithReceiptSigner(receiptSigner)
 	walletHandler := handler.NewWalletHandler(walletService)
 
-	http.HandleFunc("/api/v1/wallet", middleware.LoggingMiddleware(walletHandler.HandleWalletOperation))
-	http.HandleFunc("/api/v1/wallets/", middleware.LoggingMiddleware(walletHandler.HandleGetWallet))
+	broker := stream.NewBroker()
+	walletEventsHandler := handler.NewWalletEventsHandler(walletHandler, broker, config.Cnf.StreamHeartbeatInterval)
+
+	apiClientRepo := repository.NewAPIClientRepository(db)
+	authService := service.NewAuthService(apiClientRepo, config.Cnf.APIKeyRotationOverlap)
+	adminHandler := handler.NewAdminHandler(authService)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	limitHandler := handler.NewLimitHandler(service.NewLimitService(repository.NewLimitRepository(db)))
+	feeHandler := handler.NewFeeHandler(service.NewFeeService(repository.NewFeeRepository(db)), walletHandler)
+	accountingHandler := handler.NewAccountingHandler(service.NewAccountingService(repository.NewJournalRepository(db), reconciliationRepo))
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookService := service.NewWebhookService(webhookRepo)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+
+	importRepo := repository.NewImportRepository(db)
+	importHandler := handler.NewImportHandler(service.NewImportService(importRepo))
+
+	scheduledTransferRepo := repository.NewScheduledTransferRepository(db)
+	scheduledTransferHandler := handler.NewScheduledTransferHandler(service.NewScheduledTransferService(scheduledTransferRepo), walletHandler)
+
+	var rateLimiter *middleware.RateLimiter
+	chain := middleware.NewChain(
+		middleware.RequestIDMiddleware,
+		middleware.LoggingMiddleware,
+		middleware.RecoveryMiddleware,
+	)
+
+	if config.Cnf.RateLimitEnabled {
+		rateLimiter = newRateLimiter()
+		chain = chain.Append(rateLimiter.PerIP)
+	}
+
+	operationChain, readChain, adminChain, webhookChain, importChain, scheduleChain := chain, chain, chain, chain, chain, chain
+	var grpcAuthenticator grpcapi.Authenticator
+	switch config.Cnf.AuthMode {
+	case config.AuthModeAPIKey:
+		apiKeyAuth := middleware.NewAPIKeyAuth(authService)
+		signatureAuth := middleware.NewSignatureAuth(authService, auth.NewMemoryNonceCache(), config.Cnf.SignatureClockSkew)
+		clientAuth := middleware.SignatureOrAPIKey(signatureAuth, apiKeyAuth)
+		operationChain = chain.Append(clientAuth, middleware.RequireScope(models.ScopeWalletDeposit, models.ScopeWalletWithdraw))
+		readChain = chain.Append(clientAuth, middleware.RequireScope(models.ScopeWalletRead))
+		adminChain = chain.Append(apiKeyAuth.Authenticate, middleware.RequireScope(models.ScopeAdmin))
+		webhookChain = chain.Append(clientAuth, middleware.RequireScope(models.ScopeWalletRead))
+		importChain = chain.Append(clientAuth, middleware.RequireScope(models.ScopeWalletDeposit, models.ScopeWalletWithdraw))
+		scheduleChain = chain.Append(clientAuth, middleware.RequireScope(models.ScopeWalletWithdraw))
+		grpcAuthenticator = grpcapi.NewAPIKeyAuthenticator(authService)
+	case config.AuthModeJWT:
+		verifier, err := newJWTVerifier()
+		if err != nil {
+			logger.GlobalLogger.Error("Ошибка загрузки ключей JWT: %v", err)
+			log.Fatal(err)
+		}
+		jwtAuth := middleware.NewJWTAuth(verifier)
+		operationChain = chain.Append(jwtAuth.Authenticate)
+		readChain = chain.Append(jwtAuth.Authenticate)
+		scheduleChain = chain.Append(jwtAuth.Authenticate)
+		grpcAuthenticator = grpcapi.NewJWTAuthenticator(verifier)
+	case config.AuthModeNone:
+		logger.GlobalLogger.Warning("Аутентификация отключена (AUTH_MODE=%s)", config.AuthModeNone)
+	default:
+		log.Fatalf("Неизвестный AUTH_MODE: %s", config.Cnf.AuthMode)
+	}
+
+	if rateLimiter != nil {
+		operationChain = operationChain.Append(rateLimiter.PerClient, rateLimiter.PerWallet)
+		readChain = readChain.Append(rateLimiter.PerClient, rateLimiter.PerWallet)
+		importChain = importChain.Append(rateLimiter.PerClient)
+		webhookChain = webhookChain.Append(rateLimiter.PerClient)
+		scheduleChain = scheduleChain.Append(rateLimiter.PerClient)
+	}
+
+	spec, err := openapi.Load(api.OpenAPISpec)
+	if err != nil {
+		logger.GlobalLogger.Error("Ошибка загрузки OpenAPI спецификации: %v", err)
+		log.Fatal(err)
+	}
+	validator := middleware.NewOpenAPIValidator(spec)
+	operationChain = operationChain.Append(validator.Validate)
+	readChain = readChain.Append(validator.Validate)
+	adminChain = adminChain.Append(validator.Validate)
+	webhookChain = webhookChain.Append(validator.Validate)
+	importChain = importChain.Append(validator.Validate)
+	scheduleChain = scheduleChain.Append(validator.Validate)
+
+	http.HandleFunc("/api/v1/wallet", operationChain.Then(walletHandler.HandleWalletOperation))
+	http.HandleFunc("/api/v1/wallets", readChain.Then(walletHandler.HandleListWallets))
+	http.HandleFunc("/api/v1/wallets/lookup", readChain.Then(walletHandler.HandleLookupWallets))
+	http.HandleFunc("/api/v1/wallets/", readChain.Then(handler.WalletRoutes(walletHandler.HandleGetWallet, map[string]http.HandlerFunc{
+		"events":    walletEventsHandler.HandleWalletEvents,
+		"statement": walletHandler.HandleWalletStatement,
+		"fee-quote": feeHandler.HandleFeeQuote,
+	})))
+	if config.Cnf.AuthMode == config.AuthModeAPIKey {
+		http.HandleFunc("/api/v1/admin/clients", adminChain.Then(adminHandler.HandleClients))
+		http.HandleFunc("/api/v1/admin/clients/", adminChain.Then(adminHandler.HandleClients))
+		http.HandleFunc("/api/v1/admin/trial-balance", adminChain.Then(accountingHandler.HandleTrialBalance))
+		http.HandleFunc("/api/v1/admin/reconciliation", adminChain.Then(accountingHandler.HandleReconciliation))
+		http.HandleFunc("/api/v1/admin/ledger/verify", adminChain.Then(accountingHandler.HandleLedgerVerification))
+		http.HandleFunc("/api/v1/admin/wallets/", adminChain.Then(handler.AdminWalletRoutes(map[string]http.HandlerFunc{
+			"limits":    limitHandler.HandleWalletLimits,
+			"overdraft": limitHandler.HandleWalletOverdraft,
+		})))
+		http.HandleFunc("/api/v1/admin/fee-rules", adminChain.Then(feeHandler.HandleFeeRules))
+	}
+	if config.Cnf.AuthMode != config.AuthModeJWT {
+		http.HandleFunc("/api/v1/webhooks", webhookChain.Then(webhookHandler.HandleWebhooks))
+		http.HandleFunc("/api/v1/webhooks/", webhookChain.Then(webhookHandler.HandleWebhooks))
+		http.HandleFunc("/api/v1/imports", importChain.Then(importHandler.HandleImports))
+		http.HandleFunc("/api/v1/imports/", importChain.Then(importHandler.HandleImports))
+	}
+	http.HandleFunc("/api/v1/scheduled-transfers", scheduleChain.Then(scheduledTransferHandler.HandleScheduledTransfers))
+	http.HandleFunc("/api/v1/scheduled-transfers/", scheduleChain.Then(scheduledTransferHandler.HandleScheduledTransfers))
+	http.Handle("/metrics", metrics.Handler())
+	http.HandleFunc("/openapi.json", chain.Then(handler.ServeOpenAPI(api.OpenAPISpec)))
+	http.HandleFunc("/.well-known/wallet-receipt-keys", chain.Then(handler.ServeReceiptKeys(receiptKeys)))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := broker.Listen(ctx, connStr, repository.LedgerChannel); err != nil {
+			logger.GlobalLogger.Error("Ошибка подписки на уведомления журнала: %v", err)
+		}
+	}()
+
+	if config.Cnf.OutboxEnabled {
+		publisher, err := newOutboxPublisher()
+		if err != nil {
+			logger.GlobalLogger.Error("Ошибка настройки outbox: %v", err)
+			log.Fatal(err)
+		}
+		if config.Cnf.WebhooksEnabled {
+			publisher = outbox.NewMultiPublisher(publisher, webhook.NewFanoutPublisher(webhookService))
+		}
+		dispatcher := outbox.NewDispatcher(repository.NewOutboxRepository(db), publisher, outbox.DispatcherConfig{
+			PollInterval: config.Cnf.OutboxPollInterval,
+			BatchSize:    config.Cnf.OutboxBatchSize,
+			MaxAttempts:  config.Cnf.OutboxMaxAttempts,
+			BaseBackoff:  time.Second,
+			MaxBackoff:   10 * time.Minute,
+			ClaimLease:   config.Cnf.OutboxClaimLease,
+		})
+		go dispatcher.Run(ctx)
+	}
+
+	if config.Cnf.WebhooksEnabled {
+		worker := webhook.NewWorker(webhookRepo, webhook.WorkerConfig{
+			PollInterval: config.Cnf.WebhookPollInterval,
+			BatchSize:    config.Cnf.WebhookBatchSize,
+			MaxAttempts:  config.Cnf.WebhookMaxAttempts,
+			BaseBackoff:  5 * time.Second,
+			MaxBackoff:   time.Hour,
+			Timeout:      config.Cnf.WebhookTimeout,
+			ClaimLease:   config.Cnf.WebhookClaimLease,
+		})
+		go worker.Run(ctx)
+	}
+
+	if config.Cnf.SnapshotEnabled {
+		go snapshot.NewWorker(walletRepo, config.Cnf.SnapshotInterval).Run(ctx)
+	}
+
+	if config.Cnf.ImportsEnabled {
+		go importer.NewWorker(importRepo, importer.WorkerConfig{
+			PollInterval: config.Cnf.ImportPollInterval,
+			BatchSize:    config.Cnf.ImportBatchSize,
+			StaleAfter:   config.Cnf.ImportStaleAfter,
+		}).Run(ctx)
+	}
+
+	if config.Cnf.ReconcileEnabled {
+		go reconcile.NewWorker(reconciliationRepo, reconcile.WorkerConfig{
+			Interval:   config.Cnf.ReconcileInterval,
+			ChunkSize:  config.Cnf.ReconcileChunkSize,
+			ChunkPause: config.Cnf.ReconcileChunkPause,
+			AutoFreeze: config.Cnf.ReconcileAutoFreeze,
+			StaleAfter: config.Cnf.ReconcileStaleAfter,
+		}).Run(ctx)
+	}
+
+	if config.Cnf.SchedulerEnabled {
+		jobs, err := newSchedulerJobs(db, scheduledTransferRepo)
+		if err != nil {
+			logger.GlobalLogger.Error("Ошибка настройки планировщика: %v", err)
+			log.Fatal(err)
+		}
+		sched := scheduler.New(repository.NewAdvisoryLock(db, scheduler.LeaderLockKey), config.Cnf.SchedulerInterval)
+		for _, job := range jobs {
+			sched.Register(job)
+		}
+		go sched.Run(ctx)
+	}
+
+	if config.Cnf.GRPCEnabled {
+		grpcServer := grpcapi.NewServer(grpcapi.NewWalletServer(walletService, broker), grpcAuthenticator, rateLimiter)
+		listener, err := net.Listen("tcp", ":"+config.Cnf.GRPCPort)
+		if err != nil {
+			logger.GlobalLogger.Error("Ошибка запуска gRPC сервера: %v", err)
+			log.Fatal(err)
+		}
+		go func() {
+			<-ctx.Done()
+			grpcServer.GracefulStop()
+		}()
+		go func() {
+			logger.GlobalLogger.Info("gRPC сервер запущен на порту %s", config.Cnf.GRPCPort)
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.GlobalLogger.Error("Ошибка gRPC сервера: %v", err)
+			}
+		}()
+	}
+
+	// Контексты запросов наследуют ctx, чтобы долгие SSE соединения закрывались при остановке
+	server := &http.Server{
+		Addr:        ":" + config.Cnf.HttpPort,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.GlobalLogger.Error("Ошибка остановки сервера: %v", err)
+		}
+	}()
 
 	logger.GlobalLogger.Info("Сервер запущен на порту %s", config.Cnf.HttpPort)
-	log.Fatal(http.ListenAndServe(":"+config.Cnf.HttpPort, nil))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	logger.GlobalLogger.Info("Сервер остановлен")
+}
+
+func newSchedulerJobs(db *sql.DB, scheduledTransferRepo repository.ScheduledTransferRepositoryInterface) ([]scheduler.Job, error) {
+	var jobs []scheduler.Job
+	if config.Cnf.ScheduledTransfersEnabled {
+		jobs = append(jobs, scheduler.NewTransferJob(scheduledTransferRepo, config.Cnf.ScheduledTransferBatchSize))
+	}
+	if config.Cnf.InterestEnabled {
+		rounding := utils.Rounding(config.Cnf.InterestRounding)
+		if !utils.IsValidRounding(rounding) {
+			return nil, fmt.Errorf("unknown interest rounding: %s", config.Cnf.InterestRounding)
+		}
+		if config.Cnf.InterestAnnualRateBP < 0 {
+			return nil, fmt.Errorf("INTEREST_ANNUAL_RATE_BP must not be negative")
+		}
+		jobs = append(jobs, scheduler.NewInterestJob(repository.NewInterestRepository(db), scheduler.InterestConfig{
+			AnnualRateBP: config.Cnf.InterestAnnualRateBP,
+			Rounding:     rounding,
+			BatchSize:    config.Cnf.InterestBatchSize,
+		}))
+	}
+	return jobs, nil
+}
+
+func newOutboxPublisher() (outbox.Publisher, error) {
+	switch config.Cnf.OutboxPublisher {
+	case config.OutboxPublisherLog:
+		return outbox.NewLogPublisher(), nil
+	case config.OutboxPublisherWebhook:
+		if config.Cnf.OutboxWebhookURL == "" {
+			return nil, fmt.Errorf("OUTBOX_WEBHOOK_URL is required for webhook publisher")
+		}
+		return outbox.NewWebhookPublisher(config.Cnf.OutboxWebhookURL, config.Cnf.OutboxWebhookTimeout), nil
+	}
+	return nil, fmt.Errorf("unknown outbox publisher: %s", config.Cnf.OutboxPublisher)
+}
+
+func newJWTVerifier() (*auth.JWTVerifier, error) {
+	keys := auth.NewKeySet()
+
+	if config.Cnf.JWTJWKSFile != "" {
+		if err := auth.LoadJWKSFile(keys, config.Cnf.JWTJWKSFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Cnf.JWTPublicKeyFile != "" {
+		data, err := os.ReadFile(config.Cnf.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read jwt public key: %w", err)
+		}
+		if err := keys.AddPEMPublicKey("", data); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Cnf.JWTHMACSecret != "" {
+		keys.AddHMACKey("hs256", []byte(config.Cnf.JWTHMACSecret))
+	}
+
+	if keys.Len() == 0 {
+		return nil, fmt.Errorf("no jwt keys configured")
+	}
+
+	return auth.NewJWTVerifier(keys, config.Cnf.JWTIssuer, config.Cnf.JWTAudience, config.Cnf.JWTLeeway), nil
+}
+
+// newReceiptKeys загружает ключ подписи квитанций и публикуемые ключи проверки.
+// Без RECEIPT_SIGNING_KEY_FILE квитанции не подписываются, а опубликованы только выведенные из оборота ключи
+func newReceiptKeys() (*receipt.Signer, *receipt.KeySet, error) {
+	keys := receipt.NewKeySet()
+	for _, path := range strings.Split(config.Cnf.ReceiptRetiredKeyFiles, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := receipt.LoadPublicKey(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys.Add(key)
+	}
+
+	if config.Cnf.ReceiptSigningKeyFile == "" {
+		return nil, keys, nil
+	}
+
+	key, err := receipt.LoadPrivateKey(config.Cnf.ReceiptSigningKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer := receipt.NewSigner(key)
+	keys.Add(signer.PublicKey())
+	logger.GlobalLogger.Info("Квитанции операций подписываются ключом %s", signer.KeyID())
+
+	return signer, keys, nil
+}
+
+func newRateLimiter() *middleware.RateLimiter {
+	return middleware.NewRateLimiter(
+		ratelimit.NewMemoryLimiter(config.Cnf.RateLimitIdleTTL),
+		middleware.RateLimitRules{
+			PerIP:          ratelimit.Rule{Rate: config.Cnf.RateLimitIPRate, Burst: config.Cnf.RateLimitIPBurst},
+			PerClient:      ratelimit.Rule{Rate: config.Cnf.RateLimitClientRate, Burst: config.Cnf.RateLimitClientBurst},
+			WalletRead:     ratelimit.Rule{Rate: config.Cnf.RateLimitReadRate, Burst: config.Cnf.RateLimitReadBurst},
+			WalletDeposit:  ratelimit.Rule{Rate: config.Cnf.RateLimitDepositRate, Burst: config.Cnf.RateLimitDepositBurst},
+			WalletWithdraw: ratelimit.Rule{Rate: config.Cnf.RateLimitWithdrawRate, Burst: config.Cnf.RateLimitWithdrawBurst},
+		},
+	)
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// runVerifyLedger выполняет команду verify-ledger: проходит цепочки хэшей журнала и печатает первое
+// нарушенное звено. Код выхода 0 - цепочки целы, 1 - найден разрыв, 2 - ошибка
+func runVerifyLedger(db *sql.DB, args []string) int {
+	flags := flag.NewFlagSet("verify-ledger", flag.ContinueOnError)
+	walletFlag := flags.String("wallet", "", "UUID кошелька; без флага проверяются все кошельки")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var walletID *uuid.UUID
+	if *walletFlag != "" {
+		id, err := uuid.Parse(*walletFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Неверный UUID кошелька: %s\n", *walletFlag)
+			return 2
+		}
+		walletID = &id
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	accounting := service.NewAccountingService(repository.NewJournalRepository(db), repository.NewReconciliationRepository(db))
+	result, err := accounting.VerifyLedgerChain(ctx, walletID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка проверки журнала: %v\n", err)
+		return 2
+	}
+
+	fmt.Printf("Проверено кошельков: %d, проводок: %d\n", result.WalletsChecked, result.EntriesChecked)
+	if broken := result.Break; broken != nil {
+		fmt.Printf("Цепочка нарушена: кошелек %s, проводка %d, причина %s\n", broken.WalletID, broken.EntryID, broken.Reason)
+		fmt.Printf("  ожидаемый хэш: %s\n  в журнале:     %s\n", hex.EncodeToString(broken.Expected), hex.EncodeToString(broken.Actual))
+		return 1
+	}
+
+	fmt.Println("Цепочки хэшей целы")
+	return 0
+}
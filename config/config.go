@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v6"
 )
 
@@ -8,6 +10,17 @@ const (
 	EnvLocal string = "local"
 )
 
+const (
+	OutboxPublisherLog     string = "log"
+	OutboxPublisherWebhook string = "webhook"
+)
+
+const (
+	AuthModeNone   string = "none"
+	AuthModeAPIKey string = "api_key"
+	AuthModeJWT    string = "jwt"
+)
+
 type Conf struct {
 	AppEnv string `env:"APP_ENV" envDefault:"local"`
 
@@ -20,6 +33,85 @@ type Conf struct {
 	PgDbName   string `env:"DB_NAME"`
 
 	MaxConnections int `env:"MAX_CONNECTIONS" envDefault:"100"`
+
+	AuthMode              string        `env:"AUTH_MODE" envDefault:"api_key"`
+	APIKeyRotationOverlap time.Duration `env:"API_KEY_ROTATION_OVERLAP" envDefault:"24h"`
+
+	SignatureClockSkew time.Duration `env:"SIGNATURE_CLOCK_SKEW" envDefault:"5m"`
+
+	RateLimitEnabled       bool          `env:"RATE_LIMIT_ENABLED" envDefault:"false"`
+	RateLimitIdleTTL       time.Duration `env:"RATE_LIMIT_IDLE_TTL" envDefault:"10m"`
+	RateLimitIPRate        float64       `env:"RATE_LIMIT_IP_RATE" envDefault:"200"`
+	RateLimitIPBurst       int           `env:"RATE_LIMIT_IP_BURST" envDefault:"400"`
+	RateLimitClientRate    float64       `env:"RATE_LIMIT_CLIENT_RATE" envDefault:"500"`
+	RateLimitClientBurst   int           `env:"RATE_LIMIT_CLIENT_BURST" envDefault:"1000"`
+	RateLimitReadRate      float64       `env:"RATE_LIMIT_WALLET_READ_RATE" envDefault:"100"`
+	RateLimitReadBurst     int           `env:"RATE_LIMIT_WALLET_READ_BURST" envDefault:"200"`
+	RateLimitDepositRate   float64       `env:"RATE_LIMIT_WALLET_DEPOSIT_RATE" envDefault:"50"`
+	RateLimitDepositBurst  int           `env:"RATE_LIMIT_WALLET_DEPOSIT_BURST" envDefault:"100"`
+	RateLimitWithdrawRate  float64       `env:"RATE_LIMIT_WALLET_WITHDRAW_RATE" envDefault:"10"`
+	RateLimitWithdrawBurst int           `env:"RATE_LIMIT_WALLET_WITHDRAW_BURST" envDefault:"20"`
+
+	OutboxEnabled        bool          `env:"OUTBOX_ENABLED" envDefault:"true"`
+	OutboxPublisher      string        `env:"OUTBOX_PUBLISHER" envDefault:"log"`
+	OutboxWebhookURL     string        `env:"OUTBOX_WEBHOOK_URL" envDefault:""`
+	OutboxWebhookTimeout time.Duration `env:"OUTBOX_WEBHOOK_TIMEOUT" envDefault:"5s"`
+	OutboxPollInterval   time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	OutboxBatchSize      int           `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
+	OutboxMaxAttempts    int           `env:"OUTBOX_MAX_ATTEMPTS" envDefault:"10"`
+	OutboxClaimLease     time.Duration `env:"OUTBOX_CLAIM_LEASE" envDefault:"1m"`
+
+	WebhooksEnabled     bool          `env:"WEBHOOKS_ENABLED" envDefault:"true"`
+	WebhookTimeout      time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+	WebhookPollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"1s"`
+	WebhookBatchSize    int           `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"`
+	WebhookMaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"12"`
+	WebhookClaimLease   time.Duration `env:"WEBHOOK_CLAIM_LEASE" envDefault:"2m"`
+
+	StreamHeartbeatInterval time.Duration `env:"STREAM_HEARTBEAT_INTERVAL" envDefault:"15s"`
+
+	SnapshotEnabled  bool          `env:"SNAPSHOT_ENABLED" envDefault:"true"`
+	SnapshotInterval time.Duration `env:"SNAPSHOT_INTERVAL" envDefault:"1h"`
+
+	ImportsEnabled     bool          `env:"IMPORTS_ENABLED" envDefault:"true"`
+	ImportPollInterval time.Duration `env:"IMPORT_POLL_INTERVAL" envDefault:"2s"`
+	ImportBatchSize    int           `env:"IMPORT_BATCH_SIZE" envDefault:"100"`
+	ImportStaleAfter   time.Duration `env:"IMPORT_STALE_AFTER" envDefault:"1m"`
+
+	ReconcileEnabled    bool          `env:"RECONCILE_ENABLED" envDefault:"true"`
+	ReconcileInterval   time.Duration `env:"RECONCILE_INTERVAL" envDefault:"1h"`
+	ReconcileChunkSize  int           `env:"RECONCILE_CHUNK_SIZE" envDefault:"500"`
+	ReconcileChunkPause time.Duration `env:"RECONCILE_CHUNK_PAUSE" envDefault:"100ms"`
+	ReconcileAutoFreeze bool          `env:"RECONCILE_AUTO_FREEZE" envDefault:"false"`
+	ReconcileStaleAfter time.Duration `env:"RECONCILE_STALE_AFTER" envDefault:"5m"`
+
+	// Планировщик выполняет задачи только на реплике, взявшей advisory-блокировку в Postgres
+	SchedulerEnabled  bool          `env:"SCHEDULER_ENABLED" envDefault:"true"`
+	SchedulerInterval time.Duration `env:"SCHEDULER_INTERVAL" envDefault:"1m"`
+
+	// Проценты на остаток: годовая ставка в базисных пунктах, начисляются ежедневно, выплачиваются ежемесячно
+	InterestEnabled      bool   `env:"INTEREST_ENABLED" envDefault:"false"`
+	InterestAnnualRateBP int64  `env:"INTEREST_ANNUAL_RATE_BP" envDefault:"0"`
+	InterestRounding     string `env:"INTEREST_ROUNDING" envDefault:"half_up"`
+	InterestBatchSize    int    `env:"INTEREST_BATCH_SIZE" envDefault:"500"`
+
+	ScheduledTransfersEnabled  bool `env:"SCHEDULED_TRANSFERS_ENABLED" envDefault:"true"`
+	ScheduledTransferBatchSize int  `env:"SCHEDULED_TRANSFER_BATCH_SIZE" envDefault:"100"`
+
+	// Закрытый ключ Ed25519 (PEM, PKCS #8) для подписи квитанций; пустое значение отключает квитанции
+	ReceiptSigningKeyFile string `env:"RECEIPT_SIGNING_KEY_FILE" envDefault:""`
+	// Публичные ключи (PEM) выведенных из оборота ключей через запятую, которые продолжают публиковаться
+	ReceiptRetiredKeyFiles string `env:"RECEIPT_RETIRED_KEY_FILES" envDefault:""`
+
+	GRPCEnabled bool   `env:"GRPC_ENABLED" envDefault:"true"`
+	GRPCPort    string `env:"GRPC_PORT" envDefault:"9090"`
+
+	JWTJWKSFile      string        `env:"JWT_JWKS_FILE" envDefault:""`
+	JWTPublicKeyFile string        `env:"JWT_PUBLIC_KEY_FILE" envDefault:""`
+	JWTHMACSecret    string        `env:"JWT_HMAC_SECRET" envDefault:""`
+	JWTIssuer        string        `env:"JWT_ISSUER" envDefault:""`
+	JWTAudience      string        `env:"JWT_AUDIENCE" envDefault:""`
+	JWTLeeway        time.Duration `env:"JWT_LEEWAY" envDefault:"30s"`
 }
 
 var Cnf Conf
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	apiKeyPrefix    = "wk_"
+	apiKeyBytes     = 32
+	keyPrefixLength = 10
+)
+
+// GenerateAPIKey возвращает новый ключ в открытом виде, его хэш для хранения в БД и короткий префикс для поиска в логах
+func GenerateAPIKey() (key, hash, prefix string, err error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	key = apiKeyPrefix + hex.EncodeToString(buf)
+	return key, HashAPIKey(key), key[:keyPrefixLength], nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
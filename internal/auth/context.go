@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"wallet-api/internal/models"
+)
+
+type clientContextKey struct{}
+
+type ownerContextKey struct{}
+
+func WithClient(ctx context.Context, client *models.APIClient) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+// ClientFromContext возвращает nil, если запрос не проходил через аутентификацию
+func ClientFromContext(ctx context.Context) *models.APIClient {
+	client, _ := ctx.Value(clientContextKey{}).(*models.APIClient)
+	return client
+}
+
+// WithOwner сохраняет владельца кошельков (subject из JWT) в контексте запроса
+func WithOwner(ctx context.Context, ownerID string) context.Context {
+	return context.WithValue(ctx, ownerContextKey{}, ownerID)
+}
+
+func OwnerFromContext(ctx context.Context) string {
+	ownerID, _ := ctx.Value(ownerContextKey{}).(string)
+	return ownerID
+}
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgHS256 = "HS256"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+}
+
+type verificationKey struct {
+	alg string
+	key interface{}
+}
+
+// KeySet хранит ключи проверки подписи JWT, индексированные по kid
+type KeySet struct {
+	keys map[string]verificationKey
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{keys: make(map[string]verificationKey)}
+}
+
+func (ks *KeySet) Len() int {
+	return len(ks.keys)
+}
+
+func (ks *KeySet) AddHMACKey(kid string, secret []byte) {
+	ks.keys[kid] = verificationKey{alg: AlgHS256, key: secret}
+}
+
+func (ks *KeySet) AddRSAKey(kid string, key *rsa.PublicKey) {
+	ks.keys[kid] = verificationKey{alg: AlgRS256, key: key}
+}
+
+func (ks *KeySet) AddECDSAKey(kid string, key *ecdsa.PublicKey) {
+	ks.keys[kid] = verificationKey{alg: AlgES256, key: key}
+}
+
+// AddPEMPublicKey добавляет RSA или P-256 публичный ключ в формате PEM (PKIX)
+func (ks *KeySet) AddPEMPublicKey(kid string, data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("parse pem public key: no pem block")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parse pem public key: %w", err)
+	}
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		ks.AddRSAKey(kid, key)
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return fmt.Errorf("parse pem public key: unsupported curve %s", key.Curve.Params().Name)
+		}
+		ks.AddECDSAKey(kid, key)
+	default:
+		return fmt.Errorf("parse pem public key: unsupported key type %T", pub)
+	}
+
+	return nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func LoadJWKSFile(ks *KeySet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read jwks file: %w", err)
+	}
+	return ks.AddJWKS(data)
+}
+
+// AddJWKS добавляет ключи из JWKS документа, ключи с use отличным от "sig" пропускаются
+func (ks *KeySet) AddJWKS(data []byte) error {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return fmt.Errorf("parse jwk %s: %w", k.Kid, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return fmt.Errorf("parse jwk %s: %w", k.Kid, err)
+			}
+			ks.AddRSAKey(k.Kid, &rsa.PublicKey{N: n, E: int(e.Int64())})
+		case "EC":
+			if k.Crv != "P-256" {
+				return fmt.Errorf("parse jwk %s: unsupported curve %s", k.Kid, k.Crv)
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return fmt.Errorf("parse jwk %s: %w", k.Kid, err)
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return fmt.Errorf("parse jwk %s: %w", k.Kid, err)
+			}
+			ks.AddECDSAKey(k.Kid, &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y})
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return fmt.Errorf("parse jwk %s: %w", k.Kid, err)
+			}
+			ks.AddHMACKey(k.Kid, secret)
+		default:
+			return fmt.Errorf("parse jwk %s: unsupported key type %s", k.Kid, k.Kty)
+		}
+	}
+
+	return nil
+}
+
+type JWTVerifier struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewJWTVerifier создает проверку токенов; пустые issuer и audience не проверяются
+func NewJWTVerifier(keys *KeySet, issuer, audience string, leeway time.Duration) *JWTVerifier {
+	return &JWTVerifier{keys: keys, issuer: issuer, audience: audience, leeway: leeway, now: time.Now}
+}
+
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("verify jwt: %w", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("verify jwt header: %w", ErrInvalidToken)
+	}
+
+	key, err := v.selectKey(header.Alg, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("verify jwt: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("verify jwt signature: %w", ErrInvalidToken)
+	}
+
+	if !verifySignature(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("verify jwt signature: %w", ErrInvalidToken)
+	}
+
+	var payload struct {
+		Sub string          `json:"sub"`
+		Iss string          `json:"iss"`
+		Aud json.RawMessage `json:"aud"`
+		Exp *json.Number    `json:"exp"`
+		Nbf *json.Number    `json:"nbf"`
+	}
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return nil, fmt.Errorf("verify jwt payload: %w", ErrInvalidToken)
+	}
+
+	claims := &Claims{Subject: payload.Sub, Issuer: payload.Iss}
+	if claims.Audience, err = parseAudience(payload.Aud); err != nil {
+		return nil, fmt.Errorf("verify jwt audience: %w", ErrInvalidToken)
+	}
+	if claims.ExpiresAt, err = parseNumericDate(payload.Exp); err != nil {
+		return nil, fmt.Errorf("verify jwt exp: %w", ErrInvalidToken)
+	}
+	if claims.NotBefore, err = parseNumericDate(payload.Nbf); err != nil {
+		return nil, fmt.Errorf("verify jwt nbf: %w", ErrInvalidToken)
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, fmt.Errorf("verify jwt claims: %w", err)
+	}
+
+	return claims, nil
+}
+
+func (v *JWTVerifier) selectKey(alg, kid string) (verificationKey, error) {
+	if alg != AlgRS256 && alg != AlgES256 && alg != AlgHS256 {
+		return verificationKey{}, ErrInvalidToken
+	}
+
+	if key, ok := v.keys.keys[kid]; ok {
+		// Алгоритм из заголовка должен совпадать с типом ключа, иначе возможна подмена alg
+		if key.alg != alg {
+			return verificationKey{}, ErrInvalidToken
+		}
+		return key, nil
+	}
+
+	if kid != "" {
+		return verificationKey{}, ErrUnknownKey
+	}
+
+	var found *verificationKey
+	for _, key := range v.keys.keys {
+		if key.alg != alg {
+			continue
+		}
+		if found != nil {
+			return verificationKey{}, ErrUnknownKey
+		}
+		k := key
+		found = &k
+	}
+	if found == nil {
+		return verificationKey{}, ErrUnknownKey
+	}
+
+	return *found, nil
+}
+
+func (v *JWTVerifier) validateClaims(claims *Claims) error {
+	now := v.now()
+
+	if claims.Subject == "" {
+		return ErrInvalidToken
+	}
+	if claims.ExpiresAt.IsZero() || now.After(claims.ExpiresAt.Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+	if !claims.NotBefore.IsZero() && now.Add(v.leeway).Before(claims.NotBefore) {
+		return ErrInvalidToken
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return ErrInvalidToken
+	}
+	if v.audience != "" {
+		for _, aud := range claims.Audience {
+			if aud == v.audience {
+				return nil
+			}
+		}
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+func verifySignature(key verificationKey, signingInput, signature []byte) bool {
+	hashed := sha256.Sum256(signingInput)
+
+	switch k := key.key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write(signingInput)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(k, hashed[:], r, s)
+	}
+
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+func parseAudience(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, err
+	}
+	return many, nil
+}
+
+func parseNumericDate(n *json.Number) (time.Time, error) {
+	if n == nil {
+		return time.Time{}, nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(f), 0), nil
+}
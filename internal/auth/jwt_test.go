@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hashed := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatalf("sign rsa: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, hashed[:])
+		if err != nil {
+			t.Fatalf("sign ecdsa: %v", err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	hmacSecret := []byte("super-secret")
+
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa-1","use":"sig","n":"%s","e":"AQAB"},
+		{"kty":"EC","kid":"ec-1","crv":"P-256","x":"%s","y":"%s"},
+		{"kty":"RSA","kid":"enc-1","use":"enc","n":"%s","e":"AQAB"}
+	]}`,
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+		base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+	)
+
+	keys := NewKeySet()
+	if err := keys.AddJWKS([]byte(jwks)); err != nil {
+		t.Fatalf("AddJWKS() unexpected error = %v", err)
+	}
+	keys.AddHMACKey("hs-1", hmacSecret)
+
+	now := time.Now()
+	verifier := NewJWTVerifier(keys, "https://id.example", "wallet-api", time.Minute)
+	verifier.now = func() time.Time { return now }
+
+	valid := map[string]interface{}{
+		"sub": "user-42",
+		"iss": "https://id.example",
+		"aud": []string{"wallet-api", "other"},
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := make(map[string]interface{}, len(valid))
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid RS256", signToken(t, AlgRS256, "rsa-1", rsaKey, valid), nil},
+		{"valid ES256", signToken(t, AlgES256, "ec-1", ecKey, valid), nil},
+		{"valid HS256", signToken(t, AlgHS256, "hs-1", hmacSecret, valid), nil},
+		{"HS256 without kid", signToken(t, AlgHS256, "", hmacSecret, valid), nil},
+		{"string audience", signToken(t, AlgHS256, "hs-1", hmacSecret, with("aud", "wallet-api")), nil},
+		{"expired", signToken(t, AlgHS256, "hs-1", hmacSecret, with("exp", now.Add(-2*time.Minute).Unix())), ErrTokenExpired},
+		{"expired within leeway", signToken(t, AlgHS256, "hs-1", hmacSecret, with("exp", now.Add(-30*time.Second).Unix())), nil},
+		{"not yet valid", signToken(t, AlgHS256, "hs-1", hmacSecret, with("nbf", now.Add(time.Hour).Unix())), ErrInvalidToken},
+		{"wrong issuer", signToken(t, AlgHS256, "hs-1", hmacSecret, with("iss", "https://evil.example")), ErrInvalidToken},
+		{"wrong audience", signToken(t, AlgHS256, "hs-1", hmacSecret, with("aud", "other")), ErrInvalidToken},
+		{"missing subject", signToken(t, AlgHS256, "hs-1", hmacSecret, with("sub", "")), ErrInvalidToken},
+		{"wrong secret", signToken(t, AlgHS256, "hs-1", []byte("guess"), valid), ErrInvalidToken},
+		{"alg confusion", signToken(t, AlgHS256, "rsa-1", rsaKey.N.Bytes(), valid), ErrInvalidToken},
+		{"encryption key is ignored", signToken(t, AlgRS256, "enc-1", rsaKey, valid), ErrUnknownKey},
+		{"unknown kid", signToken(t, AlgRS256, "rsa-2", rsaKey, valid), ErrUnknownKey},
+		{"alg none", "eyJhbGciOiJub25lIn0.eyJzdWIiOiJ4In0.", ErrInvalidToken},
+		{"malformed", "not-a-token", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && claims.Subject != "user-42" {
+				t.Errorf("Verify() subject = %s, want user-42", claims.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceCache запоминает использованные подписи, чтобы отклонять повторы.
+// Для нескольких реплик реализацию можно заменить общим хранилищем
+type NonceCache interface {
+	// CheckAndStore возвращает false, если nonce уже встречался и еще не истек
+	CheckAndStore(nonce string, ttl time.Duration) bool
+}
+
+type MemoryNonceCache struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (c *MemoryNonceCache) CheckAndStore(nonce string, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.sweep(now, ttl)
+
+	if expiresAt, ok := c.entries[nonce]; ok && now.Before(expiresAt) {
+		return false
+	}
+
+	c.entries[nonce] = now.Add(ttl)
+	return true
+}
+
+func (c *MemoryNonceCache) sweep(now time.Time, interval time.Duration) {
+	if now.Sub(c.lastSweep) < interval {
+		return
+	}
+
+	for nonce, expiresAt := range c.entries {
+		if !now.Before(expiresAt) {
+			delete(c.entries, nonce)
+		}
+	}
+	c.lastSweep = now
+}
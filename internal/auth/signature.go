@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const signingSecretBytes = 32
+
+func GenerateSigningSecret() (string, error) {
+	buf := make([]byte, signingSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate signing secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CanonicalRequest строит строку для подписи: метод, путь с query, timestamp и SHA-256 тела, разделенные переводом строки
+func CanonicalRequest(method, path, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		timestamp,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// SignRequest возвращает HMAC-SHA256 подпись запроса в hex
+func SignRequest(secret []byte, method, path, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(CanonicalRequest(method, path, timestamp, body)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyRequestSignature(secret []byte, signature, method, path, timestamp string, body []byte) bool {
+	expected := SignRequest(secret, method, path, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
@@ -0,0 +1,109 @@
+package grpcapi
+
+import (
+	"context"
+	stdErrors "errors"
+	"strings"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/service"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyMetadataKey        = "x-api-key"
+	signatureMetadataKey     = "x-signature"
+	authorizationMetadataKey = "authorization"
+	apiKeyAuthScheme         = "ApiKey "
+	bearerAuthScheme         = "Bearer "
+)
+
+// Authenticator проверяет учетные данные из metadata и возвращает контекст с клиентом или владельцем
+type Authenticator func(ctx context.Context, md metadata.MD) (context.Context, error)
+
+// NewAPIKeyAuthenticator - аналог middleware.APIKeyAuth: ключ берется из x-api-key или "authorization: ApiKey <key>".
+// HMAC подпись, которую HTTP принимает вместо ключа, в gRPC не поддерживается: каноническая форма подписи
+// строится по телу HTTP запроса, а у protobuf сообщения нет однозначного байтового представления
+func NewAPIKeyAuthenticator(authService service.AuthServiceInterface) Authenticator {
+	return func(ctx context.Context, md metadata.MD) (context.Context, error) {
+		key := firstMetadataValue(md, apiKeyMetadataKey)
+		if key == "" {
+			key = credentialsWithScheme(md, apiKeyAuthScheme)
+		}
+		if key == "" && firstMetadataValue(md, signatureMetadataKey) != "" {
+			return nil, status.Error(codes.Unauthenticated, "Подпись запросов в gRPC не поддерживается, используйте API ключ")
+		}
+		if key == "" {
+			return nil, status.Error(codes.Unauthenticated, "API ключ не передан")
+		}
+
+		client, err := authService.AuthenticateAPIKey(key)
+		if err != nil {
+			if stdErrors.Is(err, service.ErrInvalidAPIKey) {
+				return nil, status.Error(codes.Unauthenticated, "Неверный API ключ")
+			}
+			logger.GlobalLogger.Error("Ошибка проверки API ключа: %v - RequestID: %s", err, requestid.FromContext(ctx))
+			return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+		}
+
+		return auth.WithClient(ctx, client), nil
+	}
+}
+
+// NewJWTAuthenticator - аналог middleware.JWTAuth: subject токена становится владельцем кошельков
+func NewJWTAuthenticator(verifier *auth.JWTVerifier) Authenticator {
+	return func(ctx context.Context, md metadata.MD) (context.Context, error) {
+		token := credentialsWithScheme(md, bearerAuthScheme)
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "Токен не передан")
+		}
+
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			if stdErrors.Is(err, auth.ErrTokenExpired) {
+				return nil, status.Error(codes.Unauthenticated, "Срок действия токена истек")
+			}
+			return nil, status.Error(codes.Unauthenticated, "Неверный токен")
+		}
+
+		return auth.WithOwner(ctx, claims.Subject), nil
+	}
+}
+
+// authorizeWallet проверяет scope и список разрешенных кошельков клиента, как WalletHandler.authorizeWallet
+func authorizeWallet(ctx context.Context, walletID uuid.UUID, scope string) error {
+	client := auth.ClientFromContext(ctx)
+	if client == nil {
+		return nil
+	}
+
+	if !client.HasScope(scope) {
+		return status.Error(codes.PermissionDenied, "Недостаточно прав: требуется "+scope)
+	}
+	if !client.CanAccessWallet(walletID) {
+		return status.Error(codes.PermissionDenied, "Нет доступа к кошельку")
+	}
+
+	return nil
+}
+
+func firstMetadataValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+func credentialsWithScheme(md metadata.MD, scheme string) string {
+	authorization := firstMetadataValue(md, authorizationMetadataKey)
+	if len(authorization) <= len(scheme) || !strings.EqualFold(authorization[:len(scheme)], scheme) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(scheme):])
+}
@@ -0,0 +1,34 @@
+package grpcapi
+
+import (
+	stdErrors "errors"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusFromError переводит ошибки service/repository в канонические коды gRPC,
+// так же как handleServiceError делает это для HTTP
+func statusFromError(err error) error {
+	switch {
+	case stdErrors.Is(err, repository.ErrWalletNotFound):
+		return status.Error(codes.NotFound, "Кошелек не найден")
+	case stdErrors.Is(err, service.ErrWalletAccessDenied):
+		return status.Error(codes.PermissionDenied, "Нет доступа к кошельку")
+	case stdErrors.Is(err, service.ErrInsufficientFunds):
+		return status.Error(codes.FailedPrecondition, "Недостаточно средств")
+	case stdErrors.Is(err, service.ErrWalletFrozen):
+		return status.Error(codes.FailedPrecondition, "Кошелек заморожен")
+	case stdErrors.Is(err, service.ErrInvalidTransfer):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var limit *models.LimitExceededError
+	if stdErrors.As(err, &limit) {
+		return status.Error(codes.ResourceExhausted, limit.Message())
+	}
+	return status.Error(codes.Internal, "Внутренняя ошибка сервера")
+}
@@ -0,0 +1,121 @@
+package grpcapi
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Интерсепторы повторяют цепочку HTTP middleware: request ID, логирование, восстановление после паники, аутентификация
+
+var requestIDMetadataKey = strings.ToLower(requestid.HeaderName)
+
+// wrappedStream подменяет контекст серверного потока
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		id = firstMetadataValue(md, requestIDMetadataKey)
+	}
+	if id == "" || len(id) > 128 {
+		id = requestid.New()
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, id))
+	return requestid.WithRequestID(ctx, id)
+}
+
+func UnaryRequestID(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func StreamRequestID(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	if err == nil {
+		return
+	}
+	logger.GlobalLogger.Error(
+		"RPC failed: %s - Code: %s - Duration: %v - RequestID: %s",
+		method, status.Code(err), time.Since(start), requestid.FromContext(ctx),
+	)
+}
+
+func UnaryLogging(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func StreamLogging(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func recoverPanic(ctx context.Context, method string, err *error) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+
+	metrics.PanicsTotal.Add(1)
+	logger.GlobalLogger.Error(
+		"Panic recovered: %s - RequestID: %s - Panic: %v\n%s",
+		method, requestid.FromContext(ctx), rec, debug.Stack(),
+	)
+	*err = status.Error(codes.Internal, "Внутренняя ошибка сервера")
+}
+
+func UnaryRecovery(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer recoverPanic(ctx, info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+func StreamRecovery(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverPanic(ss.Context(), info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+func UnaryAuth(authenticate Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx, err := authenticate(ctx, md)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuth(authenticate Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		ctx, err := authenticate(ss.Context(), md)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
@@ -0,0 +1,111 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	walletv1 "wallet-api/api/wallet/v1"
+	"wallet-api/internal/middleware"
+	"wallet-api/internal/models"
+	"wallet-api/internal/ratelimit"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Лимиты gRPC повторяют HTTP middleware.RateLimiter с теми же ключами, поэтому клиент не может удвоить
+// квоту, распределив запросы между транспортами. Transfer, как и в HTTP, ограничивается только по клиенту
+
+const retryAfterMetadataKey = "retry-after"
+
+// UnaryRateLimitIP - аналог RateLimiter.PerIP, ставится до аутентификации
+func UnaryRateLimitIP(rl *middleware.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key, rule := rl.IPLimit(peerIP(ctx))
+		if err := allowCall(ctx, rl, key, rule); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// UnaryRateLimit - аналог RateLimiter.PerClient и PerWallet, ставится после аутентификации
+func UnaryRateLimit(rl *middleware.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if key, rule := rl.ClientLimit(ctx); key != "" {
+			if err := allowCall(ctx, rl, key, rule); err != nil {
+				return nil, err
+			}
+		}
+		if key, rule := walletLimit(rl, req); key != "" {
+			if err := allowCall(ctx, rl, key, rule); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitIP и StreamRateLimit ограничивают открытие потоков; кошелек потока известен только
+// после чтения запроса, поэтому лимит кошелька к потокам не применяется
+func StreamRateLimitIP(rl *middleware.RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		key, rule := rl.IPLimit(peerIP(ss.Context()))
+		if err := allowCall(ss.Context(), rl, key, rule); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func StreamRateLimit(rl *middleware.RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if key, rule := rl.ClientLimit(ss.Context()); key != "" {
+			if err := allowCall(ss.Context(), rl, key, rule); err != nil {
+				return err
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+func walletLimit(rl *middleware.RateLimiter, req interface{}) (string, ratelimit.Rule) {
+	switch req := req.(type) {
+	case *walletv1.GetWalletRequest:
+		return rl.WalletLimit(req.GetWalletId(), "")
+	case *walletv1.ProcessOperationRequest:
+		switch req.GetOperationType() {
+		case walletv1.OperationType_OPERATION_TYPE_DEPOSIT:
+			return rl.WalletLimit(req.GetWalletId(), models.OperationTypeDeposit)
+		case walletv1.OperationType_OPERATION_TYPE_WITHDRAW:
+			return rl.WalletLimit(req.GetWalletId(), models.OperationTypeWithdraw)
+		}
+	}
+	return "", ratelimit.Rule{}
+}
+
+// allowCall отвечает ResourceExhausted с retry-after в секундах, как 429 с Retry-After в HTTP
+func allowCall(ctx context.Context, rl *middleware.RateLimiter, key string, rule ratelimit.Rule) error {
+	ok, retryAfter := rl.Allow(key, rule)
+	if ok {
+		return nil
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadataKey, strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+	return status.Error(codes.ResourceExhausted, "Слишком много запросов")
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
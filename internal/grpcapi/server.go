@@ -0,0 +1,256 @@
+package grpcapi
+
+import (
+	"context"
+	walletv1 "wallet-api/api/wallet/v1"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/middleware"
+	"wallet-api/internal/models"
+	"wallet-api/internal/service"
+	"wallet-api/internal/stream"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const historyPageSize = 500
+
+// WalletServer реализует walletv1.WalletServiceServer поверх того же service.WalletServiceInterface, что и REST
+type WalletServer struct {
+	walletv1.UnimplementedWalletServiceServer
+	service service.WalletServiceInterface
+	broker  *stream.Broker
+}
+
+func NewWalletServer(service service.WalletServiceInterface, broker *stream.Broker) *WalletServer {
+	return &WalletServer{service: service, broker: broker}
+}
+
+// NewServer собирает gRPC сервер с цепочкой интерсепторов; authenticate может быть nil, если аутентификация отключена,
+// rateLimiter - если отключены лимиты запросов
+func NewServer(walletServer *WalletServer, authenticate Authenticator, rateLimiter *middleware.RateLimiter) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{UnaryRequestID, UnaryLogging, UnaryRecovery}
+	streaming := []grpc.StreamServerInterceptor{StreamRequestID, StreamLogging, StreamRecovery}
+	if rateLimiter != nil {
+		unary = append(unary, UnaryRateLimitIP(rateLimiter))
+		streaming = append(streaming, StreamRateLimitIP(rateLimiter))
+	}
+	if authenticate != nil {
+		unary = append(unary, UnaryAuth(authenticate))
+		streaming = append(streaming, StreamAuth(authenticate))
+	}
+	if rateLimiter != nil {
+		unary = append(unary, UnaryRateLimit(rateLimiter))
+		streaming = append(streaming, StreamRateLimit(rateLimiter))
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(streaming...),
+	)
+	walletv1.RegisterWalletServiceServer(server, walletServer)
+	return server
+}
+
+func (s *WalletServer) GetWallet(ctx context.Context, req *walletv1.GetWalletRequest) (*walletv1.GetWalletResponse, error) {
+	walletID, err := parseWalletID(req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeWallet(ctx, walletID, models.ScopeWalletRead); err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.loadWallet(ctx, walletID)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return &walletv1.GetWalletResponse{Wallet: toProtoWallet(wallet)}, nil
+}
+
+func (s *WalletServer) ProcessOperation(ctx context.Context, req *walletv1.ProcessOperationRequest) (*walletv1.ProcessOperationResponse, error) {
+	walletID, err := parseWalletID(req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	var operationType string
+	switch req.GetOperationType() {
+	case walletv1.OperationType_OPERATION_TYPE_DEPOSIT:
+		operationType = models.OperationTypeDeposit
+	case walletv1.OperationType_OPERATION_TYPE_WITHDRAW:
+		operationType = models.OperationTypeWithdraw
+	default:
+		return nil, status.Error(codes.InvalidArgument, "Неверный тип операции")
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeWallet(ctx, walletID, models.ScopeForOperation(operationType)); err != nil {
+		return nil, err
+	}
+
+	result, err := s.service.ProcessWalletOperation(&models.WalletOperation{
+		WalletID:      walletID,
+		OperationType: operationType,
+		Amount:        amount.Raw,
+		OwnerID:       auth.OwnerFromContext(ctx),
+	})
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return &walletv1.ProcessOperationResponse{Wallet: toProtoWallet(result.Wallet)}, nil
+}
+
+func (s *WalletServer) Transfer(ctx context.Context, req *walletv1.TransferRequest) (*walletv1.TransferResponse, error) {
+	fromID, err := parseWalletID(req.GetFromWalletId())
+	if err != nil {
+		return nil, err
+	}
+	toID, err := parseWalletID(req.GetToWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	// Перевод - это списание с источника и зачисление получателю, поэтому проверяются оба кошелька
+	if err := authorizeWallet(ctx, fromID, models.ScopeWalletWithdraw); err != nil {
+		return nil, err
+	}
+	if err := authorizeWallet(ctx, toID, models.ScopeWalletDeposit); err != nil {
+		return nil, err
+	}
+
+	result, err := s.service.Transfer(&models.Transfer{
+		FromWalletID: fromID,
+		ToWalletID:   toID,
+		Amount:       amount.Raw,
+		OwnerID:      auth.OwnerFromContext(ctx),
+	})
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	return &walletv1.TransferResponse{From: toProtoWallet(result.From), To: toProtoWallet(result.To)}, nil
+}
+
+// StreamHistory отдает журнал кошелька после after_id; с follow поток ждет новые проводки через stream.Broker
+func (s *WalletServer) StreamHistory(req *walletv1.StreamHistoryRequest, srv walletv1.WalletService_StreamHistoryServer) error {
+	ctx := srv.Context()
+
+	walletID, err := parseWalletID(req.GetWalletId())
+	if err != nil {
+		return err
+	}
+	if req.GetAfterId() < 0 {
+		return status.Error(codes.InvalidArgument, "after_id не может быть отрицательным")
+	}
+
+	if err := authorizeWallet(ctx, walletID, models.ScopeWalletRead); err != nil {
+		return err
+	}
+	if _, err := s.loadWallet(ctx, walletID); err != nil {
+		return statusFromError(err)
+	}
+
+	var sub *stream.Subscription
+	if req.GetFollow() {
+		// Подписка оформляется до чтения журнала, чтобы не пропустить проводку между чтением и подпиской
+		sub = s.broker.Subscribe(walletID)
+		defer sub.Close()
+	}
+
+	lastID, err := s.sendHistory(srv, walletID, req.GetAfterId())
+	if err != nil || sub == nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.Notify():
+			if lastID, err = s.sendHistory(srv, walletID, lastID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *WalletServer) sendHistory(srv walletv1.WalletService_StreamHistoryServer, walletID uuid.UUID, afterID int64) (int64, error) {
+	for {
+		entries, err := s.service.ListLedgerEntries(walletID.String(), afterID, historyPageSize)
+		if err != nil {
+			return afterID, statusFromError(err)
+		}
+
+		for _, entry := range entries {
+			if err := srv.Send(&walletv1.StreamHistoryResponse{Entry: toProtoLedgerEntry(entry)}); err != nil {
+				return afterID, err
+			}
+			afterID = entry.ID
+		}
+
+		if len(entries) < historyPageSize {
+			return afterID, nil
+		}
+	}
+}
+
+func (s *WalletServer) loadWallet(ctx context.Context, walletID uuid.UUID) (*models.Wallet, error) {
+	if ownerID := auth.OwnerFromContext(ctx); ownerID != "" {
+		return s.service.GetOwnedWallet(walletID.String(), ownerID)
+	}
+	return s.service.GetWallet(walletID.String())
+}
+
+func parseWalletID(value string) (uuid.UUID, error) {
+	walletID, err := uuid.Parse(value)
+	if err != nil || walletID == uuid.Nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "Неверный UUID кошелька")
+	}
+	return walletID, nil
+}
+
+func parseAmount(value string) (utils.Money, error) {
+	amount, err := utils.NewMoneyFromString(value)
+	if err != nil {
+		return utils.Money{}, status.Error(codes.InvalidArgument, "Неверный формат суммы")
+	}
+	if amount.Raw <= 0 {
+		return utils.Money{}, status.Error(codes.InvalidArgument, "Сумма должна быть положительной")
+	}
+	return amount, nil
+}
+
+func toProtoWallet(wallet *models.Wallet) *walletv1.Wallet {
+	return &walletv1.Wallet{
+		Id:      wallet.ID.String(),
+		Balance: wallet.Balance.String(),
+	}
+}
+
+func toProtoLedgerEntry(entry models.LedgerEntry) *walletv1.LedgerEntry {
+	return &walletv1.LedgerEntry{
+		Id:           entry.ID,
+		WalletId:     entry.WalletID.String(),
+		EntryType:    entry.EntryType,
+		Amount:       entry.Amount.String(),
+		BalanceAfter: entry.BalanceAfter.String(),
+		CreatedAt:    timestamppb.New(entry.CreatedAt),
+	}
+}
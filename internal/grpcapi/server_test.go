@@ -0,0 +1,433 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+	walletv1 "wallet-api/api/wallet/v1"
+	"wallet-api/internal/middleware"
+	"wallet-api/internal/models"
+	"wallet-api/internal/ratelimit"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/internal/stream"
+	"wallet-api/utils"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func init() {
+	logger.Init()
+}
+
+type MockWalletService struct {
+	wallets map[uuid.UUID]*models.Wallet
+	ledger  []models.LedgerEntry
+}
+
+func (m *MockWalletService) GetWallet(walletID string) (*models.Wallet, error) {
+	wallet, ok := m.wallets[uuid.MustParse(walletID)]
+	if !ok {
+		return nil, fmt.Errorf("get wallet: %w", repository.ErrWalletNotFound)
+	}
+	return wallet, nil
+}
+
+func (m *MockWalletService) GetOwnedWallet(walletID, ownerID string) (*models.Wallet, error) {
+	wallet, err := m.GetWallet(walletID)
+	if err != nil {
+		return nil, err
+	}
+	if !wallet.IsOwnedBy(ownerID) {
+		return nil, service.ErrWalletAccessDenied
+	}
+	return wallet, nil
+}
+
+func (m *MockWalletService) LookupWallets(walletIDs []uuid.UUID, ownerID string) ([]models.Wallet, []uuid.UUID, error) {
+	return nil, walletIDs, nil
+}
+
+func (m *MockWalletService) ListWallets(filter models.WalletFilter) (*models.WalletPage, error) {
+	return &models.WalletPage{}, nil
+}
+
+func (m *MockWalletService) GetBalanceAsOf(walletID, ownerID string, asOf time.Time) (*models.HistoricalBalance, error) {
+	return nil, fmt.Errorf("get balance as of: %w", repository.ErrWalletNotFound)
+}
+
+func (m *MockWalletService) OpenStatement(ctx context.Context, walletID, ownerID string, from, to time.Time) (repository.StatementCursor, error) {
+	return nil, fmt.Errorf("open statement: %w", repository.ErrWalletNotFound)
+}
+
+func (m *MockWalletService) ProcessWalletOperation(operation *models.WalletOperation) (*models.OperationResult, error) {
+	wallet, err := m.GetWallet(operation.WalletID.String())
+	if err != nil {
+		return nil, err
+	}
+	if operation.OperationType == models.OperationTypeWithdraw {
+		if wallet.Balance.Raw < operation.Amount {
+			return nil, fmt.Errorf("process operation: %w", service.ErrInsufficientFunds)
+		}
+		wallet.Balance = wallet.Balance.Sub(utils.Money{Raw: operation.Amount})
+		return &models.OperationResult{Wallet: wallet}, nil
+	}
+	wallet.Balance = wallet.Balance.Add(utils.Money{Raw: operation.Amount})
+	return &models.OperationResult{Wallet: wallet}, nil
+}
+
+func (m *MockWalletService) CreateWallet(wallet *models.Wallet) error {
+	m.wallets[wallet.ID] = wallet
+	return nil
+}
+
+func (m *MockWalletService) ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	for _, entry := range m.ledger {
+		if entry.ID > afterID && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockWalletService) GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error) {
+	return nil, nil
+}
+
+func (m *MockWalletService) Transfer(transfer *models.Transfer) (*models.TransferResult, error) {
+	if transfer.FromWalletID == transfer.ToWalletID {
+		return nil, fmt.Errorf("transfer: %w", service.ErrInvalidTransfer)
+	}
+	from, err := m.GetWallet(transfer.FromWalletID.String())
+	if err != nil {
+		return nil, err
+	}
+	to, err := m.GetWallet(transfer.ToWalletID.String())
+	if err != nil {
+		return nil, err
+	}
+	from.Balance = from.Balance.Sub(utils.Money{Raw: transfer.Amount})
+	to.Balance = to.Balance.Add(utils.Money{Raw: transfer.Amount})
+	return &models.TransferResult{From: from, To: to}, nil
+}
+
+type MockAuthService struct {
+	service.AuthServiceInterface
+	clients map[string]*models.APIClient
+}
+
+func (m *MockAuthService) AuthenticateAPIKey(key string) (*models.APIClient, error) {
+	client, ok := m.clients[key]
+	if !ok {
+		return nil, service.ErrInvalidAPIKey
+	}
+	return client, nil
+}
+
+func newTestClient(t *testing.T, walletService service.WalletServiceInterface, authenticate Authenticator) walletv1.WalletServiceClient {
+	return newRateLimitedTestClient(t, walletService, authenticate, nil)
+}
+
+func newRateLimitedTestClient(t *testing.T, walletService service.WalletServiceInterface, authenticate Authenticator, rateLimiter *middleware.RateLimiter) walletv1.WalletServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer(NewWalletServer(walletService, stream.NewBroker()), authenticate, rateLimiter)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return walletv1.NewWalletServiceClient(conn)
+}
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{fmt.Errorf("get wallet: %w", repository.ErrWalletNotFound), codes.NotFound},
+		{fmt.Errorf("get wallet: %w", service.ErrWalletAccessDenied), codes.PermissionDenied},
+		{fmt.Errorf("process operation: %w", service.ErrInsufficientFunds), codes.FailedPrecondition},
+		{fmt.Errorf("transfer: %w", service.ErrInvalidTransfer), codes.InvalidArgument},
+		{fmt.Errorf("get wallet: %w", repository.ErrDatabaseError), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		if got := status.Code(statusFromError(tt.err)); got != tt.want {
+			t.Errorf("statusFromError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWalletServer(t *testing.T) {
+	walletID, otherID := uuid.New(), uuid.New()
+	walletService := &MockWalletService{
+		wallets: map[uuid.UUID]*models.Wallet{
+			walletID: {ID: walletID, Balance: utils.Money{Raw: 1000}},
+			otherID:  {ID: otherID, Balance: utils.Money{Raw: 0}},
+		},
+		ledger: []models.LedgerEntry{
+			{ID: 1, WalletID: walletID, EntryType: models.OperationTypeDeposit, Amount: utils.Money{Raw: 400}, BalanceAfter: utils.Money{Raw: 400}},
+			{ID: 2, WalletID: walletID, EntryType: models.OperationTypeDeposit, Amount: utils.Money{Raw: 600}, BalanceAfter: utils.Money{Raw: 1000}},
+		},
+	}
+	client := newTestClient(t, walletService, nil)
+	ctx := context.Background()
+
+	var header metadata.MD
+	resp, err := client.GetWallet(metadata.AppendToOutgoingContext(ctx, "x-request-id", "req-1"),
+		&walletv1.GetWalletRequest{WalletId: walletID.String()}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("GetWallet() unexpected error = %v", err)
+	}
+	if resp.GetWallet().GetBalance() != "10.00" {
+		t.Errorf("GetWallet() balance = %s, want 10.00", resp.GetWallet().GetBalance())
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("x-request-id header = %v, want req-1", got)
+	}
+
+	errorTests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{
+			name: "unknown wallet",
+			call: func() error {
+				_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: uuid.New().String()})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "invalid wallet id",
+			call: func() error {
+				_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: "abc"})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "unspecified operation type",
+			call: func() error {
+				_, err := client.ProcessOperation(ctx, &walletv1.ProcessOperationRequest{WalletId: walletID.String(), Amount: "1.00"})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "negative amount",
+			call: func() error {
+				_, err := client.ProcessOperation(ctx, &walletv1.ProcessOperationRequest{
+					WalletId: walletID.String(), OperationType: walletv1.OperationType_OPERATION_TYPE_DEPOSIT, Amount: "-1.00",
+				})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "insufficient funds",
+			call: func() error {
+				_, err := client.ProcessOperation(ctx, &walletv1.ProcessOperationRequest{
+					WalletId: walletID.String(), OperationType: walletv1.OperationType_OPERATION_TYPE_WITHDRAW, Amount: "100.00",
+				})
+				return err
+			},
+			want: codes.FailedPrecondition,
+		},
+		{
+			name: "transfer to the same wallet",
+			call: func() error {
+				_, err := client.Transfer(ctx, &walletv1.TransferRequest{FromWalletId: walletID.String(), ToWalletId: walletID.String(), Amount: "1.00"})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("code = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("transfer", func(t *testing.T) {
+		resp, err := client.Transfer(ctx, &walletv1.TransferRequest{FromWalletId: walletID.String(), ToWalletId: otherID.String(), Amount: "2.50"})
+		if err != nil {
+			t.Fatalf("Transfer() unexpected error = %v", err)
+		}
+		if resp.GetFrom().GetBalance() != "7.50" || resp.GetTo().GetBalance() != "2.50" {
+			t.Errorf("Transfer() balances = %s/%s, want 7.50/2.50", resp.GetFrom().GetBalance(), resp.GetTo().GetBalance())
+		}
+	})
+
+	t.Run("stream history", func(t *testing.T) {
+		historyStream, err := client.StreamHistory(ctx, &walletv1.StreamHistoryRequest{WalletId: walletID.String(), AfterId: 1})
+		if err != nil {
+			t.Fatalf("StreamHistory() unexpected error = %v", err)
+		}
+
+		var ids []int64
+		for {
+			resp, err := historyStream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Recv() unexpected error = %v", err)
+			}
+			ids = append(ids, resp.GetEntry().GetId())
+		}
+		if len(ids) != 1 || ids[0] != 2 {
+			t.Errorf("StreamHistory() ids = %v, want [2]", ids)
+		}
+	})
+}
+
+func TestWalletServer_APIKeyAuth(t *testing.T) {
+	walletID := uuid.New()
+	walletService := &MockWalletService{
+		wallets: map[uuid.UUID]*models.Wallet{walletID: {ID: walletID, Balance: utils.Money{Raw: 1000}}},
+	}
+	authService := &MockAuthService{clients: map[string]*models.APIClient{
+		"reader":   {ID: uuid.New(), Scopes: []string{models.ScopeWalletRead}},
+		"stranger": {ID: uuid.New(), Scopes: []string{models.ScopeWalletRead}, WalletIDs: []uuid.UUID{uuid.New()}},
+	}}
+	client := newTestClient(t, walletService, NewAPIKeyAuthenticator(authService))
+
+	tests := []struct {
+		name     string
+		metadata []string
+		call     func(ctx context.Context) error
+		want     codes.Code
+	}{
+		{
+			name: "missing key",
+			call: func(ctx context.Context) error {
+				_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: walletID.String()})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		{
+			name:     "signature instead of key",
+			metadata: []string{"x-client-id", uuid.NewString(), "x-timestamp", "1700000000", "x-signature", "abc"},
+			call: func(ctx context.Context) error {
+				_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: walletID.String()})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		{
+			name:     "invalid key",
+			metadata: []string{"x-api-key", "nope"},
+			call: func(ctx context.Context) error {
+				_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: walletID.String()})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		{
+			name:     "read with authorization header",
+			metadata: []string{"authorization", "ApiKey reader"},
+			call: func(ctx context.Context) error {
+				_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: walletID.String()})
+				return err
+			},
+			want: codes.OK,
+		},
+		{
+			name:     "withdraw without scope",
+			metadata: []string{"x-api-key", "reader"},
+			call: func(ctx context.Context) error {
+				_, err := client.ProcessOperation(ctx, &walletv1.ProcessOperationRequest{
+					WalletId: walletID.String(), OperationType: walletv1.OperationType_OPERATION_TYPE_WITHDRAW, Amount: "1.00",
+				})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+		{
+			name:     "wallet outside allow-list",
+			metadata: []string{"x-api-key", "stranger"},
+			call: func(ctx context.Context) error {
+				_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: walletID.String()})
+				return err
+			},
+			want: codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(tt.metadata) > 0 {
+				ctx = metadata.AppendToOutgoingContext(ctx, tt.metadata...)
+			}
+			if got := status.Code(tt.call(ctx)); got != tt.want {
+				t.Errorf("code = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalletServer_RateLimit(t *testing.T) {
+	walletID := uuid.New()
+	walletService := &MockWalletService{
+		wallets: map[uuid.UUID]*models.Wallet{walletID: {ID: walletID, Balance: utils.Money{Raw: 1000}}},
+	}
+	authService := &MockAuthService{clients: map[string]*models.APIClient{
+		"reader": {ID: uuid.New(), Scopes: []string{models.ScopeWalletRead}},
+	}}
+	rateLimiter := middleware.NewRateLimiter(ratelimit.NewMemoryLimiter(time.Minute), middleware.RateLimitRules{
+		PerIP:      ratelimit.Rule{Rate: 100, Burst: 100},
+		PerClient:  ratelimit.Rule{Rate: 100, Burst: 100},
+		WalletRead: ratelimit.Rule{Rate: 1, Burst: 2},
+	})
+	client := newRateLimitedTestClient(t, walletService, NewAPIKeyAuthenticator(authService), rateLimiter)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader")
+
+	// Лимит кошелька общий с HTTP: одно чтение через REST оставляет gRPC одно
+	key, rule := rateLimiter.WalletLimit(walletID.String(), "")
+	if ok, _ := rateLimiter.Allow(key, rule); !ok {
+		t.Fatalf("HTTP read must be allowed")
+	}
+
+	if _, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: walletID.String()}); err != nil {
+		t.Fatalf("GetWallet() unexpected error = %v", err)
+	}
+
+	var header metadata.MD
+	_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: walletID.String()}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("GetWallet() code = %s, want %s", status.Code(err), codes.ResourceExhausted)
+	}
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("retry-after = %v, want [1]", got)
+	}
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"encoding/hex"
+	stdErrors "errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMismatchLimit = 100
+	maxMismatchLimit     = 1000
+)
+
+type trialBalanceAccountResponse struct {
+	Account string `json:"account"`
+	Debit   string `json:"debit"`
+	Credit  string `json:"credit"`
+	Balance string `json:"balance"`
+}
+
+type trialBalanceResponse struct {
+	AsOf               time.Time                     `json:"asOf"`
+	Balanced           bool                          `json:"balanced"`
+	Accounts           []trialBalanceAccountResponse `json:"accounts"`
+	TotalDebit         string                        `json:"totalDebit"`
+	TotalCredit        string                        `json:"totalCredit"`
+	WalletsBalance     string                        `json:"walletsBalance"`
+	UnbalancedJournals int                           `json:"unbalancedJournals"`
+}
+
+type reconciliationRunResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	Status          string     `json:"status"`
+	WalletsChecked  int        `json:"walletsChecked"`
+	MismatchesFound int        `json:"mismatchesFound"`
+	FrozenWallets   int        `json:"frozenWallets"`
+	StartedAt       time.Time  `json:"startedAt"`
+	FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+}
+
+type reconciliationMismatchResponse struct {
+	WalletID         uuid.UUID `json:"walletId"`
+	Reason           string    `json:"reason"`
+	WalletBalance    string    `json:"walletBalance"`
+	LedgerBalance    string    `json:"ledgerBalance"`
+	Difference       string    `json:"difference"`
+	LedgerEntries    int       `json:"ledgerEntries"`
+	LastEntryID      *int64    `json:"lastEntryId,omitempty"`
+	LastBalanceAfter string    `json:"lastBalanceAfter,omitempty"`
+	Frozen           bool      `json:"frozen"`
+	DetectedAt       time.Time `json:"detectedAt"`
+}
+
+type reconciliationResponse struct {
+	Run        reconciliationRunResponse        `json:"run"`
+	Mismatches []reconciliationMismatchResponse `json:"mismatches"`
+}
+
+type ledgerChainBreakResponse struct {
+	WalletID     uuid.UUID `json:"walletId"`
+	EntryID      int64     `json:"entryId"`
+	Reason       string    `json:"reason"`
+	ExpectedHash string    `json:"expectedHash"`
+	ActualHash   string    `json:"actualHash"`
+}
+
+type ledgerVerificationResponse struct {
+	Valid          bool                      `json:"valid"`
+	WalletsChecked int                       `json:"walletsChecked"`
+	EntriesChecked int64                     `json:"entriesChecked"`
+	BrokenLink     *ledgerChainBreakResponse `json:"brokenLink,omitempty"`
+}
+
+type AccountingHandler struct {
+	service service.AccountingServiceInterface
+}
+
+func NewAccountingHandler(service service.AccountingServiceInterface) *AccountingHandler {
+	return &AccountingHandler{service: service}
+}
+
+// HandleTrialBalance обслуживает GET /api/v1/admin/trial-balance.
+// Расхождение не меняет статус ответа: его показывает поле balanced
+func (h *AccountingHandler) HandleTrialBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+		return
+	}
+
+	balance, err := h.service.GetTrialBalance(r.Context())
+	if err != nil {
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := newTrialBalanceResponse(balance)
+	if !resp.Balanced {
+		logger.GlobalLogger.Error("Оборотная ведомость не сходится: дебет %s, кредит %s, кошельки %s, несбалансированных записей %d",
+			resp.TotalDebit, resp.TotalCredit, resp.WalletsBalance, resp.UnbalancedJournals)
+	}
+	response.WriteJSON(w, http.StatusOK, resp)
+}
+
+func newTrialBalanceResponse(balance *models.TrialBalance) trialBalanceResponse {
+	debit, credit := balance.Totals()
+	resp := trialBalanceResponse{
+		AsOf:               balance.AsOf,
+		Balanced:           balance.IsBalanced(),
+		Accounts:           make([]trialBalanceAccountResponse, 0, len(balance.Accounts)),
+		TotalDebit:         debit.String(),
+		TotalCredit:        credit.String(),
+		WalletsBalance:     balance.WalletsBalance.String(),
+		UnbalancedJournals: balance.UnbalancedJournals,
+	}
+	for _, account := range balance.Accounts {
+		resp.Accounts = append(resp.Accounts, trialBalanceAccountResponse{
+			Account: account.Account,
+			Debit:   account.Debit.String(),
+			Credit:  account.Credit.String(),
+			Balance: account.Balance().String(),
+		})
+	}
+	return resp
+}
+
+// HandleReconciliation обслуживает GET /api/v1/admin/reconciliation: последняя сверка балансов с журналом
+// и до limit найденных расхождений
+func (h *AccountingHandler) HandleReconciliation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+		return
+	}
+
+	limit := defaultMismatchLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxMismatchLimit {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "limit должен быть от 1 до 1000")
+			return
+		}
+		limit = parsed
+	}
+
+	report, err := h.service.GetLatestReconciliation(limit)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrReconciliationRunNotFound) {
+			response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Сверка еще не выполнялась")
+			return
+		}
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, newReconciliationResponse(report))
+}
+
+func newReconciliationResponse(report *models.ReconciliationReport) reconciliationResponse {
+	run := report.Run
+	resp := reconciliationResponse{
+		Run: reconciliationRunResponse{
+			ID:              run.ID,
+			Status:          run.Status,
+			WalletsChecked:  run.WalletsChecked,
+			MismatchesFound: run.Mismatches,
+			FrozenWallets:   run.FrozenWallets,
+			StartedAt:       run.StartedAt,
+		},
+		Mismatches: make([]reconciliationMismatchResponse, 0, len(report.Mismatches)),
+	}
+	if run.FinishedAt.Valid {
+		finishedAt := run.FinishedAt.Time
+		resp.Run.FinishedAt = &finishedAt
+	}
+
+	for _, m := range report.Mismatches {
+		item := reconciliationMismatchResponse{
+			WalletID:      m.WalletID,
+			Reason:        m.Reason,
+			WalletBalance: m.WalletBalance.String(),
+			LedgerBalance: m.LedgerBalance.String(),
+			Difference:    m.Difference().String(),
+			LedgerEntries: m.LedgerEntries,
+			Frozen:        m.Frozen,
+			DetectedAt:    m.DetectedAt,
+		}
+		if m.LastEntryID.Valid {
+			lastEntryID := m.LastEntryID.Int64
+			item.LastEntryID = &lastEntryID
+		}
+		if m.LastBalanceAfter.Valid {
+			item.LastBalanceAfter = utils.Money{Raw: m.LastBalanceAfter.Int64}.String()
+		}
+		resp.Mismatches = append(resp.Mismatches, item)
+	}
+	return resp
+}
+
+// HandleLedgerVerification обслуживает GET /api/v1/admin/ledger/verify: проверяет цепочки хэшей журнала
+// всех кошельков или одного (walletId) и сообщает о первом звене, которое не сходится
+func (h *AccountingHandler) HandleLedgerVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+		return
+	}
+
+	var walletID *uuid.UUID
+	if raw := r.URL.Query().Get("walletId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат UUID кошелька")
+			return
+		}
+		walletID = &id
+	}
+
+	result, err := h.service.VerifyLedgerChain(r.Context(), walletID)
+	if err != nil {
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := ledgerVerificationResponse{
+		Valid:          result.Break == nil,
+		WalletsChecked: result.WalletsChecked,
+		EntriesChecked: result.EntriesChecked,
+	}
+	if broken := result.Break; broken != nil {
+		logger.GlobalLogger.Error("Цепочка хэшей журнала нарушена: кошелек %s, проводка %d (%s)", broken.WalletID, broken.EntryID, broken.Reason)
+		resp.BrokenLink = &ledgerChainBreakResponse{
+			WalletID:     broken.WalletID,
+			EntryID:      broken.EntryID,
+			Reason:       broken.Reason,
+			ExpectedHash: hex.EncodeToString(broken.Expected),
+			ActualHash:   hex.EncodeToString(broken.Actual),
+		}
+	}
+	response.WriteJSON(w, http.StatusOK, resp)
+}
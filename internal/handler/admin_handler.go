@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"strings"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+const adminClientsPath = "/api/v1/admin/clients"
+
+type createClientRequest struct {
+	Name      string      `json:"name"`
+	Scopes    []string    `json:"scopes"`
+	WalletIDs []uuid.UUID `json:"walletIds"`
+}
+
+type AdminHandler struct {
+	authService service.AuthServiceInterface
+}
+
+func NewAdminHandler(authService service.AuthServiceInterface) *AdminHandler {
+	return &AdminHandler{authService: authService}
+}
+
+// HandleClients обслуживает POST /api/v1/admin/clients, POST /api/v1/admin/clients/{id}/rotate-key
+// и POST /api/v1/admin/clients/{id}/signing-secret
+func (h *AdminHandler) HandleClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == adminClientsPath {
+		h.createClient(w, r)
+		return
+	}
+
+	rest := strings.TrimPrefix(path, adminClientsPath+"/")
+	clientID, action, found := strings.Cut(rest, "/")
+	if !found {
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+		return
+	}
+
+	switch action {
+	case "rotate-key":
+		h.rotateKey(w, r, clientID)
+	case "signing-secret":
+		h.rotateSigningSecret(w, r, clientID)
+	default:
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+	}
+}
+
+func (h *AdminHandler) createClient(w http.ResponseWriter, r *http.Request) {
+	var request createClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	if strings.TrimSpace(request.Name) == "" || len(request.Scopes) == 0 {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Имя клиента и scopes обязательны")
+		return
+	}
+
+	client, key, err := h.authService.CreateClient(request.Name, request.Scopes, request.WalletIDs)
+	if err != nil {
+		if stdErrors.Is(err, service.ErrInvalidScope) {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неизвестный scope")
+			return
+		}
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	response.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"client": client,
+		"apiKey": key,
+	})
+}
+
+func (h *AdminHandler) rotateKey(w http.ResponseWriter, r *http.Request, clientID string) {
+	key, err := h.authService.RotateKey(clientID)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrAPIClientNotFound) {
+			response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Клиент не найден")
+			return
+		}
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"clientId": clientID,
+		"apiKey":   key,
+	})
+}
+
+func (h *AdminHandler) rotateSigningSecret(w http.ResponseWriter, r *http.Request, clientID string) {
+	secret, err := h.authService.RotateSigningSecret(clientID)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrAPIClientNotFound) {
+			response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Клиент не найден")
+			return
+		}
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"clientId":      clientID,
+		"signingSecret": secret,
+	})
+}
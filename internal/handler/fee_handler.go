@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+// feeJSON - суммы операции с комиссией в рублях
+type feeJSON struct {
+	Gross string `json:"gross"`
+	Fee   string `json:"fee"`
+	Net   string `json:"net"`
+}
+
+func newFeeJSON(fee models.Fee) feeJSON {
+	return feeJSON{Gross: fee.Gross.String(), Fee: fee.Fee.String(), Net: fee.Net.String()}
+}
+
+type feeQuoteResponse struct {
+	WalletID      uuid.UUID `json:"walletId"`
+	OperationType string    `json:"operationType"`
+	feeJSON
+}
+
+type feeBracketJSON struct {
+	From            string `json:"from"`
+	Flat            string `json:"flat"`
+	RateBasisPoints int64  `json:"rateBasisPoints"`
+}
+
+// feeRuleJSON - правило комиссии; tier null означает правило для всех уровней
+type feeRuleJSON struct {
+	OperationType   string           `json:"operationType"`
+	Tier            *string          `json:"tier"`
+	Kind            string           `json:"kind"`
+	Flat            string           `json:"flat,omitempty"`
+	RateBasisPoints int64            `json:"rateBasisPoints,omitempty"`
+	Brackets        []feeBracketJSON `json:"brackets,omitempty"`
+	Min             *string          `json:"min"`
+	Max             *string          `json:"max"`
+	Rounding        string           `json:"rounding"`
+}
+
+type feeRulesPayload struct {
+	Rules []feeRuleJSON `json:"rules"`
+}
+
+// FeeHandler обслуживает правила комиссий и расчет комиссии без проведения операции
+type FeeHandler struct {
+	service service.FeeServiceInterface
+	wallets *WalletHandler
+}
+
+func NewFeeHandler(service service.FeeServiceInterface, wallets *WalletHandler) *FeeHandler {
+	return &FeeHandler{service: service, wallets: wallets}
+}
+
+// HandleFeeRules обслуживает GET и PUT /api/v1/admin/fee-rules. PUT заменяет все правила целиком
+func (h *FeeHandler) HandleFeeRules(w http.ResponseWriter, r *http.Request) {
+	var rules []models.FeeRule
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		rules, err = h.service.ListFeeRules()
+	case http.MethodPut:
+		var request feeRulesPayload
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат JSON")
+			return
+		}
+		requested := make([]models.FeeRule, 0, len(request.Rules))
+		for i, rule := range request.Rules {
+			parsed, err := rule.rule()
+			if err != nil {
+				response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, fmt.Sprintf("Правило %d: %s", i, err))
+				return
+			}
+			requested = append(requested, parsed)
+		}
+		rules, err = h.service.SetFeeRules(requested)
+	default:
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+		return
+	}
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	payload := feeRulesPayload{Rules: make([]feeRuleJSON, 0, len(rules))}
+	for _, rule := range rules {
+		payload.Rules = append(payload.Rules, newFeeRuleJSON(rule))
+	}
+	response.WriteJSON(w, http.StatusOK, payload)
+}
+
+// HandleFeeQuote обслуживает GET /api/v1/wallets/{walletId}/fee-quote?operationType=&amount=.
+// Комиссия считается по тем же правилам, что и при проведении операции, но баланс не меняется
+func (h *FeeHandler) HandleFeeQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+		return
+	}
+
+	walletID, err := uuid.Parse(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/wallets/"), "/fee-quote"))
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный UUID кошелька")
+		return
+	}
+
+	query := r.URL.Query()
+	operationType := query.Get("operationType")
+	if !models.IsValidFeeOperationType(operationType) {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный operationType, ожидается DEPOSIT, WITHDRAW или TRANSFER")
+		return
+	}
+	amount, err := utils.NewMoneyFromString(query.Get("amount"))
+	if err != nil || amount.Raw <= 0 {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Сумма должна быть положительной")
+		return
+	}
+
+	if !h.wallets.authorizeWallet(w, r, walletID.String(), models.ScopeWalletRead) {
+		return
+	}
+	if _, err := h.wallets.loadWallet(r, walletID.String()); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	fee, err := h.service.QuoteFee(walletID.String(), operationType, amount)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, feeQuoteResponse{
+		WalletID:      walletID,
+		OperationType: operationType,
+		feeJSON:       newFeeJSON(fee),
+	})
+}
+
+func (h *FeeHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case stdErrors.Is(err, repository.ErrWalletNotFound):
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Кошелек не найден")
+	case stdErrors.Is(err, service.ErrWalletAccessDenied):
+		response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Нет доступа к кошельку")
+	case stdErrors.Is(err, service.ErrUnknownWalletTier):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неизвестный уровень кошелька")
+	case stdErrors.Is(err, service.ErrInvalidFeeRule):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверное правило комиссии: "+err.Error())
+	case stdErrors.Is(err, service.ErrInvalidFeeQuote):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный запрос расчета комиссии: "+err.Error())
+	default:
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+	}
+}
+
+func (j feeRuleJSON) rule() (models.FeeRule, error) {
+	rule := models.FeeRule{
+		OperationType:   j.OperationType,
+		Kind:            j.Kind,
+		RateBasisPoints: j.RateBasisPoints,
+		Rounding:        utils.Rounding(j.Rounding),
+	}
+	if j.Tier != nil {
+		rule.Tier = *j.Tier
+	}
+
+	var err error
+	if j.Flat != "" {
+		if rule.Flat, err = utils.NewMoneyFromString(j.Flat); err != nil {
+			return rule, fmt.Errorf("Неверный формат flat")
+		}
+	}
+	for _, bracket := range j.Brackets {
+		from, err := utils.NewMoneyFromString(bracket.From)
+		if err != nil {
+			return rule, fmt.Errorf("Неверный формат from")
+		}
+		var flat utils.Money
+		if bracket.Flat != "" {
+			if flat, err = utils.NewMoneyFromString(bracket.Flat); err != nil {
+				return rule, fmt.Errorf("Неверный формат flat")
+			}
+		}
+		rule.Brackets = append(rule.Brackets, models.FeeBracket{From: from, Flat: flat, RateBasisPoints: bracket.RateBasisPoints})
+	}
+	if rule.Min, err = parseOptionalMoney(j.Min, "min"); err != nil {
+		return rule, err
+	}
+	if rule.Max, err = parseOptionalMoney(j.Max, "max"); err != nil {
+		return rule, err
+	}
+	return rule, nil
+}
+
+func parseOptionalMoney(raw *string, name string) (*utils.Money, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	money, err := utils.NewMoneyFromString(*raw)
+	if err != nil {
+		return nil, fmt.Errorf("Неверный формат %s", name)
+	}
+	return &money, nil
+}
+
+func newFeeRuleJSON(rule models.FeeRule) feeRuleJSON {
+	format := func(m *utils.Money) *string {
+		if m == nil {
+			return nil
+		}
+		s := m.String()
+		return &s
+	}
+
+	j := feeRuleJSON{
+		OperationType: rule.OperationType,
+		Kind:          rule.Kind,
+		Min:           format(rule.Min),
+		Max:           format(rule.Max),
+		Rounding:      string(rule.Rounding),
+	}
+	if rule.Tier != "" {
+		j.Tier = &rule.Tier
+	}
+	switch rule.Kind {
+	case models.FeeKindFlat:
+		j.Flat = rule.Flat.String()
+	case models.FeeKindPercent:
+		j.RateBasisPoints = rule.RateBasisPoints
+	case models.FeeKindTiered:
+		for _, bracket := range rule.Brackets {
+			j.Brackets = append(j.Brackets, feeBracketJSON{
+				From:            bracket.From.String(),
+				Flat:            bracket.Flat.String(),
+				RateBasisPoints: bracket.RateBasisPoints,
+			})
+		}
+	}
+	return j
+}
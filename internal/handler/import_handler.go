@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"encoding/csv"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/importer"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+const (
+	importsPath = "/api/v1/imports"
+	// maxImportFileSize ограничивает размер загружаемого CSV
+	maxImportFileSize = 5 << 20
+	// importResultsPageSize - сколько строк результата читается из БД за один запрос
+	importResultsPageSize = 1000
+)
+
+type importJobResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Status        string     `json:"status"`
+	DryRun        bool       `json:"dryRun"`
+	TotalRows     int        `json:"totalRows"`
+	ProcessedRows int        `json:"processedRows"`
+	SucceededRows int        `json:"succeededRows"`
+	FailedRows    int        `json:"failedRows"`
+	DuplicateRows int        `json:"duplicateRows"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+	ResultsURL    string     `json:"resultsUrl"`
+}
+
+type importValidationResponse struct {
+	Error response.ErrorBody  `json:"error"`
+	Rows  []importer.RowError `json:"rows"`
+}
+
+type ImportHandler struct {
+	service service.ImportServiceInterface
+}
+
+func NewImportHandler(service service.ImportServiceInterface) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// HandleImports обслуживает POST /api/v1/imports, GET /api/v1/imports/{id} и GET /api/v1/imports/{id}/results
+func (h *ImportHandler) HandleImports(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, importsPath), "/")
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, "/")
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		h.createImport(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.getImport(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "results" && r.Method == http.MethodGet:
+		h.getImportResults(w, r, parts[0])
+	case len(parts) <= 2:
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+	default:
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+	}
+}
+
+func (h *ImportHandler) createImport(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if raw := r.URL.Query().Get("dryRun"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат dryRun")
+			return
+		}
+	}
+
+	rows, rowErrors, err := importer.ParseCSV(http.MaxBytesReader(w, r.Body, maxImportFileSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stdErrors.As(err, &tooLarge) {
+			response.WriteError(w, r, http.StatusRequestEntityTooLarge, response.CodeBadRequest, "Файл больше 5 МБ")
+			return
+		}
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный файл импорта: "+err.Error())
+		return
+	}
+	rowErrors = append(rowErrors, authorizeImportRows(auth.ClientFromContext(r.Context()), rows)...)
+	if len(rowErrors) > 0 {
+		response.WriteJSON(w, http.StatusBadRequest, importValidationResponse{
+			Error: response.ErrorBody{
+				Code:      response.CodeBadRequest,
+				Message:   "Файл содержит неверные строки, задание не создано",
+				RequestID: requestid.FromContext(r.Context()),
+			},
+			Rows: rowErrors,
+		})
+		return
+	}
+
+	job, err := h.service.CreateImport(clientScope(r), rows, dryRun)
+	if err != nil {
+		h.handleImportError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", importsPath+"/"+job.ID.String())
+	response.WriteJSON(w, http.StatusAccepted, newImportJobResponse(job))
+}
+
+func (h *ImportHandler) getImport(w http.ResponseWriter, r *http.Request, jobID string) {
+	job, err := h.service.GetImport(clientScope(r), jobID)
+	if err != nil {
+		h.handleImportError(w, r, err)
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, newImportJobResponse(job))
+}
+
+// getImportResults отдает CSV с результатом по каждой строке исходного файла.
+// Пока задание выполняется, необработанные строки имеют статус pending
+func (h *ImportHandler) getImportResults(w http.ResponseWriter, r *http.Request, jobID string) {
+	clientID := clientScope(r)
+	rows, err := h.service.ListImportRows(clientID, jobID, 0, importResultsPageSize)
+	if err != nil {
+		h.handleImportError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s-results.csv"`, jobID))
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"row", "walletId", "operationType", "amount", "externalRef", "status", "balanceAfter", "error", "fee"})
+	for len(rows) > 0 {
+		for _, row := range rows {
+			balance := ""
+			if row.BalanceAfter != nil {
+				balance = row.BalanceAfter.String()
+			}
+			fee := ""
+			if row.Fee != nil {
+				fee = row.Fee.String()
+			}
+			out.Write([]string{
+				strconv.Itoa(row.RowNumber),
+				row.WalletID.String(),
+				row.OperationType,
+				row.Amount.String(),
+				row.ExternalRef,
+				row.Status,
+				balance,
+				row.Error,
+				fee,
+			})
+		}
+		if len(rows) < importResultsPageSize {
+			break
+		}
+
+		// Статус уже отправлен: при ошибке файл результатов обрывается
+		if rows, err = h.service.ListImportRows(clientID, jobID, rows[len(rows)-1].RowNumber, importResultsPageSize); err != nil {
+			logger.GlobalLogger.Error("Ошибка выгрузки результатов импорта %s: %v", jobID, err)
+			break
+		}
+	}
+	out.Flush()
+}
+
+func (h *ImportHandler) handleImportError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case stdErrors.Is(err, service.ErrInvalidImport):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+	case stdErrors.Is(err, repository.ErrImportJobNotFound):
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Задание импорта не найдено")
+	default:
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+	}
+}
+
+// authorizeImportRows проверяет, что клиент может выполнить операцию каждой строки так же,
+// как если бы отправил ее через POST /api/v1/wallet
+func authorizeImportRows(client *models.APIClient, rows []models.ImportRow) []importer.RowError {
+	if client == nil {
+		return nil
+	}
+
+	var rowErrors []importer.RowError
+	for _, row := range rows {
+		if scope := models.ScopeForOperation(row.OperationType); !client.HasScope(scope) {
+			rowErrors = append(rowErrors, importer.RowError{Row: row.RowNumber, Message: "Недостаточно прав: требуется " + scope})
+		} else if !client.CanAccessWallet(row.WalletID) {
+			rowErrors = append(rowErrors, importer.RowError{Row: row.RowNumber, Message: "Нет доступа к кошельку " + row.WalletID.String()})
+		}
+	}
+	return rowErrors
+}
+
+func newImportJobResponse(job *models.ImportJob) importJobResponse {
+	resp := importJobResponse{
+		ID:            job.ID,
+		Status:        job.Status,
+		DryRun:        job.DryRun,
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		SucceededRows: job.SucceededRows,
+		FailedRows:    job.FailedRows,
+		DuplicateRows: job.DuplicateRows,
+		CreatedAt:     job.CreatedAt,
+		ResultsURL:    importsPath + "/" + job.ID.String() + "/results",
+	}
+	if job.StartedAt.Valid {
+		startedAt := job.StartedAt.Time
+		resp.StartedAt = &startedAt
+	}
+	if job.FinishedAt.Valid {
+		finishedAt := job.FinishedAt.Time
+		resp.FinishedAt = &finishedAt
+	}
+	return resp
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type mockImportService struct {
+	created []models.ImportRow
+	dryRun  bool
+}
+
+func (m *mockImportService) CreateImport(clientID *uuid.UUID, rows []models.ImportRow, dryRun bool) (*models.ImportJob, error) {
+	m.created, m.dryRun = rows, dryRun
+	return &models.ImportJob{ID: uuid.New(), Status: models.ImportJobPending, DryRun: dryRun, TotalRows: len(rows)}, nil
+}
+
+func (m *mockImportService) GetImport(clientID *uuid.UUID, jobID string) (*models.ImportJob, error) {
+	return nil, repository.ErrImportJobNotFound
+}
+
+func (m *mockImportService) ListImportRows(clientID *uuid.UUID, jobID string, afterRow, limit int) ([]models.ImportRow, error) {
+	return nil, repository.ErrImportJobNotFound
+}
+
+func TestImportHandler_CreateImport(t *testing.T) {
+	allowed, foreign := uuid.New(), uuid.New()
+	depositOnly := &models.APIClient{ID: uuid.New(), Scopes: []string{models.ScopeWalletDeposit}, WalletIDs: []uuid.UUID{allowed}}
+
+	tests := []struct {
+		name           string
+		query          string
+		rows           string
+		client         *models.APIClient
+		expectedStatus int
+		expectedRows   []int
+	}{
+		{"dry run", "?dryRun=true", allowed.String() + ",DEPOSIT,10,a\n", depositOnly, http.StatusAccepted, nil},
+		{"no auth", "", foreign.String() + ",WITHDRAW,10,a\n", nil, http.StatusAccepted, nil},
+		{"missing scope and foreign wallet", "",
+			allowed.String() + ",DEPOSIT,10,a\n" + allowed.String() + ",WITHDRAW,10,b\n" + foreign.String() + ",DEPOSIT,10,c\n",
+			depositOnly, http.StatusBadRequest, []int{3, 4}},
+		{"invalid dryRun", "?dryRun=maybe", allowed.String() + ",DEPOSIT,10,a\n", nil, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockImportService{}
+			body := "walletId,operationType,amount,externalRef\n" + tt.rows
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/imports"+tt.query, strings.NewReader(body))
+			if tt.client != nil {
+				req = req.WithContext(auth.WithClient(req.Context(), tt.client))
+			}
+			rec := httptest.NewRecorder()
+
+			NewImportHandler(svc).HandleImports(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("HandleImports() status = %d, want %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedStatus == http.StatusAccepted {
+				if svc.dryRun != (tt.query == "?dryRun=true") || len(svc.created) != strings.Count(tt.rows, "\n") {
+					t.Errorf("CreateImport() got %d rows, dryRun %t", len(svc.created), svc.dryRun)
+				}
+				return
+			}
+			if svc.created != nil {
+				t.Error("job created despite invalid request")
+			}
+
+			var resp importValidationResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response body: %v", err)
+			}
+			if len(resp.Rows) != len(tt.expectedRows) {
+				t.Fatalf("row errors = %+v, want rows %v", resp.Rows, tt.expectedRows)
+			}
+			for i, row := range tt.expectedRows {
+				if resp.Rows[i].Row != row {
+					t.Errorf("row error %d = %+v, want row %d", i, resp.Rows[i], row)
+				}
+			}
+		})
+	}
+}
+
+func TestImportHandler_UnknownJob(t *testing.T) {
+	handler := NewImportHandler(&mockImportService{})
+	for _, path := range []string{"/api/v1/imports/" + uuid.New().String(), "/api/v1/imports/" + uuid.New().String() + "/results"} {
+		rec := httptest.NewRecorder()
+		handler.HandleImports(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want %d", path, rec.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+const adminWalletsPath = "/api/v1/admin/wallets/"
+
+// limitsJSON - лимиты в рублях; null означает, что лимита нет (в overrides - что он берется из уровня)
+type limitsJSON struct {
+	MaxBalance        *string `json:"maxBalance"`
+	MinOperation      *string `json:"minOperation"`
+	MaxOperation      *string `json:"maxOperation"`
+	DailyDeposit      *string `json:"dailyDeposit"`
+	DailyWithdrawal   *string `json:"dailyWithdrawal"`
+	MonthlyDeposit    *string `json:"monthlyDeposit"`
+	MonthlyWithdrawal *string `json:"monthlyWithdrawal"`
+}
+
+type limitUsageResponse struct {
+	DailyDeposit      string `json:"dailyDeposit"`
+	DailyWithdrawal   string `json:"dailyWithdrawal"`
+	MonthlyDeposit    string `json:"monthlyDeposit"`
+	MonthlyWithdrawal string `json:"monthlyWithdrawal"`
+}
+
+type walletLimitsResponse struct {
+	WalletID  string             `json:"walletId"`
+	Tier      string             `json:"tier"`
+	Limits    limitsJSON         `json:"limits"`
+	Overrides limitsJSON         `json:"overrides"`
+	Usage     limitUsageResponse `json:"usage"`
+}
+
+type setWalletLimitsRequest struct {
+	Tier      string     `json:"tier"`
+	Overrides limitsJSON `json:"overrides"`
+}
+
+type setOverdraftRequest struct {
+	OverdraftLimit string `json:"overdraftLimit"`
+}
+
+type walletOverdraftResponse struct {
+	WalletID uuid.UUID `json:"walletId"`
+	Balance  string    `json:"balance"`
+	creditJSON
+}
+
+// limitExceededDetails - поле details ошибки LIMIT_EXCEEDED
+type limitExceededDetails struct {
+	WalletID   uuid.UUID `json:"walletId"`
+	Limit      string    `json:"limit"`
+	LimitValue string    `json:"limitValue"`
+	Remaining  *string   `json:"remaining,omitempty"`
+}
+
+func newLimitExceededDetails(limit *models.LimitExceededError) limitExceededDetails {
+	details := limitExceededDetails{
+		WalletID:   limit.WalletID,
+		Limit:      limit.Limit,
+		LimitValue: limit.Value.String(),
+	}
+	if limit.Remaining != nil {
+		remaining := limit.Remaining.String()
+		details.Remaining = &remaining
+	}
+	return details
+}
+
+type LimitHandler struct {
+	service service.LimitServiceInterface
+}
+
+func NewLimitHandler(service service.LimitServiceInterface) *LimitHandler {
+	return &LimitHandler{service: service}
+}
+
+// HandleWalletLimits обслуживает GET и PUT /api/v1/admin/wallets/{walletId}/limits
+func (h *LimitHandler) HandleWalletLimits(w http.ResponseWriter, r *http.Request) {
+	walletID, action, found := strings.Cut(strings.TrimPrefix(r.URL.Path, adminWalletsPath), "/")
+	if !found || action != "limits" {
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+		return
+	}
+	if _, err := uuid.Parse(walletID); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный UUID кошелька")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		state, err := h.service.GetWalletLimits(walletID)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+		response.WriteJSON(w, http.StatusOK, newWalletLimitsResponse(state))
+	case http.MethodPut:
+		h.setWalletLimits(w, r, walletID)
+	default:
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+	}
+}
+
+func (h *LimitHandler) setWalletLimits(w http.ResponseWriter, r *http.Request, walletID string) {
+	var request setWalletLimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	overrides, err := request.Overrides.limits()
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+		return
+	}
+
+	state, err := h.service.SetWalletLimits(walletID, request.Tier, overrides)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, newWalletLimitsResponse(state))
+}
+
+// HandleWalletOverdraft обслуживает PUT /api/v1/admin/wallets/{walletId}/overdraft: назначает кредитную линию кошелька
+func (h *LimitHandler) HandleWalletOverdraft(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+		return
+	}
+
+	walletID, action, found := strings.Cut(strings.TrimPrefix(r.URL.Path, adminWalletsPath), "/")
+	if !found || action != "overdraft" {
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+		return
+	}
+	if _, err := uuid.Parse(walletID); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный UUID кошелька")
+		return
+	}
+
+	var request setOverdraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат JSON")
+		return
+	}
+	limit, err := utils.NewMoneyFromString(request.OverdraftLimit)
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат overdraftLimit")
+		return
+	}
+
+	wallet, err := h.service.SetOverdraftLimit(walletID, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, walletOverdraftResponse{
+		WalletID:   wallet.ID,
+		Balance:    wallet.Balance.String(),
+		creditJSON: newCreditJSON(wallet),
+	})
+}
+
+func (h *LimitHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case stdErrors.Is(err, repository.ErrWalletNotFound):
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Кошелек не найден")
+	case stdErrors.Is(err, service.ErrUnknownWalletTier):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неизвестный уровень кошелька")
+	case stdErrors.Is(err, service.ErrInvalidLimits):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверные лимиты: "+err.Error())
+	case stdErrors.Is(err, service.ErrInvalidOverdraft):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверная кредитная линия: "+err.Error())
+	default:
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+	}
+}
+
+func (l limitsJSON) limits() (models.WalletLimits, error) {
+	var limits models.WalletLimits
+	for _, field := range []struct {
+		name string
+		raw  *string
+		dst  **utils.Money
+	}{
+		{"maxBalance", l.MaxBalance, &limits.MaxBalance},
+		{"minOperation", l.MinOperation, &limits.MinOperation},
+		{"maxOperation", l.MaxOperation, &limits.MaxOperation},
+		{"dailyDeposit", l.DailyDeposit, &limits.DailyDeposit},
+		{"dailyWithdrawal", l.DailyWithdrawal, &limits.DailyWithdrawal},
+		{"monthlyDeposit", l.MonthlyDeposit, &limits.MonthlyDeposit},
+		{"monthlyWithdrawal", l.MonthlyWithdrawal, &limits.MonthlyWithdrawal},
+	} {
+		if field.raw == nil {
+			continue
+		}
+		money, err := utils.NewMoneyFromString(*field.raw)
+		if err != nil {
+			return models.WalletLimits{}, fmt.Errorf("Неверный формат %s", field.name)
+		}
+		*field.dst = &money
+	}
+	return limits, nil
+}
+
+func newLimitsJSON(limits models.WalletLimits) limitsJSON {
+	format := func(m *utils.Money) *string {
+		if m == nil {
+			return nil
+		}
+		s := m.String()
+		return &s
+	}
+	return limitsJSON{
+		MaxBalance:        format(limits.MaxBalance),
+		MinOperation:      format(limits.MinOperation),
+		MaxOperation:      format(limits.MaxOperation),
+		DailyDeposit:      format(limits.DailyDeposit),
+		DailyWithdrawal:   format(limits.DailyWithdrawal),
+		MonthlyDeposit:    format(limits.MonthlyDeposit),
+		MonthlyWithdrawal: format(limits.MonthlyWithdrawal),
+	}
+}
+
+func newWalletLimitsResponse(state *models.WalletLimitsState) walletLimitsResponse {
+	return walletLimitsResponse{
+		WalletID:  state.WalletID,
+		Tier:      state.Tier,
+		Limits:    newLimitsJSON(state.Effective),
+		Overrides: newLimitsJSON(state.Overrides),
+		Usage: limitUsageResponse{
+			DailyDeposit:      state.Usage.DailyDeposit.String(),
+			DailyWithdrawal:   state.Usage.DailyWithdrawal.String(),
+			MonthlyDeposit:    state.Usage.MonthlyDeposit.String(),
+			MonthlyWithdrawal: state.Usage.MonthlyWithdrawal.String(),
+		},
+	}
+}
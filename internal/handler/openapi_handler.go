@@ -0,0 +1,16 @@
+package handler
+
+import "net/http"
+
+// ServeOpenAPI отдает OpenAPI спецификацию как есть, чтобы партнеры могли генерировать по ней клиентов
+func ServeOpenAPI(spec []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
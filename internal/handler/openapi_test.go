@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"wallet-api/api"
+	"wallet-api/internal/models"
+	"wallet-api/internal/openapi"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/internal/stream"
+	"wallet-api/pkg/receipt"
+	"wallet-api/utils"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/google/uuid"
+)
+
+type specAuthService struct {
+	service.AuthServiceInterface
+}
+
+func (s *specAuthService) CreateClient(name string, scopes []string, walletIDs []uuid.UUID) (*models.APIClient, string, error) {
+	return &models.APIClient{ID: uuid.New(), Name: name, Scopes: scopes, WalletIDs: walletIDs, CreatedAt: time.Now()}, "wk_key", nil
+}
+
+func (s *specAuthService) RotateKey(clientID string) (string, error) {
+	return "wk_rotated", nil
+}
+
+func (s *specAuthService) RotateSigningSecret(clientID string) (string, error) {
+	return "secret", nil
+}
+
+type specWebhookService struct {
+	service.WebhookServiceInterface
+	sub *models.WebhookSubscription
+}
+
+func (s *specWebhookService) CreateSubscription(clientID *uuid.UUID, input service.WebhookSubscriptionInput) (*models.WebhookSubscription, string, error) {
+	return s.sub, "whsec", nil
+}
+
+func (s *specWebhookService) ListSubscriptions(clientID *uuid.UUID) ([]models.WebhookSubscription, error) {
+	return []models.WebhookSubscription{*s.sub}, nil
+}
+
+func (s *specWebhookService) DeleteSubscription(clientID *uuid.UUID, subscriptionID string) error {
+	return nil
+}
+
+func (s *specWebhookService) ListDeliveries(clientID *uuid.UUID, subscriptionID string) ([]models.WebhookDelivery, error) {
+	return []models.WebhookDelivery{{
+		ID:            uuid.New(),
+		EventID:       uuid.New(),
+		EventType:     models.WebhookEventDeposit,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+		CreatedAt:     time.Now(),
+	}}, nil
+}
+
+func (s *specWebhookService) ReplayDelivery(clientID *uuid.UUID, subscriptionID, deliveryID string) error {
+	return nil
+}
+
+type specImportService struct {
+	job *models.ImportJob
+}
+
+func (s *specImportService) CreateImport(clientID *uuid.UUID, rows []models.ImportRow, dryRun bool) (*models.ImportJob, error) {
+	return s.job, nil
+}
+
+func (s *specImportService) GetImport(clientID *uuid.UUID, jobID string) (*models.ImportJob, error) {
+	return s.job, nil
+}
+
+func (s *specImportService) ListImportRows(clientID *uuid.UUID, jobID string, afterRow, limit int) ([]models.ImportRow, error) {
+	balance := utils.Money{Raw: 2500}
+	return []models.ImportRow{{
+		JobID: s.job.ID, RowNumber: 2, WalletID: uuid.New(), OperationType: models.OperationTypeDeposit,
+		Amount: utils.Money{Raw: 1500}, ExternalRef: "payout-1", Status: models.ImportRowSucceeded, BalanceAfter: &balance,
+	}}, nil
+}
+
+type specScheduledTransferService struct {
+	st *models.ScheduledTransfer
+}
+
+func (s *specScheduledTransferService) CreateScheduledTransfer(clientID *uuid.UUID, ownerID string, input service.ScheduledTransferInput) (*models.ScheduledTransfer, error) {
+	return s.st, nil
+}
+
+func (s *specScheduledTransferService) ListScheduledTransfers(clientID *uuid.UUID, ownerID string) ([]models.ScheduledTransfer, error) {
+	return []models.ScheduledTransfer{*s.st}, nil
+}
+
+func (s *specScheduledTransferService) GetScheduledTransfer(clientID *uuid.UUID, ownerID, id string) (*models.ScheduledTransfer, error) {
+	return s.st, nil
+}
+
+func (s *specScheduledTransferService) UpdateScheduledTransfer(clientID *uuid.UUID, ownerID, id string, update service.ScheduledTransferUpdate) (*models.ScheduledTransfer, error) {
+	paused := *s.st
+	paused.Status = models.ScheduleStatusPaused
+	return &paused, nil
+}
+
+func (s *specScheduledTransferService) CancelScheduledTransfer(clientID *uuid.UUID, ownerID, id string) error {
+	return nil
+}
+
+func (s *specScheduledTransferService) ListScheduledTransferRuns(clientID *uuid.UUID, ownerID, id string, limit int) ([]models.ScheduledTransferRun, error) {
+	return []models.ScheduledTransferRun{
+		{ScheduleID: s.st.ID, OccurrenceAt: s.st.StartAt, Status: models.ScheduledRunSucceeded, Attempts: 1, ExecutedAt: s.st.StartAt},
+		{ScheduleID: s.st.ID, OccurrenceAt: *s.st.NextRunAt, Status: models.ScheduledRunRetrying, Attempts: 1,
+			FailureReason: models.ScheduledRunInsufficientFunds, ExecutedAt: *s.st.NextRunAt},
+	}, nil
+}
+
+type specAccountingService struct{}
+
+func (s *specAccountingService) GetTrialBalance(ctx context.Context) (*models.TrialBalance, error) {
+	return &models.TrialBalance{
+		AsOf: time.Now(),
+		Accounts: []models.TrialBalanceAccount{
+			{Account: models.SystemAccountCashIn, Credit: utils.Money{Raw: 11050}},
+			{Account: models.TrialBalanceWalletsAccount, Debit: utils.Money{Raw: 11050}},
+		},
+		WalletsBalance: utils.Money{Raw: 11050},
+	}, nil
+}
+
+func (s *specAccountingService) GetLatestReconciliation(limit int) (*models.ReconciliationReport, error) {
+	runID := uuid.New()
+	return &models.ReconciliationReport{
+		Run: models.ReconciliationRun{
+			ID: runID, Status: models.ReconciliationCompleted, WalletsChecked: 10, Mismatches: 1, FrozenWallets: 1,
+			StartedAt: time.Now(), FinishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		},
+		Mismatches: []models.ReconciliationMismatch{{
+			RunID: runID, WalletID: uuid.New(), Reason: models.MismatchLedgerDrift,
+			WalletBalance: utils.Money{Raw: 1100}, LedgerBalance: utils.Money{Raw: 1000}, LedgerEntries: 2,
+			LastEntryID: sql.NullInt64{Int64: 7, Valid: true}, LastBalanceAfter: sql.NullInt64{Int64: 1000, Valid: true},
+			Frozen: true, DetectedAt: time.Now(),
+		}},
+	}, nil
+}
+
+func (s *specAccountingService) VerifyLedgerChain(ctx context.Context, walletID *uuid.UUID) (*models.LedgerChainVerification, error) {
+	return &models.LedgerChainVerification{WalletsChecked: 1, EntriesChecked: 3}, nil
+}
+
+type specLimitService struct{}
+
+func (s *specLimitService) GetWalletLimits(walletID string) (*models.WalletLimitsState, error) {
+	maxBalance := utils.Money{Raw: 1500000}
+	return &models.WalletLimitsState{
+		WalletID:  walletID,
+		Tier:      models.WalletTierAnonymous,
+		Effective: models.WalletLimits{MaxBalance: &maxBalance},
+		Usage:     models.LimitUsage{MonthlyWithdrawal: utils.Money{Raw: 250000}},
+	}, nil
+}
+
+func (s *specLimitService) SetWalletLimits(walletID, tier string, overrides models.WalletLimits) (*models.WalletLimitsState, error) {
+	state, _ := s.GetWalletLimits(walletID)
+	state.Tier, state.Overrides = tier, overrides
+	state.Effective = state.Effective.WithOverrides(overrides)
+	return state, nil
+}
+
+func (s *specLimitService) SetOverdraftLimit(walletID string, limit utils.Money) (*models.Wallet, error) {
+	return &models.Wallet{ID: uuid.MustParse(walletID), Balance: utils.Money{Raw: -15000}, OverdraftLimit: limit, CreatedAt: time.Now()}, nil
+}
+
+type specFeeService struct{}
+
+func (s *specFeeService) ListFeeRules() ([]models.FeeRule, error) {
+	minFee := utils.Money{Raw: 50}
+	return []models.FeeRule{
+		{OperationType: models.OperationTypeWithdraw, Kind: models.FeeKindPercent, RateBasisPoints: 150, Min: &minFee, Rounding: utils.RoundHalfUp},
+		{OperationType: models.JournalOperationTransfer, Tier: models.WalletTierAnonymous, Kind: models.FeeKindTiered, Rounding: utils.RoundUp, Brackets: []models.FeeBracket{
+			{Flat: utils.Money{Raw: 1000}},
+			{From: utils.Money{Raw: 100000}, RateBasisPoints: 50},
+		}},
+	}, nil
+}
+
+func (s *specFeeService) SetFeeRules(rules []models.FeeRule) ([]models.FeeRule, error) {
+	return rules, nil
+}
+
+func (s *specFeeService) QuoteFee(walletID, operationType string, amount utils.Money) (models.Fee, error) {
+	return models.NewFee(operationType, amount, amount.MulBasisPoints(150, utils.RoundHalfUp)), nil
+}
+
+// TestOpenAPISpecMatchesHandlers проверяет, что запросы и ответы обработчиков соответствуют api/openapi.json,
+// и что для каждой операции спецификации есть обработчик. Тест падает, когда спецификация и код расходятся
+func TestOpenAPISpecMatchesHandlers(t *testing.T) {
+	spec, err := openapi.Load(api.OpenAPISpec)
+	if err != nil {
+		t.Fatalf("openapi.Load() error = %v", err)
+	}
+	openapi3filter.RegisterBodyDecoder("text/event-stream", openapi3filter.FileBodyDecoder)
+	defer openapi3filter.UnregisterBodyDecoder("text/event-stream")
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.FileBodyDecoder)
+	defer openapi3filter.UnregisterBodyDecoder("application/x-ndjson")
+	openapi3filter.RegisterBodyDecoder("application/xml", openapi3filter.FileBodyDecoder)
+	defer openapi3filter.UnregisterBodyDecoder("application/xml")
+
+	walletID := uuid.New()
+	walletService := &MockWalletService{
+		wallet: &models.Wallet{
+			ID: walletID, Balance: utils.Money{Raw: 11050}, Status: models.WalletStatusActive,
+			Metadata: models.WalletMetadata{"team": "billing"}, CreatedAt: time.Now(),
+		},
+		ledger: []models.LedgerEntry{{
+			ID: 1, WalletID: walletID, EntryType: models.OperationTypeDeposit,
+			Amount: utils.Money{Raw: 11050}, BalanceAfter: utils.Money{Raw: 11050}, CreatedAt: time.Now(),
+		}},
+	}
+	_, receiptKey, _ := ed25519.GenerateKey(nil)
+	signer := receipt.NewSigner(receiptKey)
+	walletService.receipt = &receipt.Receipt{
+		TransactionID: uuid.New(), WalletID: walletID, OperationType: models.OperationTypeDeposit,
+		Amount: "10.50", Fee: "0.00", Net: "10.50", BalanceAfter: "110.50", Timestamp: time.Now().UTC(),
+	}
+	signer.Sign(walletService.receipt)
+	receiptKeys := receipt.NewKeySet()
+	receiptKeys.Add(signer.PublicKey())
+	walletHandler := NewWalletHandler(walletService)
+	eventsHandler := NewWalletEventsHandler(walletHandler, stream.NewBroker(), time.Hour)
+	feeHandler := NewFeeHandler(&specFeeService{}, walletHandler)
+	wallets := WalletRoutes(walletHandler.HandleGetWallet, map[string]http.HandlerFunc{
+		"events":    eventsHandler.HandleWalletEvents,
+		"statement": walletHandler.HandleWalletStatement,
+		"fee-quote": feeHandler.HandleFeeQuote,
+	})
+	threshold := utils.Money{Raw: 1000}
+	webhookHandler := NewWebhookHandler(&specWebhookService{sub: &models.WebhookSubscription{
+		ID:                  uuid.New(),
+		URL:                 "https://billing.example/hooks",
+		EventTypes:          []string{models.WebhookEventLowBalance},
+		LowBalanceThreshold: &threshold,
+		Active:              true,
+		CreatedAt:           time.Now(),
+	}})
+	adminHandler := NewAdminHandler(&specAuthService{})
+	accountingHandler := NewAccountingHandler(&specAccountingService{})
+	importHandler := NewImportHandler(&specImportService{job: &models.ImportJob{
+		ID: uuid.New(), Status: models.ImportJobRunning, TotalRows: 2, ProcessedRows: 1, SucceededRows: 1,
+		CreatedAt: time.Now(), StartedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}})
+	importCSV := "walletId,operationType,amount,externalRef\n" + walletID.String() + ",DEPOSIT,15.00,payout-1\n"
+	importPath := "/api/v1/imports/" + uuid.New().String()
+	notFoundService := &MockWalletService{shouldError: true, errorType: repository.ErrWalletNotFound}
+	remaining := utils.Money{Raw: 50000}
+	limitService := &MockWalletService{shouldError: true, errorType: fmt.Errorf("process operation: %w: %w", service.ErrLimitExceeded, &models.LimitExceededError{
+		WalletID: walletID, Limit: models.LimitMaxBalance, Value: utils.Money{Raw: 1500000}, Remaining: &remaining,
+	})}
+	limitHandler := NewLimitHandler(&specLimitService{})
+	adminWallets := AdminWalletRoutes(map[string]http.HandlerFunc{
+		"limits":    limitHandler.HandleWalletLimits,
+		"overdraft": limitHandler.HandleWalletOverdraft,
+	})
+	limitsPath := "/api/v1/admin/wallets/" + walletID.String() + "/limits"
+	scheduleStart := time.Date(2025, time.November, 1, 9, 0, 0, 0, time.UTC)
+	nextRun := scheduleStart.AddDate(0, 1, 0)
+	retryAt := nextRun.Add(time.Hour)
+	scheduleHandler := NewScheduledTransferHandler(&specScheduledTransferService{st: &models.ScheduledTransfer{
+		ID: uuid.New(), FromWalletID: walletID, ToWalletID: uuid.New(), Amount: utils.Money{Raw: 50000},
+		Recurrence: models.Recurrence{Cron: "0 9 1 * *"}, StartAt: scheduleStart, MaxRetries: 3, RetryInterval: time.Hour,
+		Status: models.ScheduleStatusActive, NextRunAt: &nextRun, RetryAt: &retryAt, Attempts: 1,
+		CreatedAt: scheduleStart, UpdatedAt: retryAt,
+	}}, walletHandler)
+	schedulePath := "/api/v1/scheduled-transfers/" + uuid.New().String()
+
+	subPath := "/api/v1/webhooks/" + uuid.New().String()
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{"process operation", http.MethodPost, "/api/v1/wallet", `{"walletId":"` + walletID.String() + `","operationType":"DEPOSIT","amount":10.5}`, walletHandler.HandleWalletOperation, http.StatusOK},
+		{"operation over limit", http.MethodPost, "/api/v1/wallet", `{"walletId":"` + walletID.String() + `","operationType":"DEPOSIT","amount":1000}`, NewWalletHandler(limitService).HandleWalletOperation, http.StatusUnprocessableEntity},
+		{"list wallets", http.MethodGet, "/api/v1/wallets?status=ACTIVE&tag=team:billing&minBalance=1.00&createdFrom=2025-09-01T00:00:00Z&sort=-created_at&limit=10", "", walletHandler.HandleListWallets, http.StatusOK},
+		{"get wallet", http.MethodGet, "/api/v1/wallets/" + walletID.String(), "", wallets, http.StatusOK},
+		{"get wallet as of", http.MethodGet, "/api/v1/wallets/" + walletID.String() + "?asOf=" + url.QueryEscape(time.Now().Add(time.Minute).Format(time.RFC3339)), "", wallets, http.StatusOK},
+		{"get missing wallet", http.MethodGet, "/api/v1/wallets/" + walletID.String(), "", NewWalletHandler(notFoundService).HandleGetWallet, http.StatusNotFound},
+		{"lookup wallets", http.MethodPost, "/api/v1/wallets/lookup", `{"walletIds":["` + walletID.String() + `","` + uuid.New().String() + `"]}`, walletHandler.HandleLookupWallets, http.StatusOK},
+		{"stream wallet events", http.MethodGet, "/api/v1/wallets/" + walletID.String() + "/events", "", wallets, http.StatusOK},
+		{"wallet statement csv", http.MethodGet, "/api/v1/wallets/" + walletID.String() + "/statement?from=2025-09-01T00:00:00Z", "", wallets, http.StatusOK},
+		{"wallet statement jsonl", http.MethodGet, "/api/v1/wallets/" + walletID.String() + "/statement?format=jsonl", "", wallets, http.StatusOK},
+		{"wallet statement camt053", http.MethodGet, "/api/v1/wallets/" + walletID.String() + "/statement?format=camt053", "", wallets, http.StatusOK},
+		{"create client", http.MethodPost, "/api/v1/admin/clients", `{"name":"billing","scopes":["wallet:read"]}`, adminHandler.HandleClients, http.StatusCreated},
+		{"rotate key", http.MethodPost, "/api/v1/admin/clients/" + uuid.New().String() + "/rotate-key", "", adminHandler.HandleClients, http.StatusOK},
+		{"rotate signing secret", http.MethodPost, "/api/v1/admin/clients/" + uuid.New().String() + "/signing-secret", "", adminHandler.HandleClients, http.StatusOK},
+		{"trial balance", http.MethodGet, "/api/v1/admin/trial-balance", "", accountingHandler.HandleTrialBalance, http.StatusOK},
+		{"reconciliation", http.MethodGet, "/api/v1/admin/reconciliation?limit=10", "", accountingHandler.HandleReconciliation, http.StatusOK},
+		{"verify ledger", http.MethodGet, "/api/v1/admin/ledger/verify?walletId=" + walletID.String(), "", accountingHandler.HandleLedgerVerification, http.StatusOK},
+		{"quote fee", http.MethodGet, "/api/v1/wallets/" + walletID.String() + "/fee-quote?operationType=WITHDRAW&amount=100.00", "", wallets, http.StatusOK},
+		{"list fee rules", http.MethodGet, "/api/v1/admin/fee-rules", "", feeHandler.HandleFeeRules, http.StatusOK},
+		{"set fee rules", http.MethodPut, "/api/v1/admin/fee-rules", `{"rules":[{"operationType":"DEPOSIT","tier":null,"kind":"flat","flat":"15.00"},{"operationType":"WITHDRAW","tier":"anonymous","kind":"percent","rateBasisPoints":150,"min":"0.50","max":"500.00","rounding":"up"}]}`, feeHandler.HandleFeeRules, http.StatusOK},
+		{"get wallet limits", http.MethodGet, limitsPath, "", adminWallets, http.StatusOK},
+		{"set wallet limits", http.MethodPut, limitsPath, `{"tier":"anonymous","overrides":{"maxOperation":"5000.00","dailyDeposit":null}}`, adminWallets, http.StatusOK},
+		{"set wallet overdraft", http.MethodPut, "/api/v1/admin/wallets/" + walletID.String() + "/overdraft", `{"overdraftLimit":"500.00"}`, adminWallets, http.StatusOK},
+		{"list webhooks", http.MethodGet, "/api/v1/webhooks", "", webhookHandler.HandleWebhooks, http.StatusOK},
+		{"create webhook", http.MethodPost, "/api/v1/webhooks", `{"url":"https://billing.example/hooks","eventTypes":["low_balance"],"lowBalanceThreshold":"10.00"}`, webhookHandler.HandleWebhooks, http.StatusCreated},
+		{"delete webhook", http.MethodDelete, subPath, "", webhookHandler.HandleWebhooks, http.StatusNoContent},
+		{"list deliveries", http.MethodGet, subPath + "/deliveries", "", webhookHandler.HandleWebhooks, http.StatusOK},
+		{"replay delivery", http.MethodPost, subPath + "/deliveries/" + uuid.New().String() + "/replay", "", webhookHandler.HandleWebhooks, http.StatusAccepted},
+		{"create import", http.MethodPost, "/api/v1/imports?dryRun=true", importCSV, importHandler.HandleImports, http.StatusAccepted},
+		{"create import with invalid rows", http.MethodPost, "/api/v1/imports", importCSV + walletID.String() + ",REFUND,1,payout-2\n", importHandler.HandleImports, http.StatusBadRequest},
+		{"get import", http.MethodGet, importPath, "", importHandler.HandleImports, http.StatusOK},
+		{"get import results", http.MethodGet, importPath + "/results", "", importHandler.HandleImports, http.StatusOK},
+		{"create scheduled transfer", http.MethodPost, "/api/v1/scheduled-transfers", `{"fromWalletId":"` + walletID.String() + `","toWalletId":"` + uuid.New().String() + `","amount":"500.00","cron":"0 9 1 * *","startAt":"2025-11-01T00:00:00Z","maxRetries":3,"retryIntervalSeconds":3600}`, scheduleHandler.HandleScheduledTransfers, http.StatusCreated},
+		{"list scheduled transfers", http.MethodGet, "/api/v1/scheduled-transfers", "", scheduleHandler.HandleScheduledTransfers, http.StatusOK},
+		{"get scheduled transfer", http.MethodGet, schedulePath, "", scheduleHandler.HandleScheduledTransfers, http.StatusOK},
+		{"pause scheduled transfer", http.MethodPatch, schedulePath, `{"status":"paused","amount":"750.00"}`, scheduleHandler.HandleScheduledTransfers, http.StatusOK},
+		{"cancel scheduled transfer", http.MethodDelete, schedulePath, "", scheduleHandler.HandleScheduledTransfers, http.StatusNoContent},
+		{"list scheduled transfer runs", http.MethodGet, schedulePath + "/runs?limit=10", "", scheduleHandler.HandleScheduledTransfers, http.StatusOK},
+		{"metrics", http.MethodGet, "/metrics", "", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"panics_total": 0}`)
+		}, http.StatusOK},
+		{"receipt keys", http.MethodGet, "/.well-known/wallet-receipt-keys", "", ServeReceiptKeys(receiptKeys), http.StatusOK},
+		{"openapi", http.MethodGet, "/openapi.json", "", ServeOpenAPI(api.OpenAPISpec), http.StatusOK},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Поток событий завершается сразу после начальной отправки
+			ctx, cancel := context.WithCancel(context.Background())
+			if strings.HasSuffix(tt.path, "/events") {
+				cancel()
+			}
+			defer cancel()
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)).WithContext(ctx)
+			if strings.HasPrefix(tt.body, "{") {
+				req.Header.Set("Content-Type", "application/json")
+			} else if tt.body != "" {
+				req.Header.Set("Content-Type", "text/csv")
+			}
+
+			route, pathParams, err := spec.FindRoute(req)
+			if err != nil {
+				t.Fatalf("%s %s is not described in the spec: %v", tt.method, tt.path, err)
+			}
+			covered[route.Operation.OperationID] = true
+
+			if err := spec.ValidateRequest(req, route, pathParams); err != nil {
+				t.Fatalf("request does not match the spec: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route},
+				Status:                 rec.Code,
+				Header:                 rec.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+			})
+			if err != nil {
+				t.Errorf("response does not match the spec: %v", err)
+			}
+		})
+	}
+
+	for path, item := range spec.Doc.Paths.Map() {
+		for method, operation := range item.Operations() {
+			if !covered[operation.OperationID] {
+				t.Errorf("%s %s (%s) has no handler test", method, path, operation.OperationID)
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"wallet-api/pkg/receipt"
+)
+
+// ServeReceiptKeys отдает публичные ключи проверки квитанций в формате JWKS.
+// Ответ можно кэшировать: ключи меняются только при перезапуске с новой конфигурацией
+func ServeReceiptKeys(keys *receipt.KeySet) http.HandlerFunc {
+	body, err := json.Marshal(keys)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(body)
+	}
+}
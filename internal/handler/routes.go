@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"wallet-api/utils/response"
+)
+
+// WalletRoutes направляет /api/v1/wallets/{walletId}/{action} на обработчик действия,
+// а /api/v1/wallets/{walletId} - на getWallet
+func WalletRoutes(getWallet http.HandlerFunc, actions map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, action, found := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/wallets/"), "/")
+		if !found {
+			getWallet(w, r)
+			return
+		}
+
+		handler, ok := actions[action]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// AdminWalletRoutes направляет /api/v1/admin/wallets/{walletId}/{action} на обработчик действия
+func AdminWalletRoutes(actions map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, adminWalletsPath), "/")
+		handler, ok := actions[action]
+		if !ok {
+			response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+			return
+		}
+		handler(w, r)
+	}
+}
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+const (
+	scheduledTransfersPath = "/api/v1/scheduled-transfers"
+	defaultScheduleRuns    = 100
+	maxScheduleRuns        = 1000
+)
+
+type createScheduledTransferRequest struct {
+	FromWalletID         uuid.UUID  `json:"fromWalletId"`
+	ToWalletID           uuid.UUID  `json:"toWalletId"`
+	Amount               string     `json:"amount"`
+	Cron                 string     `json:"cron"`
+	IntervalSeconds      int64      `json:"intervalSeconds"`
+	StartAt              *time.Time `json:"startAt"`
+	EndAt                *time.Time `json:"endAt"`
+	MaxRetries           int        `json:"maxRetries"`
+	RetryIntervalSeconds int64      `json:"retryIntervalSeconds"`
+}
+
+type updateScheduledTransferRequest struct {
+	Amount               *string    `json:"amount"`
+	EndAt                *time.Time `json:"endAt"`
+	MaxRetries           *int       `json:"maxRetries"`
+	RetryIntervalSeconds *int64     `json:"retryIntervalSeconds"`
+	Status               *string    `json:"status"`
+}
+
+type scheduledTransferResponse struct {
+	ID                   uuid.UUID  `json:"id"`
+	FromWalletID         uuid.UUID  `json:"fromWalletId"`
+	ToWalletID           uuid.UUID  `json:"toWalletId"`
+	Amount               string     `json:"amount"`
+	Cron                 string     `json:"cron,omitempty"`
+	IntervalSeconds      int64      `json:"intervalSeconds,omitempty"`
+	StartAt              time.Time  `json:"startAt"`
+	EndAt                *time.Time `json:"endAt,omitempty"`
+	MaxRetries           int        `json:"maxRetries"`
+	RetryIntervalSeconds int64      `json:"retryIntervalSeconds"`
+	Status               string     `json:"status"`
+	NextRunAt            *time.Time `json:"nextRunAt,omitempty"`
+	RetryAt              *time.Time `json:"retryAt,omitempty"`
+	Attempts             int        `json:"attempts"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	UpdatedAt            time.Time  `json:"updatedAt"`
+}
+
+type scheduledTransferRunResponse struct {
+	OccurrenceAt  time.Time `json:"occurrenceAt"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	FailureReason string    `json:"failureReason,omitempty"`
+	ExecutedAt    time.Time `json:"executedAt"`
+}
+
+type ScheduledTransferHandler struct {
+	service service.ScheduledTransferServiceInterface
+	wallets *WalletHandler
+}
+
+func NewScheduledTransferHandler(service service.ScheduledTransferServiceInterface, wallets *WalletHandler) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{service: service, wallets: wallets}
+}
+
+// HandleScheduledTransfers обслуживает:
+// GET/POST /api/v1/scheduled-transfers, GET/PATCH/DELETE /api/v1/scheduled-transfers/{id}
+// и GET /api/v1/scheduled-transfers/{id}/runs
+func (h *ScheduledTransferHandler) HandleScheduledTransfers(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, scheduledTransfersPath), "/")
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, "/")
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		h.listScheduledTransfers(w, r)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		h.createScheduledTransfer(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		h.getScheduledTransfer(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		h.updateScheduledTransfer(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		h.cancelScheduledTransfer(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "runs" && r.Method == http.MethodGet:
+		h.listScheduledTransferRuns(w, r, parts[0])
+	case len(parts) <= 2:
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+	default:
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+	}
+}
+
+func (h *ScheduledTransferHandler) createScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	var request createScheduledTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	// Как и при обычном переводе, доступ проверяется к кошельку-источнику: API клиенту - по списку кошельков,
+	// владельцу из JWT - по владельцу кошелька
+	if !h.wallets.authorizeWallet(w, r, request.FromWalletID.String(), models.ScopeWalletWithdraw) {
+		return
+	}
+	if _, err := h.wallets.loadWallet(r, request.FromWalletID.String()); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	amount, err := utils.NewMoneyFromString(request.Amount)
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат amount")
+		return
+	}
+
+	input := service.ScheduledTransferInput{
+		FromWalletID: request.FromWalletID,
+		ToWalletID:   request.ToWalletID,
+		Amount:       amount,
+		Recurrence: models.Recurrence{
+			Cron:     request.Cron,
+			Interval: time.Duration(request.IntervalSeconds) * time.Second,
+		},
+		EndAt:         request.EndAt,
+		MaxRetries:    request.MaxRetries,
+		RetryInterval: time.Duration(request.RetryIntervalSeconds) * time.Second,
+	}
+	if request.StartAt != nil {
+		input.StartAt = *request.StartAt
+	}
+
+	st, err := h.service.CreateScheduledTransfer(clientScope(r), auth.OwnerFromContext(r.Context()), input)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	response.WriteJSON(w, http.StatusCreated, newScheduledTransferResponse(st))
+}
+
+func (h *ScheduledTransferHandler) listScheduledTransfers(w http.ResponseWriter, r *http.Request) {
+	items, err := h.service.ListScheduledTransfers(clientScope(r), auth.OwnerFromContext(r.Context()))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := make([]scheduledTransferResponse, 0, len(items))
+	for i := range items {
+		resp = append(resp, newScheduledTransferResponse(&items[i]))
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]interface{}{"items": resp})
+}
+
+func (h *ScheduledTransferHandler) getScheduledTransfer(w http.ResponseWriter, r *http.Request, id string) {
+	st, err := h.service.GetScheduledTransfer(clientScope(r), auth.OwnerFromContext(r.Context()), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, newScheduledTransferResponse(st))
+}
+
+func (h *ScheduledTransferHandler) updateScheduledTransfer(w http.ResponseWriter, r *http.Request, id string) {
+	var request updateScheduledTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	update := service.ScheduledTransferUpdate{
+		EndAt:      request.EndAt,
+		MaxRetries: request.MaxRetries,
+		Status:     request.Status,
+	}
+	if request.Amount != nil {
+		amount, err := utils.NewMoneyFromString(*request.Amount)
+		if err != nil {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат amount")
+			return
+		}
+		update.Amount = &amount
+	}
+	if request.RetryIntervalSeconds != nil {
+		interval := time.Duration(*request.RetryIntervalSeconds) * time.Second
+		update.RetryInterval = &interval
+	}
+
+	st, err := h.service.UpdateScheduledTransfer(clientScope(r), auth.OwnerFromContext(r.Context()), id, update)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, newScheduledTransferResponse(st))
+}
+
+func (h *ScheduledTransferHandler) cancelScheduledTransfer(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.service.CancelScheduledTransfer(clientScope(r), auth.OwnerFromContext(r.Context()), id); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ScheduledTransferHandler) listScheduledTransferRuns(w http.ResponseWriter, r *http.Request, id string) {
+	limit := defaultScheduleRuns
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxScheduleRuns {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "limit должен быть от 1 до 1000")
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := h.service.ListScheduledTransferRuns(clientScope(r), auth.OwnerFromContext(r.Context()), id, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	items := make([]scheduledTransferRunResponse, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, scheduledTransferRunResponse{
+			OccurrenceAt:  run.OccurrenceAt,
+			Status:        run.Status,
+			Attempts:      run.Attempts,
+			FailureReason: run.FailureReason,
+			ExecutedAt:    run.ExecutedAt,
+		})
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func (h *ScheduledTransferHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case stdErrors.Is(err, service.ErrInvalidSchedule):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверное расписание: "+err.Error())
+	case stdErrors.Is(err, repository.ErrScheduledTransferNotFound):
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Расписание не найдено")
+	case stdErrors.Is(err, repository.ErrWalletNotFound):
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Кошелек не найден")
+	case stdErrors.Is(err, service.ErrWalletAccessDenied):
+		response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Нет доступа к кошельку")
+	default:
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+	}
+}
+
+func newScheduledTransferResponse(st *models.ScheduledTransfer) scheduledTransferResponse {
+	return scheduledTransferResponse{
+		ID:                   st.ID,
+		FromWalletID:         st.FromWalletID,
+		ToWalletID:           st.ToWalletID,
+		Amount:               st.Amount.String(),
+		Cron:                 st.Recurrence.Cron,
+		IntervalSeconds:      int64(st.Recurrence.Interval / time.Second),
+		StartAt:              st.StartAt,
+		EndAt:                st.EndAt,
+		MaxRetries:           st.MaxRetries,
+		RetryIntervalSeconds: int64(st.RetryInterval / time.Second),
+		Status:               st.Status,
+		NextRunAt:            st.NextRunAt,
+		RetryAt:              st.RetryAt,
+		Attempts:             st.Attempts,
+		CreatedAt:            st.CreatedAt,
+		UpdatedAt:            st.UpdatedAt,
+	}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/stream"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+const ledgerReplayPageSize = 500
+
+type walletEventData struct {
+	ID            int64     `json:"id"`
+	WalletID      uuid.UUID `json:"walletId"`
+	OperationType string    `json:"operationType"`
+	Amount        string    `json:"amount"`
+	Balance       string    `json:"balance"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// WalletEventsHandler отдает изменения баланса кошелька потоком Server-Sent Events
+type WalletEventsHandler struct {
+	wallets   *WalletHandler
+	broker    *stream.Broker
+	heartbeat time.Duration
+}
+
+func NewWalletEventsHandler(wallets *WalletHandler, broker *stream.Broker, heartbeat time.Duration) *WalletEventsHandler {
+	return &WalletEventsHandler{wallets: wallets, broker: broker, heartbeat: heartbeat}
+}
+
+// HandleWalletEvents обслуживает GET /api/v1/wallets/{walletId}/events.
+// ID события - ID проводки в журнале, поэтому клиент, переподключившись с Last-Event-ID,
+// получит все пропущенные изменения
+func (h *WalletEventsHandler) HandleWalletEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	walletID, err := uuid.Parse(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/wallets/"), "/events"))
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный UUID кошелька")
+		return
+	}
+
+	var lastID int64
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный Last-Event-ID")
+			return
+		}
+	}
+
+	if !h.wallets.authorizeWallet(w, r, walletID.String(), models.ScopeWalletRead) {
+		return
+	}
+
+	if _, err := h.wallets.loadWallet(r, walletID.String()); err != nil {
+		h.wallets.handleServiceError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Потоковая передача не поддерживается")
+		return
+	}
+
+	// Подписка оформляется до чтения журнала, чтобы не пропустить проводку между чтением и подпиской
+	sub := h.broker.Subscribe(walletID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Без Last-Event-ID клиент сначала получает последнюю проводку с текущим балансом, а не всю историю
+	if r.Header.Get("Last-Event-ID") == "" {
+		if lastID, err = h.sendLatestEntry(w, walletID); err != nil {
+			return
+		}
+	}
+	if lastID, err = h.sendEntries(w, walletID, lastID); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-sub.Notify():
+			if lastID, err = h.sendEntries(w, walletID, lastID); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// sendEntries дочитывает журнал после afterID и возвращает ID последней отправленной проводки
+func (h *WalletEventsHandler) sendEntries(w http.ResponseWriter, walletID uuid.UUID, afterID int64) (int64, error) {
+	for {
+		entries, err := h.wallets.service.ListLedgerEntries(walletID.String(), afterID, ledgerReplayPageSize)
+		if err != nil {
+			logger.GlobalLogger.Error("Ошибка чтения журнала кошелька %s: %v", walletID, err)
+			return afterID, err
+		}
+
+		for _, entry := range entries {
+			if err := writeLedgerEvent(w, entry); err != nil {
+				return afterID, err
+			}
+			afterID = entry.ID
+		}
+
+		if len(entries) < ledgerReplayPageSize {
+			return afterID, nil
+		}
+	}
+}
+
+func (h *WalletEventsHandler) sendLatestEntry(w http.ResponseWriter, walletID uuid.UUID) (int64, error) {
+	entry, err := h.wallets.service.GetLastLedgerEntry(walletID.String())
+	if err != nil {
+		logger.GlobalLogger.Error("Ошибка чтения журнала кошелька %s: %v", walletID, err)
+		return 0, err
+	}
+	if entry == nil {
+		return 0, nil
+	}
+	return entry.ID, writeLedgerEvent(w, *entry)
+}
+
+func writeLedgerEvent(w http.ResponseWriter, entry models.LedgerEntry) error {
+	data, err := json.Marshal(walletEventData{
+		ID:            entry.ID,
+		WalletID:      entry.WalletID,
+		OperationType: entry.EntryType,
+		Amount:        entry.Amount.String(),
+		Balance:       entry.BalanceAfter.String(),
+		CreatedAt:     entry.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: balance\ndata: %s\n\n", entry.ID, data)
+	return err
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/stream"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// lockedLedgerService позволяет дописывать журнал, пока обработчик читает его из другой горутины
+type lockedLedgerService struct {
+	*MockWalletService
+	mu sync.Mutex
+}
+
+func (s *lockedLedgerService) append(entry models.LedgerEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ledger = append(s.ledger, entry)
+}
+
+func (s *lockedLedgerService) ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MockWalletService.ListLedgerEntries(walletID, afterID, limit)
+}
+
+func (s *lockedLedgerService) GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MockWalletService.GetLastLedgerEntry(walletID)
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) (string, walletEventData) {
+	t.Helper()
+
+	var id string
+	var data walletEventData
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && id != "":
+			return id, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data); err != nil {
+				t.Fatalf("decode event data: %v", err)
+			}
+		}
+	}
+}
+
+func TestWalletEventsHandler_HandleWalletEvents(t *testing.T) {
+	walletID := uuid.New()
+	ledgerEntry := func(id int64, balance int64) models.LedgerEntry {
+		return models.LedgerEntry{
+			ID:           id,
+			WalletID:     walletID,
+			EntryType:    models.OperationTypeDeposit,
+			Amount:       utils.Money{Raw: 100},
+			BalanceAfter: utils.Money{Raw: balance},
+			CreatedAt:    time.Now(),
+		}
+	}
+
+	mockService := &lockedLedgerService{MockWalletService: &MockWalletService{
+		wallet: &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 300}},
+		ledger: []models.LedgerEntry{ledgerEntry(1, 100), ledgerEntry(2, 200), ledgerEntry(3, 300)},
+	}}
+	broker := stream.NewBroker()
+	eventsHandler := NewWalletEventsHandler(NewWalletHandler(mockService), broker, time.Hour)
+
+	server := httptest.NewServer(WalletRoutes(nil, map[string]http.HandlerFunc{
+		"events": eventsHandler.HandleWalletEvents,
+	}))
+	defer server.Close()
+
+	connect := func(lastEventID string) (*http.Response, *bufio.Reader) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/wallets/"+walletID.String()+"/events", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("Content-Type = %q", ct)
+		}
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	t.Run("resume from Last-Event-ID", func(t *testing.T) {
+		resp, reader := connect("1")
+		defer resp.Body.Close()
+
+		for _, want := range []string{"2", "3"} {
+			if id, _ := readSSEEvent(t, reader); id != want {
+				t.Fatalf("event id = %s, want %s", id, want)
+			}
+		}
+	})
+
+	t.Run("fresh connection starts with current balance and receives live events", func(t *testing.T) {
+		resp, reader := connect("")
+		defer resp.Body.Close()
+
+		id, data := readSSEEvent(t, reader)
+		if id != "3" || data.Balance != "3.00" {
+			t.Fatalf("first event = %s %+v, want latest entry", id, data)
+		}
+
+		mockService.append(ledgerEntry(4, 400))
+		broker.Publish(walletID)
+
+		id, data = readSSEEvent(t, reader)
+		if id != "4" || data.Balance != "4.00" || data.WalletID != walletID {
+			t.Fatalf("live event = %s %+v", id, data)
+		}
+	})
+
+	t.Run("invalid Last-Event-ID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/wallets/"+walletID.String()+"/events", nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
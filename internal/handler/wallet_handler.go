@@ -5,14 +5,45 @@ import (
 	stdErrors "errors"
 	"fmt"
 	"net/http"
+	"time"
+	"wallet-api/internal/auth"
 	"wallet-api/internal/models"
 	"wallet-api/internal/repository"
 	"wallet-api/internal/service"
 	"wallet-api/utils"
+	"wallet-api/utils/response"
 
 	"github.com/google/uuid"
 )
 
+// maxLookupWalletIDs ограничивает размер одного запроса POST /api/v1/wallets/lookup
+const maxLookupWalletIDs = 500
+
+type lookupWalletsRequest struct {
+	WalletIDs []uuid.UUID `json:"walletIds"`
+}
+
+// creditJSON - кредитная линия кошелька в рублях: ее размер, использованная и оставшаяся части
+type creditJSON struct {
+	OverdraftLimit  string `json:"overdraftLimit"`
+	CreditUsed      string `json:"creditUsed"`
+	CreditRemaining string `json:"creditRemaining"`
+}
+
+func newCreditJSON(wallet *models.Wallet) creditJSON {
+	return creditJSON{
+		OverdraftLimit:  wallet.OverdraftLimit.String(),
+		CreditUsed:      wallet.CreditUsed().String(),
+		CreditRemaining: wallet.CreditRemaining().String(),
+	}
+}
+
+type walletBalanceResponse struct {
+	ID      uuid.UUID `json:"id"`
+	Balance string    `json:"balance"`
+	creditJSON
+}
+
 // Временная структура для декодирования JSON с рубли
 type walletOperationRequest struct {
 	WalletID      uuid.UUID `json:"walletId"`
@@ -53,6 +84,7 @@ func (h *WalletHandler) HandleWalletOperation(w http.ResponseWriter, r *http.Req
 		WalletID:      request.WalletID,
 		OperationType: request.OperationType,
 		Amount:        money.Raw, // int64 в копейках
+		OwnerID:       auth.OwnerFromContext(r.Context()),
 	}
 
 	if err := h.validateWalletOperation(&operation); err != nil {
@@ -60,17 +92,27 @@ func (h *WalletHandler) HandleWalletOperation(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	wallet, err := h.service.ProcessWalletOperation(&operation)
+	if !h.authorizeWallet(w, r, operation.WalletID.String(), models.ScopeForOperation(operation.OperationType)) {
+		return
+	}
+
+	result, err := h.service.ProcessWalletOperation(&operation)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
+	resp := map[string]interface{}{
+		"walletId": result.Wallet.ID,
+		"balance":  result.Wallet.Balance.String(),
+		"fee":      newFeeJSON(result.Fee),
+	}
+	if result.Receipt != nil {
+		resp["receipt"] = result.Receipt
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"walletId": wallet.ID,
-		"balance":  wallet.Balance.String(),
-	})
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (h *WalletHandler) validateWalletOperation(operation *models.WalletOperation) error {
@@ -93,7 +135,34 @@ func (h *WalletHandler) validateWalletOperation(operation *models.WalletOperatio
 	return nil
 }
 
-func (h *WalletHandler) handleServiceError(w http.ResponseWriter, err error) {
+// authorizeWallet проверяет scope и список разрешенных кошельков клиента.
+// Если аутентификация отключена и клиента в контексте нет, доступ не ограничивается
+func (h *WalletHandler) authorizeWallet(w http.ResponseWriter, r *http.Request, walletID string, scope string) bool {
+	client := auth.ClientFromContext(r.Context())
+	if client == nil {
+		return true
+	}
+
+	if !client.HasScope(scope) {
+		response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Недостаточно прав: требуется "+scope)
+		return false
+	}
+
+	id, err := uuid.Parse(walletID)
+	if err != nil || !client.CanAccessWallet(id) {
+		response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Нет доступа к кошельку")
+		return false
+	}
+
+	return true
+}
+
+func (h *WalletHandler) handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	if stdErrors.Is(err, service.ErrWalletAccessDenied) {
+		response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Нет доступа к кошельку")
+		return
+	}
+
 	if stdErrors.Is(err, repository.ErrWalletNotFound) {
 		http.Error(w, "Кошелек не найден", http.StatusNotFound)
 		return
@@ -104,6 +173,22 @@ func (h *WalletHandler) handleServiceError(w http.ResponseWriter, err error) {
 		return
 	}
 
+	if stdErrors.Is(err, service.ErrFeeExceedsAmount) {
+		http.Error(w, "Сумма пополнения не покрывает комиссию", http.StatusBadRequest)
+		return
+	}
+
+	if stdErrors.Is(err, service.ErrWalletFrozen) {
+		http.Error(w, "Кошелек заморожен", http.StatusConflict)
+		return
+	}
+
+	var limit *models.LimitExceededError
+	if stdErrors.As(err, &limit) {
+		response.WriteErrorDetails(w, r, http.StatusUnprocessableEntity, response.CodeLimitExceeded, limit.Message(), newLimitExceededDetails(limit))
+		return
+	}
+
 	http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
 }
 
@@ -119,15 +204,127 @@ func (h *WalletHandler) HandleGetWallet(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	wallet, err := h.service.GetWallet(walletID)
+	if !h.authorizeWallet(w, r, walletID, models.ScopeWalletRead) {
+		return
+	}
+
+	if raw := r.URL.Query().Get("asOf"); raw != "" {
+		h.writeBalanceAsOf(w, r, walletID, raw)
+		return
+	}
+
+	wallet, err := h.loadWallet(r, walletID)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"id":      wallet.ID,
-		"balance": wallet.Balance.String(),
+	json.NewEncoder(w).Encode(walletBalanceResponse{
+		ID:         wallet.ID,
+		Balance:    wallet.Balance.String(),
+		creditJSON: newCreditJSON(wallet),
+	})
+}
+
+// writeBalanceAsOf отвечает балансом на момент asOf. fromSnapshot показывает, был ли журнал проигран от снимка
+func (h *WalletHandler) writeBalanceAsOf(w http.ResponseWriter, r *http.Request, walletID, rawAsOf string) {
+	asOf, err := time.Parse(time.RFC3339, rawAsOf)
+	if err != nil {
+		http.Error(w, "Неверный формат asOf, ожидается RFC 3339", http.StatusBadRequest)
+		return
+	}
+
+	balance, err := h.service.GetBalanceAsOf(walletID, auth.OwnerFromContext(r.Context()), asOf)
+	if err != nil {
+		if stdErrors.Is(err, service.ErrWalletNotExisted) {
+			http.Error(w, "Кошелек еще не существовал на момент asOf", http.StatusNotFound)
+			return
+		}
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"id":              balance.WalletID,
+		"balance":         balance.Balance.String(),
+		"asOf":            balance.AsOf,
+		"fromSnapshot":    balance.Snapshot != nil,
+		"replayedEntries": balance.ReplayedEntries,
+	}
+	if balance.Snapshot != nil {
+		resp["snapshotAt"] = balance.Snapshot.TakenAt
+	}
+	response.WriteJSON(w, http.StatusOK, resp)
+}
+
+// loadWallet возвращает кошелек с учетом владельца из JWT, если он есть в контексте
+func (h *WalletHandler) loadWallet(r *http.Request, walletID string) (*models.Wallet, error) {
+	if ownerID := auth.OwnerFromContext(r.Context()); ownerID != "" {
+		return h.service.GetOwnedWallet(walletID, ownerID)
+	}
+	return h.service.GetWallet(walletID)
+}
+
+// HandleLookupWallets возвращает балансы нескольких кошельков одним запросом к БД.
+// Ненайденные и недоступные клиенту кошельки перечисляются в missing, а не приводят к ошибке
+func (h *WalletHandler) HandleLookupWallets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request lookupWalletsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.WalletIDs) == 0 || len(request.WalletIDs) > maxLookupWalletIDs {
+		http.Error(w, fmt.Sprintf("Нужно передать от 1 до %d ID кошельков", maxLookupWalletIDs), http.StatusBadRequest)
+		return
+	}
+
+	client := auth.ClientFromContext(r.Context())
+	if client != nil && !client.HasScope(models.ScopeWalletRead) {
+		response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Недостаточно прав: требуется "+models.ScopeWalletRead)
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(request.WalletIDs))
+	walletIDs := make([]uuid.UUID, 0, len(request.WalletIDs))
+	var denied []uuid.UUID
+	for _, id := range request.WalletIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if client != nil && !client.CanAccessWallet(id) {
+			denied = append(denied, id)
+			continue
+		}
+		walletIDs = append(walletIDs, id)
+	}
+
+	var wallets []models.Wallet
+	missing := make([]uuid.UUID, 0)
+	if len(walletIDs) > 0 {
+		var err error
+		wallets, missing, err = h.service.LookupWallets(walletIDs, auth.OwnerFromContext(r.Context()))
+		if err != nil {
+			h.handleServiceError(w, r, err)
+			return
+		}
+	}
+
+	items := make([]walletBalanceResponse, 0, len(wallets))
+	for i := range wallets {
+		items = append(items, walletBalanceResponse{ID: wallets[i].ID, Balance: wallets[i].Balance.String(), creditJSON: newCreditJSON(&wallets[i])})
+	}
+	missing = append(missing, denied...)
+
+	response.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"wallets": items,
+		"missing": missing,
 	})
 }
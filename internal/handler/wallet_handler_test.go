@@ -2,13 +2,18 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+	"wallet-api/internal/auth"
 	"wallet-api/internal/models"
 	"wallet-api/internal/repository"
 	"wallet-api/internal/service"
+	"wallet-api/pkg/receipt"
 	"wallet-api/utils"
 
 	"github.com/google/uuid"
@@ -18,6 +23,9 @@ type MockWalletService struct {
 	shouldError bool
 	errorType   error
 	wallet      *models.Wallet
+	receipt     *receipt.Receipt
+	ledger      []models.LedgerEntry
+	lastFilter  models.WalletFilter
 }
 
 func (m *MockWalletService) GetWallet(walletID string) (*models.Wallet, error) {
@@ -27,13 +35,110 @@ func (m *MockWalletService) GetWallet(walletID string) (*models.Wallet, error) {
 	return m.wallet, nil
 }
 
-func (m *MockWalletService) ProcessWalletOperation(operation *models.WalletOperation) (*models.Wallet, error) {
+func (m *MockWalletService) GetOwnedWallet(walletID, ownerID string) (*models.Wallet, error) {
 	if m.shouldError {
 		return nil, m.errorType
 	}
+	if !m.wallet.IsOwnedBy(ownerID) {
+		return nil, service.ErrWalletAccessDenied
+	}
 	return m.wallet, nil
 }
 
+func (m *MockWalletService) LookupWallets(walletIDs []uuid.UUID, ownerID string) ([]models.Wallet, []uuid.UUID, error) {
+	if m.shouldError {
+		return nil, nil, m.errorType
+	}
+
+	var found []models.Wallet
+	missing := make([]uuid.UUID, 0)
+	for _, id := range walletIDs {
+		if m.wallet != nil && m.wallet.ID == id {
+			found = append(found, *m.wallet)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing, nil
+}
+
+func (m *MockWalletService) ListWallets(filter models.WalletFilter) (*models.WalletPage, error) {
+	m.lastFilter = filter
+	if m.shouldError {
+		return nil, m.errorType
+	}
+
+	page := &models.WalletPage{}
+	if m.wallet != nil {
+		page.Items = append(page.Items, *m.wallet)
+		page.NextCursor = models.NewWalletCursor(m.wallet, models.WalletSortCreatedAt, false).Encode()
+	}
+	return page, nil
+}
+
+func (m *MockWalletService) GetBalanceAsOf(walletID, ownerID string, asOf time.Time) (*models.HistoricalBalance, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	if ownerID != "" && !m.wallet.IsOwnedBy(ownerID) {
+		return nil, service.ErrWalletAccessDenied
+	}
+	if m.wallet.CreatedAt.After(asOf) {
+		return nil, service.ErrWalletNotExisted
+	}
+
+	balance := &models.HistoricalBalance{WalletID: m.wallet.ID, AsOf: asOf, Balance: m.wallet.Balance, ReplayedEntries: len(m.ledger)}
+	if len(m.ledger) > 0 {
+		balance.Snapshot = &models.BalanceSnapshot{WalletID: m.wallet.ID, LedgerEntryID: m.ledger[0].ID, TakenAt: m.ledger[0].CreatedAt}
+	}
+	return balance, nil
+}
+
+func (m *MockWalletService) OpenStatement(ctx context.Context, walletID, ownerID string, from, to time.Time) (repository.StatementCursor, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	if !from.Before(to) {
+		return nil, service.ErrInvalidStatementPeriod
+	}
+
+	statement := models.Statement{WalletID: m.wallet.ID, From: from, To: to, ClosingBalance: m.wallet.Balance}
+	if len(m.ledger) > 0 {
+		first := m.ledger[0]
+		statement.OpeningBalance = first.BalanceAfter.Sub(first.SignedAmount())
+	}
+	return &mockStatementCursor{statement: statement, entries: m.ledger}, nil
+}
+
+type mockStatementCursor struct {
+	statement models.Statement
+	entries   []models.LedgerEntry
+	closed    bool
+}
+
+func (c *mockStatementCursor) Statement() models.Statement { return c.statement }
+
+func (c *mockStatementCursor) Next() (*models.LedgerEntry, error) {
+	if len(c.entries) == 0 {
+		return nil, nil
+	}
+	entry := c.entries[0]
+	c.entries = c.entries[1:]
+	return &entry, nil
+}
+
+func (c *mockStatementCursor) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (m *MockWalletService) ProcessWalletOperation(operation *models.WalletOperation) (*models.OperationResult, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	return &models.OperationResult{Wallet: m.wallet, Receipt: m.receipt}, nil
+}
+
 func (m *MockWalletService) CreateWallet(wallet *models.Wallet) error {
 	if m.shouldError {
 		return m.errorType
@@ -41,6 +146,36 @@ func (m *MockWalletService) CreateWallet(wallet *models.Wallet) error {
 	return nil
 }
 
+func (m *MockWalletService) ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	var entries []models.LedgerEntry
+	for _, entry := range m.ledger {
+		if entry.ID > afterID && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockWalletService) GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	if len(m.ledger) == 0 {
+		return nil, nil
+	}
+	return &m.ledger[len(m.ledger)-1], nil
+}
+
+func (m *MockWalletService) Transfer(transfer *models.Transfer) (*models.TransferResult, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	return &models.TransferResult{From: m.wallet, To: m.wallet}, nil
+}
+
 func TestWalletHandler_HandleWalletOperation(t *testing.T) {
 	walletID := uuid.New()
 	wallet := &models.Wallet{
@@ -120,6 +255,22 @@ func TestWalletHandler_HandleWalletOperation(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "service error - wallet frozen",
+			method: http.MethodPost,
+			requestBody: walletOperationRequest{
+				WalletID:      walletID,
+				OperationType: models.OperationTypeDeposit,
+				Amount:        15.00,
+			},
+			expectedStatus: http.StatusConflict,
+			setupMock: func() *MockWalletService {
+				return &MockWalletService{
+					shouldError: true,
+					errorType:   service.ErrWalletFrozen,
+				}
+			},
+		},
 		{
 			name:   "conversion test - rubles to kopecks",
 			method: http.MethodPost,
@@ -319,3 +470,429 @@ func TestWalletHandler_validateWalletOperation(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletHandler_authorizeWallet(t *testing.T) {
+	allowedWallet := uuid.New()
+	otherWallet := uuid.New()
+
+	tests := []struct {
+		name           string
+		client         *models.APIClient
+		walletID       string
+		scope          string
+		expectedResult bool
+		expectedStatus int
+	}{
+		{
+			name:           "auth disabled",
+			client:         nil,
+			walletID:       otherWallet.String(),
+			scope:          models.ScopeWalletWithdraw,
+			expectedResult: true,
+		},
+		{
+			name:           "scope and wallet allowed",
+			client:         &models.APIClient{Scopes: []string{models.ScopeWalletWithdraw}, WalletIDs: []uuid.UUID{allowedWallet}},
+			walletID:       allowedWallet.String(),
+			scope:          models.ScopeWalletWithdraw,
+			expectedResult: true,
+		},
+		{
+			name:           "missing scope",
+			client:         &models.APIClient{Scopes: []string{models.ScopeWalletDeposit}},
+			walletID:       allowedWallet.String(),
+			scope:          models.ScopeWalletWithdraw,
+			expectedResult: false,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "wallet outside allow-list",
+			client:         &models.APIClient{Scopes: []string{models.ScopeWalletRead}, WalletIDs: []uuid.UUID{allowedWallet}},
+			walletID:       otherWallet.String(),
+			scope:          models.ScopeWalletRead,
+			expectedResult: false,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &WalletHandler{}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+tt.walletID, nil)
+			if tt.client != nil {
+				req = req.WithContext(auth.WithClient(req.Context(), tt.client))
+			}
+			w := httptest.NewRecorder()
+
+			got := handler.authorizeWallet(w, req, tt.walletID, tt.scope)
+			if got != tt.expectedResult {
+				t.Errorf("authorizeWallet() = %v, want %v", got, tt.expectedResult)
+			}
+			if !tt.expectedResult && w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_HandleLookupWallets(t *testing.T) {
+	walletID, unknownID, foreignID := uuid.New(), uuid.New(), uuid.New()
+	mockService := &MockWalletService{wallet: &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 1050}}}
+	handler := NewWalletHandler(mockService)
+
+	tooMany := make([]uuid.UUID, maxLookupWalletIDs+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+
+	tests := []struct {
+		name           string
+		walletIDs      []uuid.UUID
+		client         *models.APIClient
+		expectedStatus int
+		wantFound      int
+		wantMissing    []uuid.UUID
+	}{
+		{
+			name:           "found and missing",
+			walletIDs:      []uuid.UUID{walletID, unknownID, walletID},
+			expectedStatus: http.StatusOK,
+			wantFound:      1,
+			wantMissing:    []uuid.UUID{unknownID},
+		},
+		{
+			name:           "wallets outside allow-list are missing",
+			walletIDs:      []uuid.UUID{walletID, foreignID},
+			client:         &models.APIClient{Scopes: []string{models.ScopeWalletRead}, WalletIDs: []uuid.UUID{walletID}},
+			expectedStatus: http.StatusOK,
+			wantFound:      1,
+			wantMissing:    []uuid.UUID{foreignID},
+		},
+		{
+			name:           "empty request",
+			walletIDs:      []uuid.UUID{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too many ids",
+			walletIDs:      tooMany,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "client without read scope",
+			walletIDs:      []uuid.UUID{walletID},
+			client:         &models.APIClient{Scopes: []string{models.ScopeWalletDeposit}},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(lookupWalletsRequest{WalletIDs: tt.walletIDs})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/lookup", bytes.NewReader(body))
+			if tt.client != nil {
+				req = req.WithContext(auth.WithClient(req.Context(), tt.client))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.HandleLookupWallets(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("HandleLookupWallets() status = %d, want %d", rec.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Wallets []walletBalanceResponse `json:"wallets"`
+				Missing []uuid.UUID             `json:"missing"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(resp.Wallets) != tt.wantFound || resp.Wallets[0].Balance != "10.50" {
+				t.Errorf("wallets = %+v, want %d", resp.Wallets, tt.wantFound)
+			}
+			if len(resp.Missing) != len(tt.wantMissing) || resp.Missing[0] != tt.wantMissing[0] {
+				t.Errorf("missing = %v, want %v", resp.Missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_HandleListWallets(t *testing.T) {
+	walletID := uuid.New()
+	allowedID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		client         *models.APIClient
+		ownerID        string
+		serviceErr     error
+		expectedStatus int
+		check          func(t *testing.T, filter models.WalletFilter)
+	}{
+		{
+			name:           "filters are parsed",
+			query:          "?minBalance=10.50&maxBalance=100&status=FROZEN&ownerId=alice&tag=team:billing&tag=region:eu&createdFrom=2025-09-01T00:00:00Z&sort=-balance&limit=20",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, filter models.WalletFilter) {
+				if filter.MinBalance == nil || filter.MinBalance.Raw != 1050 || filter.MaxBalance == nil || filter.MaxBalance.Raw != 10000 {
+					t.Errorf("balance range = %v..%v", filter.MinBalance, filter.MaxBalance)
+				}
+				if filter.Status != models.WalletStatusFrozen || filter.OwnerID != "alice" || filter.Limit != 20 {
+					t.Errorf("filter = %+v", filter)
+				}
+				if filter.SortBy != models.WalletSortBalance || !filter.Descending {
+					t.Errorf("sort = %s desc=%v, want balance desc", filter.SortBy, filter.Descending)
+				}
+				if len(filter.Tags) != 2 || filter.Tags["team"] != "billing" || filter.Tags["region"] != "eu" {
+					t.Errorf("tags = %v", filter.Tags)
+				}
+				if filter.CreatedFrom == nil || !filter.CreatedFrom.Equal(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("createdFrom = %v", filter.CreatedFrom)
+				}
+			},
+		},
+		{
+			name:           "client allow-list restricts ids",
+			client:         &models.APIClient{Scopes: []string{models.ScopeWalletRead}, WalletIDs: []uuid.UUID{allowedID}},
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, filter models.WalletFilter) {
+				if len(filter.IDs) != 1 || filter.IDs[0] != allowedID {
+					t.Errorf("ids = %v, want [%s]", filter.IDs, allowedID)
+				}
+			},
+		},
+		{
+			name:           "jwt owner is forced",
+			ownerID:        "alice",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, filter models.WalletFilter) {
+				if filter.OwnerID != "alice" {
+					t.Errorf("ownerId = %q, want alice", filter.OwnerID)
+				}
+			},
+		},
+		{
+			name:           "jwt owner cannot list foreign wallets",
+			query:          "?ownerId=bob",
+			ownerID:        "alice",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "client without read scope",
+			client:         &models.APIClient{Scopes: []string{models.ScopeWalletDeposit}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "malformed tag",
+			query:          "?tag=billing",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed cursor",
+			query:          "?cursor=not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid filter from service",
+			query:          "?sort=owner_id",
+			serviceErr:     service.ErrInvalidWalletFilter,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockWalletService{
+				wallet:      &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 1050}, Status: models.WalletStatusActive, CreatedAt: time.Now()},
+				shouldError: tt.serviceErr != nil,
+				errorType:   tt.serviceErr,
+			}
+			handler := NewWalletHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets"+tt.query, nil)
+			if tt.client != nil {
+				req = req.WithContext(auth.WithClient(req.Context(), tt.client))
+			}
+			if tt.ownerID != "" {
+				req = req.WithContext(auth.WithOwner(req.Context(), tt.ownerID))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.HandleListWallets(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("HandleListWallets() status = %d, want %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, mockService.lastFilter)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp walletListResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(resp.Items) != 1 || resp.Items[0].Balance != "10.50" || resp.NextCursor == "" {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_HandleGetWalletAsOf(t *testing.T) {
+	walletID := uuid.New()
+	created := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	mockService := &MockWalletService{
+		wallet: &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 1050}, CreatedAt: created},
+		ledger: []models.LedgerEntry{{ID: 7, WalletID: walletID, CreatedAt: created.Add(time.Hour)}},
+	}
+	handler := NewWalletHandler(mockService)
+
+	tests := []struct {
+		name           string
+		asOf           string
+		expectedStatus int
+	}{
+		{"balance at end of month", "2025-08-31T23:59:59%2B03:00", http.StatusOK},
+		{"before wallet existed", "2025-07-31T23:59:59Z", http.StatusNotFound},
+		{"malformed asOf", "yesterday", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"?asOf="+tt.asOf, nil)
+			rec := httptest.NewRecorder()
+
+			handler.HandleGetWallet(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("HandleGetWallet() status = %d, want %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Balance         string    `json:"balance"`
+				AsOf            time.Time `json:"asOf"`
+				FromSnapshot    bool      `json:"fromSnapshot"`
+				SnapshotAt      time.Time `json:"snapshotAt"`
+				ReplayedEntries int       `json:"replayedEntries"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Balance != "10.50" || !resp.FromSnapshot || resp.ReplayedEntries != 1 || resp.SnapshotAt.IsZero() {
+				t.Errorf("response = %+v", resp)
+			}
+			if !resp.AsOf.Equal(time.Date(2025, 8, 31, 20, 59, 59, 0, time.UTC)) {
+				t.Errorf("asOf = %v", resp.AsOf)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_HandleWalletStatement(t *testing.T) {
+	walletID := uuid.New()
+	day := time.Date(2025, 9, 2, 10, 0, 0, 0, time.UTC)
+	mockService := &MockWalletService{
+		wallet: &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 8950}},
+		ledger: []models.LedgerEntry{
+			{ID: 41, WalletID: walletID, EntryType: models.OperationTypeDeposit, Amount: utils.Money{Raw: 10}, BalanceAfter: utils.Money{Raw: 10010}, CreatedAt: day},
+			{ID: 42, WalletID: walletID, EntryType: models.OperationTypeWithdraw, Amount: utils.Money{Raw: 1060}, BalanceAfter: utils.Money{Raw: 8950}, CreatedAt: day.Add(time.Hour)},
+		},
+	}
+	handler := NewWalletHandler(mockService)
+	path := "/api/v1/wallets/" + walletID.String() + "/statement"
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{
+			name:           "csv",
+			query:          "?from=2025-09-01T00:00:00Z&to=2025-10-01T00:00:00Z",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv; charset=utf-8",
+			expectedBody: "record,date,entryId,entryType,amount,balance\n" +
+				"opening,2025-09-01T00:00:00Z,,,,100.00\n" +
+				"entry,2025-09-02T10:00:00Z,41,DEPOSIT,0.10,100.10\n" +
+				"entry,2025-09-02T11:00:00Z,42,WITHDRAW,-10.60,89.50\n" +
+				"closing,2025-10-01T00:00:00Z,,,,89.50\n",
+		},
+		{
+			name:           "jsonl",
+			query:          "?from=2025-09-01T00:00:00Z&to=2025-10-01T00:00:00Z&format=jsonl",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/x-ndjson",
+			expectedBody: `{"record":"opening","walletId":"` + walletID.String() + `","date":"2025-09-01T00:00:00Z","balance":"100.00"}` + "\n" +
+				`{"record":"entry","date":"2025-09-02T10:00:00Z","entryId":41,"entryType":"DEPOSIT","amount":"0.10","balance":"100.10"}` + "\n" +
+				`{"record":"entry","date":"2025-09-02T11:00:00Z","entryId":42,"entryType":"WITHDRAW","amount":"-10.60","balance":"89.50"}` + "\n" +
+				`{"record":"closing","walletId":"` + walletID.String() + `","date":"2025-10-01T00:00:00Z","balance":"89.50"}` + "\n",
+		},
+		{"unknown format", "?format=xlsx", http.StatusBadRequest, "", ""},
+		{"malformed from", "?from=yesterday", http.StatusBadRequest, "", ""},
+		{"reversed period", "?from=2025-10-01T00:00:00Z&to=2025-09-01T00:00:00Z", http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.HandleWalletStatement(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("HandleWalletStatement() status = %d, want %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.expectedType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.expectedType)
+			}
+			if rec.Body.String() != tt.expectedBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_HandleWalletStatement_CAMT053(t *testing.T) {
+	walletID := uuid.New()
+	mockService := &MockWalletService{
+		wallet: &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 10000}},
+		ledger: []models.LedgerEntry{
+			{ID: 41, WalletID: walletID, EntryType: models.OperationTypeDeposit, Amount: utils.Money{Raw: 10}, BalanceAfter: utils.Money{Raw: 10010}, CreatedAt: time.Now()},
+		},
+	}
+	handler := NewWalletHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/statement?format=camt053", nil)
+	rec := httptest.NewRecorder()
+	handler.HandleWalletStatement(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("HandleWalletStatement() status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/xml" {
+		t.Errorf("Content-Type = %q, want application/xml", got)
+	}
+	wantDisposition := `attachment; filename="statement-` + walletID.String() + `.xml"`
+	if got := rec.Header().Get("Content-Disposition"); got != wantDisposition {
+		t.Errorf("Content-Disposition = %q, want %q", got, wantDisposition)
+	}
+	if !strings.Contains(rec.Body.String(), "<NtryRef>41</NtryRef>") || !strings.HasSuffix(rec.Body.String(), "</Document>") {
+		t.Errorf("body is not a complete camt.053 document: %s", rec.Body.String())
+	}
+}
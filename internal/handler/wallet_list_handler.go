@@ -0,0 +1,174 @@
+package handler
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/service"
+	"wallet-api/utils"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+type walletListItem struct {
+	ID        uuid.UUID             `json:"id"`
+	Balance   string                `json:"balance"`
+	OwnerID   string                `json:"ownerId,omitempty"`
+	Status    string                `json:"status"`
+	Metadata  models.WalletMetadata `json:"metadata"`
+	CreatedAt time.Time             `json:"createdAt"`
+	UpdatedAt *time.Time            `json:"updatedAt,omitempty"`
+	creditJSON
+}
+
+type walletListResponse struct {
+	Items      []walletListItem `json:"items"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// HandleListWallets обслуживает GET /api/v1/wallets: фильтрация, сортировка и keyset пагинация.
+// Клиент видит только разрешенные ему кошельки, владелец из JWT - только свои
+func (h *WalletHandler) HandleListWallets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseWalletFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if client := auth.ClientFromContext(r.Context()); client != nil {
+		if !client.HasScope(models.ScopeWalletRead) {
+			response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Недостаточно прав: требуется "+models.ScopeWalletRead)
+			return
+		}
+		filter.IDs = client.WalletIDs
+	}
+	if ownerID := auth.OwnerFromContext(r.Context()); ownerID != "" {
+		if filter.OwnerID != "" && filter.OwnerID != ownerID {
+			response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Нет доступа к кошелькам владельца")
+			return
+		}
+		filter.OwnerID = ownerID
+	}
+
+	page, err := h.service.ListWallets(filter)
+	if err != nil {
+		if stdErrors.Is(err, service.ErrInvalidWalletFilter) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	resp := walletListResponse{
+		Items:      make([]walletListItem, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+	}
+	for _, wallet := range page.Items {
+		item := walletListItem{
+			ID:        wallet.ID,
+			Balance:   wallet.Balance.String(),
+			OwnerID:   wallet.OwnerID.String,
+			Status:    wallet.Status,
+			Metadata:  wallet.Metadata,
+			CreatedAt: wallet.CreatedAt,
+		}
+		item.creditJSON = newCreditJSON(&wallet)
+		if item.Metadata == nil {
+			item.Metadata = models.WalletMetadata{}
+		}
+		if wallet.UpdatedAt.Valid {
+			updatedAt := wallet.UpdatedAt.Time
+			item.UpdatedAt = &updatedAt
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	response.WriteJSON(w, http.StatusOK, resp)
+}
+
+// parseWalletFilter разбирает query параметры списка кошельков.
+// sort принимает имя поля, префикс "-" задает обратный порядок; tag повторяется в виде key:value
+func parseWalletFilter(query url.Values) (models.WalletFilter, error) {
+	filter := models.WalletFilter{
+		OwnerID: query.Get("ownerId"),
+		Status:  query.Get("status"),
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		filter.Descending = strings.HasPrefix(sort, "-")
+		filter.SortBy = strings.TrimPrefix(sort, "-")
+	} else {
+		filter.SortBy = models.WalletSortCreatedAt
+	}
+
+	for _, tag := range query["tag"] {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok || key == "" {
+			return filter, fmt.Errorf("Неверный формат tag, ожидается key:value")
+		}
+		if filter.Tags == nil {
+			filter.Tags = make(map[string]string)
+		}
+		filter.Tags[key] = value
+	}
+
+	for name, dst := range map[string]**utils.Money{"minBalance": &filter.MinBalance, "maxBalance": &filter.MaxBalance} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		money, err := utils.NewMoneyFromString(raw)
+		if err != nil {
+			return filter, fmt.Errorf("Неверный формат %s", name)
+		}
+		*dst = &money
+	}
+
+	for name, dst := range map[string]**time.Time{
+		"createdFrom": &filter.CreatedFrom,
+		"createdTo":   &filter.CreatedTo,
+		"updatedFrom": &filter.UpdatedFrom,
+		"updatedTo":   &filter.UpdatedTo,
+	} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("Неверный формат %s, ожидается RFC 3339", name)
+		}
+		*dst = &t
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("Неверный формат limit")
+		}
+		filter.Limit = limit
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := models.DecodeWalletCursor(raw)
+		if err != nil {
+			return filter, fmt.Errorf("Неверный cursor")
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/service"
+	"wallet-api/internal/statement"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+// HandleWalletStatement обслуживает GET /api/v1/wallets/{walletId}/statement?from=&to=&format=csv|jsonl|camt053.
+// Выписка пишется в ответ по мере чтения проводок из серверного курсора и не собирается в памяти
+func (h *WalletHandler) HandleWalletStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	walletID, err := uuid.Parse(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/wallets/"), "/statement"))
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный UUID кошелька")
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = models.StatementFormatCSV
+	}
+	writer, err := statement.NewWriter(format, w)
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный format, ожидается csv, jsonl или camt053")
+		return
+	}
+	from, to, err := parseStatementPeriod(query.Get("from"), query.Get("to"))
+	if err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+		return
+	}
+
+	if !h.authorizeWallet(w, r, walletID.String(), models.ScopeWalletRead) {
+		return
+	}
+
+	cursor, err := h.service.OpenStatement(r.Context(), walletID.String(), auth.OwnerFromContext(r.Context()), from, to)
+	if err != nil {
+		if stdErrors.Is(err, service.ErrInvalidStatementPeriod) {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "from должен быть раньше to")
+			return
+		}
+		h.handleServiceError(w, r, err)
+		return
+	}
+	defer cursor.Close()
+
+	w.Header().Set("Content-Type", statement.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s.%s"`, walletID, statement.FileExtension(format)))
+	w.WriteHeader(http.StatusOK)
+
+	// Статус уже отправлен: при ошибке остается только оборвать выписку, клиент не получит строку closing (или закрывающие теги camt.053)
+	if err := statement.Write(writer, cursor); err != nil {
+		logger.GlobalLogger.Error("Ошибка выгрузки выписки по кошельку %s: %v", walletID, err)
+	}
+}
+
+// parseStatementPeriod разбирает границы выписки. По умолчанию from - начало журнала, to - текущий момент
+func parseStatementPeriod(rawFrom, rawTo string) (time.Time, time.Time, error) {
+	from := time.Unix(0, 0).UTC()
+	to := time.Now().UTC()
+
+	var err error
+	if rawFrom != "" {
+		if from, err = time.Parse(time.RFC3339, rawFrom); err != nil {
+			return from, to, fmt.Errorf("Неверный формат from, ожидается RFC 3339")
+		}
+	}
+	if rawTo != "" {
+		if to, err = time.Parse(time.RFC3339, rawTo); err != nil {
+			return from, to, fmt.Errorf("Неверный формат to, ожидается RFC 3339")
+		}
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"strings"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils"
+	"wallet-api/utils/response"
+
+	"github.com/google/uuid"
+)
+
+const webhooksPath = "/api/v1/webhooks"
+
+type createWebhookRequest struct {
+	URL                 string      `json:"url"`
+	WalletIDs           []uuid.UUID `json:"walletIds"`
+	EventTypes          []string    `json:"eventTypes"`
+	LowBalanceThreshold string      `json:"lowBalanceThreshold"`
+}
+
+type webhookSubscriptionResponse struct {
+	ID                  uuid.UUID   `json:"id"`
+	URL                 string      `json:"url"`
+	WalletIDs           []uuid.UUID `json:"walletIds"`
+	EventTypes          []string    `json:"eventTypes"`
+	LowBalanceThreshold string      `json:"lowBalanceThreshold,omitempty"`
+	Active              bool        `json:"active"`
+	CreatedAt           time.Time   `json:"createdAt"`
+	Secret              string      `json:"secret,omitempty"`
+}
+
+type WebhookHandler struct {
+	service service.WebhookServiceInterface
+}
+
+func NewWebhookHandler(service service.WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// HandleWebhooks обслуживает:
+// GET/POST /api/v1/webhooks, DELETE /api/v1/webhooks/{id},
+// GET /api/v1/webhooks/{id}/deliveries и POST /api/v1/webhooks/{id}/deliveries/{deliveryId}/replay
+func (h *WebhookHandler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, webhooksPath), "/")
+	var parts []string
+	if path != "" {
+		parts = strings.Split(path, "/")
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		h.listSubscriptions(w, r)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		h.createSubscription(w, r)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		h.deleteSubscription(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "deliveries" && r.Method == http.MethodGet:
+		h.listDeliveries(w, r, parts[0])
+	case len(parts) == 4 && parts[1] == "deliveries" && parts[3] == "replay" && r.Method == http.MethodPost:
+		h.replayDelivery(w, r, parts[0], parts[2])
+	case len(parts) <= 4:
+		response.WriteError(w, r, http.StatusMethodNotAllowed, response.CodeBadRequest, "Метод не поддерживается")
+	default:
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Ресурс не найден")
+	}
+}
+
+func (h *WebhookHandler) createSubscription(w http.ResponseWriter, r *http.Request) {
+	var request createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	// Клиент с ограниченным списком кошельков может подписаться только на свои кошельки
+	if client := auth.ClientFromContext(r.Context()); client != nil && len(client.WalletIDs) > 0 {
+		if len(request.WalletIDs) == 0 {
+			response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Необходимо указать walletIds")
+			return
+		}
+		for _, id := range request.WalletIDs {
+			if !client.CanAccessWallet(id) {
+				response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Нет доступа к кошельку "+id.String())
+				return
+			}
+		}
+	}
+
+	input := service.WebhookSubscriptionInput{
+		URL:        request.URL,
+		WalletIDs:  request.WalletIDs,
+		EventTypes: request.EventTypes,
+	}
+	if request.LowBalanceThreshold != "" {
+		threshold, err := utils.NewMoneyFromString(request.LowBalanceThreshold)
+		if err != nil {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, "Неверный формат lowBalanceThreshold")
+			return
+		}
+		input.LowBalanceThreshold = &threshold
+	}
+
+	sub, secret, err := h.service.CreateSubscription(clientScope(r), input)
+	if err != nil {
+		h.handleWebhookError(w, r, err)
+		return
+	}
+
+	resp := newWebhookSubscriptionResponse(sub)
+	resp.Secret = secret
+	response.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *WebhookHandler) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(clientScope(r))
+	if err != nil {
+		h.handleWebhookError(w, r, err)
+		return
+	}
+
+	items := make([]webhookSubscriptionResponse, 0, len(subs))
+	for i := range subs {
+		items = append(items, newWebhookSubscriptionResponse(&subs[i]))
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func (h *WebhookHandler) deleteSubscription(w http.ResponseWriter, r *http.Request, subscriptionID string) {
+	if err := h.service.DeleteSubscription(clientScope(r), subscriptionID); err != nil {
+		h.handleWebhookError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) listDeliveries(w http.ResponseWriter, r *http.Request, subscriptionID string) {
+	deliveries, err := h.service.ListDeliveries(clientScope(r), subscriptionID)
+	if err != nil {
+		h.handleWebhookError(w, r, err)
+		return
+	}
+
+	items := make([]map[string]interface{}, 0, len(deliveries))
+	for _, d := range deliveries {
+		item := map[string]interface{}{
+			"id":            d.ID,
+			"eventId":       d.EventID,
+			"eventType":     d.EventType,
+			"status":        d.Status,
+			"attempts":      d.Attempts,
+			"nextAttemptAt": d.NextAttemptAt,
+			"createdAt":     d.CreatedAt,
+		}
+		if d.LastError.Valid {
+			item["lastError"] = d.LastError.String
+		}
+		items = append(items, item)
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func (h *WebhookHandler) replayDelivery(w http.ResponseWriter, r *http.Request, subscriptionID, deliveryID string) {
+	if err := h.service.ReplayDelivery(clientScope(r), subscriptionID, deliveryID); err != nil {
+		h.handleWebhookError(w, r, err)
+		return
+	}
+	response.WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"id":     deliveryID,
+		"status": models.WebhookDeliveryPending,
+	})
+}
+
+func (h *WebhookHandler) handleWebhookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case stdErrors.Is(err, service.ErrInvalidWebhook):
+		response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+	case stdErrors.Is(err, repository.ErrWebhookSubscriptionNotFound):
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Подписка не найдена")
+	case stdErrors.Is(err, repository.ErrWebhookDeliveryNotFound):
+		response.WriteError(w, r, http.StatusNotFound, response.CodeNotFound, "Доставка не найдена")
+	default:
+		response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+	}
+}
+
+// clientScope возвращает ID клиента, ресурсами которого (подписки, импорты) ограничен запрос; nil - доступ ко всем
+func clientScope(r *http.Request) *uuid.UUID {
+	client := auth.ClientFromContext(r.Context())
+	if client == nil || client.HasScope(models.ScopeAdmin) {
+		return nil
+	}
+	id := client.ID
+	return &id
+}
+
+func newWebhookSubscriptionResponse(sub *models.WebhookSubscription) webhookSubscriptionResponse {
+	resp := webhookSubscriptionResponse{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		WalletIDs:  make([]uuid.UUID, 0, len(sub.WalletIDs)),
+		EventTypes: sub.EventTypes,
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt,
+	}
+	resp.WalletIDs = append(resp.WalletIDs, sub.WalletIDs...)
+	if sub.LowBalanceThreshold != nil {
+		resp.LowBalanceThreshold = sub.LowBalanceThreshold.String()
+	}
+	return resp
+}
@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// Колонки файла импорта; порядок колонок в файле может быть любым
+const (
+	ColumnWalletID      = "walletId"
+	ColumnOperationType = "operationType"
+	ColumnAmount        = "amount"
+	ColumnExternalRef   = "externalRef"
+)
+
+// ErrInvalidFile - файл нельзя разобрать целиком: нет заголовка, строк или их слишком много
+var ErrInvalidFile = errors.New("invalid import file")
+
+// RowError - ошибка проверки строки файла; Row - номер строки в файле, заголовок - строка 1
+type RowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ParseCSV читает и проверяет все строки файла до создания задания.
+// Если хотя бы одна строка неверна, возвращаются ошибки по всем неверным строкам и ни одна строка не импортируется
+func ParseCSV(r io.Reader) ([]models.ImportRow, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, fmt.Errorf("%w: empty file", ErrInvalidFile)
+		}
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	// Excel сохраняет CSV в UTF-8 с BOM перед первым заголовком
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range []string{ColumnWalletID, ColumnOperationType, ColumnAmount, ColumnExternalRef} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: missing column %s", ErrInvalidFile, name)
+		}
+	}
+
+	var rows []models.ImportRow
+	var rowErrors []RowError
+	refs := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+		}
+		if len(rows) == models.MaxImportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, models.MaxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row, problems := parseRow(field)
+		row.RowNumber = line
+		if row.ExternalRef != "" {
+			if first, seen := refs[row.ExternalRef]; seen {
+				problems = append(problems, fmt.Sprintf("externalRef повторяет строку %d", first))
+			} else {
+				refs[row.ExternalRef] = line
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, RowError{Row: line, Message: strings.Join(problems, "; ")})
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 && len(rowErrors) == 0 {
+		return nil, nil, fmt.Errorf("%w: no rows", ErrInvalidFile)
+	}
+	if len(rowErrors) > 0 {
+		return nil, rowErrors, nil
+	}
+	return rows, nil, nil
+}
+
+func parseRow(field func(name string) string) (models.ImportRow, []string) {
+	var row models.ImportRow
+	var problems []string
+
+	walletID, err := uuid.Parse(field(ColumnWalletID))
+	if err != nil || walletID == uuid.Nil {
+		problems = append(problems, "Неверный walletId")
+	}
+	row.WalletID = walletID
+
+	row.OperationType = field(ColumnOperationType)
+	if !models.IsValidOperationType(row.OperationType) {
+		problems = append(problems, fmt.Sprintf("Неверный тип операции: %s", row.OperationType))
+	}
+
+	amount, err := utils.NewMoneyFromString(field(ColumnAmount))
+	switch {
+	case err != nil:
+		problems = append(problems, "Неверный формат суммы")
+	case amount.Raw <= 0:
+		problems = append(problems, "Сумма должна быть положительной")
+	}
+	row.Amount = amount
+
+	row.ExternalRef = field(ColumnExternalRef)
+	switch {
+	case row.ExternalRef == "":
+		problems = append(problems, "externalRef обязателен")
+	case len(row.ExternalRef) > models.MaxExternalRefLength:
+		problems = append(problems, fmt.Sprintf("externalRef длиннее %d символов", models.MaxExternalRefLength))
+	}
+
+	return row, problems
+}
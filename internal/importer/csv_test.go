@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"wallet-api/internal/models"
+)
+
+const walletA = "6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f"
+
+func TestParseCSV(t *testing.T) {
+	input := "\ufeffexternalRef,walletId,operationType,amount\n" +
+		"payout-1," + walletA + ",DEPOSIT,100.50\n" +
+		"payout-2, " + walletA + ",WITHDRAW,0.01\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(input))
+	if err != nil || len(rowErrors) > 0 {
+		t.Fatalf("ParseCSV() = %v, %v", rowErrors, err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("ParseCSV() returned %d rows, want 2", len(rows))
+	}
+	if rows[0].RowNumber != 2 || rows[0].Amount.Raw != 10050 || rows[0].ExternalRef != "payout-1" || rows[0].OperationType != models.OperationTypeDeposit {
+		t.Errorf("first row = %+v", rows[0])
+	}
+	if rows[1].RowNumber != 3 || rows[1].WalletID.String() != walletA || rows[1].Amount.Raw != 1 {
+		t.Errorf("second row = %+v", rows[1])
+	}
+}
+
+func TestParseCSV_RowErrors(t *testing.T) {
+	input := "walletId,operationType,amount,externalRef\n" +
+		walletA + ",DEPOSIT,10,ok-1\n" +
+		"not-a-uuid,DEPOSIT,10,bad-1\n" +
+		walletA + ",REFUND,10,bad-2\n" +
+		walletA + ",DEPOSIT,abc,bad-3\n" +
+		walletA + ",WITHDRAW,-5,bad-4\n" +
+		walletA + ",DEPOSIT,10,\n" +
+		walletA + ",DEPOSIT,10,ok-1\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+	if rows != nil {
+		t.Errorf("ParseCSV() returned rows despite errors: %+v", rows)
+	}
+
+	want := map[int]string{
+		3: "Неверный walletId",
+		4: "Неверный тип операции: REFUND",
+		5: "Неверный формат суммы",
+		6: "Сумма должна быть положительной",
+		7: "externalRef обязателен",
+		8: "externalRef повторяет строку 2",
+	}
+	if len(rowErrors) != len(want) {
+		t.Fatalf("ParseCSV() returned %d row errors, want %d: %+v", len(rowErrors), len(want), rowErrors)
+	}
+	for _, rowErr := range rowErrors {
+		if want[rowErr.Row] != rowErr.Message {
+			t.Errorf("row %d error = %q, want %q", rowErr.Row, rowErr.Message, want[rowErr.Row])
+		}
+	}
+}
+
+func TestParseCSV_InvalidFile(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"header only":    "walletId,operationType,amount,externalRef\n",
+		"missing column": "walletId,operationType,amount\n" + walletA + ",DEPOSIT,10\n",
+		"broken quotes":  "walletId,operationType,amount,externalRef\n\"" + walletA + ",DEPOSIT,10,x\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseCSV(strings.NewReader(input)); !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("ParseCSV() error = %v, want %v", err, ErrInvalidFile)
+			}
+		})
+	}
+}
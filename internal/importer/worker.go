@@ -0,0 +1,214 @@
+package importer
+
+import (
+	"context"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+)
+
+type WorkerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// StaleAfter - через сколько без обновлений задание в статусе running считается брошенным
+	StaleAfter time.Duration
+}
+
+// Worker обрабатывает задания импорта по одному, строки - порциями по BatchSize.
+// Результат каждой строки сохраняется сразу, поэтому прерванное задание продолжается с первой необработанной строки
+type Worker struct {
+	repo repository.ImportRepositoryInterface
+	cfg  WorkerConfig
+}
+
+func NewWorker(repo repository.ImportRepositoryInterface, cfg WorkerConfig) *Worker {
+	return &Worker{repo: repo, cfg: cfg}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := w.ProcessOnce(ctx)
+			if err != nil {
+				logger.GlobalLogger.Error("Ошибка обработки импорта: %v", err)
+				break
+			}
+			if !processed || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOnce захватывает одно задание и обрабатывает его до конца или до отмены ctx.
+// Возвращает false, если заданий в очереди нет
+func (w *Worker) ProcessOnce(ctx context.Context) (bool, error) {
+	job, err := w.repo.ClaimImportJob(w.cfg.StaleAfter)
+	if err != nil {
+		return false, err
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	// Пробный прогон ведет балансы в памяти, чтобы строки одного кошелька учитывали друг друга
+	var preview *dryRun
+	if job.DryRun {
+		preview = newDryRun()
+	}
+
+	for ctx.Err() == nil {
+		rows, err := w.repo.ListPendingImportRows(job.ID, w.cfg.BatchSize)
+		if err != nil {
+			return true, err
+		}
+		if len(rows) == 0 {
+			if err := w.repo.CompleteImportJob(job.ID); err != nil {
+				return true, err
+			}
+			logger.GlobalLogger.Info("Импорт %s завершен (dryRun=%t)", job.ID, job.DryRun)
+			return true, nil
+		}
+
+		if preview != nil {
+			err = w.previewRows(preview, rows)
+		} else {
+			err = w.applyRows(ctx, rows)
+		}
+		if err != nil {
+			return true, err
+		}
+	}
+
+	// Задание остается в running и будет подхвачено снова через StaleAfter
+	return true, nil
+}
+
+func (w *Worker) applyRows(ctx context.Context, rows []models.ImportRow) error {
+	for _, row := range rows {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if _, err := w.repo.ApplyImportRow(row); err != nil {
+			return err
+		}
+		metrics.ImportRowsProcessedTotal.Add(1)
+	}
+	return nil
+}
+
+// dryRun - кошельки с балансами, как если бы уже просчитанные строки задания были проведены,
+// и действующие для них правила комиссий
+type dryRun struct {
+	wallets map[uuid.UUID]models.Wallet
+	missing map[uuid.UUID]bool
+	fees    map[uuid.UUID][]models.FeeRule
+}
+
+func newDryRun() *dryRun {
+	return &dryRun{
+		wallets: make(map[uuid.UUID]models.Wallet),
+		missing: make(map[uuid.UUID]bool),
+		fees:    make(map[uuid.UUID][]models.FeeRule),
+	}
+}
+
+func (w *Worker) previewRows(preview *dryRun, rows []models.ImportRow) error {
+	var unknown []uuid.UUID
+	refs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		_, known := preview.wallets[row.WalletID]
+		if !known && !preview.missing[row.WalletID] {
+			unknown = append(unknown, row.WalletID)
+			preview.missing[row.WalletID] = true
+		}
+		refs = append(refs, row.ExternalRef)
+	}
+
+	if len(unknown) > 0 {
+		wallets, err := w.repo.GetImportWallets(unknown)
+		if err != nil {
+			return err
+		}
+		for id, wallet := range wallets {
+			preview.wallets[id] = wallet
+			delete(preview.missing, id)
+		}
+
+		fees, err := w.repo.GetImportFeeRules(unknown)
+		if err != nil {
+			return err
+		}
+		for id, rules := range fees {
+			preview.fees[id] = rules
+		}
+	}
+
+	used, err := w.repo.FindUsedExternalRefs(refs)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := w.repo.RecordImportRowResult(row, preview.predict(row, used[row.ExternalRef])); err != nil {
+			return err
+		}
+		metrics.ImportRowsProcessedTotal.Add(1)
+	}
+	return nil
+}
+
+// predict повторяет проверки ApplyImportRow без изменения балансов
+func (p *dryRun) predict(row models.ImportRow, refUsed bool) models.ImportRowResult {
+	if refUsed {
+		return models.ImportRowResult{Status: models.ImportRowDuplicate, Error: models.ImportErrorDuplicateRef}
+	}
+	if p.missing[row.WalletID] {
+		return models.ImportRowResult{Status: models.ImportRowFailed, Error: models.ImportErrorWalletNotFound}
+	}
+
+	fee := p.fee(row)
+	if !fee.CoversFee() {
+		return models.ImportRowResult{Status: models.ImportRowFailed, Error: models.ImportErrorFeeExceedsAmount}
+	}
+
+	wallet := p.wallets[row.WalletID]
+	if row.OperationType == models.OperationTypeWithdraw {
+		if !wallet.CanDebit(fee.Gross) {
+			return models.ImportRowResult{Status: models.ImportRowFailed, Error: models.ImportErrorInsufficientFunds}
+		}
+		wallet.Balance = wallet.Balance.Sub(fee.Gross)
+	} else {
+		wallet.Balance = wallet.Balance.Add(fee.Net)
+	}
+	p.wallets[row.WalletID] = wallet
+	balance := wallet.Balance
+
+	return models.ImportRowResult{Status: models.ImportRowSucceeded, BalanceAfter: &balance, Fee: &fee.Fee}
+}
+
+// fee считает комиссию строки по правилу, действующему для ее кошелька
+func (p *dryRun) fee(row models.ImportRow) models.Fee {
+	var fee utils.Money
+	for i := range p.fees[row.WalletID] {
+		if rule := &p.fees[row.WalletID][i]; rule.OperationType == row.OperationType {
+			fee = rule.Compute(row.Amount)
+			break
+		}
+	}
+	return models.NewFee(row.OperationType, row.Amount, fee)
+}
@@ -0,0 +1,267 @@
+package importer
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+)
+
+func init() {
+	logger.Init()
+}
+
+type mockImportRepository struct {
+	job       *models.ImportJob
+	rows      []models.ImportRow
+	wallets   map[uuid.UUID]models.Wallet
+	feeRules  map[uuid.UUID][]models.FeeRule
+	usedRefs  map[string]bool
+	applied   []string
+	completed bool
+}
+
+func (m *mockImportRepository) CreateImportJob(job *models.ImportJob, rows []models.ImportRow) error {
+	return nil
+}
+
+func (m *mockImportRepository) GetImportJob(id string) (*models.ImportJob, error) {
+	return m.job, nil
+}
+
+func (m *mockImportRepository) ListImportRows(jobID string, afterRow, limit int) ([]models.ImportRow, error) {
+	return m.rows, nil
+}
+
+func (m *mockImportRepository) ListPendingImportRows(jobID uuid.UUID, limit int) ([]models.ImportRow, error) {
+	var pending []models.ImportRow
+	for _, row := range m.rows {
+		if row.Status == models.ImportRowPending && len(pending) < limit {
+			pending = append(pending, row)
+		}
+	}
+	return pending, nil
+}
+
+func (m *mockImportRepository) ClaimImportJob(staleAfter time.Duration) (*models.ImportJob, error) {
+	if m.job == nil || m.job.Status != models.ImportJobPending {
+		return nil, nil
+	}
+	m.job.Status = models.ImportJobRunning
+	return m.job, nil
+}
+
+func (m *mockImportRepository) ApplyImportRow(row models.ImportRow) (models.ImportRowResult, error) {
+	m.applied = append(m.applied, row.ExternalRef)
+	result := models.ImportRowResult{Status: models.ImportRowSucceeded}
+	return result, m.RecordImportRowResult(row, result)
+}
+
+func (m *mockImportRepository) RecordImportRowResult(row models.ImportRow, result models.ImportRowResult) error {
+	for i := range m.rows {
+		if m.rows[i].RowNumber == row.RowNumber {
+			m.rows[i].Status = result.Status
+			m.rows[i].BalanceAfter = result.BalanceAfter
+			m.rows[i].Fee = result.Fee
+			m.rows[i].Error = result.Error
+		}
+	}
+	return nil
+}
+
+func (m *mockImportRepository) CompleteImportJob(jobID uuid.UUID) error {
+	m.completed = true
+	m.job.Status = models.ImportJobCompleted
+	return nil
+}
+
+func (m *mockImportRepository) GetImportWallets(walletIDs []uuid.UUID) (map[uuid.UUID]models.Wallet, error) {
+	found := make(map[uuid.UUID]models.Wallet)
+	for _, id := range walletIDs {
+		if wallet, ok := m.wallets[id]; ok {
+			found[id] = wallet
+		}
+	}
+	return found, nil
+}
+
+func (m *mockImportRepository) GetImportFeeRules(walletIDs []uuid.UUID) (map[uuid.UUID][]models.FeeRule, error) {
+	found := make(map[uuid.UUID][]models.FeeRule)
+	for _, id := range walletIDs {
+		if rules, ok := m.feeRules[id]; ok {
+			found[id] = rules
+		}
+	}
+	return found, nil
+}
+
+func (m *mockImportRepository) FindUsedExternalRefs(refs []string) (map[string]bool, error) {
+	used := make(map[string]bool)
+	for _, ref := range refs {
+		if m.usedRefs[ref] {
+			used[ref] = true
+		}
+	}
+	return used, nil
+}
+
+func newImportRow(number int, walletID uuid.UUID, opType string, amount int64, ref string) models.ImportRow {
+	return models.ImportRow{
+		RowNumber:     number,
+		WalletID:      walletID,
+		OperationType: opType,
+		Amount:        utils.Money{Raw: amount},
+		ExternalRef:   ref,
+		Status:        models.ImportRowPending,
+	}
+}
+
+func TestWorker_DryRun(t *testing.T) {
+	wallet, credit, unknown := uuid.New(), uuid.New(), uuid.New()
+	repo := &mockImportRepository{
+		job: &models.ImportJob{ID: uuid.New(), Status: models.ImportJobPending, DryRun: true},
+		rows: []models.ImportRow{
+			newImportRow(2, wallet, models.OperationTypeWithdraw, 600, "a"),
+			newImportRow(3, wallet, models.OperationTypeWithdraw, 600, "b"),
+			newImportRow(4, wallet, models.OperationTypeDeposit, 100, "c"),
+			newImportRow(5, unknown, models.OperationTypeDeposit, 100, "d"),
+			newImportRow(6, wallet, models.OperationTypeDeposit, 100, "used"),
+			newImportRow(7, credit, models.OperationTypeWithdraw, 1200, "e"),
+			newImportRow(8, credit, models.OperationTypeWithdraw, 400, "f"),
+		},
+		wallets: map[uuid.UUID]models.Wallet{
+			wallet: {ID: wallet, Balance: utils.Money{Raw: 1000}},
+			credit: {ID: credit, Balance: utils.Money{Raw: 1000}, OverdraftLimit: utils.Money{Raw: 500}},
+		},
+		usedRefs: map[string]bool{"used": true},
+	}
+	// Порции по 2 строки проверяют, что прогнозируемый баланс переносится между порциями
+	worker := NewWorker(repo, WorkerConfig{BatchSize: 2})
+
+	processed, err := worker.ProcessOnce(context.Background())
+	if err != nil || !processed {
+		t.Fatalf("ProcessOnce() = %t, %v", processed, err)
+	}
+	if !repo.completed {
+		t.Error("dry run job was not completed")
+	}
+	if len(repo.applied) != 0 {
+		t.Errorf("dry run applied rows %v", repo.applied)
+	}
+
+	want := []struct {
+		status  string
+		balance string
+	}{
+		{models.ImportRowSucceeded, "4.00"},
+		{models.ImportRowFailed, ""},
+		{models.ImportRowSucceeded, "5.00"},
+		{models.ImportRowFailed, ""},
+		{models.ImportRowDuplicate, ""},
+		{models.ImportRowSucceeded, "-2.00"},
+		{models.ImportRowFailed, ""},
+	}
+	for i, row := range repo.rows {
+		balance := ""
+		if row.BalanceAfter != nil {
+			balance = row.BalanceAfter.String()
+		}
+		if row.Status != want[i].status || balance != want[i].balance {
+			t.Errorf("row %d = %s %q (%s), want %s %q", row.RowNumber, row.Status, balance, row.Error, want[i].status, want[i].balance)
+		}
+	}
+}
+
+func TestWorker_DryRunChargesFees(t *testing.T) {
+	wallet := uuid.New()
+	repo := &mockImportRepository{
+		job: &models.ImportJob{ID: uuid.New(), Status: models.ImportJobPending, DryRun: true},
+		rows: []models.ImportRow{
+			newImportRow(2, wallet, models.OperationTypeWithdraw, 600, "a"),
+			newImportRow(3, wallet, models.OperationTypeWithdraw, 320, "b"),
+			newImportRow(4, wallet, models.OperationTypeDeposit, 40, "c"),
+			newImportRow(5, wallet, models.OperationTypeDeposit, 1000, "d"),
+		},
+		wallets: map[uuid.UUID]models.Wallet{wallet: {ID: wallet, Balance: utils.Money{Raw: 1000}}},
+		feeRules: map[uuid.UUID][]models.FeeRule{wallet: {
+			{OperationType: models.OperationTypeDeposit, Kind: models.FeeKindFlat, Flat: utils.Money{Raw: 50}},
+			{OperationType: models.OperationTypeWithdraw, Kind: models.FeeKindFlat, Flat: utils.Money{Raw: 50}},
+		}},
+	}
+	worker := NewWorker(repo, WorkerConfig{BatchSize: 10})
+
+	if _, err := worker.ProcessOnce(context.Background()); err != nil {
+		t.Fatalf("ProcessOnce() error = %v", err)
+	}
+
+	// Списание 3.20 прошло бы без комиссии, а пополнение 0.40 целиком уходит на нее
+	want := []struct {
+		status  string
+		balance string
+		fee     string
+		err     string
+	}{
+		{models.ImportRowSucceeded, "3.50", "0.50", ""},
+		{models.ImportRowFailed, "", "", models.ImportErrorInsufficientFunds},
+		{models.ImportRowFailed, "", "", models.ImportErrorFeeExceedsAmount},
+		{models.ImportRowSucceeded, "13.00", "0.50", ""},
+	}
+	for i, row := range repo.rows {
+		balance, fee := "", ""
+		if row.BalanceAfter != nil {
+			balance = row.BalanceAfter.String()
+		}
+		if row.Fee != nil {
+			fee = row.Fee.String()
+		}
+		if row.Status != want[i].status || balance != want[i].balance || fee != want[i].fee || row.Error != want[i].err {
+			t.Errorf("row %d = %s %q fee %q (%s), want %s %q fee %q (%s)",
+				row.RowNumber, row.Status, balance, fee, row.Error, want[i].status, want[i].balance, want[i].fee, want[i].err)
+		}
+	}
+}
+
+func TestWorker_AppliesRows(t *testing.T) {
+	wallet := uuid.New()
+	repo := &mockImportRepository{
+		job: &models.ImportJob{ID: uuid.New(), Status: models.ImportJobPending},
+		rows: []models.ImportRow{
+			newImportRow(2, wallet, models.OperationTypeDeposit, 100, "a"),
+			newImportRow(3, wallet, models.OperationTypeWithdraw, 50, "b"),
+			newImportRow(4, wallet, models.OperationTypeDeposit, 10, "c"),
+		},
+	}
+	worker := NewWorker(repo, WorkerConfig{BatchSize: 2})
+
+	if _, err := worker.ProcessOnce(context.Background()); err != nil {
+		t.Fatalf("ProcessOnce() error = %v", err)
+	}
+	if len(repo.applied) != 3 || !repo.completed {
+		t.Errorf("applied %v, completed %t", repo.applied, repo.completed)
+	}
+
+	if processed, err := worker.ProcessOnce(context.Background()); processed || err != nil {
+		t.Errorf("ProcessOnce() on empty queue = %t, %v", processed, err)
+	}
+}
+
+func TestWorker_StopsOnCancel(t *testing.T) {
+	repo := &mockImportRepository{
+		job:  &models.ImportJob{ID: uuid.New(), Status: models.ImportJobPending},
+		rows: []models.ImportRow{newImportRow(2, uuid.New(), models.OperationTypeDeposit, 100, "a")},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := NewWorker(repo, WorkerConfig{BatchSize: 10}).ProcessOnce(ctx); err != nil {
+		t.Fatalf("ProcessOnce() error = %v", err)
+	}
+	// Задание остается running и будет подхвачено снова после StaleAfter
+	if repo.completed || len(repo.applied) != 0 || repo.job.Status != models.ImportJobRunning {
+		t.Errorf("canceled worker applied %v, completed %t, status %s", repo.applied, repo.completed, repo.job.Status)
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+var (
+	PanicsTotal      = expvar.NewInt("panics_total")
+	RateLimitedTotal = expvar.NewInt("rate_limited_total")
+
+	OutboxPublishedTotal = expvar.NewInt("outbox_published_total")
+	OutboxFailedTotal    = expvar.NewInt("outbox_failed_total")
+	OutboxDeadTotal      = expvar.NewInt("outbox_dead_total")
+
+	WebhookDeliveredTotal = expvar.NewInt("webhook_delivered_total")
+	WebhookRetriedTotal   = expvar.NewInt("webhook_retried_total")
+	WebhookFailedTotal    = expvar.NewInt("webhook_failed_total")
+
+	StreamSubscribers = expvar.NewInt("stream_subscribers")
+
+	BalanceSnapshotsTotal = expvar.NewInt("balance_snapshots_total")
+
+	ImportRowsProcessedTotal = expvar.NewInt("import_rows_processed_total")
+
+	ReconciliationRunsTotal           = expvar.NewInt("reconciliation_runs_total")
+	ReconciliationWalletsCheckedTotal = expvar.NewInt("reconciliation_wallets_checked_total")
+	ReconciliationMismatchesTotal     = expvar.NewInt("reconciliation_mismatches_total")
+	ReconciliationWalletsFrozenTotal  = expvar.NewInt("reconciliation_wallets_frozen_total")
+	// ReconciliationLastMismatches - число расхождений в последней завершенной сверке
+	ReconciliationLastMismatches = expvar.NewInt("reconciliation_last_mismatches")
+	// ReconciliationLastCompletedAt - unix время завершения последней сверки
+	ReconciliationLastCompletedAt = expvar.NewInt("reconciliation_last_completed_at")
+
+	// SchedulerLeader - 1, если реплика держит блокировку планировщика и выполняет задачи
+	SchedulerLeader           = expvar.NewInt("scheduler_leader")
+	SchedulerJobFailuresTotal = expvar.NewInt("scheduler_job_failures_total")
+
+	InterestAccrualDaysTotal = expvar.NewInt("interest_accrual_days_total")
+	InterestAccrualsTotal    = expvar.NewInt("interest_accruals_total")
+	InterestPostingsTotal    = expvar.NewInt("interest_postings_total")
+
+	ScheduledTransfersExecutedTotal = expvar.NewInt("scheduled_transfers_executed_total")
+	// ScheduledTransfersFailedTotal - неудачные попытки запусков, в том числе те, что будут повторены
+	ScheduledTransfersFailedTotal = expvar.NewInt("scheduled_transfers_failed_total")
+)
+
+func Handler() http.Handler {
+	return expvar.Handler()
+}
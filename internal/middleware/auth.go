@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	stdErrors "errors"
+	"net/http"
+	"strings"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/service"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
+	"wallet-api/utils/response"
+)
+
+const (
+	APIKeyHeader       = "X-API-Key"
+	apiKeyAuthScheme   = "ApiKey "
+	authenticateHeader = "WWW-Authenticate"
+)
+
+type APIKeyAuth struct {
+	service service.AuthServiceInterface
+}
+
+func NewAPIKeyAuth(service service.AuthServiceInterface) *APIKeyAuth {
+	return &APIKeyAuth{service: service}
+}
+
+// Authenticate проверяет ключ из X-API-Key или "Authorization: ApiKey <key>" и кладет клиента в контекст
+func (a *APIKeyAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := extractAPIKey(r)
+		if key == "" {
+			w.Header().Set(authenticateHeader, strings.TrimSpace(apiKeyAuthScheme))
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "API ключ не передан")
+			return
+		}
+
+		client, err := a.service.AuthenticateAPIKey(key)
+		if err != nil {
+			if stdErrors.Is(err, service.ErrInvalidAPIKey) {
+				w.Header().Set(authenticateHeader, strings.TrimSpace(apiKeyAuthScheme))
+				response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Неверный API ключ")
+				return
+			}
+			logger.GlobalLogger.Error("Ошибка проверки API ключа: %v - RequestID: %s", err, requestid.FromContext(r.Context()))
+			response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithClient(r.Context(), client)))
+	}
+}
+
+// RequireScope пропускает запрос, если у клиента есть хотя бы один из перечисленных scope
+func RequireScope(scopes ...string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			client := auth.ClientFromContext(r.Context())
+			if client == nil {
+				response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Требуется аутентификация")
+				return
+			}
+
+			for _, scope := range scopes {
+				if client.HasScope(scope) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			response.WriteError(w, r, http.StatusForbidden, response.CodeForbidden, "Недостаточно прав: требуется "+strings.Join(scopes, " или "))
+		}
+	}
+}
+
+func extractAPIKey(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > len(apiKeyAuthScheme) && strings.EqualFold(authorization[:len(apiKeyAuthScheme)], apiKeyAuthScheme) {
+		return strings.TrimSpace(authorization[len(apiKeyAuthScheme):])
+	}
+
+	return ""
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+
+	"github.com/google/uuid"
+)
+
+type MockAuthService struct {
+	clients map[string]*models.APIClient
+	secrets map[string][]byte
+	err     error
+}
+
+func (m *MockAuthService) AuthenticateAPIKey(key string) (*models.APIClient, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	client, ok := m.clients[key]
+	if !ok {
+		return nil, service.ErrInvalidAPIKey
+	}
+	return client, nil
+}
+
+func (m *MockAuthService) CreateClient(name string, scopes []string, walletIDs []uuid.UUID) (*models.APIClient, string, error) {
+	return nil, "", nil
+}
+
+func (m *MockAuthService) RotateKey(clientID string) (string, error) {
+	return "", nil
+}
+
+func (m *MockAuthService) GetSigningSecret(clientID string) (*models.APIClient, []byte, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	secret, ok := m.secrets[clientID]
+	if !ok {
+		return nil, nil, service.ErrUnknownSigningClient
+	}
+	return m.clients[clientID], secret, nil
+}
+
+func (m *MockAuthService) RotateSigningSecret(clientID string) (string, error) {
+	return "", nil
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	reader := &models.APIClient{ID: uuid.New(), Scopes: []string{models.ScopeWalletRead}}
+	admin := &models.APIClient{ID: uuid.New(), Scopes: []string{models.ScopeAdmin}}
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		scopes         []string
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "missing key",
+			scopes:         []string{models.ScopeWalletRead},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "UNAUTHORIZED",
+		},
+		{
+			name:           "invalid key",
+			headers:        map[string]string{APIKeyHeader: "wk_unknown"},
+			scopes:         []string{models.ScopeWalletRead},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "UNAUTHORIZED",
+		},
+		{
+			name:           "valid key with scope",
+			headers:        map[string]string{APIKeyHeader: "wk_reader"},
+			scopes:         []string{models.ScopeWalletRead},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "authorization header scheme",
+			headers:        map[string]string{"Authorization": "ApiKey wk_reader"},
+			scopes:         []string{models.ScopeWalletRead},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid key without scope",
+			headers:        map[string]string{APIKeyHeader: "wk_reader"},
+			scopes:         []string{models.ScopeWalletWithdraw},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+		},
+		{
+			name:           "admin has every scope",
+			headers:        map[string]string{APIKeyHeader: "wk_admin"},
+			scopes:         []string{models.ScopeWalletWithdraw},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "service failure",
+			headers:        map[string]string{APIKeyHeader: "wk_reader"},
+			scopes:         []string{models.ScopeWalletRead},
+			serviceErr:     repository.ErrDatabaseError,
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "INTERNAL_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockAuthService{
+				clients: map[string]*models.APIClient{"wk_reader": reader, "wk_admin": admin},
+				err:     tt.serviceErr,
+			}
+			apiKeyAuth := NewAPIKeyAuth(mockService)
+
+			var seen *models.APIClient
+			h := NewChain(apiKeyAuth.Authenticate, RequireScope(tt.scopes...)).Then(func(w http.ResponseWriter, r *http.Request) {
+				seen = auth.ClientFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/x", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			h(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusOK && seen == nil {
+				t.Errorf("Expected client in request context")
+			}
+			if tt.expectedCode == "" {
+				return
+			}
+
+			var body struct {
+				Error struct {
+					Code string `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code %s, got %s", tt.expectedCode, body.Error.Code)
+			}
+		})
+	}
+}
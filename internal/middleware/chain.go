@@ -0,0 +1,28 @@
+package middleware
+
+import "net/http"
+
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Chain собирает middleware в порядке вызова: первый в списке оборачивает все остальные
+type Chain struct {
+	middlewares []Middleware
+}
+
+func NewChain(middlewares ...Middleware) Chain {
+	return Chain{middlewares: append([]Middleware(nil), middlewares...)}
+}
+
+func (c Chain) Append(middlewares ...Middleware) Chain {
+	merged := make([]Middleware, 0, len(c.middlewares)+len(middlewares))
+	merged = append(merged, c.middlewares...)
+	merged = append(merged, middlewares...)
+	return Chain{middlewares: merged}
+}
+
+func (c Chain) Then(handler http.HandlerFunc) http.HandlerFunc {
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		handler = c.middlewares[i](handler)
+	}
+	return handler
+}
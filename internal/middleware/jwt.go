@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	stdErrors "errors"
+	"net/http"
+	"strings"
+	"wallet-api/internal/auth"
+	"wallet-api/utils/response"
+)
+
+const bearerAuthScheme = "Bearer "
+
+type JWTAuth struct {
+	verifier *auth.JWTVerifier
+}
+
+func NewJWTAuth(verifier *auth.JWTVerifier) *JWTAuth {
+	return &JWTAuth{verifier: verifier}
+}
+
+// Authenticate проверяет Bearer токен и кладет его subject в контекст как владельца кошельков
+func (a *JWTAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if len(authorization) <= len(bearerAuthScheme) || !strings.EqualFold(authorization[:len(bearerAuthScheme)], bearerAuthScheme) {
+			w.Header().Set(authenticateHeader, strings.TrimSpace(bearerAuthScheme))
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Токен не передан")
+			return
+		}
+
+		claims, err := a.verifier.Verify(strings.TrimSpace(authorization[len(bearerAuthScheme):]))
+		if err != nil {
+			message := "Неверный токен"
+			if stdErrors.Is(err, auth.ErrTokenExpired) {
+				message = "Срок действия токена истек"
+			}
+			w.Header().Set(authenticateHeader, `Bearer error="invalid_token"`)
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, message)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithOwner(r.Context(), claims.Subject)))
+	}
+}
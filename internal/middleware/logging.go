@@ -4,13 +4,14 @@ import (
 	"net/http"
 	"time"
 	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
 )
 
 func LoggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		wrappedWriter := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		wrappedWriter := wrapResponseWriter(w)
 
 		next.ServeHTTP(wrappedWriter, r)
 
@@ -18,8 +19,8 @@ func LoggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		if wrappedWriter.statusCode >= 400 {
 			logger.GlobalLogger.Error(
-				"Request failed: %s %s - Status: %d - Duration: %v",
-				r.Method, r.URL.Path, wrappedWriter.statusCode, duration,
+				"Request failed: %s %s - Status: %d - Duration: %v - RequestID: %s",
+				r.Method, r.URL.Path, wrappedWriter.statusCode, duration, requestid.FromContext(r.Context()),
 			)
 		}
 	}
@@ -27,10 +28,32 @@ func LoggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	wroteHeader bool
+}
+
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
 	rw.statusCode = code
+	rw.wroteHeader = true
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
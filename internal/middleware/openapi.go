@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	stdErrors "errors"
+	"net/http"
+	"wallet-api/internal/openapi"
+	"wallet-api/utils/response"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+type OpenAPIValidator struct {
+	spec *openapi.Spec
+}
+
+func NewOpenAPIValidator(spec *openapi.Spec) *OpenAPIValidator {
+	return &OpenAPIValidator{spec: spec}
+}
+
+// Validate отклоняет запросы, не соответствующие спецификации, с 400.
+// Запросы к путям и методам, которых нет в спецификации, передаются обработчику: он ответит 404 или 405
+func (v *OpenAPIValidator) Validate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.spec.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := v.spec.ValidateRequest(r, route, pathParams); err != nil {
+			response.WriteError(w, r, http.StatusBadRequest, response.CodeBadRequest, validationMessage(err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+func validationMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if stdErrors.As(err, &requestErr) {
+		message := "Запрос не соответствует спецификации"
+		if requestErr.Parameter != nil {
+			message += ": параметр " + requestErr.Parameter.Name
+		} else if requestErr.RequestBody != nil {
+			message += ": тело запроса"
+		}
+		if requestErr.Err != nil {
+			message += ": " + requestErr.Err.Error()
+		} else if requestErr.Reason != "" {
+			message += ": " + requestErr.Reason
+		}
+		return message
+	}
+	return "Запрос не соответствует спецификации"
+}
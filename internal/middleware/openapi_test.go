@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wallet-api/api"
+	"wallet-api/internal/openapi"
+)
+
+func TestOpenAPIValidator_Validate(t *testing.T) {
+	spec, err := openapi.Load(api.OpenAPISpec)
+	if err != nil {
+		t.Fatalf("openapi.Load() error = %v", err)
+	}
+	validator := NewOpenAPIValidator(spec)
+
+	var gotBody string
+	handler := validator.Validate(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	validBody := `{"walletId":"7b1e4b1e-9c1a-4c1e-8f7e-1c2b3d4e5f60","operationType":"DEPOSIT","amount":10}`
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"valid operation", http.MethodPost, "/api/v1/wallet", validBody, http.StatusOK},
+		{"unknown operation type", http.MethodPost, "/api/v1/wallet", `{"walletId":"7b1e4b1e-9c1a-4c1e-8f7e-1c2b3d4e5f60","operationType":"REFUND","amount":10}`, http.StatusBadRequest},
+		{"negative amount", http.MethodPost, "/api/v1/wallet", `{"walletId":"7b1e4b1e-9c1a-4c1e-8f7e-1c2b3d4e5f60","operationType":"DEPOSIT","amount":-1}`, http.StatusBadRequest},
+		{"missing wallet id", http.MethodPost, "/api/v1/wallet", `{"operationType":"DEPOSIT","amount":10}`, http.StatusBadRequest},
+		{"invalid path uuid", http.MethodGet, "/api/v1/wallets/not-a-uuid", "", http.StatusBadRequest},
+		{"path outside spec", http.MethodGet, "/api/v1/unknown", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBody = ""
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+
+			handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && gotBody != tt.body {
+				t.Errorf("handler body = %q, want %q", gotBody, tt.body)
+			}
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(rec.Body.String(), `"BAD_REQUEST"`) {
+				t.Errorf("expected structured error, got %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+	"wallet-api/internal/ratelimit"
+	"wallet-api/utils/response"
+)
+
+const maxPeekBodyBytes = 1 << 20
+
+type RateLimitRules struct {
+	PerIP          ratelimit.Rule
+	PerClient      ratelimit.Rule
+	WalletRead     ratelimit.Rule
+	WalletDeposit  ratelimit.Rule
+	WalletWithdraw ratelimit.Rule
+}
+
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	rules   RateLimitRules
+}
+
+func NewRateLimiter(limiter ratelimit.Limiter, rules RateLimitRules) *RateLimiter {
+	return &RateLimiter{limiter: limiter, rules: rules}
+}
+
+// PerIP ограничивает запросы по адресу клиента, ставится до аутентификации
+func (rl *RateLimiter) PerIP(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, rule := rl.IPLimit(remoteIP(r))
+		if !rl.allow(w, r, key, rule) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// PerClient ограничивает запросы по API клиенту или владельцу из JWT, ставится после аутентификации
+func (rl *RateLimiter) PerClient(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, rule := rl.ClientLimit(r.Context())
+		if key != "" && !rl.allow(w, r, key, rule) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// PerWallet ограничивает запросы к одному кошельку; для списаний можно задать более строгое правило, чем для чтения
+func (rl *RateLimiter) PerWallet(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, rule := rl.walletRule(r)
+		if key != "" && !rl.allow(w, r, key, rule) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// Ключи и правила ниже общие для HTTP и gRPC: оба транспорта расходуют один и тот же лимит
+
+// IPLimit возвращает ключ и правило лимита по адресу клиента
+func (rl *RateLimiter) IPLimit(ip string) (string, ratelimit.Rule) {
+	return "ip:" + ip, rl.rules.PerIP
+}
+
+// ClientLimit возвращает ключ и правило лимита по API клиенту или владельцу из JWT; пустой ключ - без лимита
+func (rl *RateLimiter) ClientLimit(ctx context.Context) (string, ratelimit.Rule) {
+	if client := auth.ClientFromContext(ctx); client != nil {
+		return "client:" + client.ID.String(), rl.rules.PerClient
+	}
+	if ownerID := auth.OwnerFromContext(ctx); ownerID != "" {
+		return "owner:" + ownerID, rl.rules.PerClient
+	}
+	return "", ratelimit.Rule{}
+}
+
+// WalletLimit возвращает ключ и правило лимита кошелька: пустой operationType - чтение,
+// DEPOSIT или WITHDRAW - операция. Для других операций ключ пустой
+func (rl *RateLimiter) WalletLimit(walletID, operationType string) (string, ratelimit.Rule) {
+	switch operationType {
+	case "":
+		return "wallet:" + walletID + ":read", rl.rules.WalletRead
+	case models.OperationTypeWithdraw:
+		return "wallet:" + walletID + ":" + operationType, rl.rules.WalletWithdraw
+	case models.OperationTypeDeposit:
+		return "wallet:" + walletID + ":" + operationType, rl.rules.WalletDeposit
+	}
+	return "", ratelimit.Rule{}
+}
+
+// Allow расходует лимит по ключу и возвращает время до следующей разрешенной попытки, если лимит исчерпан
+func (rl *RateLimiter) Allow(key string, rule ratelimit.Rule) (bool, time.Duration) {
+	ok, retryAfter := rl.limiter.Allow(key, rule)
+	if !ok {
+		metrics.RateLimitedTotal.Add(1)
+	}
+	return ok, retryAfter
+}
+
+func (rl *RateLimiter) walletRule(r *http.Request) (string, ratelimit.Rule) {
+	if r.Method == http.MethodGet {
+		walletID := strings.TrimPrefix(r.URL.Path, "/api/v1/wallets/")
+		walletID, _, _ = strings.Cut(walletID, "/")
+		if walletID == "" {
+			return "", ratelimit.Rule{}
+		}
+		return rl.WalletLimit(walletID, "")
+	}
+
+	if r.Method != http.MethodPost || r.Body == nil {
+		return "", ratelimit.Rule{}
+	}
+
+	// Прочитана только голова тела: обработчик получает ее вместе с непрочитанным остатком
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBodyBytes))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return "", ratelimit.Rule{}
+	}
+
+	var operation struct {
+		WalletID      string `json:"walletId"`
+		OperationType string `json:"operationType"`
+	}
+	if err := json.Unmarshal(body, &operation); err != nil {
+		return "", ratelimit.Rule{}
+	}
+
+	if operation.OperationType == "" {
+		return "", ratelimit.Rule{}
+	}
+	return rl.WalletLimit(operation.WalletID, operation.OperationType)
+}
+
+func (rl *RateLimiter) allow(w http.ResponseWriter, r *http.Request, key string, rule ratelimit.Rule) bool {
+	ok, retryAfter := rl.Allow(key, rule)
+	if ok {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	response.WriteError(w, r, http.StatusTooManyRequests, response.CodeRateLimited, "Слишком много запросов")
+	return false
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wallet-api/internal/ratelimit"
+
+	"github.com/google/uuid"
+)
+
+func TestRateLimiter_PerWallet(t *testing.T) {
+	walletID := uuid.New().String()
+	rl := NewRateLimiter(ratelimit.NewMemoryLimiter(time.Minute), RateLimitRules{
+		WalletRead:     ratelimit.Rule{Rate: 1, Burst: 3},
+		WalletWithdraw: ratelimit.Rule{Rate: 1, Burst: 1},
+	})
+
+	var bodies []string
+	h := rl.PerWallet(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+	})
+
+	withdraw := `{"walletId":"` + walletID + `","operationType":"WITHDRAW","amount":1}`
+	for i, expected := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(withdraw)))
+		if w.Code != expected {
+			t.Errorf("withdraw %d: expected status %d, got %d", i+1, expected, w.Code)
+		}
+		if expected == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "1" {
+			t.Errorf("Expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+		}
+	}
+
+	if len(bodies) != 1 || bodies[0] != withdraw {
+		t.Errorf("Handler must receive the original body, got %v", bodies)
+	}
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("read %d: expected status %d, got %d", i+1, http.StatusOK, w.Code)
+		}
+	}
+}
+
+func TestRateLimiter_PerWallet_LargeBody(t *testing.T) {
+	rl := NewRateLimiter(ratelimit.NewMemoryLimiter(time.Minute), RateLimitRules{
+		WalletWithdraw: ratelimit.Rule{Rate: 1, Burst: 1},
+	})
+
+	var received int
+	h := rl.PerWallet(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = len(data)
+	})
+
+	body := `{"operationType":"WITHDRAW","metadata":"` + strings.Repeat("x", maxPeekBodyBytes) + `"}`
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(body)))
+	if received != len(body) {
+		t.Errorf("Handler received %d bytes, want %d", received, len(body))
+	}
+}
+
+func TestRateLimiter_PerIP(t *testing.T) {
+	rl := NewRateLimiter(ratelimit.NewMemoryLimiter(time.Minute), RateLimitRules{
+		PerIP: ratelimit.Rule{Rate: 1, Burst: 1},
+	})
+	h := rl.PerIP(func(w http.ResponseWriter, r *http.Request) {})
+
+	request := func(addr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/x", nil)
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w.Code
+	}
+
+	if code := request("10.0.0.1:5000"); code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	if code := request("10.0.0.1:5001"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, code)
+	}
+	if code := request("10.0.0.2:5000"); code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, code)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+	"wallet-api/internal/metrics"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
+	"wallet-api/utils/response"
+)
+
+func RecoveryMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wrappedWriter := wrapResponseWriter(w)
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// ErrAbortHandler - штатный способ прервать ответ, его не логируем как сбой
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			metrics.PanicsTotal.Add(1)
+			logger.GlobalLogger.Error(
+				"Panic recovered: %s %s - RequestID: %s - Panic: %v\n%s",
+				r.Method, r.URL.Path, requestid.FromContext(r.Context()), rec, debug.Stack(),
+			)
+
+			if wrappedWriter.wroteHeader {
+				return
+			}
+			response.WriteError(wrappedWriter, r, http.StatusInternalServerError,
+				response.CodeInternalError, "Внутренняя ошибка сервера")
+		}()
+
+		next.ServeHTTP(wrappedWriter, r)
+	}
+}
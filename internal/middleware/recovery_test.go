@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wallet-api/internal/metrics"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
+)
+
+func init() {
+	logger.Init()
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectJSON     bool
+	}{
+		{
+			name: "panic before write returns structured 500",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectJSON:     true,
+		},
+		{
+			name: "panic after write keeps original status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("boom")
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name: "no panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewChain(RequestIDMiddleware, LoggingMiddleware, RecoveryMiddleware).Then(tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/x", nil)
+			req.Header.Set(requestid.HeaderName, "req-123")
+			w := httptest.NewRecorder()
+
+			h(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if !tt.expectJSON {
+				return
+			}
+
+			var body struct {
+				Error struct {
+					Code      string `json:"code"`
+					RequestID string `json:"requestId"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error.Code != "INTERNAL_ERROR" || body.Error.RequestID != "req-123" {
+				t.Errorf("Unexpected error body: %+v", body.Error)
+			}
+		})
+	}
+}
+
+func TestRecoveryMiddleware_IncrementsMetric(t *testing.T) {
+	before := metrics.PanicsTotal.Value()
+
+	h := RecoveryMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := metrics.PanicsTotal.Value(); got != before+1 {
+		t.Errorf("panics_total = %d, want %d", got, before+1)
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	mark := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next(w, r)
+			}
+		}
+	}
+
+	h := NewChain(mark("first")).Append(mark("second")).Then(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"first", "second", "handler"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("calls = %v, want %v", calls, want)
+		}
+	}
+}
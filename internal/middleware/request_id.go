@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+	"wallet-api/utils/requestid"
+)
+
+func RequestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.HeaderName)
+		if id == "" || len(id) > 128 {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.HeaderName, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithRequestID(r.Context(), id)))
+	}
+}
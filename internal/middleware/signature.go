@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	stdErrors "errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/service"
+	"wallet-api/utils/logger"
+	"wallet-api/utils/requestid"
+	"wallet-api/utils/response"
+)
+
+const (
+	ClientIDHeader  = "X-Client-ID"
+	TimestampHeader = "X-Timestamp"
+	SignatureHeader = "X-Signature"
+
+	maxSignedBodyBytes = 1 << 20
+)
+
+type SignatureAuth struct {
+	service   service.AuthServiceInterface
+	nonces    auth.NonceCache
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewSignatureAuth создает проверку HMAC подписи запросов; запросы с X-Timestamp дальше clockSkew от текущего времени отклоняются
+func NewSignatureAuth(service service.AuthServiceInterface, nonces auth.NonceCache, clockSkew time.Duration) *SignatureAuth {
+	return &SignatureAuth{service: service, nonces: nonces, clockSkew: clockSkew, now: time.Now}
+}
+
+func (a *SignatureAuth) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientID := r.Header.Get(ClientIDHeader)
+		timestamp := r.Header.Get(TimestampHeader)
+		signature := r.Header.Get(SignatureHeader)
+		if clientID == "" || timestamp == "" || signature == "" {
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Требуются заголовки X-Client-ID, X-Timestamp и X-Signature")
+			return
+		}
+
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Неверный формат X-Timestamp")
+			return
+		}
+		if skew := a.now().Sub(time.Unix(unix, 0)); skew > a.clockSkew || skew < -a.clockSkew {
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Время запроса вне допустимого окна")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+		if err != nil || len(body) > maxSignedBodyBytes {
+			response.WriteError(w, r, http.StatusRequestEntityTooLarge, response.CodeBadRequest, "Слишком большое тело запроса")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		client, secret, err := a.service.GetSigningSecret(clientID)
+		if err != nil {
+			if stdErrors.Is(err, service.ErrUnknownSigningClient) {
+				response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Неверная подпись запроса")
+				return
+			}
+			logger.GlobalLogger.Error("Ошибка получения секрета подписи: %v - RequestID: %s", err, requestid.FromContext(r.Context()))
+			response.WriteError(w, r, http.StatusInternalServerError, response.CodeInternalError, "Внутренняя ошибка сервера")
+			return
+		}
+
+		if !auth.VerifyRequestSignature(secret, signature, r.Method, r.URL.RequestURI(), timestamp, body) {
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Неверная подпись запроса")
+			return
+		}
+
+		// Подпись проверяется до записи в кэш, чтобы чужие запросы не могли занять nonce
+		if !a.nonces.CheckAndStore(clientID+":"+signature, 2*a.clockSkew) {
+			response.WriteError(w, r, http.StatusUnauthorized, response.CodeUnauthorized, "Повторный запрос отклонен")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithClient(r.Context(), client)))
+	}
+}
+
+// SignatureOrAPIKey проверяет HMAC подпись, если запрос содержит X-Signature, иначе API ключ
+func SignatureOrAPIKey(signatureAuth *SignatureAuth, apiKeyAuth *APIKeyAuth) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		signed := signatureAuth.Authenticate(next)
+		withKey := apiKeyAuth.Authenticate(next)
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(SignatureHeader) != "" {
+				signed(w, r)
+				return
+			}
+			withKey(w, r)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestSignatureAuth(t *testing.T) {
+	clientID := uuid.New().String()
+	secret := []byte("partner-secret")
+	client := &models.APIClient{ID: uuid.MustParse(clientID), Scopes: []string{models.ScopeWalletDeposit}}
+	now := time.Now()
+
+	signed := func(method, path, body string, ts time.Time, key []byte) *http.Request {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(ClientIDHeader, clientID)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, auth.SignRequest(key, method, path, timestamp, []byte(body)))
+		return req
+	}
+
+	body := `{"walletId":"` + uuid.New().String() + `","operationType":"DEPOSIT","amount":10}`
+
+	tests := []struct {
+		name           string
+		request        func() *http.Request
+		expectedStatus int
+	}{
+		{
+			name: "valid signature",
+			request: func() *http.Request {
+				return signed(http.MethodPost, "/api/v1/wallet", body, now, secret)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "wrong secret",
+			request: func() *http.Request {
+				return signed(http.MethodPost, "/api/v1/wallet", body, now, []byte("other"))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered body",
+			request: func() *http.Request {
+				req := signed(http.MethodPost, "/api/v1/wallet", body, now, secret)
+				req.Body = io.NopCloser(strings.NewReader(strings.Replace(body, "10", "1000", 1)))
+				return req
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "timestamp outside skew window",
+			request: func() *http.Request {
+				return signed(http.MethodPost, "/api/v1/wallet", body, now.Add(-10*time.Minute), secret)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "missing headers",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(body))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockAuthService{
+				clients: map[string]*models.APIClient{clientID: client},
+				secrets: map[string][]byte{clientID: secret},
+			}
+			signatureAuth := NewSignatureAuth(mockService, auth.NewMemoryNonceCache(), 5*time.Minute)
+			signatureAuth.now = func() time.Time { return now }
+
+			var gotBody string
+			h := signatureAuth.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				gotBody = string(data)
+			})
+
+			w := httptest.NewRecorder()
+			h(w, tt.request())
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusOK && gotBody != body {
+				t.Errorf("Handler must receive the original body, got %q", gotBody)
+			}
+		})
+	}
+}
+
+func TestSignatureAuth_RejectsReplay(t *testing.T) {
+	clientID := uuid.New().String()
+	secret := []byte("partner-secret")
+	mockService := &MockAuthService{
+		clients: map[string]*models.APIClient{clientID: {Scopes: []string{models.ScopeWalletRead}}},
+		secrets: map[string][]byte{clientID: secret},
+	}
+	signatureAuth := NewSignatureAuth(mockService, auth.NewMemoryNonceCache(), 5*time.Minute)
+	h := signatureAuth.Authenticate(func(w http.ResponseWriter, r *http.Request) {})
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	path := "/api/v1/wallets/" + uuid.New().String()
+	signature := auth.SignRequest(secret, http.MethodGet, path, timestamp, nil)
+
+	for i, expected := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(ClientIDHeader, clientID)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, signature)
+		w := httptest.NewRecorder()
+
+		h(w, req)
+
+		if w.Code != expected {
+			t.Errorf("attempt %d: expected status %d, got %d", i+1, expected, w.Code)
+		}
+	}
+}
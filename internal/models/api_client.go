@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeWalletRead     = "wallet:read"
+	ScopeWalletDeposit  = "wallet:deposit"
+	ScopeWalletWithdraw = "wallet:withdraw"
+	ScopeAdmin          = "admin"
+)
+
+type APIClient struct {
+	ID        uuid.UUID   `db:"id" json:"id"`
+	Name      string      `db:"name" json:"name"`
+	Scopes    []string    `db:"scopes" json:"scopes"`
+	WalletIDs []uuid.UUID `db:"wallet_ids" json:"walletIds,omitempty"`
+	Disabled  bool        `db:"disabled" json:"disabled"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+}
+
+type APIKey struct {
+	ID        uuid.UUID    `db:"id" json:"id"`
+	ClientID  uuid.UUID    `db:"client_id" json:"clientId"`
+	KeyHash   string       `db:"key_hash" json:"-"`
+	KeyPrefix string       `db:"key_prefix" json:"keyPrefix"`
+	CreatedAt time.Time    `db:"created_at" json:"created_at"`
+	ExpiresAt sql.NullTime `db:"expires_at" json:"expires_at"`
+	RevokedAt sql.NullTime `db:"revoked_at" json:"revoked_at"`
+}
+
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeWalletRead, ScopeWalletDeposit, ScopeWalletWithdraw, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// ScopeForOperation возвращает scope, необходимый для выполнения операции указанного типа
+func ScopeForOperation(opType string) string {
+	switch opType {
+	case OperationTypeDeposit:
+		return ScopeWalletDeposit
+	case OperationTypeWithdraw:
+		return ScopeWalletWithdraw
+	}
+	return ""
+}
+
+// HasScope проверяет наличие scope у клиента, admin дает доступ ко всему
+func (c *APIClient) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessWallet возвращает true, если список разрешенных кошельков пуст или содержит walletID
+func (c *APIClient) CanAccessWallet(walletID uuid.UUID) bool {
+	if len(c.WalletIDs) == 0 {
+		return true
+	}
+	for _, id := range c.WalletIDs {
+		if id == walletID {
+			return true
+		}
+	}
+	return false
+}
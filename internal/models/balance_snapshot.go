@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// BalanceSnapshot - баланс кошелька после проводки LedgerEntryID
+type BalanceSnapshot struct {
+	WalletID      uuid.UUID   `db:"wallet_id"`
+	LedgerEntryID int64       `db:"ledger_entry_id"`
+	Balance       utils.Money `db:"balance"`
+	TakenAt       time.Time   `db:"taken_at"`
+}
+
+// HistoricalBalance - баланс кошелька на момент AsOf, восстановленный по журналу
+type HistoricalBalance struct {
+	WalletID uuid.UUID
+	AsOf     time.Time
+	Balance  utils.Money
+	// Snapshot - снимок, от которого проигрывался журнал; nil, если журнал проигрывался с начала
+	Snapshot        *BalanceSnapshot
+	ReplayedEntries int
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventWalletCredited = "WalletCredited"
+	EventWalletDebited  = "WalletDebited"
+	EventWalletFrozen   = "WalletFrozen"
+	// EventWalletOverdrawn - баланс перешел из неотрицательного в минус, кошелек начал пользоваться кредитной линией
+	EventWalletOverdrawn = "WalletOverdrawn"
+)
+
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusPublished = "published"
+	OutboxStatusDead      = "dead"
+)
+
+// WalletEvent - доменное событие изменения баланса, сериализуется в outbox как есть
+type WalletEvent struct {
+	EventID    uuid.UUID `json:"eventId"`
+	EventType  string    `json:"eventType"`
+	WalletID   uuid.UUID `json:"walletId"`
+	Amount     string    `json:"amount"`
+	Balance    string    `json:"balance"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type OutboxMessage struct {
+	ID          int64     `db:"id"`
+	EventID     uuid.UUID `db:"event_id"`
+	AggregateID uuid.UUID `db:"aggregate_id"`
+	EventType   string    `db:"event_type"`
+	Payload     []byte    `db:"payload"`
+	Attempts    int       `db:"attempts"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// OutboxAck - итог попытки отправить событие: Status published или dead, либо pending с новым NextAttemptAt
+type OutboxAck struct {
+	ID            int64
+	Status        string
+	LastError     string
+	NextAttemptAt time.Time
+}
+
+// NewBalanceChangedEvent строит событие по результату операции над кошельком
+func NewBalanceChangedEvent(wallet *Wallet, operationType string, amount utils.Money) WalletEvent {
+	eventType := EventWalletCredited
+	if IsDebitEntry(operationType) {
+		eventType = EventWalletDebited
+	}
+
+	occurredAt := wallet.CreatedAt
+	if wallet.UpdatedAt.Valid {
+		occurredAt = wallet.UpdatedAt.Time
+	}
+
+	return WalletEvent{
+		EventID:    uuid.New(),
+		EventType:  eventType,
+		WalletID:   wallet.ID,
+		Amount:     amount.String(),
+		Balance:    wallet.Balance.String(),
+		OccurredAt: occurredAt,
+	}
+}
+
+// NewWalletFrozenEvent строит событие заморозки кошелька; баланс при заморозке не меняется
+func NewWalletFrozenEvent(wallet *Wallet) WalletEvent {
+	occurredAt := wallet.CreatedAt
+	if wallet.UpdatedAt.Valid {
+		occurredAt = wallet.UpdatedAt.Time
+	}
+
+	return WalletEvent{
+		EventID:    uuid.New(),
+		EventType:  EventWalletFrozen,
+		WalletID:   wallet.ID,
+		Amount:     utils.Money{}.String(),
+		Balance:    wallet.Balance.String(),
+		OccurredAt: occurredAt,
+	}
+}
+
+// CrossesIntoOverdraft возвращает true, если проводка перевела баланс из неотрицательного в минус
+func CrossesIntoOverdraft(balanceBefore, balanceAfter utils.Money) bool {
+	return !balanceBefore.IsNegative() && balanceAfter.IsNegative()
+}
+
+// NewWalletOverdrawnEvent строит событие перехода в минус; amount - списание, после которого это произошло
+func NewWalletOverdrawnEvent(wallet *Wallet, amount utils.Money) WalletEvent {
+	event := NewBalanceChangedEvent(wallet, OperationTypeWithdraw, amount)
+	event.EventType = EventWalletOverdrawn
+	return event
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"fmt"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FeeKindFlat    = "flat"
+	FeeKindPercent = "percent"
+	FeeKindTiered  = "tiered"
+)
+
+// MaxFeeRateBasisPoints - ставка 100%
+const MaxFeeRateBasisPoints = 10000
+
+// FeeOperationTypes возвращает операции, для которых задаются правила комиссий
+func FeeOperationTypes() []string {
+	return []string{OperationTypeDeposit, OperationTypeWithdraw, JournalOperationTransfer}
+}
+
+func IsValidFeeOperationType(operationType string) bool {
+	for _, valid := range FeeOperationTypes() {
+		if operationType == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// FeeBracket - ступень тарифа tiered: действует для сумм от From включительно до From следующей ступени
+type FeeBracket struct {
+	From            utils.Money
+	Flat            utils.Money
+	RateBasisPoints int64
+}
+
+// FeeRule - правило комиссии для операции и уровня кошелька. Пустой Tier означает правило для всех уровней;
+// правило конкретного уровня важнее
+type FeeRule struct {
+	OperationType string
+	Tier          string
+	Kind          string
+	// Flat - комиссия правила flat
+	Flat utils.Money
+	// RateBasisPoints - ставка правила percent в базисных пунктах (1 б.п. = 0,01%)
+	RateBasisPoints int64
+	// Brackets - ступени правила tiered в порядке возрастания From, первая начинается с нуля
+	Brackets []FeeBracket
+	// Min и Max ограничивают рассчитанную комиссию правила любого вида; nil - без ограничения
+	Min      *utils.Money
+	Max      *utils.Money
+	Rounding utils.Rounding
+}
+
+// Validate проверяет правило; ошибка описывает первое нарушение
+func (r *FeeRule) Validate() error {
+	if !IsValidFeeOperationType(r.OperationType) {
+		return fmt.Errorf("unknown operation type %q", r.OperationType)
+	}
+	if !utils.IsValidRounding(r.Rounding) {
+		return fmt.Errorf("unknown rounding %q", r.Rounding)
+	}
+
+	switch r.Kind {
+	case FeeKindFlat:
+		if r.Flat.IsNegative() {
+			return fmt.Errorf("flat fee is negative")
+		}
+	case FeeKindPercent:
+		if err := validateFeeRate(r.RateBasisPoints); err != nil {
+			return err
+		}
+	case FeeKindTiered:
+		if len(r.Brackets) == 0 {
+			return fmt.Errorf("tiered rule has no brackets")
+		}
+		for i, bracket := range r.Brackets {
+			if i == 0 && !bracket.From.IsZero() {
+				return fmt.Errorf("first bracket must start from zero")
+			}
+			if i > 0 && bracket.From.Raw <= r.Brackets[i-1].From.Raw {
+				return fmt.Errorf("brackets must be ordered by from")
+			}
+			if bracket.Flat.IsNegative() {
+				return fmt.Errorf("bracket flat fee is negative")
+			}
+			if err := validateFeeRate(bracket.RateBasisPoints); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown fee kind %q", r.Kind)
+	}
+
+	if r.Min != nil && r.Min.IsNegative() || r.Max != nil && r.Max.IsNegative() {
+		return fmt.Errorf("min and max fee must not be negative")
+	}
+	if r.Min != nil && r.Max != nil && r.Min.Raw > r.Max.Raw {
+		return fmt.Errorf("min fee is greater than max fee")
+	}
+	return nil
+}
+
+func validateFeeRate(basisPoints int64) error {
+	if basisPoints < 0 || basisPoints > MaxFeeRateBasisPoints {
+		return fmt.Errorf("rate must be between 0 and %d basis points", MaxFeeRateBasisPoints)
+	}
+	return nil
+}
+
+// Compute возвращает комиссию правила за операцию на amount
+func (r *FeeRule) Compute(amount utils.Money) utils.Money {
+	var fee utils.Money
+	switch r.Kind {
+	case FeeKindFlat:
+		fee = r.Flat
+	case FeeKindPercent:
+		fee = amount.MulBasisPoints(r.RateBasisPoints, r.Rounding)
+	case FeeKindTiered:
+		if bracket := r.bracket(amount); bracket != nil {
+			fee = bracket.Flat.Add(amount.MulBasisPoints(bracket.RateBasisPoints, r.Rounding))
+		}
+	}
+
+	if r.Min != nil && fee.Raw < r.Min.Raw {
+		fee = *r.Min
+	}
+	if r.Max != nil && fee.Raw > r.Max.Raw {
+		fee = *r.Max
+	}
+	return fee
+}
+
+// bracket возвращает последнюю ступень, From которой не больше amount
+func (r *FeeRule) bracket(amount utils.Money) *FeeBracket {
+	var found *FeeBracket
+	for i := range r.Brackets {
+		if r.Brackets[i].From.Raw > amount.Raw {
+			break
+		}
+		found = &r.Brackets[i]
+	}
+	return found
+}
+
+// Fee - комиссия операции. Комиссия всегда берется с кошелька клиента: пополнение зачисляется
+// за вычетом комиссии, а при списании и переводе комиссия удерживается сверх суммы операции
+type Fee struct {
+	OperationType string
+	// Gross - сумма до вычета комиссии: сумма пополнения или сколько уходит с кошелька при списании и переводе
+	Gross utils.Money
+	Fee   utils.Money
+	// Net - сумма после вычета комиссии: сколько получит кошелек при пополнении или получатель при списании и переводе
+	Net utils.Money
+}
+
+// NewFee строит комиссию fee за операцию на amount
+func NewFee(operationType string, amount, fee utils.Money) Fee {
+	if operationType == OperationTypeDeposit {
+		return Fee{OperationType: operationType, Gross: amount, Fee: fee, Net: amount.Sub(fee)}
+	}
+	return Fee{OperationType: operationType, Gross: amount.Add(fee), Fee: fee, Net: amount}
+}
+
+// WalletAmount возвращает, на сколько операция меняет баланс кошелька клиента (без знака)
+func (f Fee) WalletAmount() utils.Money {
+	if f.OperationType == OperationTypeDeposit {
+		return f.Net
+	}
+	return f.Gross
+}
+
+// CoversFee возвращает false, если пополнение целиком уходит на комиссию
+func (f Fee) CoversFee() bool {
+	return f.Net.Raw > 0
+}
+
+// NewFeeLedgerEntries строит проводки кошелька по операции с комиссией: проводку операции на ее сумму
+// и, если комиссия не нулевая, проводку FEE. wallet - кошелек после изменения баланса на WalletAmount
+func NewFeeLedgerEntries(wallet *Wallet, entryType string, amount utils.Money, fee Fee) []LedgerEntry {
+	entry := NewLedgerEntry(wallet, entryType, amount)
+	if fee.Fee.IsZero() {
+		return []LedgerEntry{entry}
+	}
+
+	// Проводка операции идет первой, поэтому баланс после нее еще включает комиссию
+	entry.BalanceAfter = wallet.Balance.Add(fee.Fee)
+	return []LedgerEntry{entry, NewLedgerEntry(wallet, LedgerEntryFee, fee.Fee)}
+}
+
+// ChargeFee переносит комиссию fee с кошелька walletID на системный счет fees
+func (j *Journal) ChargeFee(walletID uuid.UUID, fee utils.Money) {
+	if fee.IsZero() {
+		return
+	}
+	for i := range j.Postings {
+		if j.Postings[i].WalletID == walletID {
+			j.Postings[i].Amount = j.Postings[i].Amount.Sub(fee)
+			break
+		}
+	}
+	j.Postings = append(j.Postings, Posting{SystemAccount: SystemAccountFees, Amount: fee})
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"testing"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+func TestFeeRule_Compute(t *testing.T) {
+	tiered := FeeRule{
+		Kind:     FeeKindTiered,
+		Rounding: utils.RoundHalfUp,
+		Brackets: []FeeBracket{
+			{Flat: utils.Money{Raw: 1000}},
+			{From: utils.Money{Raw: 100000}, RateBasisPoints: 100},
+			{From: utils.Money{Raw: 1000000}, Flat: utils.Money{Raw: 5000}, RateBasisPoints: 50},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		rule   FeeRule
+		amount int64
+		want   int64
+	}{
+		{"flat", FeeRule{Kind: FeeKindFlat, Flat: utils.Money{Raw: 1500}}, 100, 1500},
+		{"percent half up", FeeRule{Kind: FeeKindPercent, RateBasisPoints: 150, Rounding: utils.RoundHalfUp}, 10033, 150},
+		{"percent up", FeeRule{Kind: FeeKindPercent, RateBasisPoints: 150, Rounding: utils.RoundUp}, 10001, 151},
+		{"percent down", FeeRule{Kind: FeeKindPercent, RateBasisPoints: 150, Rounding: utils.RoundDown}, 10099, 151},
+		{"percent below min", FeeRule{Kind: FeeKindPercent, RateBasisPoints: 100, Min: &utils.Money{Raw: 50}, Rounding: utils.RoundHalfUp}, 1000, 50},
+		{"percent above max", FeeRule{Kind: FeeKindPercent, RateBasisPoints: 100, Max: &utils.Money{Raw: 5000}, Rounding: utils.RoundHalfUp}, 10000000, 5000},
+		{"tiered first bracket", tiered, 99999, 1000},
+		{"tiered bracket boundary", tiered, 100000, 1000},
+		{"tiered middle bracket", tiered, 250000, 2500},
+		{"tiered last bracket", tiered, 2000000, 15000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Compute(utils.Money{Raw: tt.amount}); got.Raw != tt.want {
+				t.Errorf("Compute(%d) = %d, want %d", tt.amount, got.Raw, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeeRule_Validate(t *testing.T) {
+	valid := func(rule FeeRule) FeeRule {
+		rule.OperationType = OperationTypeWithdraw
+		rule.Rounding = utils.RoundHalfUp
+		return rule
+	}
+
+	tests := []struct {
+		name    string
+		rule    FeeRule
+		wantErr bool
+	}{
+		{"flat", valid(FeeRule{Kind: FeeKindFlat, Flat: utils.Money{Raw: 100}}), false},
+		{"percent with bounds", valid(FeeRule{Kind: FeeKindPercent, RateBasisPoints: 150, Min: &utils.Money{Raw: 50}, Max: &utils.Money{Raw: 500}}), false},
+		{"tiered", valid(FeeRule{Kind: FeeKindTiered, Brackets: []FeeBracket{{}, {From: utils.Money{Raw: 1000}, RateBasisPoints: 10}}}), false},
+		{"unknown operation", FeeRule{OperationType: "REFUND", Kind: FeeKindFlat, Rounding: utils.RoundHalfUp}, true},
+		{"unknown kind", valid(FeeRule{Kind: "fixed"}), true},
+		{"unknown rounding", FeeRule{OperationType: OperationTypeDeposit, Kind: FeeKindFlat, Rounding: "bankers"}, true},
+		{"negative flat", valid(FeeRule{Kind: FeeKindFlat, Flat: utils.Money{Raw: -1}}), true},
+		{"rate above 100%", valid(FeeRule{Kind: FeeKindPercent, RateBasisPoints: 10001}), true},
+		{"min above max", valid(FeeRule{Kind: FeeKindPercent, Min: &utils.Money{Raw: 500}, Max: &utils.Money{Raw: 50}}), true},
+		{"no brackets", valid(FeeRule{Kind: FeeKindTiered}), true},
+		{"first bracket not from zero", valid(FeeRule{Kind: FeeKindTiered, Brackets: []FeeBracket{{From: utils.Money{Raw: 100}}}}), true},
+		{"unordered brackets", valid(FeeRule{Kind: FeeKindTiered, Brackets: []FeeBracket{{}, {From: utils.Money{Raw: 100}}, {From: utils.Money{Raw: 100}}}}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewFee(t *testing.T) {
+	amount, fee := utils.Money{Raw: 1000}, utils.Money{Raw: 30}
+
+	deposit := NewFee(OperationTypeDeposit, amount, fee)
+	if deposit.Gross.Raw != 1000 || deposit.Net.Raw != 970 || deposit.WalletAmount().Raw != 970 {
+		t.Errorf("deposit fee = %+v, want gross 1000, net and wallet amount 970", deposit)
+	}
+
+	withdraw := NewFee(OperationTypeWithdraw, amount, fee)
+	if withdraw.Gross.Raw != 1030 || withdraw.Net.Raw != 1000 || withdraw.WalletAmount().Raw != 1030 {
+		t.Errorf("withdraw fee = %+v, want gross and wallet amount 1030, net 1000", withdraw)
+	}
+
+	if NewFee(OperationTypeDeposit, fee, fee).CoversFee() {
+		t.Error("CoversFee() = true for deposit equal to fee")
+	}
+}
+
+func TestFeeLedgerEntriesAndJournal(t *testing.T) {
+	walletID := uuid.New()
+	wallet := &Wallet{ID: walletID, Balance: utils.Money{Raw: 8970}, CreatedAt: time.Now()}
+	fee := NewFee(OperationTypeWithdraw, utils.Money{Raw: 1000}, utils.Money{Raw: 30})
+
+	entries := NewFeeLedgerEntries(wallet, OperationTypeWithdraw, utils.Money{Raw: 1000}, fee)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].EntryType != OperationTypeWithdraw || entries[0].Amount.Raw != 1000 || entries[0].BalanceAfter.Raw != 9000 {
+		t.Errorf("operation entry = %+v, want WITHDRAW 1000 with balance 9000", entries[0])
+	}
+	if entries[1].EntryType != LedgerEntryFee || entries[1].Amount.Raw != 30 || entries[1].BalanceAfter.Raw != 8970 {
+		t.Errorf("fee entry = %+v, want FEE 30 with balance 8970", entries[1])
+	}
+	if entries[1].SignedAmount().Raw != -30 {
+		t.Errorf("fee entry signed amount = %d, want -30", entries[1].SignedAmount().Raw)
+	}
+
+	journal := NewOperationJournal(walletID, OperationTypeWithdraw, utils.Money{Raw: 1000})
+	journal.ChargeFee(walletID, fee.Fee)
+	if !journal.IsBalanced() {
+		t.Fatalf("journal is not balanced: %+v", journal.Postings)
+	}
+	if journal.Postings[0].Amount.Raw != -1030 {
+		t.Errorf("wallet posting = %d, want -1030", journal.Postings[0].Amount.Raw)
+	}
+	if last := journal.Postings[len(journal.Postings)-1]; last.SystemAccount != SystemAccountFees || last.Amount.Raw != 30 {
+		t.Errorf("fee posting = %+v, want fees 30", last)
+	}
+
+	if entries := NewFeeLedgerEntries(wallet, OperationTypeDeposit, utils.Money{Raw: 1000}, NewFee(OperationTypeDeposit, utils.Money{Raw: 1000}, utils.Money{})); len(entries) != 1 {
+		t.Errorf("got %d entries without fee, want 1", len(entries))
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+)
+
+const (
+	ImportRowPending   = "pending"
+	ImportRowSucceeded = "succeeded"
+	ImportRowFailed    = "failed"
+	// ImportRowDuplicate - операция с таким externalRef уже проведена, баланс не менялся
+	ImportRowDuplicate = "duplicate"
+)
+
+// Причины, по которым строка импорта не проведена; попадают в файл результатов
+const (
+	ImportErrorDuplicateRef      = "Операция с таким externalRef уже проведена"
+	ImportErrorWalletNotFound    = "Кошелек не найден"
+	ImportErrorInsufficientFunds = "Недостаточно средств"
+	ImportErrorWalletFrozen      = "Кошелек заморожен"
+	ImportErrorFeeExceedsAmount  = "Комиссия не меньше суммы пополнения"
+)
+
+const (
+	// MaxImportRows ограничивает число строк в одном файле импорта
+	MaxImportRows = 10000
+	// MaxExternalRefLength - максимальная длина внешнего референса операции
+	MaxExternalRefLength = 128
+)
+
+// ImportJob - фоновое задание на проведение операций из CSV файла.
+// В режиме DryRun строки проверяются и получают прогнозируемый результат, балансы не меняются
+type ImportJob struct {
+	ID            uuid.UUID     `db:"id"`
+	ClientID      uuid.NullUUID `db:"client_id"`
+	Status        string        `db:"status"`
+	DryRun        bool          `db:"dry_run"`
+	TotalRows     int           `db:"total_rows"`
+	ProcessedRows int           `db:"processed_rows"`
+	SucceededRows int           `db:"succeeded_rows"`
+	FailedRows    int           `db:"failed_rows"`
+	DuplicateRows int           `db:"duplicate_rows"`
+	CreatedAt     time.Time     `db:"created_at"`
+	StartedAt     sql.NullTime  `db:"started_at"`
+	FinishedAt    sql.NullTime  `db:"finished_at"`
+}
+
+// ImportRow - строка файла импорта и результат ее обработки.
+// RowNumber - номер строки в исходном файле, заголовок - строка 1
+type ImportRow struct {
+	JobID         uuid.UUID    `db:"job_id"`
+	RowNumber     int          `db:"row_number"`
+	WalletID      uuid.UUID    `db:"wallet_id"`
+	OperationType string       `db:"operation_type"`
+	Amount        utils.Money  `db:"amount"`
+	ExternalRef   string       `db:"external_ref"`
+	Status        string       `db:"status"`
+	BalanceAfter  *utils.Money `db:"balance_after"`
+	// Fee - комиссия, удержанная с кошелька по строке; пусто, если строка не проведена
+	Fee   *utils.Money `db:"fee"`
+	Error string       `db:"error"`
+}
+
+// ImportRowResult - итог обработки строки, который сохраняется вместе со счетчиками задания
+type ImportRowResult struct {
+	Status       string
+	BalanceAfter *utils.Money
+	Fee          *utils.Money
+	Error        string
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"math/big"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// LedgerEntryInterest - проценты на остаток, выплаченные кошельку за месяц
+const LedgerEntryInterest = "INTEREST"
+
+const basisPointsPerUnit = 10000
+
+// InterestAccrual - проценты за один день на положительный остаток кошелька на конец этого дня.
+// Ставка и число дней в году фиксируются в момент начисления, поэтому смена ставки не пересчитывает прошлые дни
+type InterestAccrual struct {
+	WalletID    uuid.UUID
+	AccrualDate time.Time
+	Balance     utils.Money
+	RateBP      int64
+	DaysInYear  int
+}
+
+// Amount возвращает точные проценты за день в копейках: balance * rate / 10000 / daysInYear, без округления
+func (a InterestAccrual) Amount() *big.Rat {
+	numerator := new(big.Int).Mul(big.NewInt(a.Balance.Raw), big.NewInt(a.RateBP))
+	return new(big.Rat).SetFrac(numerator, big.NewInt(int64(basisPointsPerUnit*a.DaysInYear)))
+}
+
+// SumInterest складывает точные дневные проценты и округляет сумму один раз:
+// при округлении каждого дня небольшие остатки не получили бы ничего
+func SumInterest(accruals []InterestAccrual, rounding utils.Rounding) utils.Money {
+	sum := new(big.Rat)
+	for _, accrual := range accruals {
+		sum.Add(sum, accrual.Amount())
+	}
+	return utils.NewMoneyFromRat(sum, rounding)
+}
+
+// InterestPosting - выплата процентов кошельку за месяц Period. LedgerEntryID не заполнен,
+// если проценты за месяц округлились до нуля
+type InterestPosting struct {
+	WalletID      uuid.UUID
+	Period        time.Time
+	Amount        utils.Money
+	Days          int
+	LedgerEntryID *int64
+}
+
+// DaysInYear возвращает число дней в году даты day
+func DaysInYear(day time.Time) int {
+	if time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay() == 366 {
+		return 366
+	}
+	return 365
+}
+
+// AccrualDay возвращает начало дня t по UTC: день начисления процентов
+func AccrualDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// MonthStart возвращает первый день месяца t по UTC
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// PendingAccrualDays возвращает завершившиеся к now дни, за которые проценты еще не начислены:
+// со следующего после lastAccrued до вчерашнего включительно. Без lastAccrued начисление начинается со вчерашнего дня
+func PendingAccrualDays(lastAccrued *time.Time, now time.Time) []time.Time {
+	yesterday := AccrualDay(now).AddDate(0, 0, -1)
+	day := yesterday
+	if lastAccrued != nil {
+		day = AccrualDay(*lastAccrued).AddDate(0, 0, 1)
+	}
+
+	var days []time.Time
+	for ; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
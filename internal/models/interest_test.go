@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+	"wallet-api/utils"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSumInterest(t *testing.T) {
+	// 1 000 000,00 под 7,3% годовых в невисокосный год - ровно 200,00 в день
+	accrual := InterestAccrual{Balance: utils.Money{Raw: 100000000}, RateBP: 730, DaysInYear: 365}
+	if got := SumInterest([]InterestAccrual{accrual}, utils.RoundHalfUp); got.Raw != 20000 {
+		t.Errorf("SumInterest() = %v, want 20000", got.Raw)
+	}
+
+	// 100,00 под 10% - 2,74 копейки в день: по отдельности дни округлились бы до 3 или 2, за 30 дней точно 82,19
+	small := InterestAccrual{Balance: utils.Money{Raw: 10000}, RateBP: 1000, DaysInYear: 365}
+	month := make([]InterestAccrual, 30)
+	for i := range month {
+		month[i] = small
+	}
+	tests := []struct {
+		rounding utils.Rounding
+		want     int64
+	}{
+		{utils.RoundHalfUp, 82},
+		{utils.RoundDown, 82},
+		{utils.RoundUp, 83},
+	}
+	for _, tt := range tests {
+		if got := SumInterest(month, tt.rounding); got.Raw != tt.want {
+			t.Errorf("SumInterest(%s) = %v, want %v", tt.rounding, got.Raw, tt.want)
+		}
+	}
+}
+
+func TestDaysInYear(t *testing.T) {
+	if got := DaysInYear(day("2024-03-01")); got != 366 {
+		t.Errorf("DaysInYear(2024) = %d, want 366", got)
+	}
+	if got := DaysInYear(day("2025-03-01")); got != 365 {
+		t.Errorf("DaysInYear(2025) = %d, want 365", got)
+	}
+}
+
+func TestPendingAccrualDays(t *testing.T) {
+	now := time.Date(2025, time.November, 3, 10, 0, 0, 0, time.UTC)
+
+	first := PendingAccrualDays(nil, now)
+	if len(first) != 1 || !first[0].Equal(day("2025-11-02")) {
+		t.Errorf("PendingAccrualDays(nil) = %v, want [2025-11-02]", first)
+	}
+
+	last := day("2025-10-30")
+	caughtUp := PendingAccrualDays(&last, now)
+	want := []time.Time{day("2025-10-31"), day("2025-11-01"), day("2025-11-02")}
+	if len(caughtUp) != len(want) {
+		t.Fatalf("PendingAccrualDays() = %v, want %v", caughtUp, want)
+	}
+	for i := range want {
+		if !caughtUp[i].Equal(want[i]) {
+			t.Errorf("PendingAccrualDays()[%d] = %v, want %v", i, caughtUp[i], want[i])
+		}
+	}
+
+	done := day("2025-11-02")
+	if days := PendingAccrualDays(&done, now); len(days) != 0 {
+		t.Errorf("PendingAccrualDays() after yesterday = %v, want none", days)
+	}
+}
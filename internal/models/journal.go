@@ -0,0 +1,152 @@
+package models
+
+import (
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// Системные счета - вторая сторона каждой операции с кошельком
+const (
+	SystemAccountCashIn   = "cash_in"
+	SystemAccountCashOut  = "cash_out"
+	SystemAccountFees     = "fees"
+	SystemAccountSuspense = "suspense"
+	// SystemAccountInterest - расход на проценты, выплаченные на остатки кошельков
+	SystemAccountInterest = "interest"
+)
+
+// JournalOperationTransfer - тип записи журнала для перевода между кошельками
+const JournalOperationTransfer = "TRANSFER"
+
+// TrialBalanceWalletsAccount - строка оборотной ведомости, объединяющая все кошельки пользователей
+const TrialBalanceWalletsAccount = "wallets"
+
+// SystemAccounts возвращает все системные счета
+func SystemAccounts() []string {
+	return []string{SystemAccountCashIn, SystemAccountCashOut, SystemAccountFees, SystemAccountSuspense, SystemAccountInterest}
+}
+
+// Posting - изменение одного счета: кошелька (WalletID) или системного счета (SystemAccount).
+// Amount со знаком: плюс увеличивает остаток счета
+type Posting struct {
+	WalletID      uuid.UUID
+	SystemAccount string
+	Amount        utils.Money
+}
+
+// Journal - запись журнала двойной записи. Сумма проводок сбалансированной записи равна нулю
+type Journal struct {
+	ID            uuid.UUID
+	OperationType string
+	Postings      []Posting
+}
+
+// NewOperationJournal строит запись журнала для операции над одним кошельком:
+// пополнение приходит с cash_in, списание уходит на cash_out, начальный баланс - из suspense, проценты - со счета interest
+func NewOperationJournal(walletID uuid.UUID, entryType string, amount utils.Money) Journal {
+	counterpart := SystemAccountCashIn
+	switch entryType {
+	case OperationTypeWithdraw:
+		counterpart = SystemAccountCashOut
+	case LedgerEntryOpeningBalance:
+		counterpart = SystemAccountSuspense
+	case LedgerEntryInterest:
+		counterpart = SystemAccountInterest
+	}
+
+	delta := amount
+	if IsDebitEntry(entryType) {
+		delta = utils.Money{Raw: -amount.Raw}
+	}
+
+	return Journal{
+		ID:            uuid.New(),
+		OperationType: entryType,
+		Postings: []Posting{
+			{WalletID: walletID, Amount: delta},
+			{SystemAccount: counterpart, Amount: utils.Money{Raw: -delta.Raw}},
+		},
+	}
+}
+
+// NewTransferJournal строит запись журнала для перевода amount с кошелька fromID на toID
+func NewTransferJournal(fromID, toID uuid.UUID, amount utils.Money) Journal {
+	return Journal{
+		ID:            uuid.New(),
+		OperationType: JournalOperationTransfer,
+		Postings: []Posting{
+			{WalletID: fromID, Amount: utils.Money{Raw: -amount.Raw}},
+			{WalletID: toID, Amount: amount},
+		},
+	}
+}
+
+// Sum возвращает сумму всех проводок записи
+func (j *Journal) Sum() utils.Money {
+	var sum utils.Money
+	for _, posting := range j.Postings {
+		sum = sum.Add(posting.Amount)
+	}
+	return sum
+}
+
+// IsBalanced возвращает true, если запись содержит хотя бы две ненулевые проводки и их сумма равна нулю
+func (j *Journal) IsBalanced() bool {
+	if len(j.Postings) < 2 {
+		return false
+	}
+	for _, posting := range j.Postings {
+		if posting.Amount.IsZero() {
+			return false
+		}
+	}
+	return j.Sum().IsZero()
+}
+
+// TrialBalanceAccount - обороты и остаток одного счета. Debit - сумма положительных проводок, Credit - отрицательных
+type TrialBalanceAccount struct {
+	Account string
+	Debit   utils.Money
+	Credit  utils.Money
+}
+
+// Balance возвращает остаток счета
+func (a TrialBalanceAccount) Balance() utils.Money {
+	return a.Debit.Sub(a.Credit)
+}
+
+// TrialBalance - оборотная ведомость по всем счетам на момент AsOf
+type TrialBalance struct {
+	AsOf     time.Time
+	Accounts []TrialBalanceAccount
+	// WalletsBalance - сумма wallets.balance; должна совпадать с остатком счета wallets
+	WalletsBalance utils.Money
+	// UnbalancedJournals - число записей журнала с ненулевой суммой проводок
+	UnbalancedJournals int
+}
+
+// Totals возвращает сумму оборотов по дебету и кредиту всех счетов
+func (t *TrialBalance) Totals() (debit, credit utils.Money) {
+	for _, account := range t.Accounts {
+		debit = debit.Add(account.Debit)
+		credit = credit.Add(account.Credit)
+	}
+	return debit, credit
+}
+
+// IsBalanced возвращает true, если обороты сходятся, все записи журнала сбалансированы
+// и остаток счета wallets совпадает с балансами кошельков
+func (t *TrialBalance) IsBalanced() bool {
+	debit, credit := t.Totals()
+	if debit != credit || t.UnbalancedJournals != 0 {
+		return false
+	}
+	for _, account := range t.Accounts {
+		if account.Account == TrialBalanceWalletsAccount {
+			return account.Balance() == t.WalletsBalance
+		}
+	}
+	return t.WalletsBalance.IsZero()
+}
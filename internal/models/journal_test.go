@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+func TestNewOperationJournal(t *testing.T) {
+	walletID := uuid.New()
+	amount := utils.Money{Raw: 1500}
+
+	tests := []struct {
+		name          string
+		entryType     string
+		walletDelta   int64
+		counterpart   string
+		counterAmount int64
+	}{
+		{"deposit", OperationTypeDeposit, 1500, SystemAccountCashIn, -1500},
+		{"withdraw", OperationTypeWithdraw, -1500, SystemAccountCashOut, 1500},
+		{"opening balance", LedgerEntryOpeningBalance, 1500, SystemAccountSuspense, -1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journal := NewOperationJournal(walletID, tt.entryType, amount)
+			if !journal.IsBalanced() {
+				t.Fatalf("journal is not balanced: %+v", journal.Postings)
+			}
+			if len(journal.Postings) != 2 {
+				t.Fatalf("got %d postings, want 2", len(journal.Postings))
+			}
+			if p := journal.Postings[0]; p.WalletID != walletID || p.Amount.Raw != tt.walletDelta {
+				t.Errorf("wallet posting = %+v, want %d", p, tt.walletDelta)
+			}
+			if p := journal.Postings[1]; p.SystemAccount != tt.counterpart || p.Amount.Raw != tt.counterAmount {
+				t.Errorf("system posting = %+v, want %s %d", p, tt.counterpart, tt.counterAmount)
+			}
+		})
+	}
+}
+
+func TestJournal_IsBalanced(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		journal  Journal
+		expected bool
+	}{
+		{"transfer", NewTransferJournal(from, to, utils.Money{Raw: 100}), true},
+		{"non-zero sum", Journal{Postings: []Posting{
+			{WalletID: from, Amount: utils.Money{Raw: 100}},
+			{SystemAccount: SystemAccountCashIn, Amount: utils.Money{Raw: -99}},
+		}}, false},
+		{"single posting", Journal{Postings: []Posting{{WalletID: from, Amount: utils.Money{Raw: 100}}}}, false},
+		{"zero postings", Journal{Postings: []Posting{
+			{WalletID: from},
+			{SystemAccount: SystemAccountFees},
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.journal.IsBalanced(); got != tt.expected {
+				t.Errorf("IsBalanced() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTrialBalance_IsBalanced(t *testing.T) {
+	accounts := []TrialBalanceAccount{
+		{Account: SystemAccountCashIn, Credit: utils.Money{Raw: 500}},
+		{Account: SystemAccountCashOut, Debit: utils.Money{Raw: 200}},
+		{Account: TrialBalanceWalletsAccount, Debit: utils.Money{Raw: 500}, Credit: utils.Money{Raw: 200}},
+	}
+
+	tests := []struct {
+		name     string
+		balance  TrialBalance
+		expected bool
+	}{
+		{"balanced", TrialBalance{Accounts: accounts, WalletsBalance: utils.Money{Raw: 300}}, true},
+		{"wallets drifted", TrialBalance{Accounts: accounts, WalletsBalance: utils.Money{Raw: 301}}, false},
+		{"unbalanced journal", TrialBalance{Accounts: accounts, WalletsBalance: utils.Money{Raw: 300}, UnbalancedJournals: 1}, false},
+		{"totals differ", TrialBalance{Accounts: accounts[1:], WalletsBalance: utils.Money{Raw: 300}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.balance.IsBalanced(); got != tt.expected {
+				t.Errorf("IsBalanced() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
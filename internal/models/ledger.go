@@ -0,0 +1,142 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// LedgerEntryOpeningBalance - проводка, которой баланс кошелька переносится в журнал при миграции
+	LedgerEntryOpeningBalance = "OPENING_BALANCE"
+	LedgerEntryTransferIn     = "TRANSFER_IN"
+	LedgerEntryTransferOut    = "TRANSFER_OUT"
+	// LedgerEntryFee - комиссия, удержанная с кошелька за операцию
+	LedgerEntryFee = "FEE"
+)
+
+// DebitEntryTypes возвращает типы проводок, уменьшающих баланс
+func DebitEntryTypes() []string {
+	return []string{OperationTypeWithdraw, LedgerEntryTransferOut, LedgerEntryFee}
+}
+
+// IsDebitEntry возвращает true для проводок, уменьшающих баланс
+func IsDebitEntry(entryType string) bool {
+	for _, debit := range DebitEntryTypes() {
+		if entryType == debit {
+			return true
+		}
+	}
+	return false
+}
+
+// LedgerEntry - неизменяемая запись журнала операций кошелька.
+// ID монотонно растет в пределах кошелька и используется как курсор для возобновления потоков
+type LedgerEntry struct {
+	ID           int64       `db:"id" json:"id"`
+	WalletID     uuid.UUID   `db:"wallet_id" json:"walletId"`
+	EntryType    string      `db:"entry_type" json:"entryType"`
+	Amount       utils.Money `db:"amount" json:"amount"`
+	BalanceAfter utils.Money `db:"balance_after" json:"balanceAfter"`
+	CreatedAt    time.Time   `db:"created_at" json:"createdAt"`
+	// PrevHash и Hash заполняет триггер БД при вставке; у первой проводки кошелька PrevHash пустой
+	PrevHash []byte `db:"prev_hash" json:"-"`
+	Hash     []byte `db:"hash" json:"-"`
+}
+
+// Причины разрыва цепочки хэшей журнала
+const (
+	// LedgerChainHashMismatch - содержимое проводки не совпадает с ее хэшем: проводку изменили
+	LedgerChainHashMismatch = "hash_mismatch"
+	// LedgerChainPrevHashMismatch - проводка ссылается не на предыдущую: проводку удалили или вставили
+	LedgerChainPrevHashMismatch = "prev_hash_mismatch"
+)
+
+// LedgerChainBreak - первое найденное звено цепочки, которое не сходится
+type LedgerChainBreak struct {
+	WalletID uuid.UUID
+	EntryID  int64
+	Reason   string
+	Expected []byte
+	Actual   []byte
+}
+
+// LedgerChainVerification - результат проверки цепочек хэшей журнала
+type LedgerChainVerification struct {
+	WalletsChecked int
+	EntriesChecked int64
+	// Break не заполнен, если все проверенные цепочки целы
+	Break *LedgerChainBreak
+}
+
+// NewLedgerEntry строит проводку по результату операции над кошельком
+func NewLedgerEntry(wallet *Wallet, operationType string, amount utils.Money) LedgerEntry {
+	createdAt := wallet.CreatedAt
+	if wallet.UpdatedAt.Valid {
+		createdAt = wallet.UpdatedAt.Time
+	}
+
+	return LedgerEntry{
+		WalletID:     wallet.ID,
+		EntryType:    operationType,
+		Amount:       amount,
+		BalanceAfter: wallet.Balance,
+		CreatedAt:    createdAt,
+	}
+}
+
+// SignedAmount возвращает сумму проводки со знаком: списания отрицательные
+func (e *LedgerEntry) SignedAmount() utils.Money {
+	if IsDebitEntry(e.EntryType) {
+		return utils.Money{Raw: -e.Amount.Raw}
+	}
+	return e.Amount
+}
+
+// ComputeHash возвращает SHA-256 проводки, связанный с хэшем предыдущей проводки кошелька.
+// Формат должен совпадать с функцией ledger_entry_hash в миграциях
+func (e *LedgerEntry) ComputeHash(prevHash []byte) []byte {
+	content := strings.Join([]string{
+		e.WalletID.String(),
+		strconv.FormatInt(e.ID, 10),
+		e.EntryType,
+		strconv.FormatInt(e.Amount.Raw, 10),
+		strconv.FormatInt(e.BalanceAfter.Raw, 10),
+		strconv.FormatInt(e.CreatedAt.UnixMicro(), 10),
+		hex.EncodeToString(prevHash),
+	}, "|")
+
+	sum := sha256.Sum256([]byte(content))
+	return sum[:]
+}
+
+// VerifyLink проверяет проводку как следующее звено после хэша prevHash.
+// Возвращает nil, если звено цело
+func (e *LedgerEntry) VerifyLink(prevHash []byte) *LedgerChainBreak {
+	if !bytes.Equal(e.PrevHash, prevHash) {
+		return &LedgerChainBreak{
+			WalletID: e.WalletID,
+			EntryID:  e.ID,
+			Reason:   LedgerChainPrevHashMismatch,
+			Expected: prevHash,
+			Actual:   e.PrevHash,
+		}
+	}
+
+	if expected := e.ComputeHash(prevHash); !bytes.Equal(e.Hash, expected) {
+		return &LedgerChainBreak{
+			WalletID: e.WalletID,
+			EntryID:  e.ID,
+			Reason:   LedgerChainHashMismatch,
+			Expected: expected,
+			Actual:   e.Hash,
+		}
+	}
+	return nil
+}
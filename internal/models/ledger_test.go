@@ -0,0 +1,78 @@
+package models
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+func TestLedgerEntry_ComputeHash(t *testing.T) {
+	entry := LedgerEntry{
+		ID:           7,
+		WalletID:     uuid.MustParse("6f1c1c1e-8d3a-4c8e-9a55-0a4f2b0d9e11"),
+		EntryType:    OperationTypeDeposit,
+		Amount:       utils.Money{Raw: 1500},
+		BalanceAfter: utils.Money{Raw: 2500},
+		CreatedAt:    time.Date(2025, 10, 20, 10, 0, 0, 123456000, time.UTC),
+	}
+
+	// Та же строка, которую собирает ledger_entry_hash в БД
+	want := sha256.Sum256([]byte("6f1c1c1e-8d3a-4c8e-9a55-0a4f2b0d9e11|7|DEPOSIT|1500|2500|1760954400123456|"))
+	if got := entry.ComputeHash(nil); string(got) != string(want[:]) {
+		t.Errorf("ComputeHash(nil) = %x, want %x", got, want)
+	}
+
+	linked := sha256.Sum256([]byte("6f1c1c1e-8d3a-4c8e-9a55-0a4f2b0d9e11|7|DEPOSIT|1500|2500|1760954400123456|abcd"))
+	if got := entry.ComputeHash([]byte{0xab, 0xcd}); string(got) != string(linked[:]) {
+		t.Errorf("ComputeHash(abcd) = %x, want %x", got, linked)
+	}
+}
+
+func TestLedgerEntry_VerifyLink(t *testing.T) {
+	walletID := uuid.New()
+	createdAt := time.Now()
+	chain := make([]LedgerEntry, 3)
+	var prevHash []byte
+	for i := range chain {
+		chain[i] = LedgerEntry{
+			ID:           int64(i + 1),
+			WalletID:     walletID,
+			EntryType:    OperationTypeDeposit,
+			Amount:       utils.Money{Raw: 100},
+			BalanceAfter: utils.Money{Raw: int64(100 * (i + 1))},
+			CreatedAt:    createdAt,
+			PrevHash:     prevHash,
+		}
+		chain[i].Hash = chain[i].ComputeHash(prevHash)
+		prevHash = chain[i].Hash
+	}
+
+	for i := range chain {
+		var prev []byte
+		if i > 0 {
+			prev = chain[i-1].Hash
+		}
+		if broken := chain[i].VerifyLink(prev); broken != nil {
+			t.Fatalf("entry %d: unexpected break %+v", chain[i].ID, broken)
+		}
+	}
+
+	t.Run("edited entry", func(t *testing.T) {
+		edited := chain[1]
+		edited.Amount = utils.Money{Raw: 1000}
+		broken := edited.VerifyLink(chain[0].Hash)
+		if broken == nil || broken.Reason != LedgerChainHashMismatch || broken.EntryID != 2 {
+			t.Errorf("VerifyLink() = %+v, want %s at entry 2", broken, LedgerChainHashMismatch)
+		}
+	})
+
+	t.Run("deleted entry", func(t *testing.T) {
+		broken := chain[2].VerifyLink(chain[0].Hash)
+		if broken == nil || broken.Reason != LedgerChainPrevHashMismatch || broken.EntryID != 3 {
+			t.Errorf("VerifyLink() = %+v, want %s at entry 3", broken, LedgerChainPrevHashMismatch)
+		}
+	})
+}
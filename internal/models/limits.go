@@ -0,0 +1,165 @@
+package models
+
+import (
+	"fmt"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WalletTierStandard   = "standard"
+	WalletTierAnonymous  = "anonymous"
+	WalletTierSimplified = "simplified"
+)
+
+// DefaultWalletTier - уровень нового кошелька: владелец не идентифицирован, пока администратор не сменит уровень
+const DefaultWalletTier = WalletTierAnonymous
+
+// Окна оборотных лимитов: скользящие, от момента операции назад
+const (
+	LimitDailyWindow   = 24 * time.Hour
+	LimitMonthlyWindow = 30 * 24 * time.Hour
+)
+
+// Названия лимитов в ошибке LIMIT_EXCEEDED
+const (
+	LimitMaxBalance        = "max_balance"
+	LimitMinOperation      = "min_operation"
+	LimitMaxOperation      = "max_operation"
+	LimitDailyDeposit      = "daily_deposit"
+	LimitDailyWithdrawal   = "daily_withdrawal"
+	LimitMonthlyDeposit    = "monthly_deposit"
+	LimitMonthlyWithdrawal = "monthly_withdrawal"
+)
+
+// CreditEntryTypes возвращает типы проводок, увеличивающих баланс, которые учитываются в лимитах пополнения
+func CreditEntryTypes() []string {
+	return []string{OperationTypeDeposit, LedgerEntryTransferIn}
+}
+
+// WithdrawalEntryTypes возвращает типы проводок, которые учитываются в лимитах списания. Комиссии в лимиты не входят
+func WithdrawalEntryTypes() []string {
+	return []string{OperationTypeWithdraw, LedgerEntryTransferOut}
+}
+
+// WalletLimits - лимиты кошелька; nil означает, что лимита нет
+type WalletLimits struct {
+	MaxBalance        *utils.Money
+	MinOperation      *utils.Money
+	MaxOperation      *utils.Money
+	DailyDeposit      *utils.Money
+	DailyWithdrawal   *utils.Money
+	MonthlyDeposit    *utils.Money
+	MonthlyWithdrawal *utils.Money
+}
+
+// WithOverrides возвращает лимиты, в которых заполненные поля overrides заменяют значения l
+func (l WalletLimits) WithOverrides(overrides WalletLimits) WalletLimits {
+	pick := func(base, override *utils.Money) *utils.Money {
+		if override != nil {
+			return override
+		}
+		return base
+	}
+	return WalletLimits{
+		MaxBalance:        pick(l.MaxBalance, overrides.MaxBalance),
+		MinOperation:      pick(l.MinOperation, overrides.MinOperation),
+		MaxOperation:      pick(l.MaxOperation, overrides.MaxOperation),
+		DailyDeposit:      pick(l.DailyDeposit, overrides.DailyDeposit),
+		DailyWithdrawal:   pick(l.DailyWithdrawal, overrides.DailyWithdrawal),
+		MonthlyDeposit:    pick(l.MonthlyDeposit, overrides.MonthlyDeposit),
+		MonthlyWithdrawal: pick(l.MonthlyWithdrawal, overrides.MonthlyWithdrawal),
+	}
+}
+
+// HasTurnoverLimits возвращает true, если для проводки entryType нужны обороты за окна лимитов
+func (l *WalletLimits) HasTurnoverLimits(entryType string) bool {
+	if IsDebitEntry(entryType) {
+		return l.DailyWithdrawal != nil || l.MonthlyWithdrawal != nil
+	}
+	return l.DailyDeposit != nil || l.MonthlyDeposit != nil
+}
+
+// LimitUsage - обороты кошелька за окна лимитов без учета текущей операции
+type LimitUsage struct {
+	DailyDeposit      utils.Money
+	DailyWithdrawal   utils.Money
+	MonthlyDeposit    utils.Money
+	MonthlyWithdrawal utils.Money
+}
+
+// WalletLimitsState - уровень кошелька, его собственные лимиты, действующие лимиты и текущие обороты
+type WalletLimitsState struct {
+	WalletID  string
+	Tier      string
+	Overrides WalletLimits
+	Effective WalletLimits
+	Usage     LimitUsage
+}
+
+// LimitExceededError возвращается, когда операция нарушает лимит кошелька
+type LimitExceededError struct {
+	WalletID uuid.UUID
+	Limit    string
+	Value    utils.Money
+	// Remaining - сколько еще можно провести в рамках лимита; не заполнен для min_operation
+	Remaining *utils.Money
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Remaining == nil {
+		return fmt.Sprintf("limit %s exceeded: limit %s", e.Limit, e.Value)
+	}
+	return fmt.Sprintf("limit %s exceeded: limit %s, remaining %s", e.Limit, e.Value, e.Remaining)
+}
+
+// Message возвращает описание нарушения для клиента
+func (e *LimitExceededError) Message() string {
+	if e.Remaining == nil {
+		return fmt.Sprintf("Превышен лимит %s: минимальная сумма операции %s", e.Limit, e.Value)
+	}
+	return fmt.Sprintf("Превышен лимит %s (%s): доступно %s", e.Limit, e.Value, e.Remaining)
+}
+
+// Check проверяет проводку entryType на amount, после которой баланс станет balanceAfter.
+// Возвращает первый нарушенный лимит или nil
+func (l *WalletLimits) Check(entryType string, amount, balanceAfter utils.Money, usage LimitUsage) *LimitExceededError {
+	if l.MinOperation != nil && amount.Raw < l.MinOperation.Raw {
+		return &LimitExceededError{Limit: LimitMinOperation, Value: *l.MinOperation}
+	}
+	if l.MaxOperation != nil && amount.Raw > l.MaxOperation.Raw {
+		return exceeded(LimitMaxOperation, *l.MaxOperation, utils.Money{})
+	}
+
+	if IsDebitEntry(entryType) {
+		if l.DailyWithdrawal != nil && usage.DailyWithdrawal.Raw+amount.Raw > l.DailyWithdrawal.Raw {
+			return exceeded(LimitDailyWithdrawal, *l.DailyWithdrawal, usage.DailyWithdrawal)
+		}
+		if l.MonthlyWithdrawal != nil && usage.MonthlyWithdrawal.Raw+amount.Raw > l.MonthlyWithdrawal.Raw {
+			return exceeded(LimitMonthlyWithdrawal, *l.MonthlyWithdrawal, usage.MonthlyWithdrawal)
+		}
+		return nil
+	}
+
+	if l.MaxBalance != nil && balanceAfter.Raw > l.MaxBalance.Raw {
+		return exceeded(LimitMaxBalance, *l.MaxBalance, balanceAfter.Sub(amount))
+	}
+	if l.DailyDeposit != nil && usage.DailyDeposit.Raw+amount.Raw > l.DailyDeposit.Raw {
+		return exceeded(LimitDailyDeposit, *l.DailyDeposit, usage.DailyDeposit)
+	}
+	if l.MonthlyDeposit != nil && usage.MonthlyDeposit.Raw+amount.Raw > l.MonthlyDeposit.Raw {
+		return exceeded(LimitMonthlyDeposit, *l.MonthlyDeposit, usage.MonthlyDeposit)
+	}
+	return nil
+}
+
+// exceeded строит ошибку с остатком limit - used, но не меньше нуля
+func exceeded(limit string, value, used utils.Money) *LimitExceededError {
+	remaining := value.Sub(used)
+	if remaining.IsNegative() {
+		remaining = utils.Money{}
+	}
+	return &LimitExceededError{Limit: limit, Value: value, Remaining: &remaining}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+	"wallet-api/utils"
+)
+
+func money(raw int64) *utils.Money {
+	return &utils.Money{Raw: raw}
+}
+
+func TestWalletLimits_Check(t *testing.T) {
+	limits := WalletLimits{
+		MaxBalance:        money(100000),
+		MinOperation:      money(100),
+		MaxOperation:      money(50000),
+		DailyDeposit:      money(60000),
+		DailyWithdrawal:   money(30000),
+		MonthlyDeposit:    money(200000),
+		MonthlyWithdrawal: money(80000),
+	}
+
+	tests := []struct {
+		name          string
+		entryType     string
+		amount        int64
+		balanceAfter  int64
+		usage         LimitUsage
+		wantLimit     string
+		wantRemaining int64
+	}{
+		{name: "within limits", entryType: OperationTypeDeposit, amount: 1000, balanceAfter: 1000},
+		{name: "below min operation", entryType: OperationTypeWithdraw, amount: 50, balanceAfter: 0, wantLimit: LimitMinOperation},
+		{name: "above max operation", entryType: OperationTypeDeposit, amount: 60000, balanceAfter: 60000, wantLimit: LimitMaxOperation, wantRemaining: 50000},
+		{name: "max balance", entryType: OperationTypeDeposit, amount: 20000, balanceAfter: 110000, wantLimit: LimitMaxBalance, wantRemaining: 10000},
+		{name: "max balance already over", entryType: LedgerEntryTransferIn, amount: 1000, balanceAfter: 120000, wantLimit: LimitMaxBalance, wantRemaining: 0},
+		{
+			name: "daily deposit", entryType: OperationTypeDeposit, amount: 20000, balanceAfter: 20000,
+			usage: LimitUsage{DailyDeposit: utils.Money{Raw: 45000}}, wantLimit: LimitDailyDeposit, wantRemaining: 15000,
+		},
+		{
+			name: "monthly deposit counts transfers in", entryType: LedgerEntryTransferIn, amount: 20000, balanceAfter: 20000,
+			usage: LimitUsage{MonthlyDeposit: utils.Money{Raw: 190000}}, wantLimit: LimitMonthlyDeposit, wantRemaining: 10000,
+		},
+		{
+			name: "daily withdrawal", entryType: OperationTypeWithdraw, amount: 10000, balanceAfter: 0,
+			usage: LimitUsage{DailyWithdrawal: utils.Money{Raw: 25000}}, wantLimit: LimitDailyWithdrawal, wantRemaining: 5000,
+		},
+		{
+			name: "monthly withdrawal remaining clamped", entryType: LedgerEntryTransferOut, amount: 1000, balanceAfter: 0,
+			usage: LimitUsage{MonthlyWithdrawal: utils.Money{Raw: 90000}}, wantLimit: LimitMonthlyWithdrawal, wantRemaining: 0,
+		},
+		{
+			name: "withdrawal ignores max balance", entryType: OperationTypeWithdraw, amount: 1000, balanceAfter: 150000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := limits.Check(tt.entryType, utils.Money{Raw: tt.amount}, utils.Money{Raw: tt.balanceAfter}, tt.usage)
+			if tt.wantLimit == "" {
+				if got != nil {
+					t.Fatalf("Check() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Limit != tt.wantLimit {
+				t.Fatalf("Check() = %v, want limit %s", got, tt.wantLimit)
+			}
+			if tt.wantLimit == LimitMinOperation {
+				if got.Remaining != nil {
+					t.Errorf("Check() remaining = %s, want nil", got.Remaining)
+				}
+				return
+			}
+			if got.Remaining == nil || got.Remaining.Raw != tt.wantRemaining {
+				t.Errorf("Check() remaining = %v, want %d", got.Remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestWalletLimits_WithOverrides(t *testing.T) {
+	tier := WalletLimits{MaxBalance: money(1500000), MonthlyWithdrawal: money(4000000)}
+	effective := tier.WithOverrides(WalletLimits{MaxBalance: money(500000), DailyDeposit: money(100000)})
+
+	if effective.MaxBalance.Raw != 500000 {
+		t.Errorf("MaxBalance = %s, want override 5000.00", effective.MaxBalance)
+	}
+	if effective.MonthlyWithdrawal.Raw != 4000000 {
+		t.Errorf("MonthlyWithdrawal = %s, want tier 40000.00", effective.MonthlyWithdrawal)
+	}
+	if effective.DailyDeposit.Raw != 100000 {
+		t.Errorf("DailyDeposit = %s, want override 1000.00", effective.DailyDeposit)
+	}
+	if effective.MinOperation != nil {
+		t.Errorf("MinOperation = %s, want nil", effective.MinOperation)
+	}
+}
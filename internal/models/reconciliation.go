@@ -0,0 +1,99 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+	ReconciliationFailed    = "failed"
+)
+
+// Причины расхождения при сверке
+const (
+	// MismatchLedgerDrift - wallets.balance не равен сумме проводок кошелька
+	MismatchLedgerDrift = "ledger_drift"
+	// MismatchNegativeBalance - баланс ниже кредитной линии кошелька, например после гонки параллельных списаний
+	MismatchNegativeBalance = "negative_balance"
+)
+
+// ReconciliationRun - один проход сверки балансов с журналом по всем кошелькам
+type ReconciliationRun struct {
+	ID             uuid.UUID
+	Status         string
+	WalletsChecked int
+	Mismatches     int
+	FrozenWallets  int
+	LastWalletID   uuid.NullUUID
+	StartedAt      time.Time
+	FinishedAt     sql.NullTime
+}
+
+// WalletLedgerTotals - баланс кошелька и итоги его журнала, прочитанные одним запросом
+type WalletLedgerTotals struct {
+	WalletID       uuid.UUID
+	Balance        utils.Money
+	OverdraftLimit utils.Money
+	Status         string
+	LedgerBalance  utils.Money
+	LedgerEntries  int
+	// LastEntryID и LastBalanceAfter не заполнены, если у кошелька нет проводок
+	LastEntryID      sql.NullInt64
+	LastBalanceAfter sql.NullInt64
+}
+
+// ReconciliationMismatch - расхождение, найденное у кошелька
+type ReconciliationMismatch struct {
+	RunID            uuid.UUID
+	WalletID         uuid.UUID
+	Reason           string
+	WalletBalance    utils.Money
+	LedgerBalance    utils.Money
+	LedgerEntries    int
+	LastEntryID      sql.NullInt64
+	LastBalanceAfter sql.NullInt64
+	Frozen           bool
+	DetectedAt       time.Time
+}
+
+// Difference возвращает, на сколько баланс кошелька больше суммы его проводок
+func (m *ReconciliationMismatch) Difference() utils.Money {
+	return m.WalletBalance.Sub(m.LedgerBalance)
+}
+
+// Mismatches возвращает расхождения кошелька; пустой результат означает, что баланс сходится с журналом
+func (t *WalletLedgerTotals) Mismatches() []ReconciliationMismatch {
+	var reasons []string
+	if t.Balance != t.LedgerBalance {
+		reasons = append(reasons, MismatchLedgerDrift)
+	}
+	// Минус в пределах кредитной линии - разрешенный овердрафт, а не расхождение
+	if t.Balance.Raw < -t.OverdraftLimit.Raw {
+		reasons = append(reasons, MismatchNegativeBalance)
+	}
+
+	mismatches := make([]ReconciliationMismatch, 0, len(reasons))
+	for _, reason := range reasons {
+		mismatches = append(mismatches, ReconciliationMismatch{
+			WalletID:         t.WalletID,
+			Reason:           reason,
+			WalletBalance:    t.Balance,
+			LedgerBalance:    t.LedgerBalance,
+			LedgerEntries:    t.LedgerEntries,
+			LastEntryID:      t.LastEntryID,
+			LastBalanceAfter: t.LastBalanceAfter,
+		})
+	}
+	return mismatches
+}
+
+// ReconciliationReport - проход сверки вместе с найденными расхождениями
+type ReconciliationReport struct {
+	Run        ReconciliationRun
+	Mismatches []ReconciliationMismatch
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinRecurrenceInterval - минимальный шаг повторения: планировщик не запускается чаще раза в минуту
+const MinRecurrenceInterval = time.Minute
+
+// cronSearchYears ограничивает поиск следующего запуска выражений вроде "0 0 31 2 *", которые никогда не срабатывают
+const cronSearchYears = 5
+
+// Recurrence - правило повторения: cron-выражение из пяти полей (минута, час, день месяца, месяц, день недели, UTC)
+// или фиксированный интервал. Пустое правило означает однократный запуск
+type Recurrence struct {
+	Cron     string
+	Interval time.Duration
+}
+
+// Validate проверяет, что задано не больше одного правила и оно корректно
+func (r Recurrence) Validate() error {
+	if r.Cron != "" && r.Interval != 0 {
+		return fmt.Errorf("cron and interval are mutually exclusive")
+	}
+	if r.Interval != 0 && r.Interval < MinRecurrenceInterval {
+		return fmt.Errorf("interval must be at least %s", MinRecurrenceInterval)
+	}
+	if r.Cron != "" {
+		if _, err := parseCron(r.Cron); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsRecurring возвращает false для однократного запуска
+func (r Recurrence) IsRecurring() bool {
+	return r.Cron != "" || r.Interval != 0
+}
+
+// FirstAtOrAfter возвращает первый запуск не раньше t для расписания, начинающегося в start.
+// false - запусков больше нет
+func (r Recurrence) FirstAtOrAfter(start, t time.Time) (time.Time, bool) {
+	if t.Before(start) {
+		t = start
+	}
+	switch {
+	case r.Cron != "":
+		spec, err := parseCron(r.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return spec.next(t.Add(-time.Nanosecond))
+	case r.Interval != 0:
+		// Запуски интервального расписания идут от start с шагом Interval
+		steps := (t.Sub(start) + r.Interval - 1) / r.Interval
+		return start.Add(steps * r.Interval), true
+	default:
+		return start, !t.After(start)
+	}
+}
+
+// Next возвращает запуск, следующий за occurrence; false для однократного расписания или невалидного выражения
+func (r Recurrence) Next(occurrence time.Time) (time.Time, bool) {
+	switch {
+	case r.Cron != "":
+		spec, err := parseCron(r.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return spec.next(occurrence)
+	case r.Interval != 0:
+		return occurrence.Add(r.Interval), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+type cronField struct {
+	bits uint64
+	// any - поле задано звездочкой; для дней месяца и недели это меняет правило совпадения
+	any bool
+}
+
+func (f cronField) has(v int) bool {
+	return f.bits&(1<<uint(v)) != 0
+}
+
+type cronSpec struct {
+	minute, hour, dayOfMonth, month, dayOfWeek cronField
+}
+
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var parsed [5]cronField
+	for i, field := range fields {
+		f, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron field %d %q: %w", i+1, field, err)
+		}
+		parsed[i] = f
+	}
+
+	// Воскресенье можно записать и как 0, и как 7
+	if parsed[4].has(7) {
+		parsed[4].bits |= 1
+	}
+	return &cronSpec{minute: parsed[0], hour: parsed[1], dayOfMonth: parsed[2], month: parsed[3], dayOfWeek: parsed[4]}, nil
+}
+
+// parseCronField разбирает список через запятую из *, чисел, диапазонов a-b и шагов */n или a-b/n
+func parseCronField(field string, min, max int) (cronField, error) {
+	result := cronField{any: strings.HasPrefix(field, "*")}
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return cronField{}, fmt.Errorf("invalid step")
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return cronField{}, fmt.Errorf("invalid value")
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return cronField{}, fmt.Errorf("invalid range")
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return cronField{}, fmt.Errorf("value out of range %d-%d", min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			result.bits |= 1 << uint(v)
+		}
+	}
+	return result, nil
+}
+
+// dayMatches следует правилу cron: если ограничены и день месяца, и день недели, достаточно совпадения любого
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	dom := c.dayOfMonth.has(t.Day())
+	dow := c.dayOfWeek.has(int(t.Weekday()))
+	switch {
+	case c.dayOfMonth.any && c.dayOfWeek.any:
+		return true
+	case c.dayOfMonth.any:
+		return dow
+	case c.dayOfWeek.any:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// next возвращает первую подходящую минуту строго после after (UTC)
+func (c *cronSpec) next(after time.Time) (time.Time, bool) {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !c.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !c.hour.has(t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !c.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRecurrence_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		wantErr    bool
+	}{
+		{"once", Recurrence{}, false},
+		{"monthly cron", Recurrence{Cron: "0 9 1 * *"}, false},
+		{"weekdays cron", Recurrence{Cron: "*/15 9-18 * * 1-5"}, false},
+		{"interval", Recurrence{Interval: time.Hour}, false},
+		{"both", Recurrence{Cron: "0 9 1 * *", Interval: time.Hour}, true},
+		{"short interval", Recurrence{Interval: time.Second}, true},
+		{"four fields", Recurrence{Cron: "0 9 1 *"}, true},
+		{"out of range", Recurrence{Cron: "0 24 * * *"}, true},
+		{"bad step", Recurrence{Cron: "*/0 * * * *"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.recurrence.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecurrence_Next(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		after      string
+		want       string
+	}{
+		{"first of month", Recurrence{Cron: "0 9 1 * *"}, "2025-11-01T09:00:00Z", "2025-12-01T09:00:00Z"},
+		{"first of month across year", Recurrence{Cron: "0 9 1 * *"}, "2025-12-15T00:00:00Z", "2026-01-01T09:00:00Z"},
+		{"day of month or weekday", Recurrence{Cron: "0 0 13 * 5"}, "2025-11-08T00:00:00Z", "2025-11-13T00:00:00Z"},
+		{"sunday as 7", Recurrence{Cron: "30 12 * * 7"}, "2025-11-03T00:00:00Z", "2025-11-09T12:30:00Z"},
+		{"step", Recurrence{Cron: "*/20 * * * *"}, "2025-11-01T10:41:10Z", "2025-11-01T11:00:00Z"},
+		{"leap day", Recurrence{Cron: "0 0 29 2 *"}, "2025-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"interval", Recurrence{Interval: 90 * time.Minute}, "2025-11-01T10:00:00Z", "2025-11-01T11:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.recurrence.Next(at(tt.after))
+			if !ok || !got.Equal(at(tt.want)) {
+				t.Errorf("Next() = %v, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := (Recurrence{}).Next(at("2025-11-01T00:00:00Z")); ok {
+		t.Error("Next() of one-off recurrence should have no runs")
+	}
+	if _, ok := (Recurrence{Cron: "0 0 31 2 *"}).Next(at("2025-11-01T00:00:00Z")); ok {
+		t.Error("Next() of never matching cron should have no runs")
+	}
+}
+
+func TestRecurrence_FirstAtOrAfter(t *testing.T) {
+	start := at("2025-11-01T09:00:00Z")
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		t          string
+		want       string
+		wantOK     bool
+	}{
+		{"cron at start", Recurrence{Cron: "0 9 1 * *"}, "2025-10-20T00:00:00Z", "2025-11-01T09:00:00Z", true},
+		{"cron skips past runs", Recurrence{Cron: "0 9 1 * *"}, "2026-02-10T00:00:00Z", "2026-03-01T09:00:00Z", true},
+		{"interval aligned to start", Recurrence{Interval: 24 * time.Hour}, "2025-11-03T10:00:00Z", "2025-11-04T09:00:00Z", true},
+		{"interval exact", Recurrence{Interval: 24 * time.Hour}, "2025-11-03T09:00:00Z", "2025-11-03T09:00:00Z", true},
+		{"once before start", Recurrence{}, "2025-10-01T00:00:00Z", "2025-11-01T09:00:00Z", true},
+		{"once missed", Recurrence{}, "2025-11-02T00:00:00Z", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.recurrence.FirstAtOrAfter(start, at(tt.t))
+			if ok != tt.wantOK || (ok && !got.Equal(at(tt.want))) {
+				t.Errorf("FirstAtOrAfter() = %v, %v, want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
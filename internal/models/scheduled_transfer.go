@@ -0,0 +1,147 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// Статусы расписания перевода
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusPaused    = "paused"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusCancelled = "cancelled"
+)
+
+// Статусы запуска расписания
+const (
+	ScheduledRunSucceeded = "succeeded"
+	// ScheduledRunRetrying - попытка не удалась, запуск будет повторен через RetryInterval
+	ScheduledRunRetrying = "retrying"
+	// ScheduledRunFailed - попытки исчерпаны, расписание перешло к следующему запуску
+	ScheduledRunFailed = "failed"
+)
+
+// Причины неудачной попытки запуска
+const (
+	ScheduledRunInsufficientFunds = "insufficient_funds"
+	ScheduledRunWalletFrozen      = "wallet_frozen"
+	ScheduledRunWalletNotFound    = "wallet_not_found"
+	ScheduledRunLimitExceeded     = "limit_exceeded"
+	// ScheduledRunAccessDenied - создатель расписания больше не может списывать с кошелька-источника,
+	// расписание приостановлено
+	ScheduledRunAccessDenied = "access_denied"
+)
+
+// MaxScheduleRetries ограничивает число повторов одного запуска
+const MaxScheduleRetries = 10
+
+// ScheduledTransfer - расписание перевода с FromWalletID на ToWalletID. NextRunAt - плановое время текущего запуска,
+// оно же ключ идемпотентности запуска; RetryAt - время повтора неудавшейся попытки этого запуска.
+// ClientID - API клиент, OwnerID - владелец из JWT, создавшие расписание
+type ScheduledTransfer struct {
+	ID            uuid.UUID
+	ClientID      uuid.NullUUID
+	OwnerID       sql.NullString
+	FromWalletID  uuid.UUID
+	ToWalletID    uuid.UUID
+	Amount        utils.Money
+	Recurrence    Recurrence
+	StartAt       time.Time
+	EndAt         *time.Time
+	MaxRetries    int
+	RetryInterval time.Duration
+	Status        string
+	NextRunAt     *time.Time
+	RetryAt       *time.Time
+	Attempts      int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ScheduledTransferRun - итог запуска расписания за плановое время OccurrenceAt
+type ScheduledTransferRun struct {
+	ScheduleID    uuid.UUID
+	OccurrenceAt  time.Time
+	Status        string
+	Attempts      int
+	FailureReason string
+	ExecutedAt    time.Time
+}
+
+// Validate проверяет расписание перед сохранением
+func (s *ScheduledTransfer) Validate() error {
+	if s.FromWalletID == s.ToWalletID {
+		return fmt.Errorf("source and destination are the same wallet")
+	}
+	if s.Amount.Raw <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if s.StartAt.IsZero() {
+		return fmt.Errorf("startAt is required")
+	}
+	if s.EndAt != nil && s.EndAt.Before(s.StartAt) {
+		return fmt.Errorf("endAt is before startAt")
+	}
+	if s.MaxRetries < 0 || s.MaxRetries > MaxScheduleRetries {
+		return fmt.Errorf("maxRetries must be between 0 and %d", MaxScheduleRetries)
+	}
+	if s.MaxRetries > 0 && s.RetryInterval < MinRecurrenceInterval {
+		return fmt.Errorf("retryInterval must be at least %s", MinRecurrenceInterval)
+	}
+	return s.Recurrence.Validate()
+}
+
+// DueAt возвращает время, когда расписание нужно выполнить: повтор или плановый запуск
+func (s *ScheduledTransfer) DueAt() *time.Time {
+	if s.RetryAt != nil {
+		return s.RetryAt
+	}
+	return s.NextRunAt
+}
+
+// Schedule ставит первый запуск не раньше now; расписание без запусков в пределах EndAt завершается
+func (s *ScheduledTransfer) Schedule(now time.Time) {
+	s.Attempts = 0
+	s.RetryAt = nil
+	next, ok := s.Recurrence.FirstAtOrAfter(s.StartAt, now)
+	s.setNextRun(next, ok)
+}
+
+// Advance переводит расписание к запуску, следующему за текущим
+func (s *ScheduledTransfer) Advance() {
+	s.Attempts = 0
+	s.RetryAt = nil
+	if s.NextRunAt == nil {
+		s.setNextRun(time.Time{}, false)
+		return
+	}
+	next, ok := s.Recurrence.Next(*s.NextRunAt)
+	s.setNextRun(next, ok)
+}
+
+// RecordFailure учитывает неудачную попытку текущего запуска и возвращает статус запуска:
+// пока есть повторы, запуск повторяется через RetryInterval, иначе расписание переходит к следующему
+func (s *ScheduledTransfer) RecordFailure(now time.Time) string {
+	s.Attempts++
+	if s.Attempts <= s.MaxRetries {
+		retryAt := now.Add(s.RetryInterval)
+		s.RetryAt = &retryAt
+		return ScheduledRunRetrying
+	}
+	s.Advance()
+	return ScheduledRunFailed
+}
+
+func (s *ScheduledTransfer) setNextRun(next time.Time, ok bool) {
+	if !ok || (s.EndAt != nil && next.After(*s.EndAt)) {
+		s.NextRunAt = nil
+		s.Status = ScheduleStatusCompleted
+		return
+	}
+	s.NextRunAt = &next
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+func newMonthlySchedule() *ScheduledTransfer {
+	endAt := at("2026-01-31T00:00:00Z")
+	return &ScheduledTransfer{
+		ID:            uuid.New(),
+		FromWalletID:  uuid.New(),
+		ToWalletID:    uuid.New(),
+		Amount:        utils.Money{Raw: 50000},
+		Recurrence:    Recurrence{Cron: "0 9 1 * *"},
+		StartAt:       at("2025-11-01T00:00:00Z"),
+		EndAt:         &endAt,
+		MaxRetries:    2,
+		RetryInterval: time.Hour,
+		Status:        ScheduleStatusActive,
+	}
+}
+
+func TestScheduledTransfer_Validate(t *testing.T) {
+	if err := newMonthlySchedule().Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(st *ScheduledTransfer)
+	}{
+		{"same wallet", func(st *ScheduledTransfer) { st.ToWalletID = st.FromWalletID }},
+		{"zero amount", func(st *ScheduledTransfer) { st.Amount = utils.Money{} }},
+		{"end before start", func(st *ScheduledTransfer) { end := st.StartAt.Add(-time.Hour); st.EndAt = &end }},
+		{"too many retries", func(st *ScheduledTransfer) { st.MaxRetries = MaxScheduleRetries + 1 }},
+		{"retries without interval", func(st *ScheduledTransfer) { st.RetryInterval = 0 }},
+		{"bad cron", func(st *ScheduledTransfer) { st.Recurrence.Cron = "every month" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newMonthlySchedule()
+			tt.modify(st)
+			if err := st.Validate(); err == nil {
+				t.Error("Validate() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestScheduledTransfer_Lifecycle(t *testing.T) {
+	st := newMonthlySchedule()
+	st.Schedule(at("2025-10-20T00:00:00Z"))
+	if st.NextRunAt == nil || !st.NextRunAt.Equal(at("2025-11-01T09:00:00Z")) {
+		t.Fatalf("first run = %v, want 2025-11-01T09:00:00Z", st.NextRunAt)
+	}
+
+	// Две неудачи уходят в повтор, третья исчерпывает повторы и переводит к следующему месяцу
+	now := at("2025-11-01T09:00:30Z")
+	for attempt := 1; attempt <= 2; attempt++ {
+		if status := st.RecordFailure(now); status != ScheduledRunRetrying {
+			t.Fatalf("attempt %d status = %s, want %s", attempt, status, ScheduledRunRetrying)
+		}
+		if st.RetryAt == nil || !st.RetryAt.Equal(now.Add(time.Hour)) || !st.DueAt().Equal(*st.RetryAt) {
+			t.Fatalf("attempt %d retryAt = %v, want %v", attempt, st.RetryAt, now.Add(time.Hour))
+		}
+		now = now.Add(time.Hour)
+	}
+	if status := st.RecordFailure(now); status != ScheduledRunFailed {
+		t.Fatalf("status = %s, want %s", status, ScheduledRunFailed)
+	}
+	if st.Attempts != 0 || st.RetryAt != nil || !st.NextRunAt.Equal(at("2025-12-01T09:00:00Z")) {
+		t.Fatalf("after failure: attempts %d, retryAt %v, next %v", st.Attempts, st.RetryAt, st.NextRunAt)
+	}
+
+	st.Advance()
+	if !st.NextRunAt.Equal(at("2026-01-01T09:00:00Z")) {
+		t.Fatalf("next = %v, want 2026-01-01T09:00:00Z", st.NextRunAt)
+	}
+
+	// Февральский запуск позже EndAt: расписание завершается
+	st.Advance()
+	if st.NextRunAt != nil || st.Status != ScheduleStatusCompleted {
+		t.Errorf("after end: next %v, status %s; want completed", st.NextRunAt, st.Status)
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatementFormatCSV   = "csv"
+	StatementFormatJSONL = "jsonl"
+	// StatementFormatCAMT053 - ISO 20022 BankToCustomerStatement (camt.053.001.08)
+	StatementFormatCAMT053 = "camt053"
+)
+
+// Statement - заголовок выписки по кошельку за период [From, To).
+// Входящий остаток - баланс после последней проводки до From, исходящий - после последней проводки до To
+type Statement struct {
+	WalletID       uuid.UUID
+	From           time.Time
+	To             time.Time
+	OpeningBalance utils.Money
+	ClosingBalance utils.Money
+}
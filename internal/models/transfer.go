@@ -0,0 +1,19 @@
+package models
+
+import "github.com/google/uuid"
+
+// Transfer - перевод между двумя кошельками в одной транзакции
+type Transfer struct {
+	FromWalletID uuid.UUID `json:"fromWalletId"`
+	ToWalletID   uuid.UUID `json:"toWalletId"`
+	Amount       int64     `json:"amount"`
+	// OwnerID заполняется из токена пользователя и проверяется для кошелька-источника
+	OwnerID string `json:"-"`
+}
+
+type TransferResult struct {
+	From *Wallet
+	To   *Wallet
+	// Fee - комиссия перевода, удержанная с отправителя сверх суммы
+	Fee Fee
+}
@@ -2,23 +2,79 @@ package models
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
+	"wallet-api/pkg/receipt"
 	"wallet-api/utils"
 
 	"github.com/google/uuid"
 )
 
 type Wallet struct {
-	ID        uuid.UUID    `db:"id" json:"id"`
-	Balance   utils.Money  `db:"balance" json:"balance"`
-	CreatedAt time.Time    `db:"created_at" json:"created_at"`
-	UpdatedAt sql.NullTime `db:"updated_at" json:"updated_at"`
+	ID        uuid.UUID      `db:"id" json:"id"`
+	Balance   utils.Money    `db:"balance" json:"balance"`
+	OwnerID   sql.NullString `db:"owner_id" json:"owner_id"`
+	Status    string         `db:"status" json:"status"`
+	Metadata  WalletMetadata `db:"metadata" json:"metadata"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt sql.NullTime   `db:"updated_at" json:"updated_at"`
+	// OverdraftLimit - согласованная кредитная линия: на сколько баланс может уйти в минус
+	OverdraftLimit utils.Money `db:"overdraft_limit" json:"overdraft_limit"`
+}
+
+const (
+	WalletStatusActive = "ACTIVE"
+	WalletStatusFrozen = "FROZEN"
+)
+
+func IsValidWalletStatus(status string) bool {
+	return status == WalletStatusActive || status == WalletStatusFrozen
+}
+
+// WalletMetadata - произвольные теги кошелька (ключ - значение), хранятся в JSONB колонке metadata
+type WalletMetadata map[string]string
+
+func (m *WalletMetadata) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("unsupported scan type for WalletMetadata: %T", v)
+	}
+}
+
+func (m WalletMetadata) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
 }
 
 type WalletOperation struct {
 	WalletID      uuid.UUID `json:"walletId"`
 	OperationType string    `json:"operationType"`
 	Amount        int64     `json:"amount"`
+	// OwnerID заполняется из токена пользователя, пустое значение отключает проверку владельца
+	OwnerID string `json:"-"`
+}
+
+// OperationResult - результат операции над кошельком
+type OperationResult struct {
+	Wallet *Wallet
+	// TransactionID - идентификатор записи журнала двойной записи, созданной операцией
+	TransactionID uuid.UUID
+	// Entry - проводка операции; комиссия, если она есть, записана следующей проводкой FEE
+	Entry LedgerEntry
+	Fee   Fee
+	// Receipt заполняется, только если настроен ключ подписи квитанций
+	Receipt *receipt.Receipt
 }
 
 const (
@@ -29,3 +85,31 @@ const (
 func IsValidOperationType(opType string) bool {
 	return opType == OperationTypeDeposit || opType == OperationTypeWithdraw
 }
+
+// IsOwnedBy возвращает true, если кошелек закреплен за указанным владельцем
+func (w *Wallet) IsOwnedBy(ownerID string) bool {
+	return w.OwnerID.Valid && w.OwnerID.String == ownerID
+}
+
+// CanDebit возвращает true, если после списания amount баланс не опустится ниже кредитной линии
+func (w *Wallet) CanDebit(amount utils.Money) bool {
+	return w.Balance.Sub(amount).Raw >= -w.OverdraftLimit.Raw
+}
+
+// CreditUsed возвращает использованную часть кредитной линии - долг кошелька
+func (w *Wallet) CreditUsed() utils.Money {
+	if !w.Balance.IsNegative() {
+		return utils.Money{}
+	}
+	return utils.Money{Raw: -w.Balance.Raw}
+}
+
+// CreditRemaining возвращает, на сколько еще баланс может уйти в минус. Если долг превышает
+// кредитную линию (например, возник до ее появления), остаток нулевой
+func (w *Wallet) CreditRemaining() utils.Money {
+	remaining := w.OverdraftLimit.Sub(w.CreditUsed())
+	if remaining.IsNegative() {
+		return utils.Money{}
+	}
+	return remaining
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WalletSortCreatedAt = "created_at"
+	WalletSortUpdatedAt = "updated_at"
+	WalletSortBalance   = "balance"
+
+	DefaultWalletPageSize = 50
+	MaxWalletPageSize     = 500
+)
+
+func IsValidWalletSort(sortBy string) bool {
+	return sortBy == WalletSortCreatedAt || sortBy == WalletSortUpdatedAt || sortBy == WalletSortBalance
+}
+
+// WalletFilter - условия выборки GET /api/v1/wallets. Пустые поля не ограничивают выборку
+type WalletFilter struct {
+	// IDs ограничивает выборку списком кошельков, доступных клиенту
+	IDs         []uuid.UUID
+	OwnerID     string
+	Status      string
+	Tags        map[string]string
+	MinBalance  *utils.Money
+	MaxBalance  *utils.Money
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+
+	SortBy     string
+	Descending bool
+	// After - позиция последнего кошелька предыдущей страницы
+	After *WalletCursor
+	Limit int
+}
+
+// WalletCursor - позиция в выборке кошельков: значение поля сортировки и ID как tie-breaker.
+// Клиенту передается в виде непрозрачной строки
+type WalletCursor struct {
+	SortBy     string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Value      string    `json:"v"`
+	ID         uuid.UUID `json:"id"`
+}
+
+// NewWalletCursor строит курсор, указывающий на wallet при заданной сортировке
+func NewWalletCursor(wallet *Wallet, sortBy string, descending bool) WalletCursor {
+	cursor := WalletCursor{SortBy: sortBy, Descending: descending, ID: wallet.ID}
+	switch sortBy {
+	case WalletSortBalance:
+		cursor.Value = strconv.FormatInt(wallet.Balance.Raw, 10)
+	case WalletSortUpdatedAt:
+		cursor.Value = wallet.UpdatedAt.Time.UTC().Format(time.RFC3339Nano)
+	default:
+		cursor.Value = wallet.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+func (c WalletCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeWalletCursor(s string) (*WalletCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor: %w", err)
+	}
+
+	var cursor WalletCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("decode cursor: %w", err)
+	}
+	if !IsValidWalletSort(cursor.SortBy) || cursor.ID == uuid.Nil {
+		return nil, fmt.Errorf("decode cursor: malformed position")
+	}
+
+	// Значение попадает в SQL параметром, но проверяем его заранее, чтобы вернуть 400, а не ошибку БД
+	switch cursor.SortBy {
+	case WalletSortBalance:
+		_, err = strconv.ParseInt(cursor.Value, 10, 64)
+	default:
+		_, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor: %w", err)
+	}
+
+	return &cursor, nil
+}
+
+// WalletPage - страница выборки; пустой NextCursor означает, что страниц больше нет
+type WalletPage struct {
+	Items      []Wallet
+	NextCursor string
+}
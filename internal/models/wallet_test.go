@@ -119,3 +119,96 @@ func TestWallet_Initialization(t *testing.T) {
 		t.Error("Wallet CreatedAt should not be zero")
 	}
 }
+
+func TestWalletCursor_RoundTrip(t *testing.T) {
+	wallet := &Wallet{
+		ID:        uuid.New(),
+		Balance:   utils.Money{Raw: 1050},
+		CreatedAt: time.Date(2025, 9, 1, 12, 30, 0, 123456000, time.UTC),
+	}
+
+	for _, sortBy := range []string{WalletSortCreatedAt, WalletSortBalance} {
+		encoded := NewWalletCursor(wallet, sortBy, true).Encode()
+		cursor, err := DecodeWalletCursor(encoded)
+		if err != nil {
+			t.Fatalf("DecodeWalletCursor(%s) error = %v", sortBy, err)
+		}
+		if cursor.SortBy != sortBy || !cursor.Descending || cursor.ID != wallet.ID {
+			t.Errorf("DecodeWalletCursor(%s) = %+v", sortBy, cursor)
+		}
+	}
+
+	for _, raw := range []string{"", "not-base64!", "e30", NewWalletCursor(wallet, "owner_id", false).Encode()} {
+		if _, err := DecodeWalletCursor(raw); err == nil {
+			t.Errorf("DecodeWalletCursor(%q) expected error", raw)
+		}
+	}
+}
+
+func TestWallet_Overdraft(t *testing.T) {
+	tests := []struct {
+		name          string
+		balance       int64
+		overdraft     int64
+		debit         int64
+		wantCanDebit  bool
+		wantUsed      int64
+		wantRemaining int64
+	}{
+		{"no credit line", 1000, 0, 1000, true, 0, 0},
+		{"no credit line overdraw", 1000, 0, 1001, false, 0, 0},
+		{"positive balance with credit line", 1000, 500, 1500, true, 0, 500},
+		{"beyond credit line", 1000, 500, 1501, false, 0, 500},
+		{"partly used credit line", -200, 500, 300, true, 200, 300},
+		{"debt above credit line", -700, 500, 1, false, 700, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet := &Wallet{Balance: utils.Money{Raw: tt.balance}, OverdraftLimit: utils.Money{Raw: tt.overdraft}}
+			if got := wallet.CanDebit(utils.Money{Raw: tt.debit}); got != tt.wantCanDebit {
+				t.Errorf("CanDebit(%d) = %t, want %t", tt.debit, got, tt.wantCanDebit)
+			}
+			if got := wallet.CreditUsed(); got.Raw != tt.wantUsed {
+				t.Errorf("CreditUsed() = %d, want %d", got.Raw, tt.wantUsed)
+			}
+			if got := wallet.CreditRemaining(); got.Raw != tt.wantRemaining {
+				t.Errorf("CreditRemaining() = %d, want %d", got.Raw, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestCrossesIntoOverdraft(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after int64
+		want          bool
+	}{
+		{"stays positive", 1000, 500, false},
+		{"crosses from positive", 100, -1, true},
+		{"crosses from zero", 0, -1, true},
+		{"already negative", -100, -200, false},
+		{"returns from negative", -100, 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CrossesIntoOverdraft(utils.Money{Raw: tt.before}, utils.Money{Raw: tt.after}); got != tt.want {
+				t.Errorf("CrossesIntoOverdraft(%d, %d) = %t, want %t", tt.before, tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalletLedgerTotals_MismatchesWithinOverdraft(t *testing.T) {
+	within := WalletLedgerTotals{Balance: utils.Money{Raw: -300}, OverdraftLimit: utils.Money{Raw: 500}, LedgerBalance: utils.Money{Raw: -300}}
+	if mismatches := within.Mismatches(); len(mismatches) != 0 {
+		t.Errorf("Mismatches() within overdraft = %+v, want none", mismatches)
+	}
+
+	beyond := WalletLedgerTotals{Balance: utils.Money{Raw: -600}, OverdraftLimit: utils.Money{Raw: 500}, LedgerBalance: utils.Money{Raw: -600}}
+	if mismatches := beyond.Mismatches(); len(mismatches) != 1 || mismatches[0].Reason != MismatchNegativeBalance {
+		t.Errorf("Mismatches() beyond overdraft = %+v, want %s", mismatches, MismatchNegativeBalance)
+	}
+}
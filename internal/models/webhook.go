@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookEventDeposit      = "deposit"
+	WebhookEventWithdraw     = "withdraw"
+	WebhookEventLowBalance   = "low_balance"
+	WebhookEventWalletFrozen = "wallet_frozen"
+	WebhookEventOverdrawn    = "overdrawn"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookSubscription struct {
+	ID                  uuid.UUID     `db:"id" json:"id"`
+	ClientID            uuid.NullUUID `db:"client_id" json:"-"`
+	URL                 string        `db:"url" json:"url"`
+	Secret              string        `db:"secret" json:"-"`
+	WalletIDs           []uuid.UUID   `db:"wallet_ids" json:"walletIds,omitempty"`
+	EventTypes          []string      `db:"event_types" json:"eventTypes"`
+	LowBalanceThreshold *utils.Money  `db:"low_balance_threshold" json:"-"`
+	Active              bool          `db:"active" json:"active"`
+	CreatedAt           time.Time     `db:"created_at" json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID      `db:"id" json:"id"`
+	SubscriptionID uuid.UUID      `db:"subscription_id" json:"subscriptionId"`
+	EventID        uuid.UUID      `db:"event_id" json:"eventId"`
+	EventType      string         `db:"event_type" json:"eventType"`
+	Payload        []byte         `db:"payload" json:"-"`
+	Status         string         `db:"status" json:"status"`
+	Attempts       int            `db:"attempts" json:"attempts"`
+	LastError      sql.NullString `db:"last_error" json:"-"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at" json:"nextAttemptAt"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	URL            string         `db:"url" json:"-"`
+	Secret         string         `db:"secret" json:"-"`
+}
+
+type WebhookDeliveryAttempt struct {
+	DeliveryID     uuid.UUID     `db:"delivery_id"`
+	AttemptedAt    time.Time     `db:"attempted_at"`
+	ResponseStatus sql.NullInt64 `db:"response_status"`
+	Error          string        `db:"error"`
+	Duration       time.Duration `db:"duration_ms"`
+}
+
+func IsValidWebhookEventType(eventType string) bool {
+	switch eventType {
+	case WebhookEventDeposit, WebhookEventWithdraw, WebhookEventLowBalance, WebhookEventWalletFrozen, WebhookEventOverdrawn:
+		return true
+	}
+	return false
+}
+
+// WebhookEventTypesFor возвращает типы webhook событий, которые порождает доменное событие
+func WebhookEventTypesFor(eventType string) []string {
+	switch eventType {
+	case EventWalletCredited:
+		return []string{WebhookEventDeposit}
+	case EventWalletDebited:
+		return []string{WebhookEventWithdraw, WebhookEventLowBalance}
+	case EventWalletFrozen:
+		return []string{WebhookEventWalletFrozen}
+	case EventWalletOverdrawn:
+		return []string{WebhookEventOverdrawn}
+	}
+	return nil
+}
+
+// Matches проверяет фильтры подписки по кошельку, типу события и порогу низкого баланса
+func (s *WebhookSubscription) Matches(walletID uuid.UUID, eventType string, balance utils.Money) bool {
+	if !s.Active {
+		return false
+	}
+
+	if len(s.WalletIDs) > 0 {
+		found := false
+		for _, id := range s.WalletIDs {
+			if id == walletID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	subscribed := false
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			subscribed = true
+			break
+		}
+	}
+	if !subscribed {
+		return false
+	}
+
+	if eventType == WebhookEventLowBalance {
+		return s.LowBalanceThreshold != nil && balance.Raw < s.LowBalanceThreshold.Raw
+	}
+
+	return true
+}
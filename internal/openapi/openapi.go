@@ -0,0 +1,66 @@
+// Package openapi загружает OpenAPI спецификацию и проверяет запросы и ответы на соответствие ей
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/google/uuid"
+)
+
+var defineFormats sync.Once
+
+// Spec - загруженная и проверенная спецификация вместе с роутером для поиска операций
+type Spec struct {
+	Doc    *openapi3.T
+	router routers.Router
+}
+
+func Load(data []byte) (*Spec, error) {
+	defineFormats.Do(func() {
+		// Встроенный формат kin-openapi принимает только UUID версий 1-5, сервис принимает любые
+		openapi3.DefineStringFormatCallback("uuid", func(value string) error {
+			_, err := uuid.Parse(value)
+			return err
+		})
+	})
+
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validate openapi spec: %w", err)
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build openapi router: %w", err)
+	}
+
+	return &Spec{Doc: doc, router: router}, nil
+}
+
+// FindRoute возвращает операцию спецификации для запроса; ошибка означает, что такой операции нет
+func (s *Spec) FindRoute(r *http.Request) (*routers.Route, map[string]string, error) {
+	return s.router.FindRoute(r)
+}
+
+// ValidateRequest проверяет параметры и тело запроса. Аутентификацию проверяют middleware,
+// поэтому схемы безопасности здесь не применяются. Тело запроса остается доступным обработчику
+func (s *Spec) ValidateRequest(r *http.Request, route *routers.Route, pathParams map[string]string) error {
+	return openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	})
+}
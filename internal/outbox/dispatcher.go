@@ -0,0 +1,115 @@
+package outbox
+
+import (
+	"context"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils/logger"
+)
+
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// ClaimLease - на сколько событие закрепляется за dispatcher'ом; должна превышать время отправки одного события
+	ClaimLease time.Duration
+}
+
+type Dispatcher struct {
+	repo      repository.OutboxRepositoryInterface
+	publisher Publisher
+	cfg       DispatcherConfig
+	now       func() time.Time
+}
+
+func NewDispatcher(repo repository.OutboxRepositoryInterface, publisher Publisher, cfg DispatcherConfig) *Dispatcher {
+	return &Dispatcher{repo: repo, publisher: publisher, cfg: cfg, now: time.Now}
+}
+
+// Run опрашивает outbox до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := d.DispatchOnce(ctx)
+			if err != nil {
+				logger.GlobalLogger.Error("Ошибка отправки событий outbox: %v", err)
+				break
+			}
+			if processed == 0 || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce обрабатывает одну пачку и возвращает количество обработанных событий.
+// Аренда пачки фиксируется до отправки, итоги записываются отдельной короткой транзакцией,
+// поэтому медленный получатель не держит блокировки и соединение из пула
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	messages, err := d.repo.ClaimBatch(d.cfg.BatchSize, d.cfg.ClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	leaseEnd := d.now().Add(d.cfg.ClaimLease)
+	acks := make([]models.OutboxAck, 0, len(messages))
+	for _, msg := range messages {
+		// После окончания аренды событие может забрать другая реплика: оставшиеся события отправит она
+		if !d.now().Before(leaseEnd) {
+			logger.GlobalLogger.Warning("Аренда пачки outbox истекла, не отправлено событий: %d", len(messages)-len(acks))
+			break
+		}
+
+		publishErr := d.publisher.Publish(ctx, msg)
+		ack := models.OutboxAck{ID: msg.ID}
+		switch {
+		case publishErr == nil:
+			ack.Status = models.OutboxStatusPublished
+			metrics.OutboxPublishedTotal.Add(1)
+		case msg.Attempts+1 >= d.cfg.MaxAttempts:
+			logger.GlobalLogger.Error("Событие %s перемещено в dead letter после %d попыток: %v", msg.EventID, msg.Attempts+1, publishErr)
+			ack.Status = models.OutboxStatusDead
+			ack.LastError = publishErr.Error()
+			metrics.OutboxDeadTotal.Add(1)
+		default:
+			logger.GlobalLogger.Warning("Не удалось отправить событие %s (попытка %d): %v", msg.EventID, msg.Attempts+1, publishErr)
+			ack.Status = models.OutboxStatusPending
+			ack.LastError = publishErr.Error()
+			ack.NextAttemptAt = d.now().Add(d.backoff(msg.Attempts))
+			metrics.OutboxFailedTotal.Add(1)
+		}
+		acks = append(acks, ack)
+	}
+
+	if len(acks) == 0 {
+		return 0, nil
+	}
+	if err := d.repo.AckBatch(acks); err != nil {
+		return 0, err
+	}
+	return len(acks), nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 0; i < attempts && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+	return delay
+}
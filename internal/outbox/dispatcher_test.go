@@ -0,0 +1,151 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+)
+
+func init() {
+	logger.Init()
+}
+
+type MockOutboxRepository struct {
+	messages []models.OutboxMessage
+	lease    time.Duration
+	acks     []models.OutboxAck
+	ackCalls int
+}
+
+func (m *MockOutboxRepository) ClaimBatch(limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	m.lease = lease
+	return m.messages, nil
+}
+
+func (m *MockOutboxRepository) AckBatch(acks []models.OutboxAck) error {
+	m.ackCalls++
+	m.acks = append(m.acks, acks...)
+	return nil
+}
+
+func (m *MockOutboxRepository) ack(id int64) (models.OutboxAck, bool) {
+	for _, ack := range m.acks {
+		if ack.ID == id {
+			return ack, true
+		}
+	}
+	return models.OutboxAck{}, false
+}
+
+type mockPublisher struct {
+	failFor map[int64]bool
+}
+
+func (p *mockPublisher) Publish(ctx context.Context, msg models.OutboxMessage) error {
+	if p.failFor[msg.ID] {
+		return errors.New("downstream unavailable")
+	}
+	return nil
+}
+
+func TestDispatcher_DispatchOnce(t *testing.T) {
+	now := time.Now()
+	repo := &MockOutboxRepository{
+		messages: []models.OutboxMessage{
+			{ID: 1, EventID: uuid.New(), Attempts: 0},
+			{ID: 2, EventID: uuid.New(), Attempts: 2},
+			{ID: 3, EventID: uuid.New(), Attempts: 4},
+		},
+	}
+	dispatcher := NewDispatcher(
+		repo,
+		&mockPublisher{failFor: map[int64]bool{2: true, 3: true}},
+		DispatcherConfig{BatchSize: 10, MaxAttempts: 5, BaseBackoff: time.Second, MaxBackoff: time.Minute, ClaimLease: time.Minute},
+	)
+	dispatcher.now = func() time.Time { return now }
+
+	processed, err := dispatcher.DispatchOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchOnce() unexpected error = %v", err)
+	}
+	if processed != 3 {
+		t.Errorf("DispatchOnce() processed = %d, want 3", processed)
+	}
+	if repo.lease != time.Minute || repo.ackCalls != 1 {
+		t.Errorf("claim lease = %v, ack calls = %d; want 1m and a single ack", repo.lease, repo.ackCalls)
+	}
+	if ack, ok := repo.ack(1); !ok || ack.Status != models.OutboxStatusPublished {
+		t.Errorf("message 1 ack = %+v, want published", ack)
+	}
+	if ack, ok := repo.ack(2); !ok || ack.Status != models.OutboxStatusPending || !ack.NextAttemptAt.Equal(now.Add(4*time.Second)) {
+		t.Errorf("message 2 must be retried with backoff 4s, got %+v", ack)
+	}
+	if ack, ok := repo.ack(3); !ok || ack.Status != models.OutboxStatusDead || ack.LastError == "" {
+		t.Errorf("message 3 ack = %+v, want dead with error", ack)
+	}
+}
+
+func TestDispatcher_StopsWhenLeaseExpires(t *testing.T) {
+	now := time.Now()
+	repo := &MockOutboxRepository{
+		messages: []models.OutboxMessage{{ID: 1, EventID: uuid.New()}, {ID: 2, EventID: uuid.New()}},
+	}
+	dispatcher := NewDispatcher(repo, &mockPublisher{}, DispatcherConfig{BatchSize: 10, MaxAttempts: 5, ClaimLease: time.Second})
+	// Часы сдвигаются при каждом чтении: второе событие уже не укладывается в аренду
+	dispatcher.now = func() time.Time {
+		current := now
+		now = now.Add(600 * time.Millisecond)
+		return current
+	}
+
+	processed, err := dispatcher.DispatchOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchOnce() unexpected error = %v", err)
+	}
+	if processed != 1 || len(repo.acks) != 1 || repo.acks[0].ID != 1 {
+		t.Errorf("processed = %d, acks = %+v; want only message 1, message 2 left to the next lease", processed, repo.acks)
+	}
+}
+
+func TestDispatcher_BackoffIsCapped(t *testing.T) {
+	dispatcher := NewDispatcher(nil, nil, DispatcherConfig{BaseBackoff: time.Second, MaxBackoff: time.Minute})
+
+	if got := dispatcher.backoff(0); got != time.Second {
+		t.Errorf("backoff(0) = %v, want %v", got, time.Second)
+	}
+	if got := dispatcher.backoff(20); got != time.Minute {
+		t.Errorf("backoff(20) = %v, want %v", got, time.Minute)
+	}
+}
+
+func TestWebhookPublisher_Publish(t *testing.T) {
+	var gotEventID string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEventID = r.Header.Get("X-Event-ID")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher(server.URL, time.Second)
+	msg := models.OutboxMessage{EventID: uuid.New(), EventType: models.EventWalletCredited, Payload: []byte(`{}`)}
+
+	if err := publisher.Publish(context.Background(), msg); err != nil {
+		t.Fatalf("Publish() unexpected error = %v", err)
+	}
+	if gotEventID != msg.EventID.String() {
+		t.Errorf("X-Event-ID = %s, want %s", gotEventID, msg.EventID)
+	}
+
+	status = http.StatusBadGateway
+	if err := publisher.Publish(context.Background(), msg); err == nil {
+		t.Errorf("Publish() must fail on non-2xx response")
+	}
+}
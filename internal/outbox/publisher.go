@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils/logger"
+)
+
+// Publisher доставляет событие во внешнюю систему. Доставка at-least-once:
+// одно и то же событие может прийти повторно, получатель дедуплицирует по EventID
+type Publisher interface {
+	Publish(ctx context.Context, msg models.OutboxMessage) error
+}
+
+type LogPublisher struct{}
+
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, msg models.OutboxMessage) error {
+	logger.GlobalLogger.Info("Событие %s %s для кошелька %s: %s", msg.EventType, msg.EventID, msg.AggregateID, msg.Payload)
+	return nil
+}
+
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, msg models.OutboxMessage) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", msg.EventID.String())
+	req.Header.Set("X-Event-Type", msg.EventType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("send webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MultiPublisher отправляет событие во все publisher'ы; при ошибке событие повторяется целиком,
+// поэтому каждый publisher должен быть идемпотентен
+type MultiPublisher struct {
+	publishers []Publisher
+}
+
+func NewMultiPublisher(publishers ...Publisher) *MultiPublisher {
+	return &MultiPublisher{publishers: publishers}
+}
+
+func (p *MultiPublisher) Publish(ctx context.Context, msg models.OutboxMessage) error {
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
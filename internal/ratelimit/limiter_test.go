@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemoryLimiter(time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	rule := Rule{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow("client:a", rule); !ok {
+			t.Fatalf("request %d must be allowed within burst", i+1)
+		}
+	}
+
+	ok, retryAfter := limiter.Allow("client:a", rule)
+	if ok {
+		t.Fatalf("request over burst must be rejected")
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Errorf("retryAfter = %v, want %v", retryAfter, 500*time.Millisecond)
+	}
+
+	if ok, _ := limiter.Allow("client:b", rule); !ok {
+		t.Errorf("other keys must have their own bucket")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := limiter.Allow("client:a", rule); !ok {
+		t.Errorf("bucket must refill over time")
+	}
+
+	if ok, _ := limiter.Allow("client:a", Rule{}); !ok {
+		t.Errorf("disabled rule must not limit")
+	}
+}
+
+func TestMemoryLimiter_SweepsIdleBuckets(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemoryLimiter(time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("ip:1", Rule{Rate: 1, Burst: 1})
+	now = now.Add(2 * time.Minute)
+	limiter.Allow("ip:2", Rule{Rate: 1, Burst: 1})
+
+	if _, ok := limiter.buckets["ip:1"]; ok {
+		t.Errorf("idle bucket must be removed")
+	}
+}
@@ -0,0 +1,151 @@
+package reconcile
+
+import (
+	"context"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+)
+
+type WorkerConfig struct {
+	Interval time.Duration
+	// ChunkSize и ChunkPause ограничивают нагрузку на БД: за один запрос сверяется ChunkSize кошельков,
+	// между запросами выдерживается пауза
+	ChunkSize  int
+	ChunkPause time.Duration
+	// AutoFreeze замораживает кошельки, баланс которых разошелся с журналом
+	AutoFreeze bool
+	// StaleAfter - через сколько без обновлений сверка в статусе running считается упавшей
+	StaleAfter time.Duration
+}
+
+// Worker сверяет wallets.balance с суммой проводок каждого кошелька.
+// На нескольких репликах сверку за интервал выполняет только одна из них
+type Worker struct {
+	repo repository.ReconciliationRepositoryInterface
+	cfg  WorkerConfig
+}
+
+func NewWorker(repo repository.ReconciliationRepositoryInterface, cfg WorkerConfig) *Worker {
+	return &Worker{repo: repo, cfg: cfg}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.RunOnce(ctx); err != nil {
+			logger.GlobalLogger.Error("Ошибка сверки балансов: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce проходит все кошельки порциями и возвращает завершенную сверку.
+// Возвращает nil, если сверку недавно начала другая реплика
+func (w *Worker) RunOnce(ctx context.Context) (*models.ReconciliationRun, error) {
+	// Половина интервала - запас на расхождение таймеров реплик
+	run, err := w.repo.StartReconciliationRun(w.cfg.Interval/2, w.cfg.StaleAfter)
+	if err != nil || run == nil {
+		return nil, err
+	}
+	metrics.ReconciliationRunsTotal.Add(1)
+
+	if err := w.reconcile(ctx, run); err != nil {
+		if finishErr := w.repo.FinishReconciliationRun(run.ID, models.ReconciliationFailed); finishErr != nil {
+			logger.GlobalLogger.Error("Ошибка завершения сверки %s: %v", run.ID, finishErr)
+		}
+		return nil, err
+	}
+
+	if err := w.repo.FinishReconciliationRun(run.ID, models.ReconciliationCompleted); err != nil {
+		return nil, err
+	}
+	run.Status = models.ReconciliationCompleted
+
+	metrics.ReconciliationLastMismatches.Set(int64(run.Mismatches))
+	metrics.ReconciliationLastCompletedAt.Set(time.Now().Unix())
+	if run.Mismatches > 0 {
+		logger.GlobalLogger.Error("Сверка %s: проверено кошельков %d, расхождений %d, заморожено %d",
+			run.ID, run.WalletsChecked, run.Mismatches, run.FrozenWallets)
+	} else {
+		logger.GlobalLogger.Info("Сверка %s: проверено кошельков %d, расхождений нет", run.ID, run.WalletsChecked)
+	}
+	return run, nil
+}
+
+func (w *Worker) reconcile(ctx context.Context, run *models.ReconciliationRun) error {
+	after := uuid.Nil
+	for {
+		totals, err := w.repo.ListWalletLedgerTotals(after, w.cfg.ChunkSize)
+		if err != nil {
+			return err
+		}
+		if len(totals) == 0 {
+			return nil
+		}
+
+		mismatches, err := w.checkChunk(totals)
+		if err != nil {
+			return err
+		}
+
+		after = totals[len(totals)-1].WalletID
+		if err := w.repo.RecordReconciliationChunk(run.ID, after, len(totals), mismatches); err != nil {
+			return err
+		}
+
+		run.WalletsChecked += len(totals)
+		run.Mismatches += len(mismatches)
+		for _, m := range mismatches {
+			if m.Frozen {
+				run.FrozenWallets++
+			}
+		}
+		metrics.ReconciliationWalletsCheckedTotal.Add(int64(len(totals)))
+		metrics.ReconciliationMismatchesTotal.Add(int64(len(mismatches)))
+
+		if len(totals) < w.cfg.ChunkSize {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.cfg.ChunkPause):
+		}
+	}
+}
+
+// checkChunk находит расхождения порции и при AutoFreeze замораживает разошедшиеся с журналом кошельки
+func (w *Worker) checkChunk(totals []models.WalletLedgerTotals) ([]models.ReconciliationMismatch, error) {
+	var mismatches []models.ReconciliationMismatch
+	for _, t := range totals {
+		for _, m := range t.Mismatches() {
+			if w.cfg.AutoFreeze && m.Reason == models.MismatchLedgerDrift {
+				frozen, err := w.repo.FreezeWallet(m.WalletID)
+				if err != nil {
+					return nil, err
+				}
+				if frozen {
+					m.Frozen = true
+					metrics.ReconciliationWalletsFrozenTotal.Add(1)
+					logger.GlobalLogger.Warning("Кошелек %s заморожен: баланс %s, сумма журнала %s",
+						m.WalletID, m.WalletBalance, m.LedgerBalance)
+				}
+			}
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,175 @@
+package reconcile
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+)
+
+func init() {
+	logger.Init()
+}
+
+type mockReconciliationRepository struct {
+	wallets    []models.WalletLedgerTotals
+	running    bool
+	chunks     int
+	mismatches []models.ReconciliationMismatch
+	frozen     map[uuid.UUID]bool
+	finished   string
+}
+
+func (m *mockReconciliationRepository) StartReconciliationRun(minGap, staleAfter time.Duration) (*models.ReconciliationRun, error) {
+	if m.running {
+		return nil, nil
+	}
+	m.running = true
+	return &models.ReconciliationRun{ID: uuid.New(), Status: models.ReconciliationRunning, StartedAt: time.Now()}, nil
+}
+
+func (m *mockReconciliationRepository) ListWalletLedgerTotals(afterID uuid.UUID, limit int) ([]models.WalletLedgerTotals, error) {
+	var chunk []models.WalletLedgerTotals
+	for _, w := range m.wallets {
+		if w.WalletID.String() > afterID.String() && len(chunk) < limit {
+			chunk = append(chunk, w)
+		}
+	}
+	return chunk, nil
+}
+
+func (m *mockReconciliationRepository) FreezeWallet(walletID uuid.UUID) (bool, error) {
+	if m.frozen[walletID] {
+		return false, nil
+	}
+	m.frozen[walletID] = true
+	return true, nil
+}
+
+func (m *mockReconciliationRepository) RecordReconciliationChunk(runID, lastWalletID uuid.UUID, checked int, mismatches []models.ReconciliationMismatch) error {
+	m.chunks++
+	m.mismatches = append(m.mismatches, mismatches...)
+	return nil
+}
+
+func (m *mockReconciliationRepository) FinishReconciliationRun(runID uuid.UUID, status string) error {
+	m.finished = status
+	m.running = false
+	return nil
+}
+
+func (m *mockReconciliationRepository) GetLatestReconciliationRun() (*models.ReconciliationRun, error) {
+	return nil, nil
+}
+
+func (m *mockReconciliationRepository) ListReconciliationMismatches(runID uuid.UUID, limit int) ([]models.ReconciliationMismatch, error) {
+	return m.mismatches, nil
+}
+
+// walletIDs возвращает n UUID в порядке возрастания, как их отдает курсор репозитория
+func walletIDs(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.UUID{15: byte(i + 1)}
+	}
+	return ids
+}
+
+func TestWorker_RunOnce(t *testing.T) {
+	ids := walletIDs(5)
+	repo := &mockReconciliationRepository{
+		frozen: map[uuid.UUID]bool{},
+		wallets: []models.WalletLedgerTotals{
+			{WalletID: ids[0], Balance: utils.Money{Raw: 100}, LedgerBalance: utils.Money{Raw: 100}},
+			{WalletID: ids[1], Balance: utils.Money{Raw: 150}, LedgerBalance: utils.Money{Raw: 100}},
+			{WalletID: ids[2], Balance: utils.Money{Raw: -50}, LedgerBalance: utils.Money{Raw: -50}},
+			{WalletID: ids[3]},
+			{WalletID: ids[4], Balance: utils.Money{Raw: -10}, LedgerBalance: utils.Money{Raw: 20}},
+		},
+	}
+	worker := NewWorker(repo, WorkerConfig{Interval: time.Hour, ChunkSize: 2, AutoFreeze: true})
+
+	run, err := worker.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if run == nil || run.Status != models.ReconciliationCompleted || repo.finished != models.ReconciliationCompleted {
+		t.Fatalf("run = %+v, finished = %q", run, repo.finished)
+	}
+	if run.WalletsChecked != 5 || repo.chunks != 3 {
+		t.Errorf("checked %d wallets in %d chunks, want 5 in 3", run.WalletsChecked, repo.chunks)
+	}
+
+	type key struct {
+		wallet uuid.UUID
+		reason string
+	}
+	got := map[key]bool{}
+	for _, m := range repo.mismatches {
+		got[key{m.WalletID, m.Reason}] = m.Frozen
+	}
+	want := map[key]bool{
+		{ids[1], models.MismatchLedgerDrift}:     true,
+		{ids[2], models.MismatchNegativeBalance}: false,
+		{ids[4], models.MismatchLedgerDrift}:     true,
+		{ids[4], models.MismatchNegativeBalance}: false,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("mismatches = %v, want %v", got, want)
+	}
+	for k, frozen := range want {
+		if f, ok := got[k]; !ok || f != frozen {
+			t.Errorf("mismatch %v: found = %t, frozen = %t, want frozen = %t", k, ok, f, frozen)
+		}
+	}
+	if run.Mismatches != 4 || run.FrozenWallets != 2 {
+		t.Errorf("run counters = %d mismatches, %d frozen, want 4 and 2", run.Mismatches, run.FrozenWallets)
+	}
+}
+
+func TestWorker_RunOnce_WithoutAutoFreeze(t *testing.T) {
+	ids := walletIDs(1)
+	repo := &mockReconciliationRepository{
+		frozen:  map[uuid.UUID]bool{},
+		wallets: []models.WalletLedgerTotals{{WalletID: ids[0], Balance: utils.Money{Raw: 150}, LedgerBalance: utils.Money{Raw: 100}}},
+	}
+
+	if _, err := NewWorker(repo, WorkerConfig{Interval: time.Hour, ChunkSize: 10}).RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if len(repo.frozen) != 0 || len(repo.mismatches) != 1 || repo.mismatches[0].Frozen {
+		t.Errorf("frozen = %v, mismatches = %+v", repo.frozen, repo.mismatches)
+	}
+}
+
+func TestWorker_RunOnce_SkipsWhenAnotherRunIsActive(t *testing.T) {
+	repo := &mockReconciliationRepository{running: true, frozen: map[uuid.UUID]bool{}}
+
+	run, err := NewWorker(repo, WorkerConfig{Interval: time.Hour, ChunkSize: 10}).RunOnce(context.Background())
+	if err != nil || run != nil {
+		t.Fatalf("RunOnce() = %+v, %v, want nil, nil", run, err)
+	}
+	if repo.finished != "" {
+		t.Errorf("run finished with %q, want untouched", repo.finished)
+	}
+}
+
+func TestWorker_RunOnce_CancelledRunFails(t *testing.T) {
+	repo := &mockReconciliationRepository{frozen: map[uuid.UUID]bool{}}
+	for _, id := range walletIDs(3) {
+		repo.wallets = append(repo.wallets, models.WalletLedgerTotals{WalletID: id})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := NewWorker(repo, WorkerConfig{Interval: time.Hour, ChunkSize: 1, ChunkPause: time.Hour}).RunOnce(ctx); err == nil {
+		t.Fatal("RunOnce() error = nil, want context error")
+	}
+	if repo.finished != models.ReconciliationFailed {
+		t.Errorf("finished = %q, want %q", repo.finished, models.ReconciliationFailed)
+	}
+}
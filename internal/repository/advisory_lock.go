@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// AdvisoryLock - сессионная advisory-блокировка Postgres. Блокировка принадлежит соединению,
+// поэтому оно удерживается вне пула до Release: если соединение оборвется, Postgres снимет блокировку сам
+// и ее сможет взять другая реплика
+type AdvisoryLock struct {
+	db   *sql.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func NewAdvisoryLock(db *sql.DB, key int64) *AdvisoryLock {
+	return &AdvisoryLock{db: db, key: key}
+}
+
+// TryAcquire берет блокировку без ожидания. Если блокировка уже взята, проверяет, что ее соединение живо:
+// после обрыва соединения блокировка потеряна и берется заново
+func (l *AdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire advisory lock: %w", ErrDatabaseError)
+	}
+
+	var acquired bool
+	if err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("acquire advisory lock: %w", ErrDatabaseError)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Release снимает блокировку и возвращает соединение
+func (l *AdvisoryLock) Release() error {
+	if l.conn == nil {
+		return nil
+	}
+	defer func() {
+		l.conn.Close()
+		l.conn = nil
+	}()
+
+	if _, err := l.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		return fmt.Errorf("release advisory lock: %w", ErrDatabaseError)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type APIClientRepository struct {
+	db *sql.DB
+}
+
+func NewAPIClientRepository(db *sql.DB) *APIClientRepository {
+	return &APIClientRepository{db: db}
+}
+
+func (r *APIClientRepository) GetClientByKeyHash(keyHash string) (*models.APIClient, error) {
+	row := r.db.QueryRow(
+		`SELECT 
+		c.id, 
+		c.name, 
+		c.scopes, 
+		COALESCE(c.wallet_ids::TEXT[], '{}'), 
+		c.disabled, 
+		c.created_at 
+		FROM api_keys k 
+		JOIN api_clients c ON c.id = k.client_id 
+		WHERE k.key_hash = $1 
+		AND k.revoked_at IS NULL 
+		AND (k.expires_at IS NULL OR k.expires_at > NOW()) 
+		AND c.disabled = FALSE`,
+		keyHash,
+	)
+
+	client, err := scanAPIClient(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("get client by key hash: %w", ErrAPIKeyNotFound)
+		}
+		return nil, fmt.Errorf("get client by key hash: %w", ErrDatabaseError)
+	}
+
+	return client, nil
+}
+
+// GetClientSigningSecret возвращает активного клиента вместе с секретом для проверки HMAC подписи
+func (r *APIClientRepository) GetClientSigningSecret(clientID string) (*models.APIClient, string, error) {
+	var secret sql.NullString
+
+	row := r.db.QueryRow(
+		`SELECT 
+		id, 
+		name, 
+		scopes, 
+		COALESCE(wallet_ids::TEXT[], '{}'), 
+		disabled, 
+		created_at, 
+		signing_secret 
+		FROM api_clients 
+		WHERE id = $1 
+		AND disabled = FALSE`,
+		clientID,
+	)
+
+	client, err := scanAPIClient(row, &secret)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", fmt.Errorf("get client signing secret: %w", ErrAPIClientNotFound)
+		}
+		return nil, "", fmt.Errorf("get client signing secret: %w", ErrDatabaseError)
+	}
+
+	if !secret.Valid {
+		return nil, "", fmt.Errorf("get client signing secret: %w", ErrAPIClientNotFound)
+	}
+
+	return client, secret.String, nil
+}
+
+func (r *APIClientRepository) SetSigningSecret(clientID, secret string) error {
+	result, err := r.db.Exec(
+		`UPDATE api_clients SET signing_secret = $2 WHERE id = $1`,
+		clientID,
+		secret,
+	)
+	if err != nil {
+		return fmt.Errorf("set signing secret: %w", ErrDatabaseError)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("set signing secret: %w", ErrDatabaseError)
+	}
+	if affected == 0 {
+		return fmt.Errorf("set signing secret: %w", ErrAPIClientNotFound)
+	}
+
+	return nil
+}
+
+func (r *APIClientRepository) CreateClient(client *models.APIClient, key *models.APIKey) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO api_clients (id, name, scopes, wallet_ids, disabled, created_at)
+		VALUES ($1, $2, $3, $4::UUID[], $5, $6)`,
+		client.ID,
+		client.Name,
+		pq.Array(client.Scopes),
+		uuidArray(client.WalletIDs),
+		client.Disabled,
+		client.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create api client: %w", ErrDatabaseError)
+	}
+
+	if err = insertAPIKey(tx, key); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+
+	return nil
+}
+
+// RotateKey добавляет новый ключ и ограничивает срок жизни действующих ключей клиента,
+// чтобы старый и новый ключи работали одновременно до oldKeysExpireAt
+func (r *APIClientRepository) RotateKey(clientID string, newKey *models.APIKey, oldKeysExpireAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	var lockedID uuid.UUID
+	err = tx.QueryRow(
+		`SELECT id FROM api_clients WHERE id = $1 FOR UPDATE`,
+		clientID,
+	).Scan(&lockedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("rotate api key: %w", ErrAPIClientNotFound)
+		}
+		return fmt.Errorf("rotate api key: %w", ErrDatabaseError)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE api_keys 
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2) 
+		WHERE client_id = $1 
+		AND revoked_at IS NULL 
+		AND (expires_at IS NULL OR expires_at > NOW())`,
+		clientID,
+		oldKeysExpireAt,
+	)
+	if err != nil {
+		return fmt.Errorf("expire old api keys: %w", ErrDatabaseError)
+	}
+
+	if err = insertAPIKey(tx, newKey); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+
+	return nil
+}
+
+func insertAPIKey(tx *sql.Tx, key *models.APIKey) error {
+	_, err := tx.Exec(
+		`INSERT INTO api_keys (id, client_id, key_hash, key_prefix, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		key.ID,
+		key.ClientID,
+		key.KeyHash,
+		key.KeyPrefix,
+		key.CreatedAt,
+		key.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create api key: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+func scanAPIClient(row *sql.Row, extra ...interface{}) (*models.APIClient, error) {
+	var client models.APIClient
+	var walletIDs []string
+
+	dest := []interface{}{
+		&client.ID,
+		&client.Name,
+		pq.Array(&client.Scopes),
+		pq.Array(&walletIDs),
+		&client.Disabled,
+		&client.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	for _, raw := range walletIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		client.WalletIDs = append(client.WalletIDs, id)
+	}
+
+	return &client, nil
+}
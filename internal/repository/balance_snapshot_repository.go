@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/lib/pq"
+)
+
+// GetBalanceSnapshot возвращает последний снимок кошелька, сделанный не позже asOf, или nil, если снимков нет
+func (r *WalletRepository) GetBalanceSnapshot(walletID string, asOf time.Time) (*models.BalanceSnapshot, error) {
+	var snapshot models.BalanceSnapshot
+	err := r.db.QueryRow(
+		`SELECT 
+		wallet_id, 
+		ledger_entry_id, 
+		balance, 
+		taken_at 
+		FROM wallet_balance_snapshots 
+		WHERE wallet_id = $1 AND taken_at <= $2 
+		ORDER BY taken_at DESC, ledger_entry_id DESC 
+		LIMIT 1`,
+		walletID,
+		asOf,
+	).Scan(
+		&snapshot.WalletID,
+		&snapshot.LedgerEntryID,
+		&snapshot.Balance,
+		&snapshot.TakenAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get balance snapshot: %w", ErrDatabaseError)
+	}
+
+	return &snapshot, nil
+}
+
+// ReplayLedger суммирует проводки кошелька с ID больше afterID, сделанные не позже until.
+// Возвращает изменение баланса и количество проигранных проводок
+func (r *WalletRepository) ReplayLedger(walletID string, afterID int64, until time.Time) (utils.Money, int, error) {
+	var delta utils.Money
+	var count int
+	err := r.db.QueryRow(
+		`SELECT 
+		COALESCE(SUM(CASE WHEN entry_type = ANY($4) THEN -amount ELSE amount END), 0), 
+		COUNT(*) 
+		FROM ledger_entries 
+		WHERE wallet_id = $1 AND id > $2 AND created_at <= $3`,
+		walletID,
+		afterID,
+		until,
+		pq.Array(models.DebitEntryTypes()),
+	).Scan(&delta, &count)
+	if err != nil {
+		return utils.Money{}, 0, fmt.Errorf("replay ledger: %w", ErrDatabaseError)
+	}
+
+	return delta, count, nil
+}
+
+// CreateBalanceSnapshots снимает баланс всех кошельков, у которых появились проводки после предыдущего снимка.
+// Возвращает количество созданных снимков
+func (r *WalletRepository) CreateBalanceSnapshots() (int64, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO wallet_balance_snapshots (wallet_id, ledger_entry_id, balance, taken_at)
+		SELECT DISTINCT ON (l.wallet_id) l.wallet_id, l.id, l.balance_after, l.created_at
+		FROM ledger_entries l
+		WHERE l.id > (SELECT COALESCE(MAX(ledger_entry_id), 0) FROM wallet_balance_snapshots)
+		ORDER BY l.wallet_id, l.id DESC
+		ON CONFLICT DO NOTHING`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create balance snapshots: %w", ErrDatabaseError)
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("create balance snapshots: %w", ErrDatabaseError)
+	}
+	return created, nil
+}
@@ -5,4 +5,28 @@ import "errors"
 var (
 	ErrWalletNotFound = errors.New("wallet not found in repository")
 	ErrDatabaseError  = errors.New("database error")
+	// ErrInsufficientFunds возвращается, когда нехватка средств обнаружена под блокировкой строки
+	ErrInsufficientFunds = errors.New("insufficient funds in repository")
+	// ErrWalletFrozen возвращается при попытке изменить баланс замороженного кошелька
+	ErrWalletFrozen = errors.New("wallet is frozen in repository")
+	// ErrUnbalancedJournal возвращается, когда сумма проводок записи журнала не равна нулю
+	ErrUnbalancedJournal = errors.New("unbalanced journal in repository")
+	ErrAPIKeyNotFound    = errors.New("api key not found in repository")
+	ErrAPIClientNotFound = errors.New("api client not found in repository")
+
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found in repository")
+	ErrWebhookDeliveryNotFound     = errors.New("webhook delivery not found in repository")
+
+	ErrImportJobNotFound = errors.New("import job not found in repository")
+
+	ErrReconciliationRunNotFound = errors.New("reconciliation run not found in repository")
+
+	ErrWalletTierNotFound = errors.New("wallet tier not found in repository")
+
+	// ErrFeeExceedsAmount возвращается, когда комиссия пополнения не меньше его суммы
+	ErrFeeExceedsAmount = errors.New("fee exceeds operation amount in repository")
+	// ErrOverdraftBelowDebt возвращается, когда новая кредитная линия меньше текущего долга кошелька
+	ErrOverdraftBelowDebt = errors.New("overdraft limit below wallet debt in repository")
+
+	ErrScheduledTransferNotFound = errors.New("scheduled transfer not found in repository")
 )
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+)
+
+// feeRuleColumns - колонки fee_rules в порядке, который ожидает scanFeeRule
+const feeRuleColumns = `operation_type, tier, kind, flat_amount, rate_bp, brackets, min_fee, max_fee, rounding`
+
+// feeBracketJSON - ступень тарифа в колонке brackets
+type feeBracketJSON struct {
+	From            int64 `json:"from"`
+	Flat            int64 `json:"flat"`
+	RateBasisPoints int64 `json:"rateBp"`
+}
+
+// scanFeeRule читает правило из колонок feeRuleColumns; dest - колонки, выбранные перед ними
+func scanFeeRule(row rowScanner, dest ...interface{}) (*models.FeeRule, error) {
+	var rule models.FeeRule
+	var tier sql.NullString
+	var brackets []byte
+	var minFee, maxFee sql.NullInt64
+	var rounding string
+	if err := row.Scan(append(
+		dest,
+		&rule.OperationType,
+		&tier,
+		&rule.Kind,
+		&rule.Flat,
+		&rule.RateBasisPoints,
+		&brackets,
+		&minFee,
+		&maxFee,
+		&rounding,
+	)...); err != nil {
+		return nil, err
+	}
+
+	var stored []feeBracketJSON
+	if err := json.Unmarshal(brackets, &stored); err != nil {
+		return nil, err
+	}
+	for _, bracket := range stored {
+		rule.Brackets = append(rule.Brackets, models.FeeBracket{
+			From:            utils.Money{Raw: bracket.From},
+			Flat:            utils.Money{Raw: bracket.Flat},
+			RateBasisPoints: bracket.RateBasisPoints,
+		})
+	}
+
+	rule.Tier = tier.String
+	if minFee.Valid {
+		rule.Min = &utils.Money{Raw: minFee.Int64}
+	}
+	if maxFee.Valid {
+		rule.Max = &utils.Money{Raw: maxFee.Int64}
+	}
+	rule.Rounding = utils.Rounding(rounding)
+	return &rule, nil
+}
+
+// queryFeeRule возвращает правило комиссии для операции с учетом уровня кошелька или nil, если правила нет.
+// Кошелек, которого еще нет, считается кошельком уровня models.DefaultWalletTier: пополнение создает его с этим уровнем
+func queryFeeRule(q queryRower, walletID, operationType string) (*models.FeeRule, error) {
+	rule, err := scanFeeRule(q.QueryRow(
+		`SELECT `+feeRuleColumns+` FROM fee_rules
+		WHERE operation_type = $1
+		AND (tier IS NULL OR tier = COALESCE((SELECT tier FROM wallets WHERE id = $2), $3))
+		ORDER BY tier NULLS LAST
+		LIMIT 1`,
+		operationType,
+		walletID,
+		models.DefaultWalletTier,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get fee rule: %w", ErrDatabaseError)
+	}
+	return rule, nil
+}
+
+// computeFee считает комиссию операции на amount по правилу, действующему для кошелька
+func computeFee(q queryRower, walletID, operationType string, amount utils.Money) (models.Fee, error) {
+	rule, err := queryFeeRule(q, walletID, operationType)
+	if err != nil {
+		return models.Fee{}, err
+	}
+
+	var fee utils.Money
+	if rule != nil {
+		fee = rule.Compute(amount)
+	}
+	return models.NewFee(operationType, amount, fee), nil
+}
+
+type FeeRepository struct {
+	db *sql.DB
+}
+
+func NewFeeRepository(db *sql.DB) *FeeRepository {
+	return &FeeRepository{db: db}
+}
+
+// ListFeeRules возвращает все правила комиссий по операциям; общее правило операции идет перед правилами уровней
+func (r *FeeRepository) ListFeeRules() ([]models.FeeRule, error) {
+	rows, err := r.db.Query(`SELECT ` + feeRuleColumns + ` FROM fee_rules ORDER BY operation_type, tier NULLS FIRST`)
+	if err != nil {
+		return nil, fmt.Errorf("list fee rules: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	rules := make([]models.FeeRule, 0)
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan fee rule: %w", ErrDatabaseError)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list fee rules: %w", ErrDatabaseError)
+	}
+	return rules, nil
+}
+
+// ReplaceFeeRules заменяет все правила комиссий одной транзакцией: операции видят либо старый, либо новый набор
+func (r *FeeRepository) ReplaceFeeRules(rules []models.FeeRule) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM fee_rules`); err != nil {
+		return fmt.Errorf("delete fee rules: %w", ErrDatabaseError)
+	}
+
+	for _, rule := range rules {
+		var tier sql.NullString
+		if rule.Tier != "" {
+			var tierExists bool
+			if err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM wallet_tiers WHERE code = $1)`, rule.Tier).Scan(&tierExists); err != nil {
+				return fmt.Errorf("replace fee rules: %w", ErrDatabaseError)
+			}
+			if !tierExists {
+				return fmt.Errorf("replace fee rules: %w", ErrWalletTierNotFound)
+			}
+			tier = sql.NullString{String: rule.Tier, Valid: true}
+		}
+
+		brackets := make([]feeBracketJSON, 0, len(rule.Brackets))
+		for _, bracket := range rule.Brackets {
+			brackets = append(brackets, feeBracketJSON{
+				From:            bracket.From.Raw,
+				Flat:            bracket.Flat.Raw,
+				RateBasisPoints: bracket.RateBasisPoints,
+			})
+		}
+		encoded, err := json.Marshal(brackets)
+		if err != nil {
+			return fmt.Errorf("encode fee brackets: %w", err)
+		}
+
+		var minFee, maxFee sql.NullInt64
+		if rule.Min != nil {
+			minFee = sql.NullInt64{Int64: rule.Min.Raw, Valid: true}
+		}
+		if rule.Max != nil {
+			maxFee = sql.NullInt64{Int64: rule.Max.Raw, Valid: true}
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO fee_rules (`+feeRuleColumns+`, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())`,
+			rule.OperationType,
+			tier,
+			rule.Kind,
+			rule.Flat,
+			rule.RateBasisPoints,
+			string(encoded),
+			minFee,
+			maxFee,
+			string(rule.Rounding),
+		)
+		if err != nil {
+			return fmt.Errorf("insert fee rule: %w", ErrDatabaseError)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// QuoteFee считает комиссию операции так же, как ее посчитает проведение операции, но ничего не меняет
+func (r *FeeRepository) QuoteFee(walletID, operationType string, amount utils.Money) (models.Fee, error) {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM wallets WHERE id = $1)`, walletID).Scan(&exists); err != nil {
+		return models.Fee{}, fmt.Errorf("quote fee: %w", ErrDatabaseError)
+	}
+	if !exists {
+		return models.Fee{}, fmt.Errorf("quote fee: %w", ErrWalletNotFound)
+	}
+	return computeFee(r.db, walletID, operationType, amount)
+}
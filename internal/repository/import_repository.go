@@ -0,0 +1,518 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ImportRepository struct {
+	db *sql.DB
+}
+
+func NewImportRepository(db *sql.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+const importJobColumns = `
+		id,
+		client_id,
+		status,
+		dry_run,
+		total_rows,
+		processed_rows,
+		succeeded_rows,
+		failed_rows,
+		duplicate_rows,
+		created_at,
+		started_at,
+		finished_at`
+
+const importRowColumns = `
+		job_id,
+		row_number,
+		wallet_id,
+		operation_type,
+		amount,
+		external_ref,
+		status,
+		balance_after,
+		fee,
+		COALESCE(error, '')`
+
+// CreateImportJob сохраняет задание и все его строки одной транзакцией
+func (r *ImportRepository) CreateImportJob(job *models.ImportJob, rows []models.ImportRow) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO import_jobs (id, client_id, status, dry_run, total_rows, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		job.ID,
+		job.ClientID,
+		job.Status,
+		job.DryRun,
+		job.TotalRows,
+		job.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create import job: %w", ErrDatabaseError)
+	}
+
+	numbers := make([]int64, 0, len(rows))
+	walletIDs := make([]string, 0, len(rows))
+	operationTypes := make([]string, 0, len(rows))
+	amounts := make([]int64, 0, len(rows))
+	refs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		numbers = append(numbers, int64(row.RowNumber))
+		walletIDs = append(walletIDs, row.WalletID.String())
+		operationTypes = append(operationTypes, row.OperationType)
+		amounts = append(amounts, row.Amount.Raw)
+		refs = append(refs, row.ExternalRef)
+	}
+
+	// Все строки вставляются одним запросом через unnest, а не по запросу на строку
+	_, err = tx.Exec(
+		`INSERT INTO import_rows (job_id, row_number, wallet_id, operation_type, amount, external_ref, status)
+		SELECT $1, n, w, o, a, e, $7
+		FROM unnest($2::int[], $3::uuid[], $4::text[], $5::bigint[], $6::text[]) AS t(n, w, o, a, e)`,
+		job.ID,
+		pq.Array(numbers),
+		pq.Array(walletIDs),
+		pq.Array(operationTypes),
+		pq.Array(amounts),
+		pq.Array(refs),
+		models.ImportRowPending,
+	)
+	if err != nil {
+		return fmt.Errorf("create import rows: %w", ErrDatabaseError)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+func (r *ImportRepository) GetImportJob(id string) (*models.ImportJob, error) {
+	job, err := scanImportJob(r.db.QueryRow(`SELECT`+importJobColumns+` FROM import_jobs WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("get import job: %w", ErrImportJobNotFound)
+		}
+		return nil, fmt.Errorf("get import job: %w", ErrDatabaseError)
+	}
+	return job, nil
+}
+
+// ListImportRows возвращает строки задания с номером больше afterRow в порядке файла
+func (r *ImportRepository) ListImportRows(jobID string, afterRow, limit int) ([]models.ImportRow, error) {
+	return r.queryImportRows(
+		`SELECT`+importRowColumns+`
+		FROM import_rows
+		WHERE job_id = $1 AND row_number > $2
+		ORDER BY row_number
+		LIMIT $3`,
+		jobID,
+		afterRow,
+		limit,
+	)
+}
+
+// ListPendingImportRows возвращает еще не обработанные строки задания
+func (r *ImportRepository) ListPendingImportRows(jobID uuid.UUID, limit int) ([]models.ImportRow, error) {
+	return r.queryImportRows(
+		`SELECT`+importRowColumns+`
+		FROM import_rows
+		WHERE job_id = $1 AND status = $2
+		ORDER BY row_number
+		LIMIT $3`,
+		jobID,
+		models.ImportRowPending,
+		limit,
+	)
+}
+
+// ClaimImportJob переводит в running самое старое ожидающее задание или задание, обработчик которого
+// не подавал признаков жизни дольше staleAfter. Если заданий нет, возвращает nil.
+// Пробный прогон при повторном захвате начинается заново: его прогноз зависит от уже просчитанных строк
+func (r *ImportRepository) ClaimImportJob(staleAfter time.Duration) (*models.ImportJob, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	job, err := scanImportJob(tx.QueryRow(
+		`UPDATE import_jobs
+		SET status = $1, started_at = COALESCE(started_at, NOW()), heartbeat_at = NOW()
+		WHERE id = (
+			SELECT id FROM import_jobs
+			WHERE status = $2 OR (status = $1 AND heartbeat_at < NOW() - $3 * INTERVAL '1 millisecond')
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING`+importJobColumns,
+		models.ImportJobRunning,
+		models.ImportJobPending,
+		staleAfter.Milliseconds(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim import job: %w", ErrDatabaseError)
+	}
+
+	if job.DryRun && job.ProcessedRows > 0 {
+		_, err = tx.Exec(
+			`UPDATE import_rows SET status = $2, balance_after = NULL, fee = NULL, error = NULL WHERE job_id = $1`,
+			job.ID,
+			models.ImportRowPending,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("reset import rows: %w", ErrDatabaseError)
+		}
+		_, err = tx.Exec(
+			`UPDATE import_jobs
+			SET processed_rows = 0, succeeded_rows = 0, failed_rows = 0, duplicate_rows = 0
+			WHERE id = $1`,
+			job.ID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("reset import job: %w", ErrDatabaseError)
+		}
+		job.ProcessedRows, job.SucceededRows, job.FailedRows, job.DuplicateRows = 0, 0, 0, 0
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return job, nil
+}
+
+// ApplyImportRow проводит операцию строки. Внешний референс занимается в той же транзакции, что и баланс,
+// поэтому повторная строка с тем же референсом получает статус duplicate и баланс не меняет.
+// Результат строки сохраняется в любом случае; ошибка возвращается только при сбое БД
+func (r *ImportRepository) ApplyImportRow(row models.ImportRow) (models.ImportRowResult, error) {
+	result, err := r.applyImportRow(row)
+	if err != nil {
+		return result, err
+	}
+	if result.Status == models.ImportRowSucceeded {
+		return result, nil
+	}
+
+	// Операция не проведена, транзакция откачена вместе с референсом: строку можно исправить и загрузить снова
+	if err := r.RecordImportRowResult(row, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (r *ImportRepository) applyImportRow(row models.ImportRow) (models.ImportRowResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.ImportRowResult{}, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	claimed, err := tx.Exec(
+		`INSERT INTO import_external_refs (external_ref, job_id, row_number)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (external_ref) DO NOTHING`,
+		row.ExternalRef,
+		row.JobID,
+		row.RowNumber,
+	)
+	if err != nil {
+		return models.ImportRowResult{}, fmt.Errorf("claim external ref: %w", ErrDatabaseError)
+	}
+	if affected, err := claimed.RowsAffected(); err != nil {
+		return models.ImportRowResult{}, fmt.Errorf("claim external ref: %w", ErrDatabaseError)
+	} else if affected == 0 {
+		return models.ImportRowResult{Status: models.ImportRowDuplicate, Error: models.ImportErrorDuplicateRef}, nil
+	}
+
+	var wallet models.Wallet
+	err = tx.QueryRow(
+		`SELECT balance, overdraft_limit, status FROM wallets WHERE id = $1 FOR UPDATE`,
+		row.WalletID,
+	).Scan(&wallet.Balance, &wallet.OverdraftLimit, &wallet.Status)
+	if err == sql.ErrNoRows {
+		return models.ImportRowResult{Status: models.ImportRowFailed, Error: models.ImportErrorWalletNotFound}, nil
+	}
+	if err != nil {
+		return models.ImportRowResult{}, fmt.Errorf("lock wallet: %w", ErrDatabaseError)
+	}
+	if wallet.Status == models.WalletStatusFrozen {
+		return models.ImportRowResult{Status: models.ImportRowFailed, Error: models.ImportErrorWalletFrozen}, nil
+	}
+
+	// Комиссия считается по тем же правилам, что и у операции через API, и проводится в той же транзакции
+	fee, err := computeFee(tx, row.WalletID.String(), row.OperationType, row.Amount)
+	if err != nil {
+		return models.ImportRowResult{}, err
+	}
+	if !fee.CoversFee() {
+		return models.ImportRowResult{Status: models.ImportRowFailed, Error: models.ImportErrorFeeExceedsAmount}, nil
+	}
+	if row.OperationType == models.OperationTypeWithdraw && !wallet.CanDebit(fee.Gross) {
+		return models.ImportRowResult{Status: models.ImportRowFailed, Error: models.ImportErrorInsufficientFunds}, nil
+	}
+
+	updated, _, err := applyWalletDelta(tx, row.WalletID.String(), row.OperationType, row.Amount)
+	if err == nil && !fee.Fee.IsZero() {
+		updated, _, err = applyWalletDelta(tx, row.WalletID.String(), models.LedgerEntryFee, fee.Fee)
+	}
+	var limit *models.LimitExceededError
+	if errors.As(err, &limit) {
+		return models.ImportRowResult{Status: models.ImportRowFailed, Error: limit.Message()}, nil
+	}
+	if err != nil {
+		return models.ImportRowResult{}, err
+	}
+	journal := models.NewOperationJournal(row.WalletID, row.OperationType, row.Amount)
+	journal.ChargeFee(row.WalletID, fee.Fee)
+	if err = insertJournal(tx, journal); err != nil {
+		return models.ImportRowResult{}, err
+	}
+
+	result := models.ImportRowResult{Status: models.ImportRowSucceeded, BalanceAfter: &updated.Balance, Fee: &fee.Fee}
+	if err = recordImportRowResult(tx, row, result); err != nil {
+		return models.ImportRowResult{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.ImportRowResult{}, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return result, nil
+}
+
+// RecordImportRowResult сохраняет результат строки и увеличивает счетчики задания
+func (r *ImportRepository) RecordImportRowResult(row models.ImportRow, result models.ImportRowResult) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	if err = recordImportRowResult(tx, row, result); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// recordImportRowResult обновляет только строку в статусе pending, чтобы при повторном захвате задания
+// счетчики не увеличились дважды для одной строки
+func recordImportRowResult(tx *sql.Tx, row models.ImportRow, result models.ImportRowResult) error {
+	var errorText sql.NullString
+	if result.Error != "" {
+		errorText = sql.NullString{String: result.Error, Valid: true}
+	}
+
+	updated, err := tx.Exec(
+		`UPDATE import_rows
+		SET status = $3, balance_after = $4, fee = $5, error = $6
+		WHERE job_id = $1 AND row_number = $2 AND status = $7`,
+		row.JobID,
+		row.RowNumber,
+		result.Status,
+		result.BalanceAfter,
+		result.Fee,
+		errorText,
+		models.ImportRowPending,
+	)
+	if err != nil {
+		return fmt.Errorf("record import row: %w", ErrDatabaseError)
+	}
+	if affected, err := updated.RowsAffected(); err != nil {
+		return fmt.Errorf("record import row: %w", ErrDatabaseError)
+	} else if affected == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(
+		`UPDATE import_jobs
+		SET processed_rows = processed_rows + 1,
+		succeeded_rows = succeeded_rows + CASE WHEN $2 = $3 THEN 1 ELSE 0 END,
+		failed_rows = failed_rows + CASE WHEN $2 = $4 THEN 1 ELSE 0 END,
+		duplicate_rows = duplicate_rows + CASE WHEN $2 = $5 THEN 1 ELSE 0 END,
+		heartbeat_at = NOW()
+		WHERE id = $1`,
+		row.JobID,
+		result.Status,
+		models.ImportRowSucceeded,
+		models.ImportRowFailed,
+		models.ImportRowDuplicate,
+	)
+	if err != nil {
+		return fmt.Errorf("update import job progress: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// CompleteImportJob завершает задание, у которого не осталось необработанных строк
+func (r *ImportRepository) CompleteImportJob(jobID uuid.UUID) error {
+	_, err := r.db.Exec(
+		`UPDATE import_jobs SET status = $2, finished_at = NOW() WHERE id = $1`,
+		jobID,
+		models.ImportJobCompleted,
+	)
+	if err != nil {
+		return fmt.Errorf("complete import job: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// GetImportWallets возвращает текущие балансы и кредитные линии найденных кошельков для пробного прогона
+func (r *ImportRepository) GetImportWallets(walletIDs []uuid.UUID) (map[uuid.UUID]models.Wallet, error) {
+	rows, err := r.db.Query(`SELECT id, balance, overdraft_limit FROM wallets WHERE id = ANY($1::uuid[])`, uuidArray(walletIDs))
+	if err != nil {
+		return nil, fmt.Errorf("get import wallets: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	wallets := make(map[uuid.UUID]models.Wallet, len(walletIDs))
+	for rows.Next() {
+		var wallet models.Wallet
+		if err := rows.Scan(&wallet.ID, &wallet.Balance, &wallet.OverdraftLimit); err != nil {
+			return nil, fmt.Errorf("scan import wallet: %w", ErrDatabaseError)
+		}
+		wallets[wallet.ID] = wallet
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get import wallets: %w", ErrDatabaseError)
+	}
+	return wallets, nil
+}
+
+// GetImportFeeRules возвращает для каждого найденного кошелька правила комиссий, которые действуют для него
+// при пополнении и списании, - так же, как их выбирает проведение строки. Операция без правила в списке отсутствует
+func (r *ImportRepository) GetImportFeeRules(walletIDs []uuid.UUID) (map[uuid.UUID][]models.FeeRule, error) {
+	rows, err := r.db.Query(
+		`SELECT DISTINCT ON (w.wallet_id, operation_type) w.wallet_id, `+feeRuleColumns+`
+		FROM (SELECT id AS wallet_id, tier AS wallet_tier FROM wallets WHERE id = ANY($1::uuid[])) w
+		JOIN fee_rules ON tier IS NULL OR tier = w.wallet_tier
+		WHERE operation_type IN ($2, $3)
+		ORDER BY w.wallet_id, operation_type, tier NULLS LAST`,
+		uuidArray(walletIDs),
+		models.OperationTypeDeposit,
+		models.OperationTypeWithdraw,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get import fee rules: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	rules := make(map[uuid.UUID][]models.FeeRule, len(walletIDs))
+	for rows.Next() {
+		var walletID uuid.UUID
+		rule, err := scanFeeRule(rows, &walletID)
+		if err != nil {
+			return nil, fmt.Errorf("scan import fee rule: %w", ErrDatabaseError)
+		}
+		rules[walletID] = append(rules[walletID], *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get import fee rules: %w", ErrDatabaseError)
+	}
+	return rules, nil
+}
+
+// FindUsedExternalRefs возвращает референсы из refs, операции по которым уже проведены
+func (r *ImportRepository) FindUsedExternalRefs(refs []string) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT external_ref FROM import_external_refs WHERE external_ref = ANY($1)`, pq.Array(refs))
+	if err != nil {
+		return nil, fmt.Errorf("find external refs: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	used := make(map[string]bool)
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return nil, fmt.Errorf("scan external ref: %w", ErrDatabaseError)
+		}
+		used[ref] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("find external refs: %w", ErrDatabaseError)
+	}
+	return used, nil
+}
+
+func (r *ImportRepository) queryImportRows(query string, args ...interface{}) ([]models.ImportRow, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list import rows: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	var result []models.ImportRow
+	for rows.Next() {
+		var row models.ImportRow
+		var balance, fee sql.NullInt64
+		if err := rows.Scan(
+			&row.JobID,
+			&row.RowNumber,
+			&row.WalletID,
+			&row.OperationType,
+			&row.Amount,
+			&row.ExternalRef,
+			&row.Status,
+			&balance,
+			&fee,
+			&row.Error,
+		); err != nil {
+			return nil, fmt.Errorf("scan import row: %w", ErrDatabaseError)
+		}
+		if balance.Valid {
+			row.BalanceAfter = &utils.Money{Raw: balance.Int64}
+		}
+		if fee.Valid {
+			row.Fee = &utils.Money{Raw: fee.Int64}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list import rows: %w", ErrDatabaseError)
+	}
+	return result, nil
+}
+
+func scanImportJob(row rowScanner) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := row.Scan(
+		&job.ID,
+		&job.ClientID,
+		&job.Status,
+		&job.DryRun,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.SucceededRows,
+		&job.FailedRows,
+		&job.DuplicateRows,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
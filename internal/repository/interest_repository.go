@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+type InterestRepository struct {
+	db *sql.DB
+}
+
+func NewInterestRepository(db *sql.DB) *InterestRepository {
+	return &InterestRepository{db: db}
+}
+
+// GetLastAccrualDay возвращает последний день, за который начисление завершено, или nil, если начислений еще не было
+func (r *InterestRepository) GetLastAccrualDay() (*time.Time, error) {
+	var day sql.NullTime
+	if err := r.db.QueryRow(`SELECT MAX(accrual_date) FROM interest_accrual_days`).Scan(&day); err != nil {
+		return nil, fmt.Errorf("get last accrual day: %w", ErrDatabaseError)
+	}
+	if !day.Valid {
+		return nil, nil
+	}
+	return &day.Time, nil
+}
+
+// AccrueInterest начисляет проценты за день day всем кошелькам с положительным остатком на конец дня
+// и возвращает число начислений. День отмечается завершенным в той же транзакции, поэтому повторный вызов
+// за тот же день ничего не начисляет
+func (r *InterestRepository) AccrueInterest(day time.Time, rateBP int64) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO interest_accrual_days (accrual_date, rate_bp, wallets)
+		VALUES ($1::date, $2, 0)
+		ON CONFLICT DO NOTHING`,
+		day,
+		rateBP,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("mark accrual day: %w", ErrDatabaseError)
+	}
+	if marked, err := res.RowsAffected(); err != nil || marked == 0 {
+		return 0, nil
+	}
+
+	var accrued int64
+	if rateBP > 0 {
+		// Остаток на конец дня - balance_after последней проводки до полуночи следующего дня
+		res, err = tx.Exec(
+			`INSERT INTO interest_accruals (wallet_id, accrual_date, balance, rate_bp, days_in_year)
+			SELECT w.id, $1::date, last.balance_after, $2, $3
+			FROM wallets w
+			CROSS JOIN LATERAL (
+				SELECT balance_after FROM ledger_entries
+				WHERE wallet_id = w.id AND created_at < $4
+				ORDER BY created_at DESC, id DESC
+				LIMIT 1
+			) last
+			WHERE last.balance_after > 0
+			ON CONFLICT DO NOTHING`,
+			day,
+			rateBP,
+			models.DaysInYear(day),
+			day.AddDate(0, 0, 1),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("accrue interest: %w", ErrDatabaseError)
+		}
+		if accrued, err = res.RowsAffected(); err != nil {
+			return 0, fmt.Errorf("accrue interest: %w", ErrDatabaseError)
+		}
+	}
+
+	if _, err = tx.Exec(`UPDATE interest_accrual_days SET wallets = $2 WHERE accrual_date = $1::date`, day, accrued); err != nil {
+		return 0, fmt.Errorf("mark accrual day: %w", ErrDatabaseError)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return accrued, nil
+}
+
+// ListUnpostedInterest возвращает до limit пар кошелек-месяц с невыплаченными начислениями за дни раньше before,
+// следующих за (afterPeriod, afterWalletID). Замороженные кошельки пропускаются: их проценты выплатятся после разморозки
+func (r *InterestRepository) ListUnpostedInterest(before, afterPeriod time.Time, afterWalletID uuid.UUID, limit int) ([]models.InterestPosting, error) {
+	rows, err := r.db.Query(
+		`SELECT a.wallet_id, date_trunc('month', a.accrual_date)::date AS period
+		FROM interest_accruals a
+		JOIN wallets w ON w.id = a.wallet_id
+		WHERE a.posted_at IS NULL AND a.accrual_date < $1::date AND w.status <> $2
+		AND (date_trunc('month', a.accrual_date)::date, a.wallet_id) > ($3::date, $4)
+		GROUP BY a.wallet_id, period
+		ORDER BY period, a.wallet_id
+		LIMIT $5`,
+		before,
+		models.WalletStatusFrozen,
+		afterPeriod,
+		afterWalletID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list unposted interest: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	postings := make([]models.InterestPosting, 0, limit)
+	for rows.Next() {
+		var posting models.InterestPosting
+		if err := rows.Scan(&posting.WalletID, &posting.Period); err != nil {
+			return nil, fmt.Errorf("scan unposted interest: %w", ErrDatabaseError)
+		}
+		postings = append(postings, posting)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list unposted interest: %w", ErrDatabaseError)
+	}
+	return postings, nil
+}
+
+// PostInterest выплачивает кошельку проценты за месяц period: складывает точные дневные начисления,
+// округляет сумму по rounding и зачисляет ее проводкой INTEREST со счета interest. Начисления отмечаются выплаченными
+// в той же транзакции. Возвращает nil, если невыплаченных начислений за месяц не осталось
+func (r *InterestRepository) PostInterest(walletID uuid.UUID, period time.Time, rounding utils.Rounding) (*models.InterestPosting, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM wallets WHERE id = $1 FOR UPDATE`, walletID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("post interest: %w", ErrWalletNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("post interest: %w", ErrDatabaseError)
+	}
+	if status == models.WalletStatusFrozen {
+		return nil, fmt.Errorf("post interest: %w", ErrWalletFrozen)
+	}
+
+	accruals, err := queryUnpostedAccruals(tx, walletID, period)
+	if err != nil {
+		return nil, err
+	}
+	if len(accruals) == 0 {
+		return nil, nil
+	}
+
+	posting := &models.InterestPosting{
+		WalletID: walletID,
+		Period:   period,
+		Amount:   models.SumInterest(accruals, rounding),
+		Days:     len(accruals),
+	}
+
+	// Первичный ключ выплат не дает выплатить месяц дважды, даже если начисления за него появились позже
+	res, err := tx.Exec(
+		`INSERT INTO interest_postings (wallet_id, period, amount, days)
+		VALUES ($1, $2::date, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		walletID,
+		period,
+		posting.Amount,
+		posting.Days,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert interest posting: %w", ErrDatabaseError)
+	}
+	if inserted, err := res.RowsAffected(); err != nil || inserted == 0 {
+		return nil, fmt.Errorf("insert interest posting: %w", ErrDatabaseError)
+	}
+
+	if !posting.Amount.IsZero() {
+		_, entryID, err := applyWalletDelta(tx, walletID.String(), models.LedgerEntryInterest, posting.Amount)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(
+			`UPDATE interest_postings SET ledger_entry_id = $3 WHERE wallet_id = $1 AND period = $2::date`,
+			walletID,
+			period,
+			entryID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("link interest posting: %w", ErrDatabaseError)
+		}
+		posting.LedgerEntryID = &entryID
+		if err = insertJournal(tx, models.NewOperationJournal(walletID, models.LedgerEntryInterest, posting.Amount)); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE interest_accruals SET posted_at = NOW()
+		WHERE wallet_id = $1 AND accrual_date >= $2::date AND accrual_date < $3::date AND posted_at IS NULL`,
+		walletID,
+		period,
+		period.AddDate(0, 1, 0),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("mark interest posted: %w", ErrDatabaseError)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return posting, nil
+}
+
+func queryUnpostedAccruals(tx *sql.Tx, walletID uuid.UUID, period time.Time) ([]models.InterestAccrual, error) {
+	rows, err := tx.Query(
+		`SELECT wallet_id, accrual_date, balance, rate_bp, days_in_year
+		FROM interest_accruals
+		WHERE wallet_id = $1 AND accrual_date >= $2::date AND accrual_date < $3::date AND posted_at IS NULL
+		ORDER BY accrual_date`,
+		walletID,
+		period,
+		period.AddDate(0, 1, 0),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query interest accruals: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	var accruals []models.InterestAccrual
+	for rows.Next() {
+		var accrual models.InterestAccrual
+		if err := rows.Scan(&accrual.WalletID, &accrual.AccrualDate, &accrual.Balance, &accrual.RateBP, &accrual.DaysInYear); err != nil {
+			return nil, fmt.Errorf("scan interest accrual: %w", ErrDatabaseError)
+		}
+		accruals = append(accruals, accrual)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query interest accruals: %w", ErrDatabaseError)
+	}
+	return accruals, nil
+}
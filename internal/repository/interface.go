@@ -1,12 +1,112 @@
 package repository
 
 import (
+	"context"
+	"time"
 	"wallet-api/internal/models"
 	"wallet-api/utils"
+
+	"github.com/google/uuid"
 )
 
 type WalletRepositoryInterface interface {
 	GetWalletByID(walletID string) (*models.Wallet, error)
-	UpdateWalletBalance(walletID, operationType string, amount utils.Money) (*models.Wallet, error)
+	GetWalletsByIDs(walletIDs []uuid.UUID) ([]models.Wallet, error)
+	ListWallets(filter models.WalletFilter) ([]models.Wallet, error)
+	UpdateWalletBalance(walletID, operationType string, amount utils.Money, ownerID string) (*models.OperationResult, error)
 	CreateWallet(wallet *models.Wallet) error
+	ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error)
+	GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error)
+	GetBalanceSnapshot(walletID string, asOf time.Time) (*models.BalanceSnapshot, error)
+	ReplayLedger(walletID string, afterID int64, until time.Time) (utils.Money, int, error)
+	OpenStatement(ctx context.Context, walletID string, from, to time.Time) (StatementCursor, error)
+	TransferBetweenWallets(fromID, toID string, amount utils.Money) (*models.TransferResult, error)
+}
+
+type BalanceSnapshotRepositoryInterface interface {
+	CreateBalanceSnapshots() (int64, error)
+}
+
+type APIClientRepositoryInterface interface {
+	GetClientByKeyHash(keyHash string) (*models.APIClient, error)
+	CreateClient(client *models.APIClient, key *models.APIKey) error
+	RotateKey(clientID string, newKey *models.APIKey, oldKeysExpireAt time.Time) error
+	GetClientSigningSecret(clientID string) (*models.APIClient, string, error)
+	SetSigningSecret(clientID, secret string) error
+}
+
+type OutboxRepositoryInterface interface {
+	ClaimBatch(limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	AckBatch(acks []models.OutboxAck) error
+}
+
+type WebhookRepositoryInterface interface {
+	CreateSubscription(sub *models.WebhookSubscription) error
+	ListSubscriptions(clientID *uuid.UUID) ([]models.WebhookSubscription, error)
+	GetSubscription(id string) (*models.WebhookSubscription, error)
+	DeleteSubscription(id string) error
+	FindSubscriptionsForWallet(walletID uuid.UUID) ([]models.WebhookSubscription, error)
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	ListDeliveries(subscriptionID string, limit int) ([]models.WebhookDelivery, error)
+	ReplayDelivery(subscriptionID, deliveryID string) error
+	ClaimDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(attempt models.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error
+}
+
+type ImportRepositoryInterface interface {
+	CreateImportJob(job *models.ImportJob, rows []models.ImportRow) error
+	GetImportJob(id string) (*models.ImportJob, error)
+	ListImportRows(jobID string, afterRow, limit int) ([]models.ImportRow, error)
+	ListPendingImportRows(jobID uuid.UUID, limit int) ([]models.ImportRow, error)
+	ClaimImportJob(staleAfter time.Duration) (*models.ImportJob, error)
+	ApplyImportRow(row models.ImportRow) (models.ImportRowResult, error)
+	RecordImportRowResult(row models.ImportRow, result models.ImportRowResult) error
+	CompleteImportJob(jobID uuid.UUID) error
+	GetImportWallets(walletIDs []uuid.UUID) (map[uuid.UUID]models.Wallet, error)
+	GetImportFeeRules(walletIDs []uuid.UUID) (map[uuid.UUID][]models.FeeRule, error)
+	FindUsedExternalRefs(refs []string) (map[string]bool, error)
+}
+
+type JournalRepositoryInterface interface {
+	GetTrialBalance(ctx context.Context) (*models.TrialBalance, error)
+	ListLedgerChain(ctx context.Context, walletID *uuid.UUID, afterWalletID uuid.UUID, afterID int64, limit int) ([]models.LedgerEntry, error)
+}
+
+type ReconciliationRepositoryInterface interface {
+	StartReconciliationRun(minGap, staleAfter time.Duration) (*models.ReconciliationRun, error)
+	ListWalletLedgerTotals(afterID uuid.UUID, limit int) ([]models.WalletLedgerTotals, error)
+	FreezeWallet(walletID uuid.UUID) (bool, error)
+	RecordReconciliationChunk(runID, lastWalletID uuid.UUID, checked int, mismatches []models.ReconciliationMismatch) error
+	FinishReconciliationRun(runID uuid.UUID, status string) error
+	GetLatestReconciliationRun() (*models.ReconciliationRun, error)
+	ListReconciliationMismatches(runID uuid.UUID, limit int) ([]models.ReconciliationMismatch, error)
+}
+
+type LimitRepositoryInterface interface {
+	GetWalletLimits(walletID string) (*models.WalletLimitsState, error)
+	SetWalletLimits(walletID, tier string, overrides models.WalletLimits) error
+	SetOverdraftLimit(walletID string, limit utils.Money) (*models.Wallet, error)
+}
+
+type FeeRepositoryInterface interface {
+	ListFeeRules() ([]models.FeeRule, error)
+	ReplaceFeeRules(rules []models.FeeRule) error
+	QuoteFee(walletID, operationType string, amount utils.Money) (models.Fee, error)
+}
+
+type InterestRepositoryInterface interface {
+	GetLastAccrualDay() (*time.Time, error)
+	AccrueInterest(day time.Time, rateBP int64) (int64, error)
+	ListUnpostedInterest(before, afterPeriod time.Time, afterWalletID uuid.UUID, limit int) ([]models.InterestPosting, error)
+	PostInterest(walletID uuid.UUID, period time.Time, rounding utils.Rounding) (*models.InterestPosting, error)
+}
+
+type ScheduledTransferRepositoryInterface interface {
+	CreateScheduledTransfer(st *models.ScheduledTransfer) error
+	GetScheduledTransfer(id string) (*models.ScheduledTransfer, error)
+	ListScheduledTransfers(clientID *uuid.UUID, ownerID string) ([]models.ScheduledTransfer, error)
+	UpdateScheduledTransfer(id string, update func(st *models.ScheduledTransfer) error) (*models.ScheduledTransfer, error)
+	ListDueScheduledTransfers(now time.Time, limit int) ([]models.ScheduledTransfer, error)
+	ExecuteScheduledTransfer(id uuid.UUID, occurrenceAt, now time.Time) (*models.ScheduledTransferRun, error)
+	ListScheduledTransferRuns(scheduleID string, limit int) ([]models.ScheduledTransferRun, error)
 }
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"wallet-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// insertJournal пишет запись журнала двойной записи в транзакции операции.
+// Несбалансированная запись отклоняется до записи в БД; триггер postings_balanced повторяет проверку при коммите.
+// Остатки системных счетов не хранятся, а считаются по проводкам: иначе каждая операция блокировала бы строку cash_in
+func insertJournal(tx *sql.Tx, journal models.Journal) error {
+	if !journal.IsBalanced() {
+		return fmt.Errorf("insert journal %s: %w", journal.OperationType, ErrUnbalancedJournal)
+	}
+
+	_, err := tx.Exec(
+		`INSERT INTO journal_entries (id, operation_type, created_at) VALUES ($1, $2, NOW())`,
+		journal.ID,
+		journal.OperationType,
+	)
+	if err != nil {
+		return fmt.Errorf("insert journal entry: %w", ErrDatabaseError)
+	}
+
+	walletIDs := make([]sql.NullString, len(journal.Postings))
+	accounts := make([]sql.NullString, len(journal.Postings))
+	amounts := make([]int64, len(journal.Postings))
+	for i, posting := range journal.Postings {
+		if posting.WalletID != uuid.Nil {
+			walletIDs[i] = sql.NullString{String: posting.WalletID.String(), Valid: true}
+		} else {
+			accounts[i] = sql.NullString{String: posting.SystemAccount, Valid: true}
+		}
+		amounts[i] = posting.Amount.Raw
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO postings (journal_id, wallet_id, system_account, amount)
+		SELECT $1, wallet_id, system_account, amount
+		FROM unnest($2::uuid[], $3::text[], $4::bigint[]) AS p(wallet_id, system_account, amount)`,
+		journal.ID,
+		pq.Array(walletIDs),
+		pq.Array(accounts),
+		pq.Array(amounts),
+	)
+	if err != nil {
+		return fmt.Errorf("insert postings: %w", ErrDatabaseError)
+	}
+
+	return nil
+}
+
+type JournalRepository struct {
+	db *sql.DB
+}
+
+func NewJournalRepository(db *sql.DB) *JournalRepository {
+	return &JournalRepository{db: db}
+}
+
+// GetTrialBalance считает обороты по системным счетам и по всем кошелькам вместе.
+// Запросы выполняются в одном снимке, чтобы параллельные операции не рассогласовали итоги
+func (r *JournalRepository) GetTrialBalance(ctx context.Context) (*models.TrialBalance, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	var balance models.TrialBalance
+	if err = tx.QueryRowContext(ctx, `SELECT NOW()`).Scan(&balance.AsOf); err != nil {
+		return nil, fmt.Errorf("trial balance time: %w", ErrDatabaseError)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT
+		a.code,
+		COALESCE(SUM(p.amount) FILTER (WHERE p.amount > 0), 0),
+		COALESCE(-SUM(p.amount) FILTER (WHERE p.amount < 0), 0)
+		FROM system_accounts a
+		LEFT JOIN postings p ON p.system_account = a.code
+		GROUP BY a.code
+		UNION ALL
+		SELECT
+		$1,
+		COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0),
+		COALESCE(-SUM(amount) FILTER (WHERE amount < 0), 0)
+		FROM postings
+		WHERE wallet_id IS NOT NULL
+		ORDER BY 1`,
+		models.TrialBalanceWalletsAccount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("trial balance accounts: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account models.TrialBalanceAccount
+		if err := rows.Scan(&account.Account, &account.Debit, &account.Credit); err != nil {
+			return nil, fmt.Errorf("scan trial balance account: %w", ErrDatabaseError)
+		}
+		balance.Accounts = append(balance.Accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("trial balance accounts: %w", ErrDatabaseError)
+	}
+
+	if err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(balance), 0) FROM wallets`).Scan(&balance.WalletsBalance); err != nil {
+		return nil, fmt.Errorf("trial balance wallets: %w", ErrDatabaseError)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM (
+			SELECT journal_id FROM postings GROUP BY journal_id HAVING SUM(amount) <> 0
+		) unbalanced`,
+	).Scan(&balance.UnbalancedJournals)
+	if err != nil {
+		return nil, fmt.Errorf("trial balance journals: %w", ErrDatabaseError)
+	}
+
+	return &balance, nil
+}
+
+// ListLedgerChain возвращает до limit проводок с хэшами в порядке (wallet_id, id) после позиции (afterWalletID, afterID).
+// walletID ограничивает выборку одним кошельком
+func (r *JournalRepository) ListLedgerChain(ctx context.Context, walletID *uuid.UUID, afterWalletID uuid.UUID, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	var where whereBuilder
+	if walletID != nil {
+		where.add("wallet_id = ?", *walletID)
+	}
+	where.add("(wallet_id, id) > (?::uuid, ?::bigint)", afterWalletID, afterID)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT 
+		id, 
+		wallet_id, 
+		entry_type, 
+		amount, 
+		balance_after, 
+		created_at, 
+		prev_hash, 
+		hash 
+		FROM ledger_entries`+where.sql()+
+			` ORDER BY wallet_id, id LIMIT `+where.arg(limit),
+		where.args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list ledger chain: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	entries := make([]models.LedgerEntry, 0, limit)
+	for rows.Next() {
+		var entry models.LedgerEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.WalletID,
+			&entry.EntryType,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&entry.CreatedAt,
+			&entry.PrevHash,
+			&entry.Hash,
+		); err != nil {
+			return nil, fmt.Errorf("scan ledger chain: %w", ErrDatabaseError)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list ledger chain: %w", ErrDatabaseError)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"wallet-api/internal/models"
+)
+
+// LedgerChannel - канал Postgres NOTIFY, в который после каждой проводки отправляется ID кошелька
+const LedgerChannel = "wallet_ledger"
+
+// insertLedgerEntry пишет проводку и уведомление о ней в транзакции обновления баланса.
+// NOTIFY доставляется слушателям только после коммита, поэтому подписчики не увидят откаченных операций
+func insertLedgerEntry(tx *sql.Tx, entry *models.LedgerEntry) error {
+	err := tx.QueryRow(
+		`INSERT INTO ledger_entries (
+		wallet_id, 
+		entry_type, 
+		amount, 
+		balance_after, 
+		created_at
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		entry.WalletID,
+		entry.EntryType,
+		entry.Amount,
+		entry.BalanceAfter,
+		entry.CreatedAt,
+	).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("insert ledger entry: %w", ErrDatabaseError)
+	}
+
+	if _, err = tx.Exec(`SELECT pg_notify($1, $2)`, LedgerChannel, entry.WalletID.String()); err != nil {
+		return fmt.Errorf("notify ledger entry: %w", ErrDatabaseError)
+	}
+
+	return nil
+}
+
+// ListLedgerEntries возвращает проводки кошелька с ID больше afterID в порядке возрастания
+func (r *WalletRepository) ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	rows, err := r.db.Query(
+		`SELECT 
+		id, 
+		wallet_id, 
+		entry_type, 
+		amount, 
+		balance_after, 
+		created_at 
+		FROM ledger_entries 
+		WHERE wallet_id = $1 AND id > $2 
+		ORDER BY id 
+		LIMIT $3`,
+		walletID,
+		afterID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list ledger entries: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	var entries []models.LedgerEntry
+	for rows.Next() {
+		var entry models.LedgerEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.WalletID,
+			&entry.EntryType,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan ledger entry: %w", ErrDatabaseError)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list ledger entries: %w", ErrDatabaseError)
+	}
+
+	return entries, nil
+}
+
+// GetLastLedgerEntry возвращает последнюю проводку кошелька или nil, если журнал пуст
+func (r *WalletRepository) GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error) {
+	var entry models.LedgerEntry
+	err := r.db.QueryRow(
+		`SELECT 
+		id, 
+		wallet_id, 
+		entry_type, 
+		amount, 
+		balance_after, 
+		created_at 
+		FROM ledger_entries 
+		WHERE wallet_id = $1 
+		ORDER BY id DESC 
+		LIMIT 1`,
+		walletID,
+	).Scan(
+		&entry.ID,
+		&entry.WalletID,
+		&entry.EntryType,
+		&entry.Amount,
+		&entry.BalanceAfter,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get last ledger entry: %w", ErrDatabaseError)
+	}
+
+	return &entry, nil
+}
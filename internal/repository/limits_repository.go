@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// limitColumns - колонки лимитов, общие для wallet_tiers и wallet_limits
+const limitColumns = `max_balance, min_operation, max_operation, daily_deposit, daily_withdrawal, monthly_deposit, monthly_withdrawal`
+
+// queryRower - *sql.DB или *sql.Tx: лимиты читаются и в транзакции операции, и вне ее
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// nullLimits - колонки лимитов в порядке limitColumns, прочитанные из БД
+type nullLimits [7]sql.NullInt64
+
+func (n *nullLimits) dest() []interface{} {
+	dest := make([]interface{}, len(n))
+	for i := range n {
+		dest[i] = &n[i]
+	}
+	return dest
+}
+
+func (n *nullLimits) limits() models.WalletLimits {
+	money := func(v sql.NullInt64) *utils.Money {
+		if !v.Valid {
+			return nil
+		}
+		return &utils.Money{Raw: v.Int64}
+	}
+	return models.WalletLimits{
+		MaxBalance:        money(n[0]),
+		MinOperation:      money(n[1]),
+		MaxOperation:      money(n[2]),
+		DailyDeposit:      money(n[3]),
+		DailyWithdrawal:   money(n[4]),
+		MonthlyDeposit:    money(n[5]),
+		MonthlyWithdrawal: money(n[6]),
+	}
+}
+
+func limitArgs(limits models.WalletLimits) []interface{} {
+	args := make([]interface{}, 0, 7)
+	for _, v := range []*utils.Money{
+		limits.MaxBalance,
+		limits.MinOperation,
+		limits.MaxOperation,
+		limits.DailyDeposit,
+		limits.DailyWithdrawal,
+		limits.MonthlyDeposit,
+		limits.MonthlyWithdrawal,
+	} {
+		if v == nil {
+			args = append(args, nil)
+			continue
+		}
+		args = append(args, v.Raw)
+	}
+	return args
+}
+
+// queryWalletLimits читает уровень кошелька и лимиты: уровня и собственные
+func queryWalletLimits(q queryRower, walletID string) (*models.WalletLimitsState, error) {
+	var state models.WalletLimitsState
+	var tier, overrides nullLimits
+	dest := append([]interface{}{&state.Tier}, tier.dest()...)
+	dest = append(dest, overrides.dest()...)
+
+	err := q.QueryRow(
+		`SELECT w.tier,
+		t.max_balance, t.min_operation, t.max_operation, t.daily_deposit, t.daily_withdrawal, t.monthly_deposit, t.monthly_withdrawal,
+		l.max_balance, l.min_operation, l.max_operation, l.daily_deposit, l.daily_withdrawal, l.monthly_deposit, l.monthly_withdrawal
+		FROM wallets w
+		JOIN wallet_tiers t ON t.code = w.tier
+		LEFT JOIN wallet_limits l ON l.wallet_id = w.id
+		WHERE w.id = $1`,
+		walletID,
+	).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("get wallet limits: %w", ErrWalletNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get wallet limits: %w", ErrDatabaseError)
+	}
+
+	state.WalletID = walletID
+	state.Overrides = overrides.limits()
+	state.Effective = tier.limits().WithOverrides(state.Overrides)
+	return &state, nil
+}
+
+// queryLimitUsage считает обороты кошелька за окна лимитов
+func queryLimitUsage(q queryRower, walletID string) (models.LimitUsage, error) {
+	var usage models.LimitUsage
+	err := q.QueryRow(
+		`SELECT
+		COALESCE(SUM(amount) FILTER (WHERE entry_type = ANY($2) AND created_at > NOW() - $4::float8 * INTERVAL '1 second'), 0),
+		COALESCE(SUM(amount) FILTER (WHERE entry_type = ANY($3) AND created_at > NOW() - $4::float8 * INTERVAL '1 second'), 0),
+		COALESCE(SUM(amount) FILTER (WHERE entry_type = ANY($2)), 0),
+		COALESCE(SUM(amount) FILTER (WHERE entry_type = ANY($3)), 0)
+		FROM ledger_entries
+		WHERE wallet_id = $1 AND created_at > NOW() - $5::float8 * INTERVAL '1 second'`,
+		walletID,
+		pq.Array(models.CreditEntryTypes()),
+		pq.Array(models.WithdrawalEntryTypes()),
+		models.LimitDailyWindow.Seconds(),
+		models.LimitMonthlyWindow.Seconds(),
+	).Scan(&usage.DailyDeposit, &usage.DailyWithdrawal, &usage.MonthlyDeposit, &usage.MonthlyWithdrawal)
+	if err != nil {
+		return models.LimitUsage{}, fmt.Errorf("get limit usage: %w", ErrDatabaseError)
+	}
+	return usage, nil
+}
+
+// checkWalletLimits проверяет проводку по лимитам кошелька. Вызывается после изменения баланса
+// и до записи проводки: строка кошелька уже заблокирована, поэтому параллельные операции
+// ждут коммита и видят обороты друг друга
+func checkWalletLimits(tx *sql.Tx, walletID uuid.UUID, entryType string, amount, balanceAfter utils.Money) error {
+	// Комиссия и проценты - не операции клиента: их проводки не проверяются по лимитам
+	if entryType == models.LedgerEntryFee || entryType == models.LedgerEntryInterest {
+		return nil
+	}
+
+	state, err := queryWalletLimits(tx, walletID.String())
+	if err != nil {
+		return err
+	}
+
+	var usage models.LimitUsage
+	if state.Effective.HasTurnoverLimits(entryType) {
+		if usage, err = queryLimitUsage(tx, walletID.String()); err != nil {
+			return err
+		}
+	}
+
+	if exceeded := state.Effective.Check(entryType, amount, balanceAfter, usage); exceeded != nil {
+		exceeded.WalletID = walletID
+		return fmt.Errorf("check wallet limits: %w", exceeded)
+	}
+	return nil
+}
+
+type LimitRepository struct {
+	db *sql.DB
+}
+
+func NewLimitRepository(db *sql.DB) *LimitRepository {
+	return &LimitRepository{db: db}
+}
+
+// GetWalletLimits возвращает уровень, лимиты и обороты кошелька
+func (r *LimitRepository) GetWalletLimits(walletID string) (*models.WalletLimitsState, error) {
+	state, err := queryWalletLimits(r.db, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Usage, err = queryLimitUsage(r.db, walletID); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// SetWalletLimits меняет уровень кошелька и заменяет его собственные лимиты целиком
+func (r *LimitRepository) SetWalletLimits(walletID, tier string, overrides models.WalletLimits) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	var tierExists bool
+	if err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM wallet_tiers WHERE code = $1)`, tier).Scan(&tierExists); err != nil {
+		return fmt.Errorf("set wallet limits: %w", ErrDatabaseError)
+	}
+	if !tierExists {
+		return fmt.Errorf("set wallet limits: %w", ErrWalletTierNotFound)
+	}
+
+	updated, err := tx.Exec(`UPDATE wallets SET tier = $2 WHERE id = $1`, walletID, tier)
+	if err != nil {
+		return fmt.Errorf("set wallet tier: %w", ErrDatabaseError)
+	}
+	if affected, err := updated.RowsAffected(); err != nil {
+		return fmt.Errorf("set wallet tier: %w", ErrDatabaseError)
+	} else if affected == 0 {
+		return fmt.Errorf("set wallet tier: %w", ErrWalletNotFound)
+	}
+
+	args := append([]interface{}{walletID}, limitArgs(overrides)...)
+	_, err = tx.Exec(
+		`INSERT INTO wallet_limits (wallet_id, `+limitColumns+`, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		ON CONFLICT (wallet_id) DO UPDATE SET
+		max_balance = EXCLUDED.max_balance,
+		min_operation = EXCLUDED.min_operation,
+		max_operation = EXCLUDED.max_operation,
+		daily_deposit = EXCLUDED.daily_deposit,
+		daily_withdrawal = EXCLUDED.daily_withdrawal,
+		monthly_deposit = EXCLUDED.monthly_deposit,
+		monthly_withdrawal = EXCLUDED.monthly_withdrawal,
+		updated_at = NOW()`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("set wallet limits: %w", ErrDatabaseError)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// SetOverdraftLimit назначает кошельку кредитную линию. Линия не может быть меньше текущего долга:
+// иначе кошелек сразу окажется за ее пределами и попадет в расхождения сверки
+func (r *LimitRepository) SetOverdraftLimit(walletID string, limit utils.Money) (*models.Wallet, error) {
+	wallet, err := scanWallet(r.db.QueryRow(
+		`UPDATE wallets SET overdraft_limit = $2, updated_at = NOW()
+		WHERE id = $1 AND balance >= -$2
+		RETURNING `+walletColumns,
+		walletID,
+		limit,
+	))
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err = r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM wallets WHERE id = $1)`, walletID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("set overdraft limit: %w", ErrDatabaseError)
+		}
+		if !exists {
+			return nil, fmt.Errorf("set overdraft limit: %w", ErrWalletNotFound)
+		}
+		return nil, fmt.Errorf("set overdraft limit: %w", ErrOverdraftBelowDebt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("set overdraft limit: %w", ErrDatabaseError)
+	}
+	return wallet, nil
+}
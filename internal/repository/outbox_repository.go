@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+	"wallet-api/internal/models"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// ClaimBatch арендует для каждого кошелька самое раннее неотправленное событие на время lease.
+// Пока оно не отправлено или не ушло в dead letter, следующие события кошелька не выбираются,
+// поэтому порядок доставки по кошельку сохраняется. Аренда фиксируется сразу, до отправки:
+// блокировки держатся только на время UPDATE, SKIP LOCKED позволяет работать нескольким репликам
+func (r *OutboxRepository) ClaimBatch(limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	rows, err := r.db.Query(
+		`UPDATE outbox 
+		SET claimed_until = NOW() + $3 * INTERVAL '1 millisecond' 
+		WHERE id IN (
+			SELECT o.id 
+			FROM outbox o 
+			WHERE o.status = $1 
+			AND o.next_attempt_at <= NOW() 
+			AND (o.claimed_until IS NULL OR o.claimed_until <= NOW()) 
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox p 
+				WHERE p.aggregate_id = o.aggregate_id 
+				AND p.status = $1 
+				AND p.id < o.id
+			) 
+			ORDER BY o.id 
+			LIMIT $2 
+			FOR UPDATE SKIP LOCKED
+		) 
+		RETURNING 
+		id, 
+		event_id, 
+		aggregate_id, 
+		event_type, 
+		payload, 
+		attempts, 
+		created_at`,
+		models.OutboxStatusPending,
+		limit,
+		lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox batch: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	var messages []models.OutboxMessage
+	for rows.Next() {
+		var msg models.OutboxMessage
+		if err := rows.Scan(
+			&msg.ID,
+			&msg.EventID,
+			&msg.AggregateID,
+			&msg.EventType,
+			&msg.Payload,
+			&msg.Attempts,
+			&msg.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan outbox message: %w", ErrDatabaseError)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim outbox batch: %w", ErrDatabaseError)
+	}
+
+	// RETURNING не гарантирует порядок подзапроса
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// AckBatch записывает итоги отправки одной короткой транзакцией и снимает аренду.
+// Событие, уже подтвержденное другим dispatcher'ом после истечения аренды, не меняется
+func (r *OutboxRepository) AckBatch(acks []models.OutboxAck) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	for _, ack := range acks {
+		switch ack.Status {
+		case models.OutboxStatusPublished:
+			_, err = tx.Exec(
+				`UPDATE outbox SET status = $2, attempts = attempts + 1, published_at = NOW(), last_error = NULL, claimed_until = NULL 
+				WHERE id = $1 AND status = $3`,
+				ack.ID,
+				models.OutboxStatusPublished,
+				models.OutboxStatusPending,
+			)
+		case models.OutboxStatusDead:
+			_, err = tx.Exec(
+				`UPDATE outbox SET status = $2, attempts = attempts + 1, last_error = $3, claimed_until = NULL 
+				WHERE id = $1 AND status = $4`,
+				ack.ID,
+				models.OutboxStatusDead,
+				ack.LastError,
+				models.OutboxStatusPending,
+			)
+		default:
+			_, err = tx.Exec(
+				`UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3, claimed_until = NULL 
+				WHERE id = $1 AND status = $4`,
+				ack.ID,
+				ack.LastError,
+				ack.NextAttemptAt,
+				models.OutboxStatusPending,
+			)
+		}
+		if err != nil {
+			return fmt.Errorf("ack outbox message: %w", ErrDatabaseError)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+func insertOutboxEvent(tx *sql.Tx, event models.WalletEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal outbox event: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO outbox (event_id, aggregate_id, event_type, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $5)`,
+		event.EventID,
+		event.WalletID,
+		event.EventType,
+		payload,
+		event.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert outbox event: %w", ErrDatabaseError)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+)
+
+// whereBuilder собирает условие WHERE из фрагментов с плейсхолдерами "?".
+// Значения всегда передаются параметрами, в текст запроса попадают только фрагменты из кода
+type whereBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add добавляет условие; каждый "?" в cond заменяется на очередной $N для соответствующего значения из args
+func (b *whereBuilder) add(cond string, args ...interface{}) {
+	var sb strings.Builder
+	next := 0
+	for _, ch := range cond {
+		if ch != '?' || next >= len(args) {
+			sb.WriteRune(ch)
+			continue
+		}
+		b.args = append(b.args, args[next])
+		next++
+		sb.WriteString("$")
+		sb.WriteString(strconv.Itoa(len(b.args)))
+	}
+	b.conditions = append(b.conditions, sb.String())
+}
+
+// arg добавляет значение без условия (например, для LIMIT) и возвращает его плейсхолдер
+func (b *whereBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *whereBuilder) sql() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+func TestWhereBuilder(t *testing.T) {
+	var b whereBuilder
+	if b.sql() != "" {
+		t.Errorf("empty builder sql = %q, want empty", b.sql())
+	}
+
+	b.add("owner_id = ?", "alice")
+	b.add("(created_at, id) > (?, ?)", "2025-09-01T00:00:00Z", "id")
+	limit := b.arg(10)
+
+	if want := " WHERE owner_id = $1 AND (created_at, id) > ($2, $3)"; b.sql() != want {
+		t.Errorf("sql() = %q, want %q", b.sql(), want)
+	}
+	if limit != "$4" || len(b.args) != 4 {
+		t.Errorf("arg() = %s with %d args, want $4 with 4", limit, len(b.args))
+	}
+}
+
+func TestBuildListWalletsQuery(t *testing.T) {
+	min := utils.Money{Raw: 100}
+	from := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	cursorID := uuid.New()
+	injection := "x'); DROP TABLE wallets; --"
+
+	query, args, err := buildListWalletsQuery(models.WalletFilter{
+		OwnerID:     injection,
+		Status:      models.WalletStatusActive,
+		Tags:        map[string]string{"team": "billing"},
+		MinBalance:  &min,
+		CreatedFrom: &from,
+		SortBy:      models.WalletSortBalance,
+		Descending:  true,
+		After:       &models.WalletCursor{SortBy: models.WalletSortBalance, Descending: true, Value: "500", ID: cursorID},
+		Limit:       51,
+	})
+	if err != nil {
+		t.Fatalf("buildListWalletsQuery() error = %v", err)
+	}
+
+	for _, fragment := range []string{
+		"owner_id = $1",
+		"status = $2",
+		"metadata @> $3::jsonb",
+		"balance >= $4",
+		"created_at >= $5",
+		"(balance, id) < ($6::bigint, $7::uuid)",
+		"ORDER BY balance DESC, id DESC LIMIT $8",
+	} {
+		if !strings.Contains(query, fragment) {
+			t.Errorf("query %q does not contain %q", query, fragment)
+		}
+	}
+	if strings.Contains(query, injection) {
+		t.Errorf("filter value leaked into SQL text: %q", query)
+	}
+	if len(args) != 8 || args[0] != injection || args[2] != `{"team":"billing"}` || args[7] != 51 {
+		t.Errorf("args = %v", args)
+	}
+
+	if _, _, err := buildListWalletsQuery(models.WalletFilter{SortBy: "id; DROP TABLE wallets"}); err == nil {
+		t.Error("buildListWalletsQuery() accepted unknown sort column")
+	}
+}
@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const reconciliationRunColumns = `
+	id,
+	status,
+	wallets_checked,
+	mismatches,
+	frozen_wallets,
+	last_wallet_id,
+	started_at,
+	finished_at`
+
+type ReconciliationRepository struct {
+	db *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// StartReconciliationRun начинает сверку, если за последние minGap ее не начинала ни одна реплика.
+// Сверка, обработчик которой молчит дольше staleAfter, считается упавшей. Если начинать не нужно, возвращает nil
+func (r *ReconciliationRepository) StartReconciliationRun(minGap, staleAfter time.Duration) (*models.ReconciliationRun, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE reconciliation_runs
+		SET status = $1, finished_at = NOW()
+		WHERE status = $2 AND heartbeat_at < NOW() - $3 * INTERVAL '1 millisecond'`,
+		models.ReconciliationFailed,
+		models.ReconciliationRunning,
+		staleAfter.Milliseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("expire reconciliation runs: %w", ErrDatabaseError)
+	}
+
+	// Уникальный индекс по running не дает двум репликам начать сверку одновременно
+	run, err := scanReconciliationRun(tx.QueryRow(
+		`INSERT INTO reconciliation_runs (id, status, started_at, heartbeat_at)
+		SELECT $1, $2, NOW(), NOW()
+		WHERE NOT EXISTS (
+			SELECT 1 FROM reconciliation_runs
+			WHERE status = $2 OR started_at > NOW() - $3 * INTERVAL '1 millisecond'
+		)
+		ON CONFLICT DO NOTHING
+		RETURNING`+reconciliationRunColumns,
+		uuid.New(),
+		models.ReconciliationRunning,
+		minGap.Milliseconds(),
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("start reconciliation run: %w", ErrDatabaseError)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return run, nil
+}
+
+// ListWalletLedgerTotals возвращает до limit кошельков с id больше afterID вместе с суммой их проводок.
+// Баланс и журнал читаются одним запросом, поэтому видят один снимок и параллельная операция
+// не дает ложного расхождения
+func (r *ReconciliationRepository) ListWalletLedgerTotals(afterID uuid.UUID, limit int) ([]models.WalletLedgerTotals, error) {
+	rows, err := r.db.Query(
+		`SELECT
+		w.id,
+		w.balance,
+		w.overdraft_limit,
+		w.status,
+		COALESCE(l.ledger_balance, 0),
+		l.entries,
+		l.last_id,
+		(SELECT balance_after FROM ledger_entries WHERE id = l.last_id)
+		FROM (
+			SELECT id, balance, overdraft_limit, status FROM wallets WHERE id > $1 ORDER BY id LIMIT $2
+		) w
+		CROSS JOIN LATERAL (
+			SELECT
+			SUM(CASE WHEN entry_type = ANY($3) THEN -amount ELSE amount END) AS ledger_balance,
+			COUNT(*) AS entries,
+			MAX(id) AS last_id
+			FROM ledger_entries
+			WHERE wallet_id = w.id
+		) l
+		ORDER BY w.id`,
+		afterID,
+		limit,
+		pq.Array(models.DebitEntryTypes()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list wallet ledger totals: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	totals := make([]models.WalletLedgerTotals, 0, limit)
+	for rows.Next() {
+		var t models.WalletLedgerTotals
+		if err := rows.Scan(
+			&t.WalletID,
+			&t.Balance,
+			&t.OverdraftLimit,
+			&t.Status,
+			&t.LedgerBalance,
+			&t.LedgerEntries,
+			&t.LastEntryID,
+			&t.LastBalanceAfter,
+		); err != nil {
+			return nil, fmt.Errorf("scan wallet ledger totals: %w", ErrDatabaseError)
+		}
+		totals = append(totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list wallet ledger totals: %w", ErrDatabaseError)
+	}
+
+	return totals, nil
+}
+
+// FreezeWallet замораживает активный кошелек и пишет событие WalletFrozen в outbox.
+// Возвращает false, если кошелек уже заморожен
+func (r *ReconciliationRepository) FreezeWallet(walletID uuid.UUID) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	wallet, err := scanWallet(tx.QueryRow(
+		`UPDATE wallets
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND status <> $2
+		RETURNING `+walletColumns,
+		walletID,
+		models.WalletStatusFrozen,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("freeze wallet: %w", ErrDatabaseError)
+	}
+
+	if err = insertOutboxEvent(tx, models.NewWalletFrozenEvent(wallet)); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return true, nil
+}
+
+// RecordReconciliationChunk сохраняет расхождения порции и продвигает счетчики и курсор сверки
+func (r *ReconciliationRepository) RecordReconciliationChunk(runID, lastWalletID uuid.UUID, checked int, mismatches []models.ReconciliationMismatch) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	frozen := 0
+	for _, m := range mismatches {
+		if m.Frozen {
+			frozen++
+		}
+		_, err = tx.Exec(
+			`INSERT INTO reconciliation_mismatches (
+			run_id,
+			wallet_id,
+			reason,
+			wallet_balance,
+			ledger_balance,
+			ledger_entries,
+			last_entry_id,
+			last_balance_after,
+			frozen,
+			detected_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+			ON CONFLICT DO NOTHING`,
+			runID,
+			m.WalletID,
+			m.Reason,
+			m.WalletBalance,
+			m.LedgerBalance,
+			m.LedgerEntries,
+			m.LastEntryID,
+			m.LastBalanceAfter,
+			m.Frozen,
+		)
+		if err != nil {
+			return fmt.Errorf("insert reconciliation mismatch: %w", ErrDatabaseError)
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE reconciliation_runs
+		SET wallets_checked = wallets_checked + $2,
+		mismatches = mismatches + $3,
+		frozen_wallets = frozen_wallets + $4,
+		last_wallet_id = $5,
+		heartbeat_at = NOW()
+		WHERE id = $1`,
+		runID,
+		checked,
+		len(mismatches),
+		frozen,
+		lastWalletID,
+	)
+	if err != nil {
+		return fmt.Errorf("update reconciliation run: %w", ErrDatabaseError)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// FinishReconciliationRun завершает сверку со статусом completed или failed
+func (r *ReconciliationRepository) FinishReconciliationRun(runID uuid.UUID, status string) error {
+	_, err := r.db.Exec(
+		`UPDATE reconciliation_runs SET status = $2, finished_at = NOW() WHERE id = $1`,
+		runID,
+		status,
+	)
+	if err != nil {
+		return fmt.Errorf("finish reconciliation run: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// GetLatestReconciliationRun возвращает последнюю начатую сверку
+func (r *ReconciliationRepository) GetLatestReconciliationRun() (*models.ReconciliationRun, error) {
+	run, err := scanReconciliationRun(r.db.QueryRow(
+		`SELECT` + reconciliationRunColumns + ` FROM reconciliation_runs ORDER BY started_at DESC LIMIT 1`,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("get latest reconciliation run: %w", ErrReconciliationRunNotFound)
+		}
+		return nil, fmt.Errorf("get latest reconciliation run: %w", ErrDatabaseError)
+	}
+	return run, nil
+}
+
+// ListReconciliationMismatches возвращает до limit расхождений сверки в порядке обнаружения
+func (r *ReconciliationRepository) ListReconciliationMismatches(runID uuid.UUID, limit int) ([]models.ReconciliationMismatch, error) {
+	rows, err := r.db.Query(
+		`SELECT
+		run_id,
+		wallet_id,
+		reason,
+		wallet_balance,
+		ledger_balance,
+		ledger_entries,
+		last_entry_id,
+		last_balance_after,
+		frozen,
+		detected_at
+		FROM reconciliation_mismatches
+		WHERE run_id = $1
+		ORDER BY detected_at, wallet_id, reason
+		LIMIT $2`,
+		runID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list reconciliation mismatches: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	var mismatches []models.ReconciliationMismatch
+	for rows.Next() {
+		var m models.ReconciliationMismatch
+		if err := rows.Scan(
+			&m.RunID,
+			&m.WalletID,
+			&m.Reason,
+			&m.WalletBalance,
+			&m.LedgerBalance,
+			&m.LedgerEntries,
+			&m.LastEntryID,
+			&m.LastBalanceAfter,
+			&m.Frozen,
+			&m.DetectedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan reconciliation mismatch: %w", ErrDatabaseError)
+		}
+		mismatches = append(mismatches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list reconciliation mismatches: %w", ErrDatabaseError)
+	}
+
+	return mismatches, nil
+}
+
+func scanReconciliationRun(row rowScanner) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	if err := row.Scan(
+		&run.ID,
+		&run.Status,
+		&run.WalletsChecked,
+		&run.Mismatches,
+		&run.FrozenWallets,
+		&run.LastWalletID,
+		&run.StartedAt,
+		&run.FinishedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
@@ -0,0 +1,483 @@
+package repository
+
+import (
+	"database/sql"
+	stdErrors "errors"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const scheduledTransferColumns = `
+	id,
+	client_id,
+	owner_id,
+	from_wallet_id,
+	to_wallet_id,
+	amount,
+	cron,
+	interval_seconds,
+	start_at,
+	end_at,
+	max_retries,
+	retry_interval_seconds,
+	status,
+	next_run_at,
+	retry_at,
+	attempts,
+	created_at,
+	updated_at`
+
+const scheduledTransferRunColumns = `schedule_id, occurrence_at, status, attempts, failure_reason, executed_at`
+
+type ScheduledTransferRepository struct {
+	db *sql.DB
+}
+
+func NewScheduledTransferRepository(db *sql.DB) *ScheduledTransferRepository {
+	return &ScheduledTransferRepository{db: db}
+}
+
+// CreateScheduledTransfer сохраняет расписание, если оба кошелька существуют
+func (r *ScheduledTransferRepository) CreateScheduledTransfer(st *models.ScheduledTransfer) error {
+	res, err := r.db.Exec(
+		`INSERT INTO scheduled_transfers (`+scheduledTransferColumns+`)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+		WHERE (SELECT COUNT(*) FROM wallets WHERE id IN ($4, $5)) = 2`,
+		scheduledTransferArgs(st)...,
+	)
+	if err != nil {
+		return fmt.Errorf("create scheduled transfer: %w", ErrDatabaseError)
+	}
+	if inserted, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("create scheduled transfer: %w", ErrDatabaseError)
+	} else if inserted == 0 {
+		return fmt.Errorf("create scheduled transfer: %w", ErrWalletNotFound)
+	}
+	return nil
+}
+
+func (r *ScheduledTransferRepository) GetScheduledTransfer(id string) (*models.ScheduledTransfer, error) {
+	st, err := scanScheduledTransfer(r.db.QueryRow(
+		`SELECT`+scheduledTransferColumns+` FROM scheduled_transfers WHERE id = $1`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("get scheduled transfer: %w", ErrScheduledTransferNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get scheduled transfer: %w", ErrDatabaseError)
+	}
+	return st, nil
+}
+
+// ListScheduledTransfers возвращает расписания клиента или владельца; nil clientID и пустой ownerID
+// не ограничивают выборку
+func (r *ScheduledTransferRepository) ListScheduledTransfers(clientID *uuid.UUID, ownerID string) ([]models.ScheduledTransfer, error) {
+	rows, err := r.db.Query(
+		`SELECT`+scheduledTransferColumns+`
+		FROM scheduled_transfers
+		WHERE ($1::UUID IS NULL OR client_id = $1) AND ($2 = '' OR owner_id = $2)
+		ORDER BY created_at`,
+		nullableUUID(clientID),
+		ownerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled transfers: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	return scanScheduledTransfers(rows)
+}
+
+// UpdateScheduledTransfer блокирует расписание, применяет к нему update и сохраняет результат.
+// Блокировка не дает изменению клиента затереть продвижение расписания обработчиком
+func (r *ScheduledTransferRepository) UpdateScheduledTransfer(id string, update func(st *models.ScheduledTransfer) error) (*models.ScheduledTransfer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	st, err := lockScheduledTransfer(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = update(st); err != nil {
+		return nil, err
+	}
+	if err = saveScheduledTransferState(tx, st); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return st, nil
+}
+
+// ListDueScheduledTransfers возвращает до limit активных расписаний, запуск или повтор которых наступил к now
+func (r *ScheduledTransferRepository) ListDueScheduledTransfers(now time.Time, limit int) ([]models.ScheduledTransfer, error) {
+	rows, err := r.db.Query(
+		`SELECT`+scheduledTransferColumns+`
+		FROM scheduled_transfers
+		WHERE status = $1 AND COALESCE(retry_at, next_run_at) <= $2
+		ORDER BY COALESCE(retry_at, next_run_at), id
+		LIMIT $3`,
+		models.ScheduleStatusActive,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list due scheduled transfers: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	return scanScheduledTransfers(rows)
+}
+
+// ExecuteScheduledTransfer выполняет запуск occurrenceAt расписания обычным переводом и в той же транзакции
+// записывает итог запуска и продвигает расписание. Запуск идемпотентен: уже выполненный или перенесенный
+// запуск не проводится повторно, и тогда возвращается nil. Отказ перевода (нехватка средств, заморозка, лимит)
+// откатывается до точки сохранения и записывается как неудачная попытка
+func (r *ScheduledTransferRepository) ExecuteScheduledTransfer(id uuid.UUID, occurrenceAt, now time.Time) (*models.ScheduledTransferRun, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	st, err := lockScheduledTransfer(tx, id.String())
+	if err != nil {
+		return nil, err
+	}
+	due := st.DueAt()
+	if st.Status != models.ScheduleStatusActive || st.NextRunAt == nil || !st.NextRunAt.Equal(occurrenceAt) || due.After(now) {
+		return nil, nil
+	}
+
+	run := &models.ScheduledTransferRun{
+		ScheduleID:   st.ID,
+		OccurrenceAt: occurrenceAt,
+		Attempts:     st.Attempts + 1,
+		ExecutedAt:   now,
+	}
+
+	var previous string
+	err = tx.QueryRow(
+		`SELECT status FROM scheduled_transfer_runs WHERE schedule_id = $1 AND occurrence_at = $2`,
+		st.ID,
+		occurrenceAt,
+	).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("get scheduled transfer run: %w", ErrDatabaseError)
+	}
+
+	if previous == models.ScheduledRunSucceeded {
+		// Перевод за этот запуск уже проведен: остается только продвинуть расписание
+		st.Advance()
+		if err = saveScheduledTransferState(tx, st); err != nil {
+			return nil, err
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+		}
+		return nil, nil
+	}
+
+	allowed, err := scheduleAccessAllowed(tx, st)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		// Создатель расписания потерял доступ к кошельку-источнику: повторы бесполезны, расписание
+		// приостанавливается до возобновления, которое снова проверит доступ
+		run.FailureReason = models.ScheduledRunAccessDenied
+		run.Status = models.ScheduledRunFailed
+		st.Status = models.ScheduleStatusPaused
+		st.RetryAt = nil
+	} else if err = executeScheduledTransfer(tx, st, run, now); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO scheduled_transfer_runs (`+scheduledTransferRunColumns+`)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		ON CONFLICT (schedule_id, occurrence_at) DO UPDATE SET
+		status = EXCLUDED.status,
+		attempts = EXCLUDED.attempts,
+		failure_reason = EXCLUDED.failure_reason,
+		executed_at = EXCLUDED.executed_at`,
+		run.ScheduleID,
+		run.OccurrenceAt,
+		run.Status,
+		run.Attempts,
+		run.FailureReason,
+		run.ExecutedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("record scheduled transfer run: %w", ErrDatabaseError)
+	}
+	if err = saveScheduledTransferState(tx, st); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return run, nil
+}
+
+// executeScheduledTransfer проводит перевод запуска run. Отказ перевода откатывается до точки сохранения
+// и учитывается как неудачная попытка
+func executeScheduledTransfer(tx *sql.Tx, st *models.ScheduledTransfer, run *models.ScheduledTransferRun, now time.Time) error {
+	if _, err := tx.Exec(`SAVEPOINT scheduled_transfer`); err != nil {
+		return fmt.Errorf("savepoint scheduled transfer: %w", ErrDatabaseError)
+	}
+	_, err := transferBetweenWallets(tx, st.FromWalletID.String(), st.ToWalletID.String(), st.Amount)
+	if err != nil {
+		reason := transferRejectionReason(err)
+		if reason == "" {
+			return err
+		}
+		if _, err = tx.Exec(`ROLLBACK TO SAVEPOINT scheduled_transfer`); err != nil {
+			return fmt.Errorf("rollback scheduled transfer: %w", ErrDatabaseError)
+		}
+		run.FailureReason = reason
+		run.Status = st.RecordFailure(now)
+		return nil
+	}
+
+	run.Status = models.ScheduledRunSucceeded
+	st.Advance()
+	return nil
+}
+
+// scheduleAccessAllowed повторяет при запуске проверку доступа, сделанную при создании расписания: API клиент
+// должен существовать, быть включен, иметь действующий ключ или секрет подписи, scope wallet:withdraw и доступ
+// к кошельку-источнику, а владелец из JWT - по-прежнему владеть кошельком-источником. Расписание без клиента
+// и владельца создано без аутентификации и не проверяется
+func scheduleAccessAllowed(tx *sql.Tx, st *models.ScheduledTransfer) (bool, error) {
+	if st.OwnerID.Valid {
+		var owned bool
+		err := tx.QueryRow(
+			`SELECT owner_id IS NOT DISTINCT FROM $2 FROM wallets WHERE id = $1`,
+			st.FromWalletID,
+			st.OwnerID.String,
+		).Scan(&owned)
+		if err == sql.ErrNoRows || (err == nil && !owned) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("check scheduled transfer owner: %w", ErrDatabaseError)
+		}
+	}
+	if !st.ClientID.Valid {
+		return true, nil
+	}
+
+	var canAuthenticate bool
+	client, err := scanAPIClient(tx.QueryRow(
+		`SELECT 
+		c.id, 
+		c.name, 
+		c.scopes, 
+		COALESCE(c.wallet_ids::TEXT[], '{}'), 
+		c.disabled, 
+		c.created_at, 
+		c.signing_secret IS NOT NULL OR EXISTS (
+			SELECT 1 FROM api_keys k 
+			WHERE k.client_id = c.id 
+			AND k.revoked_at IS NULL 
+			AND (k.expires_at IS NULL OR k.expires_at > NOW())
+		) 
+		FROM api_clients c 
+		WHERE c.id = $1`,
+		st.ClientID.UUID,
+	), &canAuthenticate)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("check scheduled transfer access: %w", ErrDatabaseError)
+	}
+
+	return !client.Disabled && canAuthenticate && client.HasScope(models.ScopeWalletWithdraw) &&
+		client.CanAccessWallet(st.FromWalletID), nil
+}
+
+// ListScheduledTransferRuns возвращает до limit последних запусков расписания
+func (r *ScheduledTransferRepository) ListScheduledTransferRuns(scheduleID string, limit int) ([]models.ScheduledTransferRun, error) {
+	rows, err := r.db.Query(
+		`SELECT `+scheduledTransferRunColumns+`
+		FROM scheduled_transfer_runs
+		WHERE schedule_id = $1
+		ORDER BY occurrence_at DESC
+		LIMIT $2`,
+		scheduleID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled transfer runs: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	runs := make([]models.ScheduledTransferRun, 0)
+	for rows.Next() {
+		var run models.ScheduledTransferRun
+		var reason sql.NullString
+		if err := rows.Scan(&run.ScheduleID, &run.OccurrenceAt, &run.Status, &run.Attempts, &reason, &run.ExecutedAt); err != nil {
+			return nil, fmt.Errorf("scan scheduled transfer run: %w", ErrDatabaseError)
+		}
+		run.FailureReason = reason.String
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list scheduled transfer runs: %w", ErrDatabaseError)
+	}
+	return runs, nil
+}
+
+// transferRejectionReason возвращает причину отказа перевода, которую стоит записать в запуск и, возможно, повторить.
+// Пустая строка - техническая ошибка: транзакция откатывается и запуск повторится на следующем тике
+func transferRejectionReason(err error) string {
+	var limit *models.LimitExceededError
+	switch {
+	case stdErrors.Is(err, ErrInsufficientFunds):
+		return models.ScheduledRunInsufficientFunds
+	case stdErrors.Is(err, ErrWalletFrozen):
+		return models.ScheduledRunWalletFrozen
+	case stdErrors.Is(err, ErrWalletNotFound):
+		return models.ScheduledRunWalletNotFound
+	case stdErrors.As(err, &limit):
+		return models.ScheduledRunLimitExceeded
+	default:
+		return ""
+	}
+}
+
+func lockScheduledTransfer(tx *sql.Tx, id string) (*models.ScheduledTransfer, error) {
+	st, err := scanScheduledTransfer(tx.QueryRow(
+		`SELECT`+scheduledTransferColumns+` FROM scheduled_transfers WHERE id = $1 FOR UPDATE`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("lock scheduled transfer: %w", ErrScheduledTransferNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock scheduled transfer: %w", ErrDatabaseError)
+	}
+	return st, nil
+}
+
+// saveScheduledTransferState сохраняет изменяемые поля расписания; кошельки и правило повторения не меняются
+func saveScheduledTransferState(tx *sql.Tx, st *models.ScheduledTransfer) error {
+	st.UpdatedAt = time.Now().UTC()
+	_, err := tx.Exec(
+		`UPDATE scheduled_transfers SET
+		amount = $2,
+		end_at = $3,
+		max_retries = $4,
+		retry_interval_seconds = $5,
+		status = $6,
+		next_run_at = $7,
+		retry_at = $8,
+		attempts = $9,
+		updated_at = $10
+		WHERE id = $1`,
+		st.ID,
+		st.Amount,
+		st.EndAt,
+		st.MaxRetries,
+		int64(st.RetryInterval/time.Second),
+		st.Status,
+		st.NextRunAt,
+		st.RetryAt,
+		st.Attempts,
+		st.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save scheduled transfer: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+func scheduledTransferArgs(st *models.ScheduledTransfer) []interface{} {
+	var cron sql.NullString
+	if st.Recurrence.Cron != "" {
+		cron = sql.NullString{String: st.Recurrence.Cron, Valid: true}
+	}
+	var interval sql.NullInt64
+	if st.Recurrence.Interval != 0 {
+		interval = sql.NullInt64{Int64: int64(st.Recurrence.Interval / time.Second), Valid: true}
+	}
+	return []interface{}{
+		st.ID,
+		st.ClientID,
+		st.OwnerID,
+		st.FromWalletID,
+		st.ToWalletID,
+		st.Amount,
+		cron,
+		interval,
+		st.StartAt,
+		st.EndAt,
+		st.MaxRetries,
+		int64(st.RetryInterval / time.Second),
+		st.Status,
+		st.NextRunAt,
+		st.RetryAt,
+		st.Attempts,
+		st.CreatedAt,
+		st.UpdatedAt,
+	}
+}
+
+func scanScheduledTransfer(row rowScanner) (*models.ScheduledTransfer, error) {
+	var st models.ScheduledTransfer
+	var cron sql.NullString
+	var interval sql.NullInt64
+	var retrySeconds int64
+	if err := row.Scan(
+		&st.ID,
+		&st.ClientID,
+		&st.OwnerID,
+		&st.FromWalletID,
+		&st.ToWalletID,
+		&st.Amount,
+		&cron,
+		&interval,
+		&st.StartAt,
+		&st.EndAt,
+		&st.MaxRetries,
+		&retrySeconds,
+		&st.Status,
+		&st.NextRunAt,
+		&st.RetryAt,
+		&st.Attempts,
+		&st.CreatedAt,
+		&st.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	st.Recurrence = models.Recurrence{Cron: cron.String, Interval: time.Duration(interval.Int64) * time.Second}
+	st.RetryInterval = time.Duration(retrySeconds) * time.Second
+	return &st, nil
+}
+
+func scanScheduledTransfers(rows *sql.Rows) ([]models.ScheduledTransfer, error) {
+	items := make([]models.ScheduledTransfer, 0)
+	for rows.Next() {
+		st, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan scheduled transfer: %w", ErrDatabaseError)
+		}
+		items = append(items, *st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan scheduled transfers: %w", ErrDatabaseError)
+	}
+	return items, nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// statementFetchSize - сколько проводок читается из серверного курсора за один FETCH
+const statementFetchSize = 500
+
+// StatementCursor читает проводки выписки порциями из серверного курсора Postgres,
+// поэтому в памяти одновременно находится не больше statementFetchSize строк
+type StatementCursor interface {
+	Statement() models.Statement
+	// Next возвращает следующую проводку или nil, когда проводки закончились
+	Next() (*models.LedgerEntry, error)
+	Close() error
+}
+
+// OpenStatement открывает выписку по кошельку за период [from, to).
+// Остатки и проводки читаются в одной read-only транзакции REPEATABLE READ и согласованы между собой
+func (r *WalletRepository) OpenStatement(ctx context.Context, walletID string, from, to time.Time) (StatementCursor, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+
+	statement := models.Statement{WalletID: uuid.MustParse(walletID), From: from, To: to}
+	if statement.OpeningBalance, err = balanceBefore(tx, walletID, from); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if statement.ClosingBalance, err = balanceBefore(tx, walletID, to); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`DECLARE statement_entries NO SCROLL CURSOR FOR 
+		SELECT 
+		id, 
+		wallet_id, 
+		entry_type, 
+		amount, 
+		balance_after, 
+		created_at 
+		FROM ledger_entries 
+		WHERE wallet_id = $1 AND created_at >= $2 AND created_at < $3 
+		ORDER BY id`,
+		walletID,
+		from,
+		to,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("declare statement cursor: %w", ErrDatabaseError)
+	}
+
+	return &statementCursor{tx: tx, statement: statement}, nil
+}
+
+// balanceBefore возвращает баланс после последней проводки, сделанной раньше at, или ноль
+func balanceBefore(tx *sql.Tx, walletID string, at time.Time) (utils.Money, error) {
+	var balance utils.Money
+	err := tx.QueryRow(
+		`SELECT balance_after 
+		FROM ledger_entries 
+		WHERE wallet_id = $1 AND created_at < $2 
+		ORDER BY id DESC 
+		LIMIT 1`,
+		walletID,
+		at,
+	).Scan(&balance)
+	if err != nil && err != sql.ErrNoRows {
+		return utils.Money{}, fmt.Errorf("get statement balance: %w", ErrDatabaseError)
+	}
+	return balance, nil
+}
+
+type statementCursor struct {
+	tx        *sql.Tx
+	statement models.Statement
+	buffer    []models.LedgerEntry
+	done      bool
+}
+
+func (c *statementCursor) Statement() models.Statement {
+	return c.statement
+}
+
+func (c *statementCursor) Next() (*models.LedgerEntry, error) {
+	if len(c.buffer) == 0 && !c.done {
+		if err := c.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.buffer) == 0 {
+		return nil, nil
+	}
+
+	entry := c.buffer[0]
+	c.buffer = c.buffer[1:]
+	return &entry, nil
+}
+
+func (c *statementCursor) fetch() error {
+	rows, err := c.tx.Query(fmt.Sprintf(`FETCH %d FROM statement_entries`, statementFetchSize))
+	if err != nil {
+		return fmt.Errorf("fetch statement entries: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	c.buffer = c.buffer[:0]
+	for rows.Next() {
+		var entry models.LedgerEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.WalletID,
+			&entry.EntryType,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&entry.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("scan statement entry: %w", ErrDatabaseError)
+		}
+		c.buffer = append(c.buffer, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("fetch statement entries: %w", ErrDatabaseError)
+	}
+
+	c.done = len(c.buffer) < statementFetchSize
+	return nil
+}
+
+// Close закрывает курсор вместе с транзакцией; транзакция только читает, поэтому откатывается
+func (c *statementCursor) Close() error {
+	return c.tx.Rollback()
+}
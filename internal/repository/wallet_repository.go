@@ -2,11 +2,21 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"wallet-api/internal/models"
 	"wallet-api/utils"
+
+	"github.com/google/uuid"
 )
 
+// walletColumns - колонки wallets в порядке, который ожидает scanWallet
+const walletColumns = `id, balance, owner_id, status, metadata, created_at, updated_at, overdraft_limit`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 type WalletRepository struct {
 	db *sql.DB
 }
@@ -16,22 +26,10 @@ func NewWalletRepository(db *sql.DB) *WalletRepository {
 }
 
 func (r *WalletRepository) GetWalletByID(walletID string) (*models.Wallet, error) {
-	var wallet models.Wallet
-	err := r.db.QueryRow(
-		`SELECT 
-		id, 
-		balance, 
-		created_at, 
-		updated_at 
-		FROM wallets 
-		WHERE id = $1`,
+	wallet, err := scanWallet(r.db.QueryRow(
+		`SELECT `+walletColumns+` FROM wallets WHERE id = $1`,
 		walletID,
-	).Scan(
-		&wallet.ID,
-		&wallet.Balance,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-	)
+	))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -40,18 +38,139 @@ func (r *WalletRepository) GetWalletByID(walletID string) (*models.Wallet, error
 		return nil, fmt.Errorf("get wallet by id: %w", ErrDatabaseError)
 	}
 
-	return &wallet, nil
+	return wallet, nil
 }
 
-func (r *WalletRepository) UpdateWalletBalance(walletID string, operationType string, amount utils.Money) (*models.Wallet, error) {
-	var wallet models.Wallet
+// GetWalletsByIDs возвращает найденные кошельки одним запросом; отсутствующие ID просто не попадают в результат
+func (r *WalletRepository) GetWalletsByIDs(walletIDs []uuid.UUID) ([]models.Wallet, error) {
+	rows, err := r.db.Query(
+		`SELECT `+walletColumns+` FROM wallets WHERE id = ANY($1::uuid[])`,
+		uuidArray(walletIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get wallets by ids: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	wallets, err := scanWallets(rows, len(walletIDs))
+	if err != nil {
+		return nil, fmt.Errorf("get wallets by ids: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// walletSortColumns - допустимые колонки сортировки; в SQL попадают только значения из этой таблицы
+var walletSortColumns = map[string]string{
+	models.WalletSortCreatedAt: "created_at",
+	models.WalletSortUpdatedAt: "updated_at",
+	models.WalletSortBalance:   "balance",
+}
+
+// ListWallets возвращает до filter.Limit кошельков, подходящих под фильтр, в порядке (поле сортировки, id)
+func (r *WalletRepository) ListWallets(filter models.WalletFilter) ([]models.Wallet, error) {
+	query, args, err := buildListWalletsQuery(filter)
+	if err != nil {
+		return nil, fmt.Errorf("list wallets: %w", err)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list wallets: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	wallets, err := scanWallets(rows, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("list wallets: %w", err)
+	}
+
+	return wallets, nil
+}
+
+func buildListWalletsQuery(filter models.WalletFilter) (string, []interface{}, error) {
+	column, ok := walletSortColumns[filter.SortBy]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown sort %q", filter.SortBy)
+	}
+
+	var where whereBuilder
+	if len(filter.IDs) > 0 {
+		where.add("id = ANY(?::uuid[])", uuidArray(filter.IDs))
+	}
+	if filter.OwnerID != "" {
+		where.add("owner_id = ?", filter.OwnerID)
+	}
+	if filter.Status != "" {
+		where.add("status = ?", filter.Status)
+	}
+	if len(filter.Tags) > 0 {
+		tags, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return "", nil, err
+		}
+		// jsonb_path_ops индекс обслуживает только оператор @>
+		where.add("metadata @> ?::jsonb", string(tags))
+	}
+	if filter.MinBalance != nil {
+		where.add("balance >= ?", *filter.MinBalance)
+	}
+	if filter.MaxBalance != nil {
+		where.add("balance <= ?", *filter.MaxBalance)
+	}
+	if filter.CreatedFrom != nil {
+		where.add("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		where.add("created_at < ?", *filter.CreatedTo)
+	}
+	if filter.UpdatedFrom != nil {
+		where.add("updated_at >= ?", *filter.UpdatedFrom)
+	}
+	if filter.UpdatedTo != nil {
+		where.add("updated_at < ?", *filter.UpdatedTo)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		cast := "timestamp"
+		if filter.SortBy == models.WalletSortBalance {
+			cast = "bigint"
+		}
+		// Сравнение строк (поле, id) дает стабильную позицию даже при одинаковых значениях поля
+		where.add(fmt.Sprintf("(%s, id) %s (?::%s, ?::uuid)", column, comparison, cast), filter.After.Value, filter.After.ID)
+	}
+
+	query := `SELECT ` + walletColumns + ` FROM wallets` + where.sql() +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, where.arg(filter.Limit))
+
+	return query, where.args, nil
+}
 
+// UpdateWalletBalance проводит пополнение или списание. Пополнение несуществующего кошелька создает его;
+// непустой ownerID становится владельцем нового кошелька
+func (r *WalletRepository) UpdateWalletBalance(walletID string, operationType string, amount utils.Money, ownerID string) (*models.OperationResult, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
 	}
 	defer tx.Rollback()
 
+	// Комиссия берется с кошелька: пополнение зачисляется за ее вычетом, списание увеличивается на нее
+	fee, err := computeFee(tx, walletID, operationType, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !fee.CoversFee() {
+		return nil, fmt.Errorf("update wallet balance: %w", ErrFeeExceedsAmount)
+	}
+
+	// Списание не должно увести баланс ниже кредитной линии: условие проверяется под блокировкой строки,
+	// поэтому параллельные списания не проходят вдвоем
 	updateQuery := `
 		UPDATE wallets 
 		SET balance = CASE 
@@ -59,47 +178,48 @@ func (r *WalletRepository) UpdateWalletBalance(walletID string, operationType st
 			WHEN $2 = $5 THEN balance - $4
 		END,
 		updated_at = NOW()
-		WHERE id = $1
-		RETURNING 
-		id, 
-		balance, 
-		created_at, 
-		updated_at
-	`
+		WHERE id = $1 AND status <> $6
+		AND ($2 <> $5 OR balance - $4 >= -overdraft_limit)
+		RETURNING ` + walletColumns
 
-	err = tx.QueryRow(
+	wallet, err := scanWallet(tx.QueryRow(
 		updateQuery,
 		walletID,
 		operationType,
 		models.OperationTypeDeposit,
-		amount,
+		fee.WalletAmount(),
 		models.OperationTypeWithdraw,
-	).Scan(
-		&wallet.ID,
-		&wallet.Balance,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-	)
+		models.WalletStatusFrozen,
+	))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
+			// Строка не обновлена: кошелька нет, он заморожен или списание выходит за кредитную линию
+			var status string
+			err = tx.QueryRow(`SELECT status FROM wallets WHERE id = $1`, walletID).Scan(&status)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, fmt.Errorf("update wallet balance: %w", ErrDatabaseError)
+			}
+			if err == nil {
+				if status == models.WalletStatusFrozen {
+					return nil, fmt.Errorf("update wallet balance: %w", ErrWalletFrozen)
+				}
+				return nil, fmt.Errorf("update wallet balance: %w", ErrInsufficientFunds)
+			}
+
 			if operationType == models.OperationTypeDeposit {
 				createQuery := `
-					INSERT INTO wallets (id, balance, created_at, updated_at)
-					VALUES ($1, $2, NOW(), NOW())
-					RETURNING id, balance, created_at, updated_at
-				`
+					INSERT INTO wallets (id, balance, owner_id, tier, created_at, updated_at)
+					VALUES ($1, $2, $3, $4, NOW(), NOW())
+					RETURNING ` + walletColumns
 
-				err = tx.QueryRow(
+				wallet, err = scanWallet(tx.QueryRow(
 					createQuery,
 					walletID,
-					amount,
-				).Scan(
-					&wallet.ID,
-					&wallet.Balance,
-					&wallet.CreatedAt,
-					&wallet.UpdatedAt,
-				)
+					fee.WalletAmount(),
+					sql.NullString{String: ownerID, Valid: ownerID != ""},
+					models.DefaultWalletTier,
+				))
 
 				if err != nil {
 					return nil, fmt.Errorf("create wallet: %w", ErrDatabaseError)
@@ -112,33 +232,265 @@ func (r *WalletRepository) UpdateWalletBalance(walletID string, operationType st
 		}
 	}
 
+	if err = checkWalletLimits(tx, wallet.ID, operationType, amount, wallet.Balance); err != nil {
+		return nil, err
+	}
+
+	entries := models.NewFeeLedgerEntries(wallet, operationType, amount, fee)
+	for i := range entries {
+		if err = insertLedgerEntry(tx, &entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	journal := models.NewOperationJournal(wallet.ID, operationType, amount)
+	journal.ChargeFee(wallet.ID, fee.Fee)
+	if err = insertJournal(tx, journal); err != nil {
+		return nil, err
+	}
+
+	// События пишутся в той же транзакции, что и баланс: либо сохранится и то, и другое, либо ничего.
+	// Каждая проводка дает свое событие с балансом после нее
+	for _, entry := range entries {
+		state := *wallet
+		state.Balance = entry.BalanceAfter
+		if err = insertOutboxEvent(tx, models.NewBalanceChangedEvent(&state, entry.EntryType, entry.Amount)); err != nil {
+			return nil, err
+		}
+	}
+	if operationType == models.OperationTypeWithdraw && models.CrossesIntoOverdraft(wallet.Balance.Add(fee.WalletAmount()), wallet.Balance) {
+		if err = insertOutboxEvent(tx, models.NewWalletOverdrawnEvent(wallet, fee.WalletAmount())); err != nil {
+			return nil, err
+		}
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
 	}
 
-	return &wallet, nil
+	return &models.OperationResult{Wallet: wallet, TransactionID: journal.ID, Entry: entries[0], Fee: fee}, nil
 }
 
 func (r *WalletRepository) CreateWallet(wallet *models.Wallet) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	if wallet.Status == "" {
+		wallet.Status = models.WalletStatusActive
+	}
+
 	query := `
 		INSERT INTO wallets (
 		id, 
 		balance, 
+		owner_id, 
+		status, 
+		metadata, 
 		created_at, 
-		updated_at
+		updated_at,
+		overdraft_limit,
+		tier
 		)
-		VALUES ($1, $2, $3, $4)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.Exec(
+	_, err = tx.Exec(
 		query,
 		wallet.ID,
 		wallet.Balance,
+		wallet.OwnerID,
+		wallet.Status,
+		wallet.Metadata,
 		wallet.CreatedAt,
-		wallet.UpdatedAt)
+		wallet.UpdatedAt,
+		wallet.OverdraftLimit,
+		models.DefaultWalletTier)
 	if err != nil {
 		return fmt.Errorf("create wallet: %w", ErrDatabaseError)
 	}
 
+	// Начальный баланс тоже должен попасть в журнал, иначе история кошелька не сойдется с балансом
+	if !wallet.Balance.IsZero() {
+		entry := models.NewLedgerEntry(wallet, models.LedgerEntryOpeningBalance, wallet.Balance)
+		if err = insertLedgerEntry(tx, &entry); err != nil {
+			return err
+		}
+		if err = insertJournal(tx, models.NewOperationJournal(wallet.ID, models.LedgerEntryOpeningBalance, wallet.Balance)); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+
 	return nil
 }
+
+// TransferBetweenWallets списывает amount с fromID и зачисляет на toID в одной транзакции
+func (r *WalletRepository) TransferBetweenWallets(fromID, toID string, amount utils.Money) (*models.TransferResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	result, err := transferBetweenWallets(tx, fromID, toID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return result, nil
+}
+
+// transferBetweenWallets проводит перевод в транзакции вызывающего: баланс, комиссия, лимиты, журнал и события.
+// Кошельки блокируются в порядке ID, чтобы встречные переводы не приводили к взаимоблокировке
+func transferBetweenWallets(tx *sql.Tx, fromID, toID string, amount utils.Money) (*models.TransferResult, error) {
+	rows, err := tx.Query(
+		`SELECT id, balance, overdraft_limit, status FROM wallets WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`,
+		fromID,
+		toID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("lock wallets: %w", ErrDatabaseError)
+	}
+
+	locked := make(map[string]models.Wallet, 2)
+	frozen := false
+	for rows.Next() {
+		var id string
+		var wallet models.Wallet
+		if err := rows.Scan(&id, &wallet.Balance, &wallet.OverdraftLimit, &wallet.Status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("lock wallets: %w", ErrDatabaseError)
+		}
+		locked[id] = wallet
+		frozen = frozen || wallet.Status == models.WalletStatusFrozen
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("lock wallets: %w", ErrDatabaseError)
+	}
+
+	fromWallet, fromExists := locked[fromID]
+	if _, toExists := locked[toID]; !fromExists || !toExists {
+		return nil, fmt.Errorf("transfer: %w", ErrWalletNotFound)
+	}
+	if frozen {
+		return nil, fmt.Errorf("transfer: %w", ErrWalletFrozen)
+	}
+
+	// Комиссию перевода платит отправитель по правилу своего уровня
+	fee, err := computeFee(tx, fromID, models.JournalOperationTransfer, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !fromWallet.CanDebit(fee.Gross) {
+		return nil, fmt.Errorf("transfer: %w", ErrInsufficientFunds)
+	}
+
+	from, _, err := applyWalletDelta(tx, fromID, models.LedgerEntryTransferOut, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !fee.Fee.IsZero() {
+		if from, _, err = applyWalletDelta(tx, fromID, models.LedgerEntryFee, fee.Fee); err != nil {
+			return nil, err
+		}
+	}
+	to, _, err := applyWalletDelta(tx, toID, models.LedgerEntryTransferIn, amount)
+	if err != nil {
+		return nil, err
+	}
+	journal := models.NewTransferJournal(from.ID, to.ID, amount)
+	journal.ChargeFee(from.ID, fee.Fee)
+	if err = insertJournal(tx, journal); err != nil {
+		return nil, err
+	}
+
+	return &models.TransferResult{From: from, To: to, Fee: fee}, nil
+}
+
+// applyWalletDelta меняет баланс уже заблокированного кошелька на amount (списания - со знаком минус),
+// проверяет кредитную линию и лимиты кошелька и пишет проводку и события в той же транзакции. Возвращает кошелек
+// после изменения и ID проводки. Запись журнала двойной записи добавляет вызывающий: у перевода она одна на оба кошелька
+func applyWalletDelta(tx *sql.Tx, walletID, entryType string, amount utils.Money) (*models.Wallet, int64, error) {
+	delta := amount
+	if models.IsDebitEntry(entryType) {
+		delta = utils.Money{Raw: -amount.Raw}
+	}
+
+	wallet, err := scanWallet(tx.QueryRow(
+		`UPDATE wallets 
+		SET balance = balance + $2, 
+		updated_at = NOW() 
+		WHERE id = $1 AND ($2 >= 0 OR balance + $2 >= -overdraft_limit)
+		RETURNING `+walletColumns,
+		walletID,
+		delta,
+	))
+	if err == sql.ErrNoRows {
+		// Кошелек заблокирован вызывающим, значит строку не обновило только условие кредитной линии
+		return nil, 0, fmt.Errorf("apply wallet delta: %w", ErrInsufficientFunds)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("apply wallet delta: %w", ErrDatabaseError)
+	}
+
+	if err = checkWalletLimits(tx, wallet.ID, entryType, amount, wallet.Balance); err != nil {
+		return nil, 0, err
+	}
+
+	entry := models.NewLedgerEntry(wallet, entryType, amount)
+	if err = insertLedgerEntry(tx, &entry); err != nil {
+		return nil, 0, err
+	}
+
+	if err = insertOutboxEvent(tx, models.NewBalanceChangedEvent(wallet, entryType, amount)); err != nil {
+		return nil, 0, err
+	}
+	if models.CrossesIntoOverdraft(wallet.Balance.Sub(delta), wallet.Balance) {
+		if err = insertOutboxEvent(tx, models.NewWalletOverdrawnEvent(wallet, amount)); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return wallet, entry.ID, nil
+}
+
+func scanWallet(row rowScanner) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := row.Scan(
+		&wallet.ID,
+		&wallet.Balance,
+		&wallet.OwnerID,
+		&wallet.Status,
+		&wallet.Metadata,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+		&wallet.OverdraftLimit,
+	); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func scanWallets(rows *sql.Rows, capacity int) ([]models.Wallet, error) {
+	wallets := make([]models.Wallet, 0, capacity)
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan wallet: %w", ErrDatabaseError)
+		}
+		wallets = append(wallets, *wallet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan wallets: %w", ErrDatabaseError)
+	}
+	return wallets, nil
+}
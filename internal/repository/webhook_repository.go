@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = `
+		id, 
+		client_id, 
+		url, 
+		secret, 
+		COALESCE(wallet_ids::TEXT[], '{}'), 
+		event_types, 
+		low_balance_threshold, 
+		active, 
+		created_at`
+
+func (r *WebhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	_, err := r.db.Exec(
+		`INSERT INTO webhook_subscriptions (
+		id, 
+		client_id, 
+		url, 
+		secret, 
+		wallet_ids, 
+		event_types, 
+		low_balance_threshold, 
+		active, 
+		created_at
+		)
+		VALUES ($1, $2, $3, $4, $5::UUID[], $6, $7, $8, $9)`,
+		sub.ID,
+		sub.ClientID,
+		sub.URL,
+		sub.Secret,
+		uuidArray(sub.WalletIDs),
+		pq.Array(sub.EventTypes),
+		sub.LowBalanceThreshold,
+		sub.Active,
+		sub.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create webhook subscription: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+// ListSubscriptions возвращает подписки клиента, nil clientID означает все подписки
+func (r *WebhookRepository) ListSubscriptions(clientID *uuid.UUID) ([]models.WebhookSubscription, error) {
+	rows, err := r.db.Query(
+		`SELECT`+webhookSubscriptionColumns+` 
+		FROM webhook_subscriptions 
+		WHERE $1::UUID IS NULL OR client_id = $1 
+		ORDER BY created_at`,
+		nullableUUID(clientID),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	return scanWebhookSubscriptions(rows)
+}
+
+func (r *WebhookRepository) GetSubscription(id string) (*models.WebhookSubscription, error) {
+	rows, err := r.db.Query(
+		`SELECT`+webhookSubscriptionColumns+` 
+		FROM webhook_subscriptions 
+		WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook subscription: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	subs, err := scanWebhookSubscriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("get webhook subscription: %w", ErrWebhookSubscriptionNotFound)
+	}
+	return &subs[0], nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(id string) error {
+	result, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", ErrDatabaseError)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", ErrDatabaseError)
+	}
+	if affected == 0 {
+		return fmt.Errorf("delete webhook subscription: %w", ErrWebhookSubscriptionNotFound)
+	}
+	return nil
+}
+
+// FindSubscriptionsForWallet возвращает активные подписки, фильтр по кошельку которых пуст или содержит walletID
+func (r *WebhookRepository) FindSubscriptionsForWallet(walletID uuid.UUID) ([]models.WebhookSubscription, error) {
+	rows, err := r.db.Query(
+		`SELECT`+webhookSubscriptionColumns+` 
+		FROM webhook_subscriptions 
+		WHERE active = TRUE 
+		AND (wallet_ids IS NULL OR cardinality(wallet_ids) = 0 OR $1 = ANY(wallet_ids))`,
+		walletID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find webhook subscriptions: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	return scanWebhookSubscriptions(rows)
+}
+
+// CreateDeliveries сохраняет доставки; повторная обработка того же события ничего не дублирует
+func (r *WebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	for _, d := range deliveries {
+		_, err = tx.Exec(
+			`INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7)
+			ON CONFLICT (subscription_id, event_id, event_type) DO NOTHING`,
+			d.ID,
+			d.SubscriptionID,
+			d.EventID,
+			d.EventType,
+			d.Payload,
+			models.WebhookDeliveryPending,
+			d.NextAttemptAt,
+		)
+		if err != nil {
+			return fmt.Errorf("create webhook delivery: %w", ErrDatabaseError)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) ListDeliveries(subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(
+		`SELECT 
+		id, 
+		subscription_id, 
+		event_id, 
+		event_type, 
+		payload, 
+		status, 
+		attempts, 
+		last_error, 
+		next_attempt_at, 
+		created_at 
+		FROM webhook_deliveries 
+		WHERE subscription_id = $1 
+		ORDER BY created_at DESC 
+		LIMIT $2`,
+		subscriptionID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(
+			&d.ID,
+			&d.SubscriptionID,
+			&d.EventID,
+			&d.EventType,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&d.LastError,
+			&d.NextAttemptAt,
+			&d.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", ErrDatabaseError)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", ErrDatabaseError)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDelivery возвращает доставку в очередь; subscriptionID нужен для проверки принадлежности
+func (r *WebhookRepository) ReplayDelivery(subscriptionID, deliveryID string) error {
+	result, err := r.db.Exec(
+		`UPDATE webhook_deliveries 
+		SET status = $3, next_attempt_at = NOW(), updated_at = NOW() 
+		WHERE id = $1 AND subscription_id = $2`,
+		deliveryID,
+		subscriptionID,
+		models.WebhookDeliveryPending,
+	)
+	if err != nil {
+		return fmt.Errorf("replay webhook delivery: %w", ErrDatabaseError)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("replay webhook delivery: %w", ErrDatabaseError)
+	}
+	if affected == 0 {
+		return fmt.Errorf("replay webhook delivery: %w", ErrWebhookDeliveryNotFound)
+	}
+	return nil
+}
+
+// ClaimDeliveries арендует готовые к отправке доставки на время lease. Аренда фиксируется сразу, до отправки:
+// блокировки держатся только на время UPDATE, SKIP LOCKED позволяет работать нескольким репликам
+func (r *WebhookRepository) ClaimDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(
+		`WITH claimed AS (
+			UPDATE webhook_deliveries 
+			SET claimed_until = NOW() + $3 * INTERVAL '1 millisecond' 
+			WHERE id IN (
+				SELECT d.id 
+				FROM webhook_deliveries d 
+				JOIN webhook_subscriptions s ON s.id = d.subscription_id 
+				WHERE d.status = $1 
+				AND d.next_attempt_at <= NOW() 
+				AND (d.claimed_until IS NULL OR d.claimed_until <= NOW()) 
+				AND s.active = TRUE 
+				ORDER BY d.next_attempt_at 
+				LIMIT $2 
+				FOR UPDATE OF d SKIP LOCKED
+			) 
+			RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at
+		) 
+		SELECT 
+		c.id, 
+		c.subscription_id, 
+		c.event_id, 
+		c.event_type, 
+		c.payload, 
+		c.status, 
+		c.attempts, 
+		c.next_attempt_at, 
+		c.created_at, 
+		s.url, 
+		s.secret 
+		FROM claimed c 
+		JOIN webhook_subscriptions s ON s.id = c.subscription_id 
+		ORDER BY c.next_attempt_at`,
+		models.WebhookDeliveryPending,
+		limit,
+		lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", ErrDatabaseError)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(
+			&d.ID,
+			&d.SubscriptionID,
+			&d.EventID,
+			&d.EventType,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.CreatedAt,
+			&d.URL,
+			&d.Secret,
+		); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", ErrDatabaseError)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", ErrDatabaseError)
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt сохраняет попытку и итог доставки отдельной короткой транзакцией и снимает аренду.
+// Доставка, уже завершенная другим worker'ом после истечения аренды, не меняется; попытка сохраняется в любом случае
+func (r *WebhookRepository) RecordAttempt(attempt models.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	var attemptErr sql.NullString
+	if attempt.Error != "" {
+		attemptErr = sql.NullString{String: attempt.Error, Valid: true}
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", ErrDatabaseError)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO webhook_delivery_attempts (delivery_id, attempted_at, response_status, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)`,
+		attempt.DeliveryID,
+		attempt.AttemptedAt,
+		attempt.ResponseStatus,
+		attemptErr,
+		attempt.Duration.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("record webhook attempt: %w", ErrDatabaseError)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE webhook_deliveries 
+		SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4, claimed_until = NULL, updated_at = NOW() 
+		WHERE id = $1 AND status = $5`,
+		attempt.DeliveryID,
+		status,
+		attemptErr,
+		nextAttemptAt,
+		models.WebhookDeliveryPending,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery: %w", ErrDatabaseError)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", ErrDatabaseError)
+	}
+	return nil
+}
+
+func scanWebhookSubscriptions(rows *sql.Rows) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		var walletIDs []string
+		var threshold sql.NullInt64
+
+		if err := rows.Scan(
+			&sub.ID,
+			&sub.ClientID,
+			&sub.URL,
+			&sub.Secret,
+			pq.Array(&walletIDs),
+			pq.Array(&sub.EventTypes),
+			&threshold,
+			&sub.Active,
+			&sub.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan webhook subscription: %w", ErrDatabaseError)
+		}
+
+		for _, raw := range walletIDs {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, fmt.Errorf("parse webhook wallet id: %w", ErrDatabaseError)
+			}
+			sub.WalletIDs = append(sub.WalletIDs, id)
+		}
+		if threshold.Valid {
+			sub.LowBalanceThreshold = &utils.Money{Raw: threshold.Int64}
+		}
+
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan webhook subscriptions: %w", ErrDatabaseError)
+	}
+
+	return subs, nil
+}
+
+func uuidArray(ids []uuid.UUID) interface{} {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	raw := make([]string, 0, len(ids))
+	for _, id := range ids {
+		raw = append(raw, id.String())
+	}
+	return pq.Array(raw)
+}
+
+func nullableUUID(id *uuid.UUID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+)
+
+type InterestConfig struct {
+	// AnnualRateBP - годовая ставка в базисных пунктах (1 б.п. = 0,01%)
+	AnnualRateBP int64
+	// Rounding - округление суммы процентов за месяц до копейки
+	Rounding  utils.Rounding
+	BatchSize int
+}
+
+// InterestJob ежедневно начисляет проценты на положительные остатки и раз в месяц выплачивает их проводками.
+// Дни после простоя досчитываются по одному, каждый в своей транзакции
+type InterestJob struct {
+	repo repository.InterestRepositoryInterface
+	cfg  InterestConfig
+}
+
+func NewInterestJob(repo repository.InterestRepositoryInterface, cfg InterestConfig) *InterestJob {
+	return &InterestJob{repo: repo, cfg: cfg}
+}
+
+func (j *InterestJob) Name() string {
+	return "interest"
+}
+
+func (j *InterestJob) Run(ctx context.Context, now time.Time) error {
+	last, err := j.repo.GetLastAccrualDay()
+	if err != nil {
+		return err
+	}
+
+	for _, day := range models.PendingAccrualDays(last, now) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		accrued, err := j.repo.AccrueInterest(day, j.cfg.AnnualRateBP)
+		if err != nil {
+			return err
+		}
+		metrics.InterestAccrualDaysTotal.Add(1)
+		metrics.InterestAccrualsTotal.Add(accrued)
+		logger.GlobalLogger.Info("Проценты за %s начислены кошелькам: %d", day.Format(time.DateOnly), accrued)
+
+		accruedDay := day
+		last = &accruedDay
+	}
+	if last == nil {
+		return nil
+	}
+
+	// Выплачиваются только месяцы, все дни которых уже начислены
+	return j.post(ctx, models.MonthStart(last.AddDate(0, 0, 1)))
+}
+
+// post выплачивает проценты за месяцы раньше before. Ошибка выплаты одному кошельку не останавливает остальные:
+// его начисления останутся невыплаченными до следующего запуска
+func (j *InterestJob) post(ctx context.Context, before time.Time) error {
+	var afterPeriod time.Time
+	afterWalletID := uuid.Nil
+	for {
+		pending, err := j.repo.ListUnpostedInterest(before, afterPeriod, afterWalletID, j.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range pending {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			posting, err := j.repo.PostInterest(p.WalletID, p.Period, j.cfg.Rounding)
+			if err != nil {
+				logger.GlobalLogger.Error("Ошибка выплаты процентов кошельку %s за %s: %v",
+					p.WalletID, p.Period.Format("2006-01"), err)
+				continue
+			}
+			if posting != nil {
+				metrics.InterestPostingsTotal.Add(1)
+			}
+		}
+
+		if len(pending) < j.cfg.BatchSize {
+			return nil
+		}
+		afterPeriod = pending[len(pending)-1].Period
+		afterWalletID = pending[len(pending)-1].WalletID
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+type mockInterestRepository struct {
+	lastDay  *time.Time
+	accrued  []time.Time
+	unposted []models.InterestPosting
+	before   time.Time
+	posted   []models.InterestPosting
+}
+
+func (m *mockInterestRepository) GetLastAccrualDay() (*time.Time, error) {
+	return m.lastDay, nil
+}
+
+func (m *mockInterestRepository) AccrueInterest(day time.Time, rateBP int64) (int64, error) {
+	m.accrued = append(m.accrued, day)
+	return 2, nil
+}
+
+func (m *mockInterestRepository) ListUnpostedInterest(before, afterPeriod time.Time, afterWalletID uuid.UUID, limit int) ([]models.InterestPosting, error) {
+	m.before = before
+	var batch []models.InterestPosting
+	for _, p := range m.unposted {
+		after := p.Period.After(afterPeriod) || (p.Period.Equal(afterPeriod) && p.WalletID.String() > afterWalletID.String())
+		if p.Period.Before(before) && after && len(batch) < limit {
+			batch = append(batch, p)
+		}
+	}
+	return batch, nil
+}
+
+func (m *mockInterestRepository) PostInterest(walletID uuid.UUID, period time.Time, rounding utils.Rounding) (*models.InterestPosting, error) {
+	posting := models.InterestPosting{WalletID: walletID, Period: period}
+	m.posted = append(m.posted, posting)
+	return &posting, nil
+}
+
+func TestInterestJob_CatchesUpMissedDaysAndPostsClosedMonths(t *testing.T) {
+	last := time.Date(2025, time.October, 29, 0, 0, 0, 0, time.UTC)
+	october := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	november := time.Date(2025, time.November, 1, 0, 0, 0, 0, time.UTC)
+	repo := &mockInterestRepository{
+		lastDay: &last,
+		unposted: []models.InterestPosting{
+			{WalletID: uuid.UUID{15: 1}, Period: october},
+			{WalletID: uuid.UUID{15: 2}, Period: october},
+			{WalletID: uuid.UUID{15: 3}, Period: october},
+			{WalletID: uuid.UUID{15: 1}, Period: november},
+		},
+	}
+	job := NewInterestJob(repo, InterestConfig{AnnualRateBP: 500, Rounding: utils.RoundHalfUp, BatchSize: 2})
+
+	now := time.Date(2025, time.November, 2, 3, 0, 0, 0, time.UTC)
+	if err := job.Run(context.Background(), now); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Простой с 30 октября: досчитываются 30, 31 октября и 1 ноября
+	if len(repo.accrued) != 3 || !repo.accrued[0].Equal(last.AddDate(0, 0, 1)) || !repo.accrued[2].Equal(november) {
+		t.Errorf("accrued days = %v, want 2025-10-30..2025-11-01", repo.accrued)
+	}
+	if !repo.before.Equal(november) {
+		t.Errorf("posting before = %v, want %v", repo.before, november)
+	}
+	if len(repo.posted) != 3 {
+		t.Errorf("posted = %d, want 3 October postings across batches", len(repo.posted))
+	}
+}
+
+func TestInterestJob_DoesNotPostIncompleteMonth(t *testing.T) {
+	last := time.Date(2025, time.November, 1, 0, 0, 0, 0, time.UTC)
+	repo := &mockInterestRepository{lastDay: &last}
+	job := NewInterestJob(repo, InterestConfig{AnnualRateBP: 500, Rounding: utils.RoundHalfUp, BatchSize: 10})
+
+	// Уже начислено по 1 ноября, сейчас 2 ноября: новых дней нет, октябрь закрыт
+	if err := job.Run(context.Background(), time.Date(2025, time.November, 2, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(repo.accrued) != 0 {
+		t.Errorf("accrued = %v, want none", repo.accrued)
+	}
+	if want := time.Date(2025, time.November, 1, 0, 0, 0, 0, time.UTC); !repo.before.Equal(want) {
+		t.Errorf("posting before = %v, want %v", repo.before, want)
+	}
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/utils/logger"
+)
+
+// LeaderLockKey - ключ advisory-блокировки, которую держит реплика, выполняющая задачи планировщика
+const LeaderLockKey int64 = 7395081442
+
+// LeaderLock - блокировка, которую в каждый момент держит не больше одной реплики
+type LeaderLock interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Release() error
+}
+
+// Job - периодическая задача планировщика. now - время запуска тика: задача досчитывает все,
+// что должно было выполниться к этому моменту, поэтому пропущенные при простое запуски не теряются
+type Job interface {
+	Name() string
+	Run(ctx context.Context, now time.Time) error
+}
+
+// Scheduler выполняет зарегистрированные задачи по очереди раз в интервал, но только пока держит
+// блокировку лидера: на нескольких репликах задачи выполняет одна
+type Scheduler struct {
+	lock     LeaderLock
+	interval time.Duration
+	jobs     []Job
+}
+
+func New(lock LeaderLock, interval time.Duration) *Scheduler {
+	return &Scheduler{lock: lock, interval: interval}
+}
+
+// Register добавляет задачу; задачи выполняются в порядке регистрации
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	defer func() {
+		if err := s.lock.Release(); err != nil {
+			logger.GlobalLogger.Error("Ошибка освобождения блокировки планировщика: %v", err)
+		}
+		metrics.SchedulerLeader.Set(0)
+	}()
+
+	for {
+		if _, err := s.RunOnce(ctx, time.Now()); err != nil {
+			logger.GlobalLogger.Error("Ошибка планировщика: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce выполняет все задачи, если реплика держит блокировку лидера, и возвращает, держит ли она ее.
+// Ошибка одной задачи не мешает выполнить следующие
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) (bool, error) {
+	leader, err := s.lock.TryAcquire(ctx)
+	if err != nil {
+		metrics.SchedulerLeader.Set(0)
+		return false, err
+	}
+	if !leader {
+		metrics.SchedulerLeader.Set(0)
+		return false, nil
+	}
+	metrics.SchedulerLeader.Set(1)
+
+	for _, job := range s.jobs {
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+		if err := job.Run(ctx, now); err != nil {
+			metrics.SchedulerJobFailuresTotal.Add(1)
+			logger.GlobalLogger.Error("Ошибка задачи планировщика %s: %v", job.Name(), err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-api/utils/logger"
+)
+
+func init() {
+	logger.Init()
+}
+
+type mockLock struct {
+	available bool
+	err       error
+	released  bool
+}
+
+func (m *mockLock) TryAcquire(ctx context.Context) (bool, error) {
+	return m.available, m.err
+}
+
+func (m *mockLock) Release() error {
+	m.released = true
+	return nil
+}
+
+type mockJob struct {
+	name string
+	err  error
+	runs []time.Time
+}
+
+func (m *mockJob) Name() string {
+	return m.name
+}
+
+func (m *mockJob) Run(ctx context.Context, now time.Time) error {
+	m.runs = append(m.runs, now)
+	return m.err
+}
+
+func TestScheduler_RunOnce_Follower(t *testing.T) {
+	job := &mockJob{name: "job"}
+	s := New(&mockLock{available: false}, time.Minute)
+	s.Register(job)
+
+	leader, err := s.RunOnce(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if leader || len(job.runs) != 0 {
+		t.Errorf("RunOnce() leader = %v, runs = %d; want follower without runs", leader, len(job.runs))
+	}
+}
+
+func TestScheduler_RunOnce_LeaderRunsAllJobs(t *testing.T) {
+	failing := &mockJob{name: "failing", err: errors.New("boom")}
+	next := &mockJob{name: "next"}
+	s := New(&mockLock{available: true}, time.Minute)
+	s.Register(failing)
+	s.Register(next)
+
+	now := time.Date(2025, time.November, 1, 0, 1, 0, 0, time.UTC)
+	leader, err := s.RunOnce(context.Background(), now)
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if !leader {
+		t.Fatal("RunOnce() leader = false, want true")
+	}
+	if len(failing.runs) != 1 || len(next.runs) != 1 || !next.runs[0].Equal(now) {
+		t.Errorf("runs = %d, %d; want both jobs run once at now", len(failing.runs), len(next.runs))
+	}
+}
+
+func TestScheduler_RunOnce_LockError(t *testing.T) {
+	job := &mockJob{name: "job"}
+	s := New(&mockLock{err: errors.New("connection refused")}, time.Minute)
+	s.Register(job)
+
+	if _, err := s.RunOnce(context.Background(), time.Now()); err == nil {
+		t.Error("RunOnce() error = nil, want lock error")
+	}
+	if len(job.runs) != 0 {
+		t.Errorf("job runs = %d, want 0", len(job.runs))
+	}
+}
+
+func TestScheduler_RunReleasesLock(t *testing.T) {
+	lock := &mockLock{available: true}
+	s := New(lock, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+
+	if !lock.released {
+		t.Error("lock was not released after Run returned")
+	}
+}
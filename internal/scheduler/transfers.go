@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils/logger"
+)
+
+// TransferJob выполняет наступившие запуски расписаний переводов. Пропущенные при простое запуски выполняются
+// по очереди: каждый запуск проводится отдельно и ровно один раз
+type TransferJob struct {
+	repo      repository.ScheduledTransferRepositoryInterface
+	batchSize int
+}
+
+func NewTransferJob(repo repository.ScheduledTransferRepositoryInterface, batchSize int) *TransferJob {
+	return &TransferJob{repo: repo, batchSize: batchSize}
+}
+
+func (j *TransferJob) Name() string {
+	return "scheduled_transfers"
+}
+
+func (j *TransferJob) Run(ctx context.Context, now time.Time) error {
+	for {
+		due, err := j.repo.ListDueScheduledTransfers(now, j.batchSize)
+		if err != nil {
+			return err
+		}
+
+		executed := 0
+		for _, st := range due {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Техническая ошибка прерывает задачу: иначе то же расписание вернулось бы в следующей порции
+			run, err := j.repo.ExecuteScheduledTransfer(st.ID, *st.NextRunAt, now)
+			if err != nil {
+				return err
+			}
+			if run == nil {
+				continue
+			}
+			executed++
+
+			if run.Status == models.ScheduledRunSucceeded {
+				metrics.ScheduledTransfersExecutedTotal.Add(1)
+				continue
+			}
+			metrics.ScheduledTransfersFailedTotal.Add(1)
+			logger.GlobalLogger.Warning("Перевод по расписанию %s за %s не выполнен (%s), попытка %d: %s",
+				st.ID, run.OccurrenceAt.Format(time.RFC3339), run.FailureReason, run.Attempts, run.Status)
+		}
+
+		// Порция без выполненных запусков значит, что их перехватили изменения расписаний: остальное - на следующем тике
+		if len(due) < j.batchSize || executed == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type mockScheduledTransferRepository struct {
+	due      []models.ScheduledTransfer
+	failures map[uuid.UUID]bool
+	executed []uuid.UUID
+	err      error
+}
+
+func (m *mockScheduledTransferRepository) CreateScheduledTransfer(st *models.ScheduledTransfer) error {
+	return nil
+}
+
+func (m *mockScheduledTransferRepository) GetScheduledTransfer(id string) (*models.ScheduledTransfer, error) {
+	return nil, nil
+}
+
+func (m *mockScheduledTransferRepository) ListScheduledTransfers(clientID *uuid.UUID, ownerID string) ([]models.ScheduledTransfer, error) {
+	return nil, nil
+}
+
+func (m *mockScheduledTransferRepository) UpdateScheduledTransfer(id string, update func(st *models.ScheduledTransfer) error) (*models.ScheduledTransfer, error) {
+	return nil, nil
+}
+
+func (m *mockScheduledTransferRepository) ListDueScheduledTransfers(now time.Time, limit int) ([]models.ScheduledTransfer, error) {
+	var batch []models.ScheduledTransfer
+	for _, st := range m.due {
+		if len(batch) == limit {
+			break
+		}
+		batch = append(batch, st)
+	}
+	return batch, nil
+}
+
+func (m *mockScheduledTransferRepository) ExecuteScheduledTransfer(id uuid.UUID, occurrenceAt, now time.Time) (*models.ScheduledTransferRun, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	// Выполненный запуск уходит из очереди, как после Advance в репозитории
+	for i, st := range m.due {
+		if st.ID == id {
+			m.due = append(m.due[:i], m.due[i+1:]...)
+			break
+		}
+	}
+	m.executed = append(m.executed, id)
+
+	run := &models.ScheduledTransferRun{ScheduleID: id, OccurrenceAt: occurrenceAt, Status: models.ScheduledRunSucceeded, Attempts: 1}
+	if m.failures[id] {
+		run.Status = models.ScheduledRunRetrying
+		run.FailureReason = models.ScheduledRunInsufficientFunds
+	}
+	return run, nil
+}
+
+func (m *mockScheduledTransferRepository) ListScheduledTransferRuns(scheduleID string, limit int) ([]models.ScheduledTransferRun, error) {
+	return nil, nil
+}
+
+func TestTransferJob_ExecutesDueSchedulesInBatches(t *testing.T) {
+	occurrence := time.Date(2025, time.November, 1, 9, 0, 0, 0, time.UTC)
+	repo := &mockScheduledTransferRepository{failures: map[uuid.UUID]bool{}}
+	for i := 0; i < 5; i++ {
+		repo.due = append(repo.due, models.ScheduledTransfer{ID: uuid.New(), NextRunAt: &occurrence})
+	}
+	repo.failures[repo.due[1].ID] = true
+
+	executedBefore := metrics.ScheduledTransfersExecutedTotal.Value()
+	failedBefore := metrics.ScheduledTransfersFailedTotal.Value()
+
+	job := NewTransferJob(repo, 2)
+	if err := job.Run(context.Background(), occurrence.Add(time.Minute)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(repo.executed) != 5 || len(repo.due) != 0 {
+		t.Errorf("executed = %d, left = %d; want 5 and 0", len(repo.executed), len(repo.due))
+	}
+	if got := metrics.ScheduledTransfersExecutedTotal.Value() - executedBefore; got != 4 {
+		t.Errorf("executed metric += %d, want 4", got)
+	}
+	if got := metrics.ScheduledTransfersFailedTotal.Value() - failedBefore; got != 1 {
+		t.Errorf("failed metric += %d, want 1", got)
+	}
+}
+
+func TestTransferJob_StopsOnRepositoryError(t *testing.T) {
+	occurrence := time.Date(2025, time.November, 1, 9, 0, 0, 0, time.UTC)
+	repo := &mockScheduledTransferRepository{
+		due: []models.ScheduledTransfer{{ID: uuid.New(), NextRunAt: &occurrence}},
+		err: errors.New("connection reset"),
+	}
+
+	if err := NewTransferJob(repo, 10).Run(context.Background(), occurrence); err == nil {
+		t.Error("Run() error = nil, want repository error")
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ledgerChainBatchSize - сколько проводок читается за один запрос при проверке цепочек хэшей
+const ledgerChainBatchSize = 1000
+
+// AccountingService отвечает за отчеты по журналу двойной записи и результаты сверки балансов
+type AccountingService struct {
+	repo           repository.JournalRepositoryInterface
+	reconciliation repository.ReconciliationRepositoryInterface
+}
+
+func NewAccountingService(repo repository.JournalRepositoryInterface, reconciliation repository.ReconciliationRepositoryInterface) *AccountingService {
+	return &AccountingService{repo: repo, reconciliation: reconciliation}
+}
+
+// GetTrialBalance возвращает оборотную ведомость; расхождения не считаются ошибкой, их показывает TrialBalance.IsBalanced
+func (s *AccountingService) GetTrialBalance(ctx context.Context) (*models.TrialBalance, error) {
+	balance, err := s.repo.GetTrialBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get trial balance: %w", err)
+	}
+	return balance, nil
+}
+
+// GetLatestReconciliation возвращает последнюю сверку и до limit найденных в ней расхождений.
+// Сверка может быть еще не завершена: тогда в отчете расхождения уже проверенных кошельков
+func (s *AccountingService) GetLatestReconciliation(limit int) (*models.ReconciliationReport, error) {
+	run, err := s.reconciliation.GetLatestReconciliationRun()
+	if err != nil {
+		return nil, fmt.Errorf("get latest reconciliation: %w", err)
+	}
+
+	mismatches, err := s.reconciliation.ListReconciliationMismatches(run.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get latest reconciliation: %w", err)
+	}
+
+	return &models.ReconciliationReport{Run: *run, Mismatches: mismatches}, nil
+}
+
+// VerifyLedgerChain проходит цепочки хэшей журнала кошелька walletID (nil - всех кошельков)
+// и останавливается на первом звене, которое не сходится
+func (s *AccountingService) VerifyLedgerChain(ctx context.Context, walletID *uuid.UUID) (*models.LedgerChainVerification, error) {
+	var result models.LedgerChainVerification
+	var prevHash []byte
+	afterWallet, afterID := uuid.Nil, int64(0)
+
+	for {
+		entries, err := s.repo.ListLedgerChain(ctx, walletID, afterWallet, afterID, ledgerChainBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("verify ledger chain: %w", err)
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if entry.WalletID != afterWallet {
+				// Цепочка следующего кошелька начинается с пустого хэша
+				prevHash = nil
+				result.WalletsChecked++
+			}
+			afterWallet, afterID = entry.WalletID, entry.ID
+			result.EntriesChecked++
+
+			if broken := entry.VerifyLink(prevHash); broken != nil {
+				result.Break = broken
+				return &result, nil
+			}
+			prevHash = entry.Hash
+		}
+
+		if len(entries) < ledgerChainBatchSize {
+			return &result, nil
+		}
+	}
+}
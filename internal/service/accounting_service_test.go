@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+type MockJournalRepository struct {
+	repository.JournalRepositoryInterface
+	// entries упорядочены по (wallet_id, id), как в БД
+	entries []models.LedgerEntry
+}
+
+func (m *MockJournalRepository) ListLedgerChain(ctx context.Context, walletID *uuid.UUID, afterWalletID uuid.UUID, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	var page []models.LedgerEntry
+	for _, entry := range m.entries {
+		if walletID != nil && entry.WalletID != *walletID {
+			continue
+		}
+		cmp := compareUUID(entry.WalletID, afterWalletID)
+		if cmp < 0 || (cmp == 0 && entry.ID <= afterID) {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, entry)
+	}
+	return page, nil
+}
+
+func compareUUID(a, b uuid.UUID) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// hashChain строит цепочку из n проводок кошелька с хэшами, как их пишет триггер БД
+func hashChain(walletID uuid.UUID, firstID int64, n int) []models.LedgerEntry {
+	entries := make([]models.LedgerEntry, n)
+	var prevHash []byte
+	for i := range entries {
+		entries[i] = models.LedgerEntry{
+			ID:           firstID + int64(i),
+			WalletID:     walletID,
+			EntryType:    models.OperationTypeDeposit,
+			Amount:       utils.Money{Raw: 100},
+			BalanceAfter: utils.Money{Raw: int64(100 * (i + 1))},
+			CreatedAt:    time.Date(2025, 10, 20, 10, 0, i, 0, time.UTC),
+			PrevHash:     prevHash,
+		}
+		entries[i].Hash = entries[i].ComputeHash(prevHash)
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+func TestAccountingService_VerifyLedgerChain(t *testing.T) {
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	second := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	// Цепочка длиннее пачки, чтобы проверить продолжение по курсору
+	valid := append(hashChain(first, 1, ledgerChainBatchSize+1), hashChain(second, ledgerChainBatchSize+2, 3)...)
+
+	svc := NewAccountingService(&MockJournalRepository{entries: valid}, nil)
+	result, err := svc.VerifyLedgerChain(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("VerifyLedgerChain() error = %v", err)
+	}
+	if result.Break != nil {
+		t.Fatalf("VerifyLedgerChain() break = %+v, want none", result.Break)
+	}
+	if result.WalletsChecked != 2 || result.EntriesChecked != int64(len(valid)) {
+		t.Errorf("checked %d wallets / %d entries, want 2 / %d", result.WalletsChecked, result.EntriesChecked, len(valid))
+	}
+
+	result, err = svc.VerifyLedgerChain(context.Background(), &second)
+	if err != nil {
+		t.Fatalf("VerifyLedgerChain(wallet) error = %v", err)
+	}
+	if result.Break != nil || result.WalletsChecked != 1 || result.EntriesChecked != 3 {
+		t.Errorf("VerifyLedgerChain(wallet) = %+v, want 1 wallet / 3 entries", result)
+	}
+
+	t.Run("edited entry", func(t *testing.T) {
+		tampered := append([]models.LedgerEntry(nil), valid...)
+		tampered[5].BalanceAfter = utils.Money{Raw: 999999}
+
+		result, err := NewAccountingService(&MockJournalRepository{entries: tampered}, nil).VerifyLedgerChain(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("VerifyLedgerChain() error = %v", err)
+		}
+		if result.Break == nil || result.Break.Reason != models.LedgerChainHashMismatch || result.Break.EntryID != tampered[5].ID {
+			t.Errorf("VerifyLedgerChain() break = %+v, want %s at entry %d", result.Break, models.LedgerChainHashMismatch, tampered[5].ID)
+		}
+	})
+
+	t.Run("deleted entry", func(t *testing.T) {
+		tampered := append(append([]models.LedgerEntry(nil), valid[:ledgerChainBatchSize+2]...), valid[ledgerChainBatchSize+3:]...)
+
+		result, err := NewAccountingService(&MockJournalRepository{entries: tampered}, nil).VerifyLedgerChain(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("VerifyLedgerChain() error = %v", err)
+		}
+		want := valid[ledgerChainBatchSize+3]
+		if result.Break == nil || result.Break.Reason != models.LedgerChainPrevHashMismatch || result.Break.WalletID != second || result.Break.EntryID != want.ID {
+			t.Errorf("VerifyLedgerChain() break = %+v, want %s at entry %d", result.Break, models.LedgerChainPrevHashMismatch, want.ID)
+		}
+	})
+}
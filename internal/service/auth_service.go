@@ -0,0 +1,145 @@
+package service
+
+import (
+	"database/sql"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type AuthService struct {
+	repo          repository.APIClientRepositoryInterface
+	rotateOverlap time.Duration
+	now           func() time.Time
+}
+
+// NewAuthService создает сервис API ключей, rotateOverlap задает, сколько старый ключ живет после ротации
+func NewAuthService(repo repository.APIClientRepositoryInterface, rotateOverlap time.Duration) *AuthService {
+	return &AuthService{repo: repo, rotateOverlap: rotateOverlap, now: time.Now}
+}
+
+func (s *AuthService) AuthenticateAPIKey(key string) (*models.APIClient, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("authenticate api key: %w", ErrInvalidAPIKey)
+	}
+
+	client, err := s.repo.GetClientByKeyHash(auth.HashAPIKey(key))
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, fmt.Errorf("authenticate api key: %w", ErrInvalidAPIKey)
+		}
+		return nil, fmt.Errorf("authenticate api key: %w", repository.ErrDatabaseError)
+	}
+
+	return client, nil
+}
+
+func (s *AuthService) CreateClient(name string, scopes []string, walletIDs []uuid.UUID) (*models.APIClient, string, error) {
+	for _, scope := range scopes {
+		if !models.IsValidScope(scope) {
+			return nil, "", fmt.Errorf("create api client: %w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	client := &models.APIClient{
+		ID:        uuid.New(),
+		Name:      name,
+		Scopes:    scopes,
+		WalletIDs: walletIDs,
+		CreatedAt: s.now(),
+	}
+
+	rawKey, key, err := s.newKey(client.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("create api client: %w", err)
+	}
+
+	if err := s.repo.CreateClient(client, key); err != nil {
+		return nil, "", fmt.Errorf("create api client: %w", repository.ErrDatabaseError)
+	}
+
+	return client, rawKey, nil
+}
+
+// RotateKey выпускает новый ключ, старые ключи клиента продолжают работать еще rotateOverlap
+func (s *AuthService) RotateKey(clientID string) (string, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return "", fmt.Errorf("rotate api key: %w", repository.ErrAPIClientNotFound)
+	}
+
+	rawKey, key, err := s.newKey(id)
+	if err != nil {
+		return "", fmt.Errorf("rotate api key: %w", err)
+	}
+
+	err = s.repo.RotateKey(clientID, key, s.now().Add(s.rotateOverlap))
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrAPIClientNotFound) {
+			return "", fmt.Errorf("rotate api key: %w", repository.ErrAPIClientNotFound)
+		}
+		return "", fmt.Errorf("rotate api key: %w", repository.ErrDatabaseError)
+	}
+
+	return rawKey, nil
+}
+
+func (s *AuthService) GetSigningSecret(clientID string) (*models.APIClient, []byte, error) {
+	if _, err := uuid.Parse(clientID); err != nil {
+		return nil, nil, fmt.Errorf("get signing secret: %w", ErrUnknownSigningClient)
+	}
+
+	client, secret, err := s.repo.GetClientSigningSecret(clientID)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrAPIClientNotFound) {
+			return nil, nil, fmt.Errorf("get signing secret: %w", ErrUnknownSigningClient)
+		}
+		return nil, nil, fmt.Errorf("get signing secret: %w", repository.ErrDatabaseError)
+	}
+
+	return client, []byte(secret), nil
+}
+
+// RotateSigningSecret выпускает новый HMAC секрет клиента, старый перестает действовать сразу
+func (s *AuthService) RotateSigningSecret(clientID string) (string, error) {
+	if _, err := uuid.Parse(clientID); err != nil {
+		return "", fmt.Errorf("rotate signing secret: %w", repository.ErrAPIClientNotFound)
+	}
+
+	secret, err := auth.GenerateSigningSecret()
+	if err != nil {
+		return "", fmt.Errorf("rotate signing secret: %w", err)
+	}
+
+	if err := s.repo.SetSigningSecret(clientID, secret); err != nil {
+		if stdErrors.Is(err, repository.ErrAPIClientNotFound) {
+			return "", fmt.Errorf("rotate signing secret: %w", repository.ErrAPIClientNotFound)
+		}
+		return "", fmt.Errorf("rotate signing secret: %w", repository.ErrDatabaseError)
+	}
+
+	return secret, nil
+}
+
+func (s *AuthService) newKey(clientID uuid.UUID) (string, *models.APIKey, error) {
+	rawKey, hash, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rawKey, &models.APIKey{
+		ID:        uuid.New(),
+		ClientID:  clientID,
+		KeyHash:   hash,
+		KeyPrefix: prefix,
+		CreatedAt: s.now(),
+		ExpiresAt: sql.NullTime{},
+	}, nil
+}
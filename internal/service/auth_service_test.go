@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type mockAPIKey struct {
+	clientID  uuid.UUID
+	expiresAt time.Time
+}
+
+type MockAPIClientRepository struct {
+	clients map[string]*models.APIClient
+	keys    map[string]*mockAPIKey
+	secrets map[string]string
+	now     time.Time
+}
+
+func NewMockAPIClientRepository(now time.Time) *MockAPIClientRepository {
+	return &MockAPIClientRepository{
+		clients: make(map[string]*models.APIClient),
+		keys:    make(map[string]*mockAPIKey),
+		secrets: make(map[string]string),
+		now:     now,
+	}
+}
+
+func (m *MockAPIClientRepository) GetClientByKeyHash(keyHash string) (*models.APIClient, error) {
+	key, ok := m.keys[keyHash]
+	if !ok || (!key.expiresAt.IsZero() && !key.expiresAt.After(m.now)) {
+		return nil, repository.ErrAPIKeyNotFound
+	}
+	return m.clients[key.clientID.String()], nil
+}
+
+func (m *MockAPIClientRepository) CreateClient(client *models.APIClient, key *models.APIKey) error {
+	m.clients[client.ID.String()] = client
+	m.keys[key.KeyHash] = &mockAPIKey{clientID: key.ClientID}
+	return nil
+}
+
+func (m *MockAPIClientRepository) RotateKey(clientID string, newKey *models.APIKey, oldKeysExpireAt time.Time) error {
+	if _, ok := m.clients[clientID]; !ok {
+		return repository.ErrAPIClientNotFound
+	}
+	for _, key := range m.keys {
+		if key.clientID.String() == clientID && (key.expiresAt.IsZero() || key.expiresAt.After(oldKeysExpireAt)) {
+			key.expiresAt = oldKeysExpireAt
+		}
+	}
+	m.keys[newKey.KeyHash] = &mockAPIKey{clientID: newKey.ClientID}
+	return nil
+}
+
+func (m *MockAPIClientRepository) GetClientSigningSecret(clientID string) (*models.APIClient, string, error) {
+	secret, ok := m.secrets[clientID]
+	if !ok {
+		return nil, "", repository.ErrAPIClientNotFound
+	}
+	return m.clients[clientID], secret, nil
+}
+
+func (m *MockAPIClientRepository) SetSigningSecret(clientID, secret string) error {
+	if _, ok := m.clients[clientID]; !ok {
+		return repository.ErrAPIClientNotFound
+	}
+	m.secrets[clientID] = secret
+	return nil
+}
+
+func TestAuthService_CreateAndAuthenticate(t *testing.T) {
+	now := time.Now()
+	mockRepo := NewMockAPIClientRepository(now)
+	authService := NewAuthService(mockRepo, time.Hour)
+
+	client, key, err := authService.CreateClient("billing", []string{models.ScopeWalletRead}, nil)
+	if err != nil {
+		t.Fatalf("CreateClient() unexpected error = %v", err)
+	}
+
+	got, err := authService.AuthenticateAPIKey(key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey() unexpected error = %v", err)
+	}
+	if got.ID != client.ID {
+		t.Errorf("AuthenticateAPIKey() = %v, want %v", got.ID, client.ID)
+	}
+
+	if _, err := authService.AuthenticateAPIKey("wk_wrong"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("AuthenticateAPIKey() error = %v, wantErr %v", err, ErrInvalidAPIKey)
+	}
+
+	if _, _, err := authService.CreateClient("bad", []string{"wallet:delete"}, nil); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("CreateClient() error = %v, wantErr %v", err, ErrInvalidScope)
+	}
+}
+
+func TestAuthService_RotateKeyOverlap(t *testing.T) {
+	now := time.Now()
+	mockRepo := NewMockAPIClientRepository(now)
+	authService := NewAuthService(mockRepo, time.Hour)
+	authService.now = func() time.Time { return now }
+
+	client, oldKey, err := authService.CreateClient("partner", []string{models.ScopeWalletDeposit}, nil)
+	if err != nil {
+		t.Fatalf("CreateClient() unexpected error = %v", err)
+	}
+
+	newKey, err := authService.RotateKey(client.ID.String())
+	if err != nil {
+		t.Fatalf("RotateKey() unexpected error = %v", err)
+	}
+
+	if _, err := authService.AuthenticateAPIKey(oldKey); err != nil {
+		t.Errorf("old key must work during overlap window, got error = %v", err)
+	}
+	if _, err := authService.AuthenticateAPIKey(newKey); err != nil {
+		t.Errorf("new key must work, got error = %v", err)
+	}
+
+	mockRepo.now = now.Add(2 * time.Hour)
+	if _, err := authService.AuthenticateAPIKey(oldKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("old key must expire after overlap window, got error = %v", err)
+	}
+	if _, err := authService.AuthenticateAPIKey(newKey); err != nil {
+		t.Errorf("new key must keep working, got error = %v", err)
+	}
+
+	if _, err := authService.RotateKey(uuid.New().String()); !errors.Is(err, repository.ErrAPIClientNotFound) {
+		t.Errorf("RotateKey() error = %v, wantErr %v", err, repository.ErrAPIClientNotFound)
+	}
+
+	if auth.HashAPIKey(oldKey) == auth.HashAPIKey(newKey) {
+		t.Errorf("rotated key must differ from the old one")
+	}
+}
+
+func TestAuthService_SigningSecret(t *testing.T) {
+	mockRepo := NewMockAPIClientRepository(time.Now())
+	authService := NewAuthService(mockRepo, time.Hour)
+
+	client, _, err := authService.CreateClient("partner", []string{models.ScopeWalletDeposit}, nil)
+	if err != nil {
+		t.Fatalf("CreateClient() unexpected error = %v", err)
+	}
+
+	if _, _, err := authService.GetSigningSecret(client.ID.String()); !errors.Is(err, ErrUnknownSigningClient) {
+		t.Errorf("GetSigningSecret() without secret error = %v, wantErr %v", err, ErrUnknownSigningClient)
+	}
+
+	secret, err := authService.RotateSigningSecret(client.ID.String())
+	if err != nil {
+		t.Fatalf("RotateSigningSecret() unexpected error = %v", err)
+	}
+
+	got, gotSecret, err := authService.GetSigningSecret(client.ID.String())
+	if err != nil {
+		t.Fatalf("GetSigningSecret() unexpected error = %v", err)
+	}
+	if got.ID != client.ID || string(gotSecret) != secret {
+		t.Errorf("GetSigningSecret() returned unexpected client or secret")
+	}
+
+	if _, _, err := authService.GetSigningSecret("not-a-uuid"); !errors.Is(err, ErrUnknownSigningClient) {
+		t.Errorf("GetSigningSecret() error = %v, wantErr %v", err, ErrUnknownSigningClient)
+	}
+}
@@ -3,5 +3,26 @@ package service
 import "errors"
 
 var (
-	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrInsufficientFunds      = errors.New("insufficient funds")
+	ErrWalletFrozen           = errors.New("wallet is frozen")
+	ErrInvalidAPIKey          = errors.New("invalid api key")
+	ErrInvalidScope           = errors.New("invalid scope")
+	ErrWalletAccessDenied     = errors.New("wallet access denied")
+	ErrUnknownSigningClient   = errors.New("unknown signing client")
+	ErrInvalidWebhook         = errors.New("invalid webhook subscription")
+	ErrInvalidTransfer        = errors.New("invalid transfer")
+	ErrInvalidWalletFilter    = errors.New("invalid wallet filter")
+	ErrWalletNotExisted       = errors.New("wallet did not exist at requested time")
+	ErrInvalidStatementPeriod = errors.New("invalid statement period")
+	ErrInvalidImport          = errors.New("invalid import")
+	// ErrLimitExceeded оборачивается вместе с *models.LimitExceededError, в которой названы лимит и остаток
+	ErrLimitExceeded     = errors.New("wallet limit exceeded")
+	ErrInvalidLimits     = errors.New("invalid wallet limits")
+	ErrUnknownWalletTier = errors.New("unknown wallet tier")
+	ErrInvalidFeeRule    = errors.New("invalid fee rule")
+	ErrInvalidFeeQuote   = errors.New("invalid fee quote")
+	// ErrFeeExceedsAmount возвращается, когда комиссия пополнения не меньше его суммы
+	ErrFeeExceedsAmount = errors.New("fee exceeds operation amount")
+	ErrInvalidOverdraft = errors.New("invalid overdraft limit")
+	ErrInvalidSchedule  = errors.New("invalid scheduled transfer")
 )
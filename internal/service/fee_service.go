@@ -0,0 +1,75 @@
+package service
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+)
+
+type FeeService struct {
+	repo repository.FeeRepositoryInterface
+}
+
+func NewFeeService(repo repository.FeeRepositoryInterface) *FeeService {
+	return &FeeService{repo: repo}
+}
+
+// ListFeeRules возвращает действующие правила комиссий
+func (s *FeeService) ListFeeRules() ([]models.FeeRule, error) {
+	rules, err := s.repo.ListFeeRules()
+	if err != nil {
+		return nil, fmt.Errorf("list fee rules: %w", repository.ErrDatabaseError)
+	}
+	return rules, nil
+}
+
+// SetFeeRules заменяет все правила комиссий. Для каждой пары операции и уровня допускается одно правило;
+// пустое округление означает half_up
+func (s *FeeService) SetFeeRules(rules []models.FeeRule) ([]models.FeeRule, error) {
+	type ruleKey struct{ operationType, tier string }
+	seen := make(map[ruleKey]bool, len(rules))
+	for i := range rules {
+		if rules[i].Rounding == "" {
+			rules[i].Rounding = utils.RoundHalfUp
+		}
+		if err := rules[i].Validate(); err != nil {
+			return nil, fmt.Errorf("set fee rules: %w: rule %d: %s", ErrInvalidFeeRule, i, err)
+		}
+
+		key := ruleKey{rules[i].OperationType, rules[i].Tier}
+		if seen[key] {
+			return nil, fmt.Errorf("set fee rules: %w: rule %d duplicates %s for tier %q", ErrInvalidFeeRule, i, key.operationType, key.tier)
+		}
+		seen[key] = true
+	}
+
+	if err := s.repo.ReplaceFeeRules(rules); err != nil {
+		if stdErrors.Is(err, repository.ErrWalletTierNotFound) {
+			return nil, fmt.Errorf("set fee rules: %w", ErrUnknownWalletTier)
+		}
+		return nil, fmt.Errorf("set fee rules: %w", repository.ErrDatabaseError)
+	}
+
+	return s.ListFeeRules()
+}
+
+// QuoteFee считает комиссию операции на amount для кошелька, не проводя операцию
+func (s *FeeService) QuoteFee(walletID, operationType string, amount utils.Money) (models.Fee, error) {
+	if !models.IsValidFeeOperationType(operationType) {
+		return models.Fee{}, fmt.Errorf("quote fee: %w: unknown operation type %s", ErrInvalidFeeQuote, operationType)
+	}
+	if amount.Raw <= 0 {
+		return models.Fee{}, fmt.Errorf("quote fee: %w: amount must be positive", ErrInvalidFeeQuote)
+	}
+
+	fee, err := s.repo.QuoteFee(walletID, operationType, amount)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrWalletNotFound) {
+			return models.Fee{}, fmt.Errorf("quote fee: %w", repository.ErrWalletNotFound)
+		}
+		return models.Fee{}, fmt.Errorf("quote fee: %w", repository.ErrDatabaseError)
+	}
+	return fee, nil
+}
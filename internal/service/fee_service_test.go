@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+)
+
+type MockFeeRepository struct {
+	rules []models.FeeRule
+}
+
+func (m *MockFeeRepository) ListFeeRules() ([]models.FeeRule, error) {
+	return m.rules, nil
+}
+
+func (m *MockFeeRepository) ReplaceFeeRules(rules []models.FeeRule) error {
+	for _, rule := range rules {
+		if rule.Tier != "" && rule.Tier != models.WalletTierAnonymous {
+			return repository.ErrWalletTierNotFound
+		}
+	}
+	m.rules = rules
+	return nil
+}
+
+func (m *MockFeeRepository) QuoteFee(walletID, operationType string, amount utils.Money) (models.Fee, error) {
+	if walletID == "missing" {
+		return models.Fee{}, repository.ErrWalletNotFound
+	}
+	return models.NewFee(operationType, amount, utils.Money{Raw: 10}), nil
+}
+
+func TestFeeService_SetFeeRules(t *testing.T) {
+	repo := &MockFeeRepository{}
+	svc := NewFeeService(repo)
+
+	flat := func(operationType, tier string) models.FeeRule {
+		return models.FeeRule{OperationType: operationType, Tier: tier, Kind: models.FeeKindFlat, Flat: utils.Money{Raw: 100}}
+	}
+
+	tests := []struct {
+		name    string
+		rules   []models.FeeRule
+		wantErr error
+	}{
+		{"invalid rule", []models.FeeRule{{OperationType: models.OperationTypeDeposit, Kind: "fixed"}}, ErrInvalidFeeRule},
+		{"duplicate rule", []models.FeeRule{flat(models.OperationTypeDeposit, ""), flat(models.OperationTypeDeposit, "")}, ErrInvalidFeeRule},
+		{"unknown tier", []models.FeeRule{flat(models.OperationTypeDeposit, "vip")}, ErrUnknownWalletTier},
+		{"general and tier rules", []models.FeeRule{flat(models.OperationTypeDeposit, ""), flat(models.OperationTypeDeposit, models.WalletTierAnonymous)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SetFeeRules(tt.rules)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetFeeRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if len(repo.rules) != 2 || repo.rules[0].Rounding != utils.RoundHalfUp {
+		t.Errorf("stored rules = %+v, want 2 rules with half_up rounding by default", repo.rules)
+	}
+}
+
+func TestFeeService_QuoteFee(t *testing.T) {
+	svc := NewFeeService(&MockFeeRepository{})
+
+	if _, err := svc.QuoteFee("wallet", "REFUND", utils.Money{Raw: 100}); !errors.Is(err, ErrInvalidFeeQuote) {
+		t.Errorf("QuoteFee() with unknown operation error = %v, want %v", err, ErrInvalidFeeQuote)
+	}
+	if _, err := svc.QuoteFee("wallet", models.OperationTypeWithdraw, utils.Money{}); !errors.Is(err, ErrInvalidFeeQuote) {
+		t.Errorf("QuoteFee() with zero amount error = %v, want %v", err, ErrInvalidFeeQuote)
+	}
+	if _, err := svc.QuoteFee("missing", models.OperationTypeWithdraw, utils.Money{Raw: 100}); !errors.Is(err, repository.ErrWalletNotFound) {
+		t.Errorf("QuoteFee() for missing wallet error = %v, want %v", err, repository.ErrWalletNotFound)
+	}
+
+	fee, err := svc.QuoteFee("wallet", models.JournalOperationTransfer, utils.Money{Raw: 100})
+	if err != nil || fee.Gross.Raw != 110 || fee.Net.Raw != 100 {
+		t.Errorf("QuoteFee() = %+v, %v, want gross 1.10 and net 1.00", fee, err)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type ImportService struct {
+	repo repository.ImportRepositoryInterface
+	now  func() time.Time
+}
+
+func NewImportService(repo repository.ImportRepositoryInterface) *ImportService {
+	return &ImportService{repo: repo, now: time.Now}
+}
+
+// CreateImport ставит в очередь задание на проведение уже проверенных строк файла.
+// Сами операции выполняет importer.Worker
+func (s *ImportService) CreateImport(clientID *uuid.UUID, rows []models.ImportRow, dryRun bool) (*models.ImportJob, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("create import: %w: no rows", ErrInvalidImport)
+	}
+	if len(rows) > models.MaxImportRows {
+		return nil, fmt.Errorf("create import: %w: more than %d rows", ErrInvalidImport, models.MaxImportRows)
+	}
+
+	job := &models.ImportJob{
+		ID:        uuid.New(),
+		Status:    models.ImportJobPending,
+		DryRun:    dryRun,
+		TotalRows: len(rows),
+		CreatedAt: s.now().UTC(),
+	}
+	if clientID != nil {
+		job.ClientID = uuid.NullUUID{UUID: *clientID, Valid: true}
+	}
+
+	for i := range rows {
+		rows[i].JobID = job.ID
+		rows[i].Status = models.ImportRowPending
+	}
+
+	if err := s.repo.CreateImportJob(job, rows); err != nil {
+		return nil, fmt.Errorf("create import: %w", repository.ErrDatabaseError)
+	}
+	return job, nil
+}
+
+func (s *ImportService) GetImport(clientID *uuid.UUID, jobID string) (*models.ImportJob, error) {
+	job, err := s.getOwnedJob(clientID, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("get import: %w", err)
+	}
+	return job, nil
+}
+
+// ListImportRows возвращает порцию строк с результатами; строки без результата еще в статусе pending
+func (s *ImportService) ListImportRows(clientID *uuid.UUID, jobID string, afterRow, limit int) ([]models.ImportRow, error) {
+	if _, err := s.getOwnedJob(clientID, jobID); err != nil {
+		return nil, fmt.Errorf("list import rows: %w", err)
+	}
+
+	rows, err := s.repo.ListImportRows(jobID, afterRow, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list import rows: %w", repository.ErrDatabaseError)
+	}
+	return rows, nil
+}
+
+// getOwnedJob скрывает чужие задания как несуществующие; nil clientID видит все задания
+func (s *ImportService) getOwnedJob(clientID *uuid.UUID, jobID string) (*models.ImportJob, error) {
+	if _, err := uuid.Parse(jobID); err != nil {
+		return nil, repository.ErrImportJobNotFound
+	}
+
+	job, err := s.repo.GetImportJob(jobID)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrImportJobNotFound) {
+			return nil, repository.ErrImportJobNotFound
+		}
+		return nil, repository.ErrDatabaseError
+	}
+
+	if clientID != nil && (!job.ClientID.Valid || job.ClientID.UUID != *clientID) {
+		return nil, repository.ErrImportJobNotFound
+	}
+	return job, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type MockImportRepository struct {
+	repository.ImportRepositoryInterface
+	jobs map[string]*models.ImportJob
+	rows []models.ImportRow
+}
+
+func (m *MockImportRepository) CreateImportJob(job *models.ImportJob, rows []models.ImportRow) error {
+	m.jobs[job.ID.String()] = job
+	m.rows = rows
+	return nil
+}
+
+func (m *MockImportRepository) GetImportJob(id string) (*models.ImportJob, error) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, repository.ErrImportJobNotFound
+	}
+	return job, nil
+}
+
+func TestImportService_CreateAndGetImport(t *testing.T) {
+	repo := &MockImportRepository{jobs: make(map[string]*models.ImportJob)}
+	svc := NewImportService(repo)
+	owner, other := uuid.New(), uuid.New()
+
+	if _, err := svc.CreateImport(&owner, nil, false); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("CreateImport() without rows error = %v, want %v", err, ErrInvalidImport)
+	}
+
+	job, err := svc.CreateImport(&owner, []models.ImportRow{{RowNumber: 2, ExternalRef: "a"}}, true)
+	if err != nil {
+		t.Fatalf("CreateImport() error = %v", err)
+	}
+	if job.Status != models.ImportJobPending || !job.DryRun || job.TotalRows != 1 {
+		t.Errorf("CreateImport() job = %+v", job)
+	}
+	if repo.rows[0].JobID != job.ID || repo.rows[0].Status != models.ImportRowPending {
+		t.Errorf("row not bound to job: %+v", repo.rows[0])
+	}
+
+	if _, err := svc.GetImport(&owner, job.ID.String()); err != nil {
+		t.Errorf("GetImport() by owner error = %v", err)
+	}
+	if _, err := svc.GetImport(nil, job.ID.String()); err != nil {
+		t.Errorf("GetImport() by admin error = %v", err)
+	}
+	// Чужое задание неотличимо от несуществующего
+	if _, err := svc.GetImport(&other, job.ID.String()); !errors.Is(err, repository.ErrImportJobNotFound) {
+		t.Errorf("GetImport() by other client error = %v, want %v", err, repository.ErrImportJobNotFound)
+	}
+	if _, err := svc.GetImport(&owner, "not-a-uuid"); !errors.Is(err, repository.ErrImportJobNotFound) {
+		t.Errorf("GetImport() with malformed id error = %v, want %v", err, repository.ErrImportJobNotFound)
+	}
+}
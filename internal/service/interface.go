@@ -1,11 +1,75 @@
 package service
 
 import (
+	"context"
+	"time"
 	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
 )
 
 type WalletServiceInterface interface {
 	GetWallet(walletID string) (*models.Wallet, error)
-	ProcessWalletOperation(operation *models.WalletOperation) (*models.Wallet, error)
+	GetOwnedWallet(walletID, ownerID string) (*models.Wallet, error)
+	GetBalanceAsOf(walletID, ownerID string, asOf time.Time) (*models.HistoricalBalance, error)
+	OpenStatement(ctx context.Context, walletID, ownerID string, from, to time.Time) (repository.StatementCursor, error)
+	LookupWallets(walletIDs []uuid.UUID, ownerID string) ([]models.Wallet, []uuid.UUID, error)
+	ListWallets(filter models.WalletFilter) (*models.WalletPage, error)
+	ProcessWalletOperation(operation *models.WalletOperation) (*models.OperationResult, error)
 	CreateWallet(wallet *models.Wallet) error
+	ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error)
+	GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error)
+	Transfer(transfer *models.Transfer) (*models.TransferResult, error)
+}
+
+type AuthServiceInterface interface {
+	AuthenticateAPIKey(key string) (*models.APIClient, error)
+	CreateClient(name string, scopes []string, walletIDs []uuid.UUID) (*models.APIClient, string, error)
+	RotateKey(clientID string) (string, error)
+	GetSigningSecret(clientID string) (*models.APIClient, []byte, error)
+	RotateSigningSecret(clientID string) (string, error)
+}
+
+type WebhookServiceInterface interface {
+	CreateSubscription(clientID *uuid.UUID, input WebhookSubscriptionInput) (*models.WebhookSubscription, string, error)
+	ListSubscriptions(clientID *uuid.UUID) ([]models.WebhookSubscription, error)
+	DeleteSubscription(clientID *uuid.UUID, subscriptionID string) error
+	ListDeliveries(clientID *uuid.UUID, subscriptionID string) ([]models.WebhookDelivery, error)
+	ReplayDelivery(clientID *uuid.UUID, subscriptionID, deliveryID string) error
+	FanOut(msg models.OutboxMessage) error
+}
+
+type ImportServiceInterface interface {
+	CreateImport(clientID *uuid.UUID, rows []models.ImportRow, dryRun bool) (*models.ImportJob, error)
+	GetImport(clientID *uuid.UUID, jobID string) (*models.ImportJob, error)
+	ListImportRows(clientID *uuid.UUID, jobID string, afterRow, limit int) ([]models.ImportRow, error)
+}
+
+type AccountingServiceInterface interface {
+	GetTrialBalance(ctx context.Context) (*models.TrialBalance, error)
+	GetLatestReconciliation(limit int) (*models.ReconciliationReport, error)
+	VerifyLedgerChain(ctx context.Context, walletID *uuid.UUID) (*models.LedgerChainVerification, error)
+}
+
+type LimitServiceInterface interface {
+	GetWalletLimits(walletID string) (*models.WalletLimitsState, error)
+	SetWalletLimits(walletID, tier string, overrides models.WalletLimits) (*models.WalletLimitsState, error)
+	SetOverdraftLimit(walletID string, limit utils.Money) (*models.Wallet, error)
+}
+
+type FeeServiceInterface interface {
+	ListFeeRules() ([]models.FeeRule, error)
+	SetFeeRules(rules []models.FeeRule) ([]models.FeeRule, error)
+	QuoteFee(walletID, operationType string, amount utils.Money) (models.Fee, error)
+}
+
+type ScheduledTransferServiceInterface interface {
+	CreateScheduledTransfer(clientID *uuid.UUID, ownerID string, input ScheduledTransferInput) (*models.ScheduledTransfer, error)
+	ListScheduledTransfers(clientID *uuid.UUID, ownerID string) ([]models.ScheduledTransfer, error)
+	GetScheduledTransfer(clientID *uuid.UUID, ownerID, id string) (*models.ScheduledTransfer, error)
+	UpdateScheduledTransfer(clientID *uuid.UUID, ownerID, id string, update ScheduledTransferUpdate) (*models.ScheduledTransfer, error)
+	CancelScheduledTransfer(clientID *uuid.UUID, ownerID, id string) error
+	ListScheduledTransferRuns(clientID *uuid.UUID, ownerID, id string, limit int) ([]models.ScheduledTransferRun, error)
 }
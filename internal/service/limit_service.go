@@ -0,0 +1,101 @@
+package service
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+)
+
+type LimitService struct {
+	repo repository.LimitRepositoryInterface
+}
+
+func NewLimitService(repo repository.LimitRepositoryInterface) *LimitService {
+	return &LimitService{repo: repo}
+}
+
+// GetWalletLimits возвращает уровень кошелька, действующие лимиты и обороты за окна лимитов
+func (s *LimitService) GetWalletLimits(walletID string) (*models.WalletLimitsState, error) {
+	state, err := s.repo.GetWalletLimits(walletID)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrWalletNotFound) {
+			return nil, fmt.Errorf("get wallet limits: %w", repository.ErrWalletNotFound)
+		}
+		return nil, fmt.Errorf("get wallet limits: %w", repository.ErrDatabaseError)
+	}
+	return state, nil
+}
+
+// SetWalletLimits назначает кошельку уровень и собственные лимиты. Незаполненные лимиты overrides
+// берутся из уровня; прежние собственные лимиты кошелька заменяются целиком
+func (s *LimitService) SetWalletLimits(walletID, tier string, overrides models.WalletLimits) (*models.WalletLimitsState, error) {
+	if tier == "" {
+		tier = models.WalletTierStandard
+	}
+	if err := validateLimits(overrides); err != nil {
+		return nil, fmt.Errorf("set wallet limits: %w", err)
+	}
+
+	if err := s.repo.SetWalletLimits(walletID, tier, overrides); err != nil {
+		if stdErrors.Is(err, repository.ErrWalletNotFound) {
+			return nil, fmt.Errorf("set wallet limits: %w", repository.ErrWalletNotFound)
+		}
+		if stdErrors.Is(err, repository.ErrWalletTierNotFound) {
+			return nil, fmt.Errorf("set wallet limits: %w: %s", ErrUnknownWalletTier, tier)
+		}
+		return nil, fmt.Errorf("set wallet limits: %w", repository.ErrDatabaseError)
+	}
+
+	return s.GetWalletLimits(walletID)
+}
+
+// SetOverdraftLimit назначает кошельку кредитную линию; нулевая линия запрещает уход в минус
+func (s *LimitService) SetOverdraftLimit(walletID string, limit utils.Money) (*models.Wallet, error) {
+	if limit.IsNegative() {
+		return nil, fmt.Errorf("set overdraft limit: %w: limit is negative", ErrInvalidOverdraft)
+	}
+
+	wallet, err := s.repo.SetOverdraftLimit(walletID, limit)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrWalletNotFound) {
+			return nil, fmt.Errorf("set overdraft limit: %w", repository.ErrWalletNotFound)
+		}
+		if stdErrors.Is(err, repository.ErrOverdraftBelowDebt) {
+			return nil, fmt.Errorf("set overdraft limit: %w: limit is below current debt", ErrInvalidOverdraft)
+		}
+		return nil, fmt.Errorf("set overdraft limit: %w", repository.ErrDatabaseError)
+	}
+	return wallet, nil
+}
+
+func validateLimits(limits models.WalletLimits) error {
+	for _, limit := range []struct {
+		name  string
+		value *utils.Money
+	}{
+		{models.LimitMaxBalance, limits.MaxBalance},
+		{models.LimitMinOperation, limits.MinOperation},
+		{models.LimitMaxOperation, limits.MaxOperation},
+		{models.LimitDailyDeposit, limits.DailyDeposit},
+		{models.LimitDailyWithdrawal, limits.DailyWithdrawal},
+		{models.LimitMonthlyDeposit, limits.MonthlyDeposit},
+		{models.LimitMonthlyWithdrawal, limits.MonthlyWithdrawal},
+	} {
+		if limit.value != nil && limit.value.IsNegative() {
+			return fmt.Errorf("%w: %s is negative", ErrInvalidLimits, limit.name)
+		}
+	}
+
+	if limits.MinOperation != nil && limits.MinOperation.IsZero() {
+		return fmt.Errorf("%w: %s must be positive", ErrInvalidLimits, models.LimitMinOperation)
+	}
+	if limits.MaxOperation != nil && limits.MaxOperation.IsZero() {
+		return fmt.Errorf("%w: %s must be positive", ErrInvalidLimits, models.LimitMaxOperation)
+	}
+	if limits.MinOperation != nil && limits.MaxOperation != nil && limits.MinOperation.Raw > limits.MaxOperation.Raw {
+		return fmt.Errorf("%w: %s is greater than %s", ErrInvalidLimits, models.LimitMinOperation, models.LimitMaxOperation)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+)
+
+type MockLimitRepository struct {
+	tiers   map[string]models.WalletLimits
+	state   map[string]*models.WalletLimitsState
+	wallets map[string]*models.Wallet
+}
+
+func (m *MockLimitRepository) GetWalletLimits(walletID string) (*models.WalletLimitsState, error) {
+	state, ok := m.state[walletID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	return state, nil
+}
+
+func (m *MockLimitRepository) SetWalletLimits(walletID, tier string, overrides models.WalletLimits) error {
+	state, ok := m.state[walletID]
+	if !ok {
+		return repository.ErrWalletNotFound
+	}
+	limits, ok := m.tiers[tier]
+	if !ok {
+		return repository.ErrWalletTierNotFound
+	}
+	state.Tier = tier
+	state.Overrides = overrides
+	state.Effective = limits.WithOverrides(overrides)
+	return nil
+}
+
+func (m *MockLimitRepository) SetOverdraftLimit(walletID string, limit utils.Money) (*models.Wallet, error) {
+	wallet, ok := m.wallets[walletID]
+	if !ok {
+		return nil, repository.ErrWalletNotFound
+	}
+	if wallet.Balance.Raw < -limit.Raw {
+		return nil, repository.ErrOverdraftBelowDebt
+	}
+	wallet.OverdraftLimit = limit
+	return wallet, nil
+}
+
+func TestLimitService_SetWalletLimits(t *testing.T) {
+	maxBalance := utils.Money{Raw: 1500000}
+	repo := &MockLimitRepository{
+		tiers: map[string]models.WalletLimits{
+			models.WalletTierStandard:  {},
+			models.WalletTierAnonymous: {MaxBalance: &maxBalance},
+		},
+		state: map[string]*models.WalletLimitsState{
+			"wallet": {WalletID: "wallet", Tier: models.WalletTierStandard},
+		},
+	}
+	svc := NewLimitService(repo)
+
+	negative := utils.Money{Raw: -100}
+	zero := utils.Money{}
+	small, large := utils.Money{Raw: 100}, utils.Money{Raw: 10000}
+
+	tests := []struct {
+		name      string
+		walletID  string
+		tier      string
+		overrides models.WalletLimits
+		wantErr   error
+	}{
+		{name: "negative limit", walletID: "wallet", overrides: models.WalletLimits{DailyDeposit: &negative}, wantErr: ErrInvalidLimits},
+		{name: "zero max operation", walletID: "wallet", overrides: models.WalletLimits{MaxOperation: &zero}, wantErr: ErrInvalidLimits},
+		{name: "min above max", walletID: "wallet", overrides: models.WalletLimits{MinOperation: &large, MaxOperation: &small}, wantErr: ErrInvalidLimits},
+		{name: "unknown tier", walletID: "wallet", tier: "vip", wantErr: ErrUnknownWalletTier},
+		{name: "unknown wallet", walletID: "missing", wantErr: repository.ErrWalletNotFound},
+		{name: "zero daily limit blocks operations", walletID: "wallet", overrides: models.WalletLimits{DailyWithdrawal: &zero}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SetWalletLimits(tt.walletID, tt.tier, tt.overrides)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetWalletLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	state, err := svc.SetWalletLimits("wallet", models.WalletTierAnonymous, models.WalletLimits{MinOperation: &small})
+	if err != nil {
+		t.Fatalf("SetWalletLimits() error = %v", err)
+	}
+	if state.Tier != models.WalletTierAnonymous || state.Effective.MaxBalance.Raw != maxBalance.Raw || state.Effective.MinOperation.Raw != small.Raw {
+		t.Errorf("SetWalletLimits() = %+v, want anonymous tier with min operation override", state)
+	}
+
+	if state, err = svc.SetWalletLimits("wallet", "", models.WalletLimits{}); err != nil || state.Tier != models.WalletTierStandard {
+		t.Errorf("SetWalletLimits() with empty tier = %+v, %v, want standard", state, err)
+	}
+}
+
+func TestLimitService_SetOverdraftLimit(t *testing.T) {
+	repo := &MockLimitRepository{
+		wallets: map[string]*models.Wallet{"wallet": {Balance: utils.Money{Raw: -300}}},
+	}
+	svc := NewLimitService(repo)
+
+	tests := []struct {
+		name     string
+		walletID string
+		limit    int64
+		wantErr  error
+	}{
+		{"credit line above debt", "wallet", 500, nil},
+		{"credit line equal to debt", "wallet", 300, nil},
+		{"credit line below debt", "wallet", 200, ErrInvalidOverdraft},
+		{"negative credit line", "wallet", -1, ErrInvalidOverdraft},
+		{"unknown wallet", "missing", 500, repository.ErrWalletNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet, err := svc.SetOverdraftLimit(tt.walletID, utils.Money{Raw: tt.limit})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetOverdraftLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && wallet.OverdraftLimit.Raw != tt.limit {
+				t.Errorf("overdraft limit = %d, want %d", wallet.OverdraftLimit.Raw, tt.limit)
+			}
+		})
+	}
+
+	if limit := repo.wallets["wallet"].OverdraftLimit; limit.Raw != 300 {
+		t.Errorf("overdraft limit after rejected updates = %d, want 300", limit.Raw)
+	}
+}
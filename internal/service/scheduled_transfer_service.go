@@ -0,0 +1,223 @@
+package service
+
+import (
+	"database/sql"
+	stdErrors "errors"
+	"fmt"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+// ScheduledTransferInput - параметры нового расписания; нулевой StartAt означает "с текущего момента"
+type ScheduledTransferInput struct {
+	FromWalletID  uuid.UUID
+	ToWalletID    uuid.UUID
+	Amount        utils.Money
+	Recurrence    models.Recurrence
+	StartAt       time.Time
+	EndAt         *time.Time
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+// ScheduledTransferUpdate - изменение расписания; nil-поля не меняются. Кошельки и правило повторения не меняются:
+// для них создается новое расписание
+type ScheduledTransferUpdate struct {
+	Amount        *utils.Money
+	EndAt         *time.Time
+	MaxRetries    *int
+	RetryInterval *time.Duration
+	// Status - active или paused; при возобновлении пропущенные за паузу запуски не выполняются
+	Status *string
+}
+
+type ScheduledTransferService struct {
+	repo repository.ScheduledTransferRepositoryInterface
+	now  func() time.Time
+}
+
+func NewScheduledTransferService(repo repository.ScheduledTransferRepositoryInterface) *ScheduledTransferService {
+	return &ScheduledTransferService{repo: repo, now: time.Now}
+}
+
+// CreateScheduledTransfer создает расписание клиента clientID или владельца ownerID и ставит его первый запуск.
+// Доступ к кошельку-источнику проверяет вызывающий. Переводы выполняет scheduler.TransferJob
+func (s *ScheduledTransferService) CreateScheduledTransfer(clientID *uuid.UUID, ownerID string, input ScheduledTransferInput) (*models.ScheduledTransfer, error) {
+	now := s.now().UTC()
+	st := &models.ScheduledTransfer{
+		ID:            uuid.New(),
+		FromWalletID:  input.FromWalletID,
+		ToWalletID:    input.ToWalletID,
+		Amount:        input.Amount,
+		Recurrence:    input.Recurrence,
+		StartAt:       input.StartAt.UTC(),
+		MaxRetries:    input.MaxRetries,
+		RetryInterval: input.RetryInterval,
+		Status:        models.ScheduleStatusActive,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if input.StartAt.IsZero() {
+		st.StartAt = now
+	}
+	if input.EndAt != nil {
+		endAt := input.EndAt.UTC()
+		st.EndAt = &endAt
+	}
+	if clientID != nil {
+		st.ClientID = uuid.NullUUID{UUID: *clientID, Valid: true}
+	}
+	if ownerID != "" {
+		st.OwnerID = sql.NullString{String: ownerID, Valid: true}
+	}
+
+	if err := st.Validate(); err != nil {
+		return nil, fmt.Errorf("create scheduled transfer: %w: %v", ErrInvalidSchedule, err)
+	}
+	st.Schedule(now)
+	if st.NextRunAt == nil {
+		return nil, fmt.Errorf("create scheduled transfer: %w: schedule has no runs", ErrInvalidSchedule)
+	}
+
+	if err := s.repo.CreateScheduledTransfer(st); err != nil {
+		if stdErrors.Is(err, repository.ErrWalletNotFound) {
+			return nil, fmt.Errorf("create scheduled transfer: %w", repository.ErrWalletNotFound)
+		}
+		return nil, fmt.Errorf("create scheduled transfer: %w", repository.ErrDatabaseError)
+	}
+	return st, nil
+}
+
+func (s *ScheduledTransferService) ListScheduledTransfers(clientID *uuid.UUID, ownerID string) ([]models.ScheduledTransfer, error) {
+	items, err := s.repo.ListScheduledTransfers(clientID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled transfers: %w", repository.ErrDatabaseError)
+	}
+	return items, nil
+}
+
+func (s *ScheduledTransferService) GetScheduledTransfer(clientID *uuid.UUID, ownerID, id string) (*models.ScheduledTransfer, error) {
+	st, err := s.getOwnedSchedule(clientID, ownerID, id)
+	if err != nil {
+		return nil, fmt.Errorf("get scheduled transfer: %w", err)
+	}
+	return st, nil
+}
+
+// UpdateScheduledTransfer меняет сумму, окончание, повторы или приостанавливает и возобновляет расписание.
+// Завершенное или отмененное расписание не меняется
+func (s *ScheduledTransferService) UpdateScheduledTransfer(clientID *uuid.UUID, ownerID, id string, update ScheduledTransferUpdate) (*models.ScheduledTransfer, error) {
+	if _, err := s.getOwnedSchedule(clientID, ownerID, id); err != nil {
+		return nil, fmt.Errorf("update scheduled transfer: %w", err)
+	}
+
+	st, err := s.repo.UpdateScheduledTransfer(id, func(st *models.ScheduledTransfer) error {
+		if st.Status != models.ScheduleStatusActive && st.Status != models.ScheduleStatusPaused {
+			return fmt.Errorf("%w: schedule is %s", ErrInvalidSchedule, st.Status)
+		}
+		if update.Amount != nil {
+			st.Amount = *update.Amount
+		}
+		if update.EndAt != nil {
+			endAt := update.EndAt.UTC()
+			st.EndAt = &endAt
+		}
+		if update.MaxRetries != nil {
+			st.MaxRetries = *update.MaxRetries
+		}
+		if update.RetryInterval != nil {
+			st.RetryInterval = *update.RetryInterval
+		}
+		if err := st.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+
+		if update.Status != nil && *update.Status != st.Status {
+			switch *update.Status {
+			case models.ScheduleStatusPaused:
+				st.Status = models.ScheduleStatusPaused
+			case models.ScheduleStatusActive:
+				st.Status = models.ScheduleStatusActive
+				st.Schedule(s.now().UTC())
+			default:
+				return fmt.Errorf("%w: status must be active or paused", ErrInvalidSchedule)
+			}
+		}
+		// Новое окончание могло оказаться раньше текущего запуска
+		if st.NextRunAt != nil && st.EndAt != nil && st.NextRunAt.After(*st.EndAt) {
+			st.NextRunAt = nil
+			st.RetryAt = nil
+			st.Status = models.ScheduleStatusCompleted
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update scheduled transfer: %w", scheduleRepositoryError(err))
+	}
+	return st, nil
+}
+
+// CancelScheduledTransfer отменяет расписание; история запусков сохраняется
+func (s *ScheduledTransferService) CancelScheduledTransfer(clientID *uuid.UUID, ownerID, id string) error {
+	if _, err := s.getOwnedSchedule(clientID, ownerID, id); err != nil {
+		return fmt.Errorf("cancel scheduled transfer: %w", err)
+	}
+
+	_, err := s.repo.UpdateScheduledTransfer(id, func(st *models.ScheduledTransfer) error {
+		st.Status = models.ScheduleStatusCancelled
+		st.NextRunAt = nil
+		st.RetryAt = nil
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cancel scheduled transfer: %w", scheduleRepositoryError(err))
+	}
+	return nil
+}
+
+func (s *ScheduledTransferService) ListScheduledTransferRuns(clientID *uuid.UUID, ownerID, id string, limit int) ([]models.ScheduledTransferRun, error) {
+	if _, err := s.getOwnedSchedule(clientID, ownerID, id); err != nil {
+		return nil, fmt.Errorf("list scheduled transfer runs: %w", err)
+	}
+
+	runs, err := s.repo.ListScheduledTransferRuns(id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled transfer runs: %w", repository.ErrDatabaseError)
+	}
+	return runs, nil
+}
+
+// getOwnedSchedule скрывает чужие расписания как несуществующие; nil clientID и пустой ownerID видят все расписания
+func (s *ScheduledTransferService) getOwnedSchedule(clientID *uuid.UUID, ownerID, id string) (*models.ScheduledTransfer, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, repository.ErrScheduledTransferNotFound
+	}
+
+	st, err := s.repo.GetScheduledTransfer(id)
+	if err != nil {
+		return nil, scheduleRepositoryError(err)
+	}
+
+	if clientID != nil && (!st.ClientID.Valid || st.ClientID.UUID != *clientID) {
+		return nil, repository.ErrScheduledTransferNotFound
+	}
+	if ownerID != "" && (!st.OwnerID.Valid || st.OwnerID.String != ownerID) {
+		return nil, repository.ErrScheduledTransferNotFound
+	}
+	return st, nil
+}
+
+func scheduleRepositoryError(err error) error {
+	switch {
+	case stdErrors.Is(err, ErrInvalidSchedule):
+		return err
+	case stdErrors.Is(err, repository.ErrScheduledTransferNotFound):
+		return repository.ErrScheduledTransferNotFound
+	default:
+		return repository.ErrDatabaseError
+	}
+}
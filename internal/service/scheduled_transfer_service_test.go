@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+type MockScheduledTransferRepository struct {
+	wallets   map[uuid.UUID]bool
+	schedules map[string]*models.ScheduledTransfer
+}
+
+func newMockScheduledTransferRepository(wallets ...uuid.UUID) *MockScheduledTransferRepository {
+	m := &MockScheduledTransferRepository{wallets: map[uuid.UUID]bool{}, schedules: map[string]*models.ScheduledTransfer{}}
+	for _, id := range wallets {
+		m.wallets[id] = true
+	}
+	return m
+}
+
+func (m *MockScheduledTransferRepository) CreateScheduledTransfer(st *models.ScheduledTransfer) error {
+	if !m.wallets[st.FromWalletID] || !m.wallets[st.ToWalletID] {
+		return repository.ErrWalletNotFound
+	}
+	stored := *st
+	m.schedules[st.ID.String()] = &stored
+	return nil
+}
+
+func (m *MockScheduledTransferRepository) GetScheduledTransfer(id string) (*models.ScheduledTransfer, error) {
+	st, ok := m.schedules[id]
+	if !ok {
+		return nil, repository.ErrScheduledTransferNotFound
+	}
+	stored := *st
+	return &stored, nil
+}
+
+func (m *MockScheduledTransferRepository) ListScheduledTransfers(clientID *uuid.UUID, ownerID string) ([]models.ScheduledTransfer, error) {
+	var items []models.ScheduledTransfer
+	for _, st := range m.schedules {
+		if (clientID == nil || (st.ClientID.Valid && st.ClientID.UUID == *clientID)) && (ownerID == "" || st.OwnerID.String == ownerID) {
+			items = append(items, *st)
+		}
+	}
+	return items, nil
+}
+
+func (m *MockScheduledTransferRepository) UpdateScheduledTransfer(id string, update func(st *models.ScheduledTransfer) error) (*models.ScheduledTransfer, error) {
+	st, err := m.GetScheduledTransfer(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(st); err != nil {
+		return nil, err
+	}
+	m.schedules[id] = st
+	return st, nil
+}
+
+func (m *MockScheduledTransferRepository) ListDueScheduledTransfers(now time.Time, limit int) ([]models.ScheduledTransfer, error) {
+	return nil, nil
+}
+
+func (m *MockScheduledTransferRepository) ExecuteScheduledTransfer(id uuid.UUID, occurrenceAt, now time.Time) (*models.ScheduledTransferRun, error) {
+	return nil, nil
+}
+
+func (m *MockScheduledTransferRepository) ListScheduledTransferRuns(scheduleID string, limit int) ([]models.ScheduledTransferRun, error) {
+	return []models.ScheduledTransferRun{}, nil
+}
+
+func scheduleTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func TestScheduledTransferService_Create(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+	clientID := uuid.New()
+	repo := newMockScheduledTransferRepository(from, to)
+	svc := NewScheduledTransferService(repo)
+	svc.now = func() time.Time { return scheduleTime("2025-10-20T12:00:00Z") }
+
+	monthly := ScheduledTransferInput{
+		FromWalletID: from,
+		ToWalletID:   to,
+		Amount:       utils.Money{Raw: 100000},
+		Recurrence:   models.Recurrence{Cron: "0 9 1 * *"},
+	}
+	st, err := svc.CreateScheduledTransfer(&clientID, "", monthly)
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer() error = %v", err)
+	}
+	if st.NextRunAt == nil || !st.NextRunAt.Equal(scheduleTime("2025-11-01T09:00:00Z")) {
+		t.Errorf("NextRunAt = %v, want 2025-11-01T09:00:00Z", st.NextRunAt)
+	}
+	if !st.ClientID.Valid || st.ClientID.UUID != clientID {
+		t.Errorf("ClientID = %v, want %s", st.ClientID, clientID)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(in *ScheduledTransferInput)
+		wantErr error
+	}{
+		{"bad cron", func(in *ScheduledTransferInput) { in.Recurrence.Cron = "0 9 1 *" }, ErrInvalidSchedule},
+		{"one-off in the past", func(in *ScheduledTransferInput) {
+			in.Recurrence = models.Recurrence{}
+			in.StartAt = scheduleTime("2025-10-01T00:00:00Z")
+		}, ErrInvalidSchedule},
+		{"unknown wallet", func(in *ScheduledTransferInput) { in.ToWalletID = uuid.New() }, repository.ErrWalletNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := monthly
+			tt.modify(&input)
+			if _, err := svc.CreateScheduledTransfer(&clientID, "", input); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateScheduledTransfer() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduledTransferService_ClientScope(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+	owner, other := uuid.New(), uuid.New()
+	repo := newMockScheduledTransferRepository(from, to)
+	svc := NewScheduledTransferService(repo)
+
+	st, err := svc.CreateScheduledTransfer(&owner, "", ScheduledTransferInput{
+		FromWalletID: from,
+		ToWalletID:   to,
+		Amount:       utils.Money{Raw: 100},
+		Recurrence:   models.Recurrence{Interval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer() error = %v", err)
+	}
+
+	if _, err := svc.GetScheduledTransfer(&other, "", st.ID.String()); !errors.Is(err, repository.ErrScheduledTransferNotFound) {
+		t.Errorf("GetScheduledTransfer() by other client error = %v, want not found", err)
+	}
+	if err := svc.CancelScheduledTransfer(&other, "", st.ID.String()); !errors.Is(err, repository.ErrScheduledTransferNotFound) {
+		t.Errorf("CancelScheduledTransfer() by other client error = %v, want not found", err)
+	}
+	if _, err := svc.GetScheduledTransfer(nil, "", st.ID.String()); err != nil {
+		t.Errorf("GetScheduledTransfer() by admin error = %v", err)
+	}
+	if items, _ := svc.ListScheduledTransfers(&other, ""); len(items) != 0 {
+		t.Errorf("ListScheduledTransfers() for other client = %d items, want 0", len(items))
+	}
+}
+
+func TestScheduledTransferService_OwnerScope(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+	repo := newMockScheduledTransferRepository(from, to)
+	svc := NewScheduledTransferService(repo)
+
+	st, err := svc.CreateScheduledTransfer(nil, "user-1", ScheduledTransferInput{
+		FromWalletID: from,
+		ToWalletID:   to,
+		Amount:       utils.Money{Raw: 100},
+		Recurrence:   models.Recurrence{Interval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer() error = %v", err)
+	}
+	if !st.OwnerID.Valid || st.OwnerID.String != "user-1" {
+		t.Errorf("OwnerID = %v, want user-1", st.OwnerID)
+	}
+
+	if _, err := svc.GetScheduledTransfer(nil, "user-2", st.ID.String()); !errors.Is(err, repository.ErrScheduledTransferNotFound) {
+		t.Errorf("GetScheduledTransfer() by other owner error = %v, want not found", err)
+	}
+	if _, err := svc.ListScheduledTransferRuns(nil, "user-2", st.ID.String(), 10); !errors.Is(err, repository.ErrScheduledTransferNotFound) {
+		t.Errorf("ListScheduledTransferRuns() by other owner error = %v, want not found", err)
+	}
+	if _, err := svc.GetScheduledTransfer(nil, "user-1", st.ID.String()); err != nil {
+		t.Errorf("GetScheduledTransfer() by owner error = %v", err)
+	}
+	if items, _ := svc.ListScheduledTransfers(nil, "user-2"); len(items) != 0 {
+		t.Errorf("ListScheduledTransfers() for other owner = %d items, want 0", len(items))
+	}
+}
+
+func TestScheduledTransferService_PauseResumeSkipsMissedRuns(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+	repo := newMockScheduledTransferRepository(from, to)
+	svc := NewScheduledTransferService(repo)
+	svc.now = func() time.Time { return scheduleTime("2025-10-20T12:00:00Z") }
+
+	st, err := svc.CreateScheduledTransfer(nil, "", ScheduledTransferInput{
+		FromWalletID: from,
+		ToWalletID:   to,
+		Amount:       utils.Money{Raw: 100},
+		Recurrence:   models.Recurrence{Cron: "0 9 1 * *"},
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer() error = %v", err)
+	}
+
+	paused := models.ScheduleStatusPaused
+	if st, err = svc.UpdateScheduledTransfer(nil, "", st.ID.String(), ScheduledTransferUpdate{Status: &paused}); err != nil || st.Status != paused {
+		t.Fatalf("pause: status %v, error %v", st, err)
+	}
+
+	// Возобновление в феврале: ноябрьский - февральский запуски пропускаются
+	svc.now = func() time.Time { return scheduleTime("2026-02-10T00:00:00Z") }
+	active := models.ScheduleStatusActive
+	st, err = svc.UpdateScheduledTransfer(nil, "", st.ID.String(), ScheduledTransferUpdate{Status: &active})
+	if err != nil {
+		t.Fatalf("resume error = %v", err)
+	}
+	if st.NextRunAt == nil || !st.NextRunAt.Equal(scheduleTime("2026-03-01T09:00:00Z")) {
+		t.Errorf("NextRunAt after resume = %v, want 2026-03-01T09:00:00Z", st.NextRunAt)
+	}
+
+	if err := svc.CancelScheduledTransfer(nil, "", st.ID.String()); err != nil {
+		t.Fatalf("CancelScheduledTransfer() error = %v", err)
+	}
+	if _, err := svc.UpdateScheduledTransfer(nil, "", st.ID.String(), ScheduledTransferUpdate{Status: &active}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("resume cancelled error = %v, want %v", err, ErrInvalidSchedule)
+	}
+}
@@ -1,22 +1,35 @@
 package service
 
 import (
+	"context"
 	stdErrors "errors"
 	"fmt"
+	"time"
 	"wallet-api/internal/models"
 	"wallet-api/internal/repository"
+	"wallet-api/pkg/receipt"
 	"wallet-api/utils"
 	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
 )
 
 type WalletService struct {
 	repo repository.WalletRepositoryInterface
+	// receipts подписывает квитанции операций; nil отключает квитанции
+	receipts *receipt.Signer
 }
 
 func NewWalletService(repo repository.WalletRepositoryInterface) *WalletService {
 	return &WalletService{repo: repo}
 }
 
+// WithReceiptSigner включает подписанные квитанции в результатах ProcessWalletOperation
+func (s *WalletService) WithReceiptSigner(signer *receipt.Signer) *WalletService {
+	s.receipts = signer
+	return s
+}
+
 func (s *WalletService) GetWallet(walletID string) (*models.Wallet, error) {
 	wallet, err := s.repo.GetWalletByID(walletID)
 	if err != nil {
@@ -28,7 +41,172 @@ func (s *WalletService) GetWallet(walletID string) (*models.Wallet, error) {
 	return wallet, nil
 }
 
-func (s *WalletService) ProcessWalletOperation(operation *models.WalletOperation) (*models.Wallet, error) {
+// GetOwnedWallet возвращает кошелек только его владельцу
+func (s *WalletService) GetOwnedWallet(walletID, ownerID string) (*models.Wallet, error) {
+	wallet, err := s.GetWallet(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !wallet.IsOwnedBy(ownerID) {
+		logger.GlobalLogger.Warning("Access denied to wallet %s for owner %s", walletID, ownerID)
+		return nil, fmt.Errorf("get wallet: %w", ErrWalletAccessDenied)
+	}
+
+	return wallet, nil
+}
+
+// GetBalanceAsOf восстанавливает баланс кошелька на момент asOf: берет последний снимок не позже asOf
+// и проигрывает проводки после него. Без снимка журнал проигрывается с начала
+func (s *WalletService) GetBalanceAsOf(walletID, ownerID string, asOf time.Time) (*models.HistoricalBalance, error) {
+	var wallet *models.Wallet
+	var err error
+	if ownerID != "" {
+		wallet, err = s.GetOwnedWallet(walletID, ownerID)
+	} else {
+		wallet, err = s.GetWallet(walletID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	asOf = asOf.UTC()
+	if wallet.CreatedAt.After(asOf) {
+		return nil, fmt.Errorf("get balance as of: %w", ErrWalletNotExisted)
+	}
+
+	snapshot, err := s.repo.GetBalanceSnapshot(walletID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("get balance as of: %w", repository.ErrDatabaseError)
+	}
+
+	result := &models.HistoricalBalance{WalletID: wallet.ID, AsOf: asOf, Snapshot: snapshot}
+	var afterID int64
+	if snapshot != nil {
+		result.Balance = snapshot.Balance
+		afterID = snapshot.LedgerEntryID
+	}
+
+	delta, replayed, err := s.repo.ReplayLedger(walletID, afterID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("get balance as of: %w", repository.ErrDatabaseError)
+	}
+	result.Balance = result.Balance.Add(delta)
+	result.ReplayedEntries = replayed
+
+	return result, nil
+}
+
+// OpenStatement открывает выписку по кошельку за период [from, to). Курсор нужно закрыть после чтения
+func (s *WalletService) OpenStatement(ctx context.Context, walletID, ownerID string, from, to time.Time) (repository.StatementCursor, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("open statement: %w: from must be before to", ErrInvalidStatementPeriod)
+	}
+
+	var err error
+	if ownerID != "" {
+		_, err = s.GetOwnedWallet(walletID, ownerID)
+	} else {
+		_, err = s.GetWallet(walletID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.repo.OpenStatement(ctx, walletID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("open statement: %w", repository.ErrDatabaseError)
+	}
+	return cursor, nil
+}
+
+// LookupWallets возвращает найденные кошельки и список ID, которых нет.
+// Если задан ownerID, чужие кошельки тоже считаются отсутствующими, чтобы не раскрывать их существование
+func (s *WalletService) LookupWallets(walletIDs []uuid.UUID, ownerID string) ([]models.Wallet, []uuid.UUID, error) {
+	wallets, err := s.repo.GetWalletsByIDs(walletIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lookup wallets: %w", repository.ErrDatabaseError)
+	}
+
+	byID := make(map[uuid.UUID]models.Wallet, len(wallets))
+	for _, wallet := range wallets {
+		if ownerID != "" && !wallet.IsOwnedBy(ownerID) {
+			continue
+		}
+		byID[wallet.ID] = wallet
+	}
+
+	// Результат сохраняет порядок запроса
+	found := make([]models.Wallet, 0, len(byID))
+	missing := make([]uuid.UUID, 0)
+	for _, id := range walletIDs {
+		if wallet, ok := byID[id]; ok {
+			found = append(found, wallet)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	return found, missing, nil
+}
+
+// ListWallets возвращает страницу кошельков по фильтру. Курсор должен быть выдан для той же сортировки,
+// иначе позиция в выборке не имеет смысла
+func (s *WalletService) ListWallets(filter models.WalletFilter) (*models.WalletPage, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = models.WalletSortCreatedAt
+	}
+	if !models.IsValidWalletSort(filter.SortBy) {
+		return nil, fmt.Errorf("list wallets: %w: unknown sort %s", ErrInvalidWalletFilter, filter.SortBy)
+	}
+	if filter.Status != "" && !models.IsValidWalletStatus(filter.Status) {
+		return nil, fmt.Errorf("list wallets: %w: unknown status %s", ErrInvalidWalletFilter, filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultWalletPageSize
+	}
+	if filter.Limit > models.MaxWalletPageSize {
+		return nil, fmt.Errorf("list wallets: %w: limit exceeds %d", ErrInvalidWalletFilter, models.MaxWalletPageSize)
+	}
+	if filter.MinBalance != nil && filter.MaxBalance != nil && filter.MinBalance.Raw > filter.MaxBalance.Raw {
+		return nil, fmt.Errorf("list wallets: %w: minBalance exceeds maxBalance", ErrInvalidWalletFilter)
+	}
+	if filter.After != nil && (filter.After.SortBy != filter.SortBy || filter.After.Descending != filter.Descending) {
+		return nil, fmt.Errorf("list wallets: %w: cursor belongs to another sort", ErrInvalidWalletFilter)
+	}
+
+	// Колонки wallets хранят время без зоны в UTC
+	filter.CreatedFrom = utcTime(filter.CreatedFrom)
+	filter.CreatedTo = utcTime(filter.CreatedTo)
+	filter.UpdatedFrom = utcTime(filter.UpdatedFrom)
+	filter.UpdatedTo = utcTime(filter.UpdatedTo)
+
+	pageSize := filter.Limit
+	// Лишняя запись показывает, есть ли следующая страница, без отдельного COUNT
+	filter.Limit = pageSize + 1
+	wallets, err := s.repo.ListWallets(filter)
+	if err != nil {
+		return nil, fmt.Errorf("list wallets: %w", repository.ErrDatabaseError)
+	}
+
+	page := &models.WalletPage{Items: wallets}
+	if len(wallets) > pageSize {
+		page.Items = wallets[:pageSize]
+		page.NextCursor = models.NewWalletCursor(&page.Items[pageSize-1], filter.SortBy, filter.Descending).Encode()
+	}
+
+	return page, nil
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+func (s *WalletService) ProcessWalletOperation(operation *models.WalletOperation) (*models.OperationResult, error) {
 	if operation.OperationType == models.OperationTypeWithdraw {
 		existingWallet, err := s.repo.GetWalletByID(operation.WalletID.String())
 		if err != nil {
@@ -38,23 +216,66 @@ func (s *WalletService) ProcessWalletOperation(operation *models.WalletOperation
 			return nil, fmt.Errorf("process operation: %w", repository.ErrDatabaseError)
 		}
 
+		if operation.OwnerID != "" && !existingWallet.IsOwnedBy(operation.OwnerID) {
+			logger.GlobalLogger.Warning("Withdraw denied from wallet %s for owner %s", existingWallet.ID, operation.OwnerID)
+			return nil, fmt.Errorf("process operation: %w", ErrWalletAccessDenied)
+		}
+
+		// Кошелек с кредитной линией может уйти в минус в ее пределах; комиссию сверх суммы
+		// и параллельные списания учитывает условие списания в репозитории
 		withdrawAmount := utils.Money{Raw: operation.Amount}
-		if existingWallet.Balance.Raw < withdrawAmount.Raw {
+		if !existingWallet.CanDebit(withdrawAmount) {
 			logger.GlobalLogger.Warning("Insufficient funds detected for wallet %s", existingWallet.ID)
 			return nil, fmt.Errorf("process operation: %w", ErrInsufficientFunds)
 		}
 	}
 
+	// Пополнение нового кошелька создает его: владельцем становится пользователь из токена,
+	// иначе в режиме jwt кошелек остался бы недоступен тому, кто его пополнил
 	amount := utils.Money{Raw: operation.Amount}
-	wallet, err := s.repo.UpdateWalletBalance(operation.WalletID.String(), operation.OperationType, amount)
+	result, err := s.repo.UpdateWalletBalance(operation.WalletID.String(), operation.OperationType, amount, operation.OwnerID)
 	if err != nil {
 		if stdErrors.Is(err, repository.ErrWalletNotFound) {
 			return nil, fmt.Errorf("process operation: %w", repository.ErrWalletNotFound)
 		}
+		if stdErrors.Is(err, repository.ErrWalletFrozen) {
+			return nil, fmt.Errorf("process operation: %w", ErrWalletFrozen)
+		}
+		if stdErrors.Is(err, repository.ErrInsufficientFunds) {
+			logger.GlobalLogger.Warning("Insufficient funds with fee for wallet %s", operation.WalletID)
+			return nil, fmt.Errorf("process operation: %w", ErrInsufficientFunds)
+		}
+		if stdErrors.Is(err, repository.ErrFeeExceedsAmount) {
+			return nil, fmt.Errorf("process operation: %w", ErrFeeExceedsAmount)
+		}
+		var limit *models.LimitExceededError
+		if stdErrors.As(err, &limit) {
+			return nil, fmt.Errorf("process operation: %w: %w", ErrLimitExceeded, limit)
+		}
 		return nil, fmt.Errorf("process operation: %w", repository.ErrDatabaseError)
 	}
 
-	return wallet, nil
+	if s.receipts != nil {
+		result.Receipt = newReceipt(result)
+		s.receipts.Sign(result.Receipt)
+	}
+
+	return result, nil
+}
+
+// newReceipt строит неподписанную квитанцию по операции: сумма и время - из проводки операции,
+// комиссия - из результата, баланс - после всех проводок, включая FEE
+func newReceipt(result *models.OperationResult) *receipt.Receipt {
+	return &receipt.Receipt{
+		TransactionID: result.TransactionID,
+		WalletID:      result.Entry.WalletID,
+		OperationType: result.Entry.EntryType,
+		Amount:        result.Entry.Amount.String(),
+		Fee:           result.Fee.Fee.String(),
+		Net:           result.Fee.Net.String(),
+		BalanceAfter:  result.Wallet.Balance.String(),
+		Timestamp:     result.Entry.CreatedAt.UTC(),
+	}
 }
 
 func (s *WalletService) CreateWallet(wallet *models.Wallet) error {
@@ -65,3 +286,58 @@ func (s *WalletService) CreateWallet(wallet *models.Wallet) error {
 
 	return nil
 }
+
+// ListLedgerEntries возвращает проводки кошелька после курсора afterID
+func (s *WalletService) ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	entries, err := s.repo.ListLedgerEntries(walletID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list ledger entries: %w", repository.ErrDatabaseError)
+	}
+	return entries, nil
+}
+
+func (s *WalletService) GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error) {
+	entry, err := s.repo.GetLastLedgerEntry(walletID)
+	if err != nil {
+		return nil, fmt.Errorf("get last ledger entry: %w", repository.ErrDatabaseError)
+	}
+	return entry, nil
+}
+
+// Transfer переводит средства между кошельками. Владелец проверяется только у источника,
+// как и при обычном списании; достаточность средств повторно проверяется под блокировкой в репозитории
+func (s *WalletService) Transfer(transfer *models.Transfer) (*models.TransferResult, error) {
+	if transfer.FromWalletID == transfer.ToWalletID {
+		return nil, fmt.Errorf("transfer: %w: source and destination are the same wallet", ErrInvalidTransfer)
+	}
+	if transfer.Amount <= 0 {
+		return nil, fmt.Errorf("transfer: %w: amount must be positive", ErrInvalidTransfer)
+	}
+
+	if transfer.OwnerID != "" {
+		if _, err := s.GetOwnedWallet(transfer.FromWalletID.String(), transfer.OwnerID); err != nil {
+			return nil, fmt.Errorf("transfer: %w", err)
+		}
+	}
+
+	result, err := s.repo.TransferBetweenWallets(transfer.FromWalletID.String(), transfer.ToWalletID.String(), utils.Money{Raw: transfer.Amount})
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrWalletNotFound) {
+			return nil, fmt.Errorf("transfer: %w", repository.ErrWalletNotFound)
+		}
+		if stdErrors.Is(err, repository.ErrInsufficientFunds) {
+			logger.GlobalLogger.Warning("Insufficient funds detected for wallet %s", transfer.FromWalletID)
+			return nil, fmt.Errorf("transfer: %w", ErrInsufficientFunds)
+		}
+		if stdErrors.Is(err, repository.ErrWalletFrozen) {
+			return nil, fmt.Errorf("transfer: %w", ErrWalletFrozen)
+		}
+		var limit *models.LimitExceededError
+		if stdErrors.As(err, &limit) {
+			return nil, fmt.Errorf("transfer: %w: %w", ErrLimitExceeded, limit)
+		}
+		return nil, fmt.Errorf("transfer: %w", repository.ErrDatabaseError)
+	}
+
+	return result, nil
+}
@@ -1,11 +1,17 @@
 package service
 
 import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
 	"errors"
+	"fmt"
+	"sort"
 	"testing"
 	"time"
 	"wallet-api/internal/models"
 	"wallet-api/internal/repository"
+	"wallet-api/pkg/receipt"
 	"wallet-api/utils"
 	"wallet-api/utils/logger"
 
@@ -20,6 +26,17 @@ type MockWalletRepository struct {
 	wallets     map[string]*models.Wallet
 	shouldError bool
 	errorType   error
+	lastFilter  models.WalletFilter
+	ledger      []models.LedgerEntry
+	snapshots   []models.BalanceSnapshot
+	// limits проверяются, как в транзакции UpdateWalletBalance, без учета оборотов
+	limits map[string]models.WalletLimits
+	// fees - правила комиссий по типу операции, одинаковые для всех кошельков
+	fees map[string]models.FeeRule
+	// createOnDeposit создает кошелек при пополнении, как UpdateWalletBalance репозитория
+	createOnDeposit bool
+	// tierLimits - лимиты уровней; новый кошелек получает лимиты models.DefaultWalletTier
+	tierLimits map[string]models.WalletLimits
 }
 
 func NewMockWalletRepository() *MockWalletRepository {
@@ -40,26 +57,122 @@ func (m *MockWalletRepository) GetWalletByID(walletID string) (*models.Wallet, e
 	return wallet, nil
 }
 
-func (m *MockWalletRepository) UpdateWalletBalance(walletID, operationType string, amount utils.Money) (*models.Wallet, error) {
+func (m *MockWalletRepository) GetWalletsByIDs(walletIDs []uuid.UUID) ([]models.Wallet, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+
+	var wallets []models.Wallet
+	for _, id := range walletIDs {
+		if wallet, exists := m.wallets[id.String()]; exists {
+			wallets = append(wallets, *wallet)
+		}
+	}
+	return wallets, nil
+}
+
+// ListWallets поддерживает только сортировку по created_at по возрастанию, этого достаточно для проверки пагинации
+func (m *MockWalletRepository) ListWallets(filter models.WalletFilter) ([]models.Wallet, error) {
+	m.lastFilter = filter
+	if m.shouldError {
+		return nil, m.errorType
+	}
+
+	var wallets []models.Wallet
+	for _, wallet := range m.wallets {
+		wallets = append(wallets, *wallet)
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		if !wallets[i].CreatedAt.Equal(wallets[j].CreatedAt) {
+			return wallets[i].CreatedAt.Before(wallets[j].CreatedAt)
+		}
+		return wallets[i].ID.String() < wallets[j].ID.String()
+	})
+
+	result := make([]models.Wallet, 0, filter.Limit)
+	for _, wallet := range wallets {
+		if filter.After != nil {
+			position := models.NewWalletCursor(&wallet, filter.SortBy, filter.Descending)
+			if position.Value < filter.After.Value || (position.Value == filter.After.Value && wallet.ID.String() <= filter.After.ID.String()) {
+				continue
+			}
+		}
+		if len(result) == filter.Limit {
+			break
+		}
+		result = append(result, wallet)
+	}
+	return result, nil
+}
+
+func (m *MockWalletRepository) UpdateWalletBalance(walletID, operationType string, amount utils.Money, ownerID string) (*models.OperationResult, error) {
 	if m.shouldError {
 		return nil, m.errorType
 	}
 
 	wallet, exists := m.wallets[walletID]
-	if !exists {
+	created := !exists && m.createOnDeposit && operationType == models.OperationTypeDeposit
+	if created {
+		wallet = &models.Wallet{
+			ID:        uuid.MustParse(walletID),
+			OwnerID:   sql.NullString{String: ownerID, Valid: ownerID != ""},
+			CreatedAt: time.Now(),
+		}
+		if limits, ok := m.tierLimits[models.DefaultWalletTier]; ok {
+			if m.limits == nil {
+				m.limits = map[string]models.WalletLimits{}
+			}
+			m.limits[walletID] = limits
+		}
+	} else if !exists {
 		return nil, repository.ErrWalletNotFound
 	}
+	if wallet.Status == models.WalletStatusFrozen {
+		return nil, repository.ErrWalletFrozen
+	}
+
+	fee := m.fee(operationType, amount)
+	if !fee.CoversFee() {
+		return nil, repository.ErrFeeExceedsAmount
+	}
 
+	balance := wallet.Balance
 	switch operationType {
 	case models.OperationTypeDeposit:
-		wallet.Balance = wallet.Balance.Add(amount)
+		balance = balance.Add(fee.WalletAmount())
 	case models.OperationTypeWithdraw:
-		wallet.Balance = wallet.Balance.Sub(amount)
+		if !wallet.CanDebit(fee.WalletAmount()) {
+			return nil, repository.ErrInsufficientFunds
+		}
+		balance = balance.Sub(fee.WalletAmount())
+	}
+	if limits, ok := m.limits[walletID]; ok {
+		if exceeded := limits.Check(operationType, amount, balance, models.LimitUsage{}); exceeded != nil {
+			exceeded.WalletID = wallet.ID
+			return nil, fmt.Errorf("update wallet balance: %w", exceeded)
+		}
+	}
+	wallet.Balance = balance
+	if created {
+		m.wallets[walletID] = wallet
 	}
 
 	wallet.UpdatedAt.Time = time.Now()
 	wallet.UpdatedAt.Valid = true
-	return wallet, nil
+	return &models.OperationResult{
+		Wallet:        wallet,
+		TransactionID: uuid.New(),
+		Entry:         models.NewFeeLedgerEntries(wallet, operationType, amount, fee)[0],
+		Fee:           fee,
+	}, nil
+}
+
+func (m *MockWalletRepository) fee(operationType string, amount utils.Money) models.Fee {
+	var fee utils.Money
+	if rule, ok := m.fees[operationType]; ok {
+		fee = rule.Compute(amount)
+	}
+	return models.NewFee(operationType, amount, fee)
 }
 
 func (m *MockWalletRepository) CreateWallet(wallet *models.Wallet) error {
@@ -71,6 +184,83 @@ func (m *MockWalletRepository) CreateWallet(wallet *models.Wallet) error {
 	return nil
 }
 
+func (m *MockWalletRepository) ListLedgerEntries(walletID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	return nil, nil
+}
+
+func (m *MockWalletRepository) GetLastLedgerEntry(walletID string) (*models.LedgerEntry, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	return nil, nil
+}
+
+func (m *MockWalletRepository) GetBalanceSnapshot(walletID string, asOf time.Time) (*models.BalanceSnapshot, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+
+	var latest *models.BalanceSnapshot
+	for i, snapshot := range m.snapshots {
+		if snapshot.WalletID.String() == walletID && !snapshot.TakenAt.After(asOf) &&
+			(latest == nil || snapshot.TakenAt.After(latest.TakenAt)) {
+			latest = &m.snapshots[i]
+		}
+	}
+	return latest, nil
+}
+
+func (m *MockWalletRepository) ReplayLedger(walletID string, afterID int64, until time.Time) (utils.Money, int, error) {
+	if m.shouldError {
+		return utils.Money{}, 0, m.errorType
+	}
+
+	var delta utils.Money
+	var count int
+	for _, entry := range m.ledger {
+		if entry.WalletID.String() != walletID || entry.ID <= afterID || entry.CreatedAt.After(until) {
+			continue
+		}
+		if models.IsDebitEntry(entry.EntryType) {
+			delta = delta.Sub(entry.Amount)
+		} else {
+			delta = delta.Add(entry.Amount)
+		}
+		count++
+	}
+	return delta, count, nil
+}
+
+func (m *MockWalletRepository) OpenStatement(ctx context.Context, walletID string, from, to time.Time) (repository.StatementCursor, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+	return nil, nil
+}
+
+func (m *MockWalletRepository) TransferBetweenWallets(fromID, toID string, amount utils.Money) (*models.TransferResult, error) {
+	if m.shouldError {
+		return nil, m.errorType
+	}
+
+	from, fromExists := m.wallets[fromID]
+	to, toExists := m.wallets[toID]
+	if !fromExists || !toExists {
+		return nil, repository.ErrWalletNotFound
+	}
+	fee := m.fee(models.JournalOperationTransfer, amount)
+	if !from.CanDebit(fee.Gross) {
+		return nil, repository.ErrInsufficientFunds
+	}
+
+	from.Balance = from.Balance.Sub(fee.Gross)
+	to.Balance = to.Balance.Add(amount)
+	return &models.TransferResult{From: from, To: to, Fee: fee}, nil
+}
+
 func TestWalletService_GetWallet(t *testing.T) {
 	mockRepo := NewMockWalletRepository()
 	service := NewWalletService(mockRepo)
@@ -128,6 +318,21 @@ func TestWalletService_ProcessWalletOperation(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 	mockRepo.wallets[walletID.String()] = wallet
+	frozenID := uuid.New()
+	mockRepo.wallets[frozenID.String()] = &models.Wallet{
+		ID:        frozenID,
+		Balance:   utils.Money{Raw: 1000},
+		Status:    models.WalletStatusFrozen,
+		CreatedAt: time.Now(),
+	}
+
+	limitedID := uuid.New()
+	maxBalance := utils.Money{Raw: 2000}
+	mockRepo.wallets[limitedID.String()] = &models.Wallet{ID: limitedID, Balance: utils.Money{Raw: 1000}, CreatedAt: time.Now()}
+	mockRepo.limits = map[string]models.WalletLimits{limitedID.String(): {MaxBalance: &maxBalance}}
+
+	creditID := uuid.New()
+	mockRepo.wallets[creditID.String()] = &models.Wallet{ID: creditID, Balance: utils.Money{Raw: 1000}, OverdraftLimit: utils.Money{Raw: 500}, CreatedAt: time.Now()}
 
 	tests := []struct {
 		name      string
@@ -162,7 +367,43 @@ func TestWalletService_ProcessWalletOperation(t *testing.T) {
 			},
 			wantErr: ErrInsufficientFunds,
 		},
+		{
+			name: "withdraw within overdraft limit",
+			operation: &models.WalletOperation{
+				WalletID:      creditID,
+				OperationType: models.OperationTypeWithdraw,
+				Amount:        1400,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "withdraw beyond overdraft limit",
+			operation: &models.WalletOperation{
+				WalletID:      creditID,
+				OperationType: models.OperationTypeWithdraw,
+				Amount:        200,
+			},
+			wantErr: ErrInsufficientFunds,
+		},
+		{
+			name: "deposit to frozen wallet",
+			operation: &models.WalletOperation{
+				WalletID:      frozenID,
+				OperationType: models.OperationTypeDeposit,
+				Amount:        100,
+			},
+			wantErr: ErrWalletFrozen,
+		},
 
+		{
+			name: "deposit over max balance",
+			operation: &models.WalletOperation{
+				WalletID:      limitedID,
+				OperationType: models.OperationTypeDeposit,
+				Amount:        1500,
+			},
+			wantErr: ErrLimitExceeded,
+		},
 		{
 			name: "non-existing wallet",
 			operation: &models.WalletOperation{
@@ -201,6 +442,19 @@ func TestWalletService_ProcessWalletOperation(t *testing.T) {
 			}
 		})
 	}
+
+	mockRepo.shouldError = false
+	var limit *models.LimitExceededError
+	_, err := service.ProcessWalletOperation(&models.WalletOperation{WalletID: limitedID, OperationType: models.OperationTypeDeposit, Amount: 1500})
+	if !errors.As(err, &limit) || limit.Limit != models.LimitMaxBalance || limit.WalletID != limitedID || limit.Remaining.Raw != 1000 {
+		t.Errorf("ProcessWalletOperation() error = %v, want max_balance with 10.00 remaining", err)
+	}
+	if balance := mockRepo.wallets[limitedID.String()].Balance; balance.Raw != 1000 {
+		t.Errorf("balance after rejected operation = %s, want 10.00", balance)
+	}
+	if credit := mockRepo.wallets[creditID.String()]; credit.Balance.Raw != -400 || credit.CreditRemaining().Raw != 100 {
+		t.Errorf("overdrawn wallet balance = %s, credit remaining = %s, want -4.00 and 1.00", credit.Balance, credit.CreditRemaining())
+	}
 }
 
 func TestWalletService_ProcessWalletOperation_BalanceChanges(t *testing.T) {
@@ -248,14 +502,67 @@ func TestWalletService_ProcessWalletOperation_BalanceChanges(t *testing.T) {
 				return
 			}
 
-			if result.Balance.Raw != tt.expectedBalance {
+			if result.Wallet.Balance.Raw != tt.expectedBalance {
 				t.Errorf("WalletService.ProcessWalletOperation() balance = %v, want %v",
-					result.Balance.Raw, tt.expectedBalance)
+					result.Wallet.Balance.Raw, tt.expectedBalance)
 			}
 		})
 	}
 }
 
+func TestWalletService_ProcessWalletOperation_Receipt(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	walletID := uuid.New()
+	mockRepo.wallets[walletID.String()] = &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 1000}, CreatedAt: time.Now()}
+	operation := &models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 250}
+
+	result, err := NewWalletService(mockRepo).ProcessWalletOperation(operation)
+	if err != nil {
+		t.Fatalf("ProcessWalletOperation() error = %v", err)
+	}
+	if result.Receipt != nil {
+		t.Errorf("ProcessWalletOperation() without signer receipt = %+v, want nil", result.Receipt)
+	}
+
+	public, private, _ := ed25519.GenerateKey(nil)
+	service := NewWalletService(mockRepo).WithReceiptSigner(receipt.NewSigner(private))
+	result, err = service.ProcessWalletOperation(operation)
+	if err != nil {
+		t.Fatalf("ProcessWalletOperation() error = %v", err)
+	}
+
+	got := result.Receipt
+	if got == nil {
+		t.Fatal("ProcessWalletOperation() receipt = nil")
+	}
+	if got.TransactionID != result.TransactionID || got.WalletID != walletID || got.OperationType != models.OperationTypeWithdraw ||
+		got.Amount != "2.50" || got.Fee != "0.00" || got.Net != "2.50" || got.BalanceAfter != "5.00" {
+		t.Errorf("receipt = %+v, want transaction %s, WITHDRAW 2.50 without fee, balance 5.00", got, result.TransactionID)
+	}
+
+	keys := receipt.NewKeySet()
+	keys.Add(public)
+	if err := keys.Verify(got); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	// С комиссией квитанция подписывает ее и баланс после проводки FEE, а не промежуточный
+	mockRepo.fees = map[string]models.FeeRule{
+		models.OperationTypeWithdraw: {Kind: models.FeeKindFlat, Flat: utils.Money{Raw: 30}},
+	}
+	result, err = service.ProcessWalletOperation(operation)
+	if err != nil {
+		t.Fatalf("ProcessWalletOperation() with fee error = %v", err)
+	}
+	got = result.Receipt
+	if got.Amount != "2.50" || got.Fee != "0.30" || got.Net != "2.50" || got.BalanceAfter != "2.20" {
+		t.Errorf("receipt with fee = %+v, want WITHDRAW 2.50, fee 0.30, net 2.50, balance 2.20", got)
+	}
+	if err := keys.Verify(got); err != nil {
+		t.Errorf("Verify() with fee error = %v", err)
+	}
+}
+
 func TestWalletService_CreateWallet(t *testing.T) {
 	mockRepo := NewMockWalletRepository()
 	service := NewWalletService(mockRepo)
@@ -303,3 +610,435 @@ func TestWalletService_CreateWallet(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletService_OwnershipChecks(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	service := NewWalletService(mockRepo)
+
+	walletID := uuid.New()
+	mockRepo.wallets[walletID.String()] = &models.Wallet{
+		ID:      walletID,
+		Balance: utils.Money{Raw: 1000},
+		OwnerID: sql.NullString{String: "alice", Valid: true},
+	}
+
+	if _, err := service.GetOwnedWallet(walletID.String(), "alice"); err != nil {
+		t.Errorf("GetOwnedWallet() owner unexpected error = %v", err)
+	}
+	if _, err := service.GetOwnedWallet(walletID.String(), "bob"); !errors.Is(err, ErrWalletAccessDenied) {
+		t.Errorf("GetOwnedWallet() error = %v, wantErr %v", err, ErrWalletAccessDenied)
+	}
+	if _, err := service.GetOwnedWallet(uuid.New().String(), "alice"); !errors.Is(err, repository.ErrWalletNotFound) {
+		t.Errorf("GetOwnedWallet() error = %v, wantErr %v", err, repository.ErrWalletNotFound)
+	}
+
+	tests := []struct {
+		name      string
+		operation *models.WalletOperation
+		wantErr   error
+	}{
+		{
+			name:      "owner withdraws",
+			operation: &models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 100, OwnerID: "alice"},
+		},
+		{
+			name:      "stranger withdraws",
+			operation: &models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 100, OwnerID: "bob"},
+			wantErr:   ErrWalletAccessDenied,
+		},
+		{
+			name:      "stranger deposits",
+			operation: &models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 100, OwnerID: "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ProcessWalletOperation(tt.operation)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WalletService.ProcessWalletOperation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWalletService_DepositCreatesOwnedWallet(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	mockRepo.createOnDeposit = true
+	service := NewWalletService(mockRepo)
+
+	walletID := uuid.New()
+	deposit := &models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 1000, OwnerID: "alice"}
+	if _, err := service.ProcessWalletOperation(deposit); err != nil {
+		t.Fatalf("ProcessWalletOperation() deposit error = %v", err)
+	}
+
+	if _, err := service.GetOwnedWallet(walletID.String(), "alice"); err != nil {
+		t.Errorf("GetOwnedWallet() by depositor error = %v", err)
+	}
+	withdraw := &models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 400, OwnerID: "alice"}
+	result, err := service.ProcessWalletOperation(withdraw)
+	if err != nil {
+		t.Fatalf("ProcessWalletOperation() withdraw by depositor error = %v", err)
+	}
+	if result.Wallet.Balance.Raw != 600 {
+		t.Errorf("balance = %s, want 6.00", result.Wallet.Balance)
+	}
+
+	withdraw.OwnerID = "bob"
+	if _, err := service.ProcessWalletOperation(withdraw); !errors.Is(err, ErrWalletAccessDenied) {
+		t.Errorf("ProcessWalletOperation() withdraw by stranger error = %v, wantErr %v", err, ErrWalletAccessDenied)
+	}
+}
+
+func TestWalletService_NewWalletStartsWithAnonymousLimits(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	mockRepo.createOnDeposit = true
+	anonymousMaxBalance := utils.Money{Raw: 1500000}
+	mockRepo.tierLimits = map[string]models.WalletLimits{
+		models.WalletTierStandard:  {},
+		models.WalletTierAnonymous: {MaxBalance: &anonymousMaxBalance},
+	}
+	service := NewWalletService(mockRepo)
+
+	walletID := uuid.New()
+	var limit *models.LimitExceededError
+	_, err := service.ProcessWalletOperation(&models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 1500001})
+	if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &limit) || limit.Limit != models.LimitMaxBalance {
+		t.Fatalf("ProcessWalletOperation() error = %v, want %s on a fresh wallet", err, models.LimitMaxBalance)
+	}
+	if _, exists := mockRepo.wallets[walletID.String()]; exists {
+		t.Errorf("rejected deposit must not create the wallet")
+	}
+
+	if _, err := service.ProcessWalletOperation(&models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 1500000}); err != nil {
+		t.Errorf("ProcessWalletOperation() within anonymous max balance error = %v", err)
+	}
+}
+
+func TestWalletService_Transfer(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	service := NewWalletService(mockRepo)
+
+	fromID, toID := uuid.New(), uuid.New()
+	mockRepo.wallets[fromID.String()] = &models.Wallet{
+		ID:      fromID,
+		Balance: utils.Money{Raw: 1000},
+		OwnerID: sql.NullString{String: "alice", Valid: true},
+	}
+	mockRepo.wallets[toID.String()] = &models.Wallet{ID: toID, Balance: utils.Money{Raw: 0}}
+
+	tests := []struct {
+		name     string
+		transfer *models.Transfer
+		wantErr  error
+	}{
+		{
+			name:     "same wallet",
+			transfer: &models.Transfer{FromWalletID: fromID, ToWalletID: fromID, Amount: 100},
+			wantErr:  ErrInvalidTransfer,
+		},
+		{
+			name:     "non-positive amount",
+			transfer: &models.Transfer{FromWalletID: fromID, ToWalletID: toID, Amount: 0},
+			wantErr:  ErrInvalidTransfer,
+		},
+		{
+			name:     "stranger transfers",
+			transfer: &models.Transfer{FromWalletID: fromID, ToWalletID: toID, Amount: 100, OwnerID: "bob"},
+			wantErr:  ErrWalletAccessDenied,
+		},
+		{
+			name:     "insufficient funds",
+			transfer: &models.Transfer{FromWalletID: fromID, ToWalletID: toID, Amount: 1500},
+			wantErr:  ErrInsufficientFunds,
+		},
+		{
+			name:     "unknown destination",
+			transfer: &models.Transfer{FromWalletID: fromID, ToWalletID: uuid.New(), Amount: 100},
+			wantErr:  repository.ErrWalletNotFound,
+		},
+		{
+			name:     "owner transfers",
+			transfer: &models.Transfer{FromWalletID: fromID, ToWalletID: toID, Amount: 300, OwnerID: "alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Transfer(tt.transfer)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WalletService.Transfer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (result.From.Balance.Raw != 700 || result.To.Balance.Raw != 300) {
+				t.Errorf("WalletService.Transfer() balances = %d/%d, want 700/300", result.From.Balance.Raw, result.To.Balance.Raw)
+			}
+		})
+	}
+}
+
+func TestWalletService_LookupWallets(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	service := NewWalletService(mockRepo)
+
+	aliceID, bobID, unknownID := uuid.New(), uuid.New(), uuid.New()
+	mockRepo.wallets[aliceID.String()] = &models.Wallet{ID: aliceID, Balance: utils.Money{Raw: 100}, OwnerID: sql.NullString{String: "alice", Valid: true}}
+	mockRepo.wallets[bobID.String()] = &models.Wallet{ID: bobID, Balance: utils.Money{Raw: 200}, OwnerID: sql.NullString{String: "bob", Valid: true}}
+
+	found, missing, err := service.LookupWallets([]uuid.UUID{unknownID, bobID, aliceID}, "")
+	if err != nil {
+		t.Fatalf("LookupWallets() unexpected error = %v", err)
+	}
+	if len(found) != 2 || found[0].ID != bobID || found[1].ID != aliceID {
+		t.Errorf("LookupWallets() found = %v, want [bob alice] in request order", found)
+	}
+	if len(missing) != 1 || missing[0] != unknownID {
+		t.Errorf("LookupWallets() missing = %v, want [%s]", missing, unknownID)
+	}
+
+	found, missing, err = service.LookupWallets([]uuid.UUID{aliceID, bobID}, "alice")
+	if err != nil {
+		t.Fatalf("LookupWallets() unexpected error = %v", err)
+	}
+	if len(found) != 1 || found[0].ID != aliceID || len(missing) != 1 || missing[0] != bobID {
+		t.Errorf("LookupWallets() with owner = %v / %v, foreign wallet must be reported as missing", found, missing)
+	}
+
+	mockRepo.shouldError = true
+	mockRepo.errorType = repository.ErrDatabaseError
+	if _, _, err := service.LookupWallets([]uuid.UUID{aliceID}, ""); !errors.Is(err, repository.ErrDatabaseError) {
+		t.Errorf("LookupWallets() error = %v, wantErr %v", err, repository.ErrDatabaseError)
+	}
+}
+
+func TestWalletService_ListWallets(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	service := NewWalletService(mockRepo)
+
+	base := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		id := uuid.New()
+		mockRepo.wallets[id.String()] = &models.Wallet{ID: id, CreatedAt: base.Add(time.Duration(i) * time.Minute), Status: models.WalletStatusActive}
+	}
+
+	var seen []uuid.UUID
+	filter := models.WalletFilter{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("ListWallets() pagination does not terminate")
+		}
+		page, err := service.ListWallets(filter)
+		if err != nil {
+			t.Fatalf("ListWallets() unexpected error = %v", err)
+		}
+		if mockRepo.lastFilter.Limit != 3 {
+			t.Errorf("repository limit = %d, want page size + 1", mockRepo.lastFilter.Limit)
+		}
+		for _, wallet := range page.Items {
+			seen = append(seen, wallet.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := models.DecodeWalletCursor(page.NextCursor)
+		if err != nil {
+			t.Fatalf("DecodeWalletCursor() error = %v", err)
+		}
+		filter.After = cursor
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("ListWallets() returned %d wallets over all pages, want 5", len(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		if mockRepo.wallets[seen[i-1].String()].CreatedAt.After(mockRepo.wallets[seen[i].String()].CreatedAt) {
+			t.Errorf("ListWallets() wallets are not ordered by created_at")
+		}
+	}
+
+	from := time.Date(2025, 9, 1, 15, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	if _, err := service.ListWallets(models.WalletFilter{CreatedFrom: &from}); err != nil {
+		t.Fatalf("ListWallets() unexpected error = %v", err)
+	}
+	if got := mockRepo.lastFilter.CreatedFrom; got == nil || got.Location() != time.UTC || !got.Equal(from) {
+		t.Errorf("CreatedFrom = %v, want %v in UTC", got, from)
+	}
+	if mockRepo.lastFilter.SortBy != models.WalletSortCreatedAt || mockRepo.lastFilter.Limit != models.DefaultWalletPageSize+1 {
+		t.Errorf("defaults not applied: %+v", mockRepo.lastFilter)
+	}
+
+	min, max := utils.Money{Raw: 500}, utils.Money{Raw: 100}
+	balanceCursor := models.NewWalletCursor(&models.Wallet{ID: uuid.New()}, models.WalletSortBalance, false)
+	invalid := []models.WalletFilter{
+		{SortBy: "owner_id"},
+		{Status: "CLOSED"},
+		{Limit: models.MaxWalletPageSize + 1},
+		{MinBalance: &min, MaxBalance: &max},
+		{SortBy: models.WalletSortCreatedAt, After: &balanceCursor},
+	}
+	for _, filter := range invalid {
+		if _, err := service.ListWallets(filter); !errors.Is(err, ErrInvalidWalletFilter) {
+			t.Errorf("ListWallets(%+v) error = %v, want %v", filter, err, ErrInvalidWalletFilter)
+		}
+	}
+}
+
+func TestWalletService_GetBalanceAsOf(t *testing.T) {
+	walletID := uuid.New()
+	start := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+
+	newRepo := func() *MockWalletRepository {
+		repo := NewMockWalletRepository()
+		repo.wallets[walletID.String()] = &models.Wallet{
+			ID: walletID, Balance: utils.Money{Raw: 700}, CreatedAt: start,
+			OwnerID: sql.NullString{String: "alice", Valid: true},
+		}
+		entry := func(id int64, entryType string, amount int64, at time.Duration) models.LedgerEntry {
+			return models.LedgerEntry{ID: id, WalletID: walletID, EntryType: entryType, Amount: utils.Money{Raw: amount}, CreatedAt: start.Add(at)}
+		}
+		repo.ledger = []models.LedgerEntry{
+			entry(1, models.OperationTypeDeposit, 1000, 0),
+			entry(2, models.OperationTypeWithdraw, 200, time.Hour),
+			entry(3, models.LedgerEntryTransferOut, 300, 48*time.Hour),
+			entry(4, models.LedgerEntryTransferIn, 200, 72*time.Hour),
+		}
+		return repo
+	}
+
+	tests := []struct {
+		name         string
+		asOf         time.Time
+		snapshots    []models.BalanceSnapshot
+		wantBalance  int64
+		wantReplayed int
+		fromSnapshot bool
+	}{
+		{"full replay", start.Add(50 * time.Hour), nil, 500, 3, false},
+		{"before any entry except the first", start.Add(30 * time.Minute), nil, 1000, 1, false},
+		{
+			name: "snapshot plus replay",
+			asOf: start.Add(100 * time.Hour),
+			snapshots: []models.BalanceSnapshot{
+				{WalletID: walletID, LedgerEntryID: 2, Balance: utils.Money{Raw: 800}, TakenAt: start.Add(time.Hour)},
+			},
+			wantBalance:  700,
+			wantReplayed: 2,
+			fromSnapshot: true,
+		},
+		{
+			name: "later snapshot is ignored",
+			asOf: start.Add(2 * time.Hour),
+			snapshots: []models.BalanceSnapshot{
+				{WalletID: walletID, LedgerEntryID: 2, Balance: utils.Money{Raw: 800}, TakenAt: start.Add(time.Hour)},
+				{WalletID: walletID, LedgerEntryID: 4, Balance: utils.Money{Raw: 700}, TakenAt: start.Add(72 * time.Hour)},
+			},
+			wantBalance:  800,
+			wantReplayed: 0,
+			fromSnapshot: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo()
+			repo.snapshots = tt.snapshots
+			service := NewWalletService(repo)
+
+			balance, err := service.GetBalanceAsOf(walletID.String(), "", tt.asOf)
+			if err != nil {
+				t.Fatalf("GetBalanceAsOf() unexpected error = %v", err)
+			}
+			if balance.Balance.Raw != tt.wantBalance || balance.ReplayedEntries != tt.wantReplayed {
+				t.Errorf("GetBalanceAsOf() = %s after %d entries, want %d after %d", balance.Balance, balance.ReplayedEntries, tt.wantBalance, tt.wantReplayed)
+			}
+			if (balance.Snapshot != nil) != tt.fromSnapshot {
+				t.Errorf("GetBalanceAsOf() snapshot = %v, want fromSnapshot %v", balance.Snapshot, tt.fromSnapshot)
+			}
+		})
+	}
+
+	service := NewWalletService(newRepo())
+	if _, err := service.GetBalanceAsOf(walletID.String(), "", start.Add(-time.Second)); !errors.Is(err, ErrWalletNotExisted) {
+		t.Errorf("GetBalanceAsOf() before creation error = %v, want %v", err, ErrWalletNotExisted)
+	}
+	if _, err := service.GetBalanceAsOf(walletID.String(), "bob", start.Add(time.Hour)); !errors.Is(err, ErrWalletAccessDenied) {
+		t.Errorf("GetBalanceAsOf() for foreign owner error = %v, want %v", err, ErrWalletAccessDenied)
+	}
+	if _, err := service.GetBalanceAsOf(uuid.New().String(), "", start); !errors.Is(err, repository.ErrWalletNotFound) {
+		t.Errorf("GetBalanceAsOf() for unknown wallet error = %v, want %v", err, repository.ErrWalletNotFound)
+	}
+}
+
+func TestWalletService_OpenStatement(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	service := NewWalletService(mockRepo)
+
+	walletID := uuid.New()
+	mockRepo.wallets[walletID.String()] = &models.Wallet{ID: walletID, OwnerID: sql.NullString{String: "alice", Valid: true}}
+	from := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if _, err := service.OpenStatement(context.Background(), walletID.String(), "alice", from, to); err != nil {
+		t.Errorf("OpenStatement() unexpected error = %v", err)
+	}
+	if _, err := service.OpenStatement(context.Background(), walletID.String(), "", to, from); !errors.Is(err, ErrInvalidStatementPeriod) {
+		t.Errorf("OpenStatement() with reversed period error = %v, want %v", err, ErrInvalidStatementPeriod)
+	}
+	if _, err := service.OpenStatement(context.Background(), walletID.String(), "bob", from, to); !errors.Is(err, ErrWalletAccessDenied) {
+		t.Errorf("OpenStatement() for foreign owner error = %v, want %v", err, ErrWalletAccessDenied)
+	}
+	if _, err := service.OpenStatement(context.Background(), uuid.New().String(), "", from, to); !errors.Is(err, repository.ErrWalletNotFound) {
+		t.Errorf("OpenStatement() for unknown wallet error = %v, want %v", err, repository.ErrWalletNotFound)
+	}
+}
+
+func TestWalletService_OperationFees(t *testing.T) {
+	mockRepo := NewMockWalletRepository()
+	service := NewWalletService(mockRepo)
+
+	minFee := utils.Money{Raw: 50}
+	mockRepo.fees = map[string]models.FeeRule{
+		models.OperationTypeDeposit:     {Kind: models.FeeKindFlat, Flat: utils.Money{Raw: 100}},
+		models.OperationTypeWithdraw:    {Kind: models.FeeKindPercent, RateBasisPoints: 100, Min: &minFee, Rounding: utils.RoundHalfUp},
+		models.JournalOperationTransfer: {Kind: models.FeeKindFlat, Flat: utils.Money{Raw: 30}},
+	}
+
+	walletID, toID := uuid.New(), uuid.New()
+	mockRepo.wallets[walletID.String()] = &models.Wallet{ID: walletID, Balance: utils.Money{Raw: 10000}, CreatedAt: time.Now()}
+	mockRepo.wallets[toID.String()] = &models.Wallet{ID: toID, CreatedAt: time.Now()}
+
+	result, err := service.ProcessWalletOperation(&models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 1000})
+	if err != nil {
+		t.Fatalf("ProcessWalletOperation() error = %v", err)
+	}
+	if result.Fee.Gross.Raw != 1050 || result.Fee.Fee.Raw != 50 || result.Fee.Net.Raw != 1000 {
+		t.Errorf("withdraw fee = %+v, want gross 10.50, fee 0.50 (minimum), net 10.00", result.Fee)
+	}
+	if result.Wallet.Balance.Raw != 8950 {
+		t.Errorf("balance after withdraw = %s, want 89.50", result.Wallet.Balance)
+	}
+
+	result, err = service.ProcessWalletOperation(&models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 500})
+	if err != nil {
+		t.Fatalf("ProcessWalletOperation() error = %v", err)
+	}
+	if result.Fee.Gross.Raw != 500 || result.Fee.Net.Raw != 400 || result.Wallet.Balance.Raw != 9350 {
+		t.Errorf("deposit fee = %+v, balance %s, want 4.00 credited and balance 93.50", result.Fee, result.Wallet.Balance)
+	}
+
+	// Баланса хватает на списание, но не на списание с комиссией
+	if _, err = service.ProcessWalletOperation(&models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeWithdraw, Amount: 9300}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("withdraw without fee coverage error = %v, want %v", err, ErrInsufficientFunds)
+	}
+	if _, err = service.ProcessWalletOperation(&models.WalletOperation{WalletID: walletID, OperationType: models.OperationTypeDeposit, Amount: 100}); !errors.Is(err, ErrFeeExceedsAmount) {
+		t.Errorf("deposit equal to fee error = %v, want %v", err, ErrFeeExceedsAmount)
+	}
+
+	transfer, err := service.Transfer(&models.Transfer{FromWalletID: walletID, ToWalletID: toID, Amount: 1000})
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if transfer.Fee.Gross.Raw != 1030 || transfer.From.Balance.Raw != 8320 || transfer.To.Balance.Raw != 1000 {
+		t.Errorf("transfer = fee %+v, from %s, to %s, want sender charged 10.30 and recipient credited 10.00", transfer.Fee, transfer.From.Balance, transfer.To.Balance)
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+	"wallet-api/internal/auth"
+	"wallet-api/internal/models"
+	"wallet-api/internal/netguard"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookDeliveriesPageSize = 100
+	webhookResolveTimeout     = 5 * time.Second
+)
+
+// WebhookPayload - тело, которое получает подписчик
+type WebhookPayload struct {
+	ID        uuid.UUID          `json:"id"`
+	Type      string             `json:"type"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Data      models.WalletEvent `json:"data"`
+}
+
+type WebhookSubscriptionInput struct {
+	URL                 string
+	WalletIDs           []uuid.UUID
+	EventTypes          []string
+	LowBalanceThreshold *utils.Money
+}
+
+type WebhookService struct {
+	repo     repository.WebhookRepositoryInterface
+	resolver netguard.Resolver
+	now      func() time.Time
+}
+
+func NewWebhookService(repo repository.WebhookRepositoryInterface) *WebhookService {
+	return &WebhookService{repo: repo, resolver: net.DefaultResolver, now: time.Now}
+}
+
+// CreateSubscription создает подписку и возвращает секрет для проверки подписи, секрет показывается один раз
+func (s *WebhookService) CreateSubscription(clientID *uuid.UUID, input WebhookSubscriptionInput) (*models.WebhookSubscription, string, error) {
+	if err := s.validateWebhookInput(input); err != nil {
+		return nil, "", fmt.Errorf("create webhook subscription: %w", err)
+	}
+
+	secret, err := auth.GenerateSigningSecret()
+	if err != nil {
+		return nil, "", fmt.Errorf("create webhook subscription: %w", err)
+	}
+
+	sub := &models.WebhookSubscription{
+		ID:                  uuid.New(),
+		URL:                 input.URL,
+		Secret:              secret,
+		WalletIDs:           input.WalletIDs,
+		EventTypes:          input.EventTypes,
+		LowBalanceThreshold: input.LowBalanceThreshold,
+		Active:              true,
+		CreatedAt:           s.now(),
+	}
+	if clientID != nil {
+		sub.ClientID = uuid.NullUUID{UUID: *clientID, Valid: true}
+	}
+
+	if err := s.repo.CreateSubscription(sub); err != nil {
+		return nil, "", fmt.Errorf("create webhook subscription: %w", repository.ErrDatabaseError)
+	}
+
+	return sub, secret, nil
+}
+
+func (s *WebhookService) ListSubscriptions(clientID *uuid.UUID) ([]models.WebhookSubscription, error) {
+	subs, err := s.repo.ListSubscriptions(clientID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", repository.ErrDatabaseError)
+	}
+	return subs, nil
+}
+
+func (s *WebhookService) DeleteSubscription(clientID *uuid.UUID, subscriptionID string) error {
+	if _, err := s.getOwnedSubscription(clientID, subscriptionID); err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+
+	if err := s.repo.DeleteSubscription(subscriptionID); err != nil {
+		if stdErrors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			return fmt.Errorf("delete webhook subscription: %w", repository.ErrWebhookSubscriptionNotFound)
+		}
+		return fmt.Errorf("delete webhook subscription: %w", repository.ErrDatabaseError)
+	}
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(clientID *uuid.UUID, subscriptionID string) ([]models.WebhookDelivery, error) {
+	if _, err := s.getOwnedSubscription(clientID, subscriptionID); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+
+	deliveries, err := s.repo.ListDeliveries(subscriptionID, webhookDeliveriesPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", repository.ErrDatabaseError)
+	}
+	return deliveries, nil
+}
+
+// ReplayDelivery ставит доставку в очередь повторно, в том числе успешную или окончательно проваленную
+func (s *WebhookService) ReplayDelivery(clientID *uuid.UUID, subscriptionID, deliveryID string) error {
+	if _, err := s.getOwnedSubscription(clientID, subscriptionID); err != nil {
+		return fmt.Errorf("replay webhook delivery: %w", err)
+	}
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		return fmt.Errorf("replay webhook delivery: %w", repository.ErrWebhookDeliveryNotFound)
+	}
+
+	if err := s.repo.ReplayDelivery(subscriptionID, deliveryID); err != nil {
+		if stdErrors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+			return fmt.Errorf("replay webhook delivery: %w", repository.ErrWebhookDeliveryNotFound)
+		}
+		return fmt.Errorf("replay webhook delivery: %w", repository.ErrDatabaseError)
+	}
+	return nil
+}
+
+// FanOut создает доставки по всем подпискам, подходящим под событие из outbox
+func (s *WebhookService) FanOut(msg models.OutboxMessage) error {
+	eventTypes := models.WebhookEventTypesFor(msg.EventType)
+	if len(eventTypes) == 0 {
+		return nil
+	}
+
+	var event models.WalletEvent
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return fmt.Errorf("fan out webhook event: %w", err)
+	}
+
+	balance, err := utils.NewMoneyFromString(event.Balance)
+	if err != nil && event.Balance != "" {
+		return fmt.Errorf("fan out webhook event: %w", err)
+	}
+
+	subs, err := s.repo.FindSubscriptionsForWallet(event.WalletID)
+	if err != nil {
+		return fmt.Errorf("fan out webhook event: %w", repository.ErrDatabaseError)
+	}
+
+	now := s.now()
+	var deliveries []models.WebhookDelivery
+	for i := range subs {
+		for _, eventType := range eventTypes {
+			if !subs[i].Matches(event.WalletID, eventType, balance) {
+				continue
+			}
+
+			delivery := models.WebhookDelivery{
+				ID:             uuid.New(),
+				SubscriptionID: subs[i].ID,
+				EventID:        event.EventID,
+				EventType:      eventType,
+				Status:         models.WebhookDeliveryPending,
+				NextAttemptAt:  now,
+			}
+			delivery.Payload, err = json.Marshal(WebhookPayload{
+				ID:        delivery.ID,
+				Type:      eventType,
+				CreatedAt: now,
+				Data:      event,
+			})
+			if err != nil {
+				return fmt.Errorf("fan out webhook event: %w", err)
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		return fmt.Errorf("fan out webhook event: %w", repository.ErrDatabaseError)
+	}
+	return nil
+}
+
+// getOwnedSubscription скрывает чужие подписки как несуществующие; nil clientID видит все подписки
+func (s *WebhookService) getOwnedSubscription(clientID *uuid.UUID, subscriptionID string) (*models.WebhookSubscription, error) {
+	if _, err := uuid.Parse(subscriptionID); err != nil {
+		return nil, repository.ErrWebhookSubscriptionNotFound
+	}
+
+	sub, err := s.repo.GetSubscription(subscriptionID)
+	if err != nil {
+		if stdErrors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			return nil, repository.ErrWebhookSubscriptionNotFound
+		}
+		return nil, repository.ErrDatabaseError
+	}
+
+	if clientID != nil && (!sub.ClientID.Valid || sub.ClientID.UUID != *clientID) {
+		return nil, repository.ErrWebhookSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) validateWebhookInput(input WebhookSubscriptionInput) error {
+	parsed, err := url.Parse(input.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidWebhook)
+	}
+
+	// Доставки не должны уходить во внутреннюю сеть; при отправке адрес проверяется еще раз
+	ctx, cancel := context.WithTimeout(context.Background(), webhookResolveTimeout)
+	defer cancel()
+	if err := netguard.CheckHost(ctx, s.resolver, parsed.Hostname()); err != nil {
+		return fmt.Errorf("%w: url must point to a public host", ErrInvalidWebhook)
+	}
+
+	if len(input.EventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+
+	for _, eventType := range input.EventTypes {
+		if !models.IsValidWebhookEventType(eventType) {
+			return fmt.Errorf("%w: unknown event type %s", ErrInvalidWebhook, eventType)
+		}
+		if eventType == models.WebhookEventLowBalance && input.LowBalanceThreshold == nil {
+			return fmt.Errorf("%w: low_balance requires lowBalanceThreshold", ErrInvalidWebhook)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+type MockWebhookRepository struct {
+	subs       map[string]*models.WebhookSubscription
+	deliveries []models.WebhookDelivery
+	replayed   []string
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{subs: make(map[string]*models.WebhookSubscription)}
+}
+
+func (m *MockWebhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	m.subs[sub.ID.String()] = sub
+	return nil
+}
+
+func (m *MockWebhookRepository) ListSubscriptions(clientID *uuid.UUID) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	for _, sub := range m.subs {
+		if clientID == nil || (sub.ClientID.Valid && sub.ClientID.UUID == *clientID) {
+			subs = append(subs, *sub)
+		}
+	}
+	return subs, nil
+}
+
+func (m *MockWebhookRepository) GetSubscription(id string) (*models.WebhookSubscription, error) {
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, repository.ErrWebhookSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (m *MockWebhookRepository) DeleteSubscription(id string) error {
+	delete(m.subs, id)
+	return nil
+}
+
+func (m *MockWebhookRepository) FindSubscriptionsForWallet(walletID uuid.UUID) ([]models.WebhookSubscription, error) {
+	return m.ListSubscriptions(nil)
+}
+
+func (m *MockWebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	m.deliveries = append(m.deliveries, deliveries...)
+	return nil
+}
+
+func (m *MockWebhookRepository) ListDeliveries(subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	return m.deliveries, nil
+}
+
+func (m *MockWebhookRepository) ReplayDelivery(subscriptionID, deliveryID string) error {
+	m.replayed = append(m.replayed, deliveryID)
+	return nil
+}
+
+func (m *MockWebhookRepository) ClaimDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (m *MockWebhookRepository) RecordAttempt(attempt models.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	return nil
+}
+
+type stubResolver map[string][]netip.Addr
+
+func (r stubResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestWebhookService_CreateSubscriptionValidation(t *testing.T) {
+	webhookService := NewWebhookService(NewMockWebhookRepository())
+	webhookService.resolver = stubResolver{
+		"billing.example":  {netip.MustParseAddr("93.184.216.34")},
+		"intranet.example": {netip.MustParseAddr("10.0.0.5")},
+	}
+	threshold := utils.Money{Raw: 10000}
+
+	tests := []struct {
+		name    string
+		input   WebhookSubscriptionInput
+		wantErr error
+	}{
+		{
+			name:  "valid subscription",
+			input: WebhookSubscriptionInput{URL: "https://billing.example/hooks", EventTypes: []string{models.WebhookEventDeposit}},
+		},
+		{
+			name:    "relative url",
+			input:   WebhookSubscriptionInput{URL: "/hooks", EventTypes: []string{models.WebhookEventDeposit}},
+			wantErr: ErrInvalidWebhook,
+		},
+		{
+			name:    "loopback url",
+			input:   WebhookSubscriptionInput{URL: "http://127.0.0.1:8080/hooks", EventTypes: []string{models.WebhookEventDeposit}},
+			wantErr: ErrInvalidWebhook,
+		},
+		{
+			name:    "metadata url",
+			input:   WebhookSubscriptionInput{URL: "http://169.254.169.254/latest/meta-data", EventTypes: []string{models.WebhookEventDeposit}},
+			wantErr: ErrInvalidWebhook,
+		},
+		{
+			name:    "host resolving to private network",
+			input:   WebhookSubscriptionInput{URL: "https://intranet.example/hooks", EventTypes: []string{models.WebhookEventDeposit}},
+			wantErr: ErrInvalidWebhook,
+		},
+		{
+			name:    "unknown event type",
+			input:   WebhookSubscriptionInput{URL: "https://billing.example/hooks", EventTypes: []string{"refund"}},
+			wantErr: ErrInvalidWebhook,
+		},
+		{
+			name:    "low balance without threshold",
+			input:   WebhookSubscriptionInput{URL: "https://billing.example/hooks", EventTypes: []string{models.WebhookEventLowBalance}},
+			wantErr: ErrInvalidWebhook,
+		},
+		{
+			name:  "low balance with threshold",
+			input: WebhookSubscriptionInput{URL: "https://billing.example/hooks", EventTypes: []string{models.WebhookEventLowBalance}, LowBalanceThreshold: &threshold},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, secret, err := webhookService.CreateSubscription(nil, tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (sub == nil || secret == "") {
+				t.Errorf("CreateSubscription() must return subscription and secret")
+			}
+		})
+	}
+}
+
+func TestWebhookService_FanOut(t *testing.T) {
+	mockRepo := NewMockWebhookRepository()
+	webhookService := NewWebhookService(mockRepo)
+
+	walletID := uuid.New()
+	threshold := utils.Money{Raw: 10000}
+	withdrawSub, _, _ := webhookService.CreateSubscription(nil, WebhookSubscriptionInput{
+		URL:                 "https://a.example/hooks",
+		WalletIDs:           []uuid.UUID{walletID},
+		EventTypes:          []string{models.WebhookEventWithdraw, models.WebhookEventLowBalance},
+		LowBalanceThreshold: &threshold,
+	})
+	webhookService.CreateSubscription(nil, WebhookSubscriptionInput{
+		URL:        "https://b.example/hooks",
+		WalletIDs:  []uuid.UUID{uuid.New()},
+		EventTypes: []string{models.WebhookEventWithdraw},
+	})
+	webhookService.CreateSubscription(nil, WebhookSubscriptionInput{
+		URL:        "https://c.example/hooks",
+		EventTypes: []string{models.WebhookEventDeposit},
+	})
+
+	event := models.WalletEvent{
+		EventID:   uuid.New(),
+		EventType: models.EventWalletDebited,
+		WalletID:  walletID,
+		Amount:    "50.00",
+		Balance:   "99.99",
+	}
+	payload, _ := json.Marshal(event)
+
+	if err := webhookService.FanOut(models.OutboxMessage{EventType: event.EventType, Payload: payload}); err != nil {
+		t.Fatalf("FanOut() unexpected error = %v", err)
+	}
+
+	if len(mockRepo.deliveries) != 2 {
+		t.Fatalf("FanOut() created %d deliveries, want 2", len(mockRepo.deliveries))
+	}
+	got := map[string]bool{}
+	for _, d := range mockRepo.deliveries {
+		if d.SubscriptionID != withdrawSub.ID || d.EventID != event.EventID {
+			t.Errorf("delivery for unexpected subscription or event: %+v", d)
+		}
+		got[d.EventType] = true
+	}
+	if !got[models.WebhookEventWithdraw] || !got[models.WebhookEventLowBalance] {
+		t.Errorf("expected withdraw and low_balance deliveries, got %v", got)
+	}
+}
+
+func TestWebhookService_HidesForeignSubscriptions(t *testing.T) {
+	mockRepo := NewMockWebhookRepository()
+	webhookService := NewWebhookService(mockRepo)
+
+	owner := uuid.New()
+	stranger := uuid.New()
+	sub, _, _ := webhookService.CreateSubscription(&owner, WebhookSubscriptionInput{
+		URL:        "https://a.example/hooks",
+		EventTypes: []string{models.WebhookEventDeposit},
+	})
+
+	if err := webhookService.ReplayDelivery(&stranger, sub.ID.String(), uuid.New().String()); !errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+		t.Errorf("ReplayDelivery() error = %v, wantErr %v", err, repository.ErrWebhookSubscriptionNotFound)
+	}
+	if err := webhookService.ReplayDelivery(&owner, sub.ID.String(), uuid.New().String()); err != nil {
+		t.Errorf("ReplayDelivery() unexpected error = %v", err)
+	}
+	if err := webhookService.ReplayDelivery(nil, sub.ID.String(), uuid.New().String()); err != nil {
+		t.Errorf("ReplayDelivery() as admin unexpected error = %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/repository"
+	"wallet-api/utils/logger"
+)
+
+// Worker периодически снимает балансы кошельков, чтобы запрос баланса на момент времени
+// проигрывал журнал только от ближайшего снимка, а не с первой проводки
+type Worker struct {
+	repo     repository.BalanceSnapshotRepositoryInterface
+	interval time.Duration
+}
+
+func NewWorker(repo repository.BalanceSnapshotRepositoryInterface, interval time.Duration) *Worker {
+	return &Worker{repo: repo, interval: interval}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.SnapshotOnce(); err != nil {
+			logger.GlobalLogger.Error("Ошибка снятия снимков балансов: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SnapshotOnce создает снимки для кошельков с новыми проводками и возвращает их количество
+func (w *Worker) SnapshotOnce() (int64, error) {
+	created, err := w.repo.CreateBalanceSnapshots()
+	if err != nil {
+		return 0, err
+	}
+
+	metrics.BalanceSnapshotsTotal.Add(created)
+	if created > 0 {
+		logger.GlobalLogger.Info("Создано снимков балансов: %d", created)
+	}
+	return created, nil
+}
@@ -0,0 +1,39 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wallet-api/internal/repository"
+	"wallet-api/utils/logger"
+)
+
+func init() {
+	logger.Init()
+}
+
+type mockSnapshotRepository struct {
+	created int64
+	err     error
+	calls   int
+}
+
+func (m *mockSnapshotRepository) CreateBalanceSnapshots() (int64, error) {
+	m.calls++
+	return m.created, m.err
+}
+
+func TestWorker_SnapshotOnce(t *testing.T) {
+	repo := &mockSnapshotRepository{created: 3}
+	worker := NewWorker(repo, time.Hour)
+
+	created, err := worker.SnapshotOnce()
+	if err != nil || created != 3 || repo.calls != 1 {
+		t.Errorf("SnapshotOnce() = %d, %v after %d calls, want 3, nil after 1", created, err, repo.calls)
+	}
+
+	repo.err = repository.ErrDatabaseError
+	if _, err := worker.SnapshotOnce(); !errors.Is(err, repository.ErrDatabaseError) {
+		t.Errorf("SnapshotOnce() error = %v, want %v", err, repository.ErrDatabaseError)
+	}
+}
@@ -0,0 +1,198 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+	// camtCurrency - валюта всех кошельков сервиса
+	camtCurrency = "RUB"
+	// camtCodeIssuer - издатель собственных кодов операций (BkTxCd/Prtry), коды совпадают с типами проводок
+	camtCodeIssuer = "WALLET-API"
+)
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtDateTime struct {
+	Date     string `xml:"Dt,omitempty"`
+	DateTime string `xml:"DtTm,omitempty"`
+}
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type camtPeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type camtBalance struct {
+	Code        string       `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      camtAmount   `xml:"Amt"`
+	CreditDebit string       `xml:"CdtDbtInd"`
+	Date        camtDateTime `xml:"Dt"`
+}
+
+type camtEntry struct {
+	Reference       string       `xml:"NtryRef"`
+	Amount          camtAmount   `xml:"Amt"`
+	CreditDebit     string       `xml:"CdtDbtInd"`
+	Status          string       `xml:"Sts>Cd"`
+	BookingDate     camtDateTime `xml:"BookgDt"`
+	ValueDate       camtDateTime `xml:"ValDt"`
+	ServicerRef     string       `xml:"AcctSvcrRef"`
+	TransactionCode string       `xml:"BkTxCd>Prtry>Cd"`
+	CodeIssuer      string       `xml:"BkTxCd>Prtry>Issr"`
+	Details         camtDetails  `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDetails struct {
+	ServicerRef   string     `xml:"Refs>AcctSvcrRef"`
+	TransactionID string     `xml:"Refs>TxId"`
+	Amount        camtAmount `xml:"Amt"`
+	CreditDebit   string     `xml:"CdtDbtInd"`
+}
+
+// camtWriter выводит выписку как ISO 20022 camt.053.001.08 (BankToCustomerStatement).
+// Документ пишется потоком: заголовок и остатки, затем элементы Ntry по мере чтения проводок
+type camtWriter struct {
+	enc       *xml.Encoder
+	w         io.Writer
+	messageID string
+	now       time.Time
+}
+
+func newCamtWriter(w io.Writer) *camtWriter {
+	return &camtWriter{
+		enc:       xml.NewEncoder(w),
+		w:         w,
+		messageID: strings.ReplaceAll(uuid.NewString(), "-", ""),
+		now:       time.Now(),
+	}
+}
+
+func (c *camtWriter) Begin(statement models.Statement) error {
+	if _, err := io.WriteString(c.w, xml.Header); err != nil {
+		return err
+	}
+
+	for _, start := range []xml.StartElement{
+		{Name: xml.Name{Local: "Document"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace}}},
+		{Name: xml.Name{Local: "BkToCstmrStmt"}},
+	} {
+		if err := c.enc.EncodeToken(start); err != nil {
+			return err
+		}
+	}
+
+	header := camtGroupHeader{MessageID: c.messageID, CreatedAt: formatTime(c.now)}
+	if err := c.enc.EncodeElement(header, xml.StartElement{Name: xml.Name{Local: "GrpHdr"}}); err != nil {
+		return err
+	}
+
+	if err := c.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Stmt"}}); err != nil {
+		return err
+	}
+	// Stmt остается открытым: элементы Ntry пишутся внутрь него по мере чтения проводок
+	for _, el := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"Id", camtStatementID(statement)},
+		{"CreDtTm", formatTime(c.now)},
+		{"FrToDt", camtPeriod{From: formatTime(statement.From), To: formatTime(statement.To)}},
+		{"Acct", camtAccount{ID: camtAccountID(statement.WalletID), Currency: camtCurrency}},
+		{"Bal", newCamtBalance("OPBD", statement.OpeningBalance, statement.From)},
+		{"Bal", newCamtBalance("CLBD", statement.ClosingBalance, statement.To)},
+	} {
+		if err := c.enc.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *camtWriter) Entry(entry models.LedgerEntry) error {
+	reference := strconv.FormatInt(entry.ID, 10)
+	amount := camtAmount{Currency: camtCurrency, Value: entry.Amount.Abs().String()}
+	indicator := camtIndicator(entry.SignedAmount())
+
+	return c.enc.EncodeElement(camtEntry{
+		Reference:       reference,
+		Amount:          amount,
+		CreditDebit:     indicator,
+		Status:          "BOOK",
+		BookingDate:     camtDateTime{DateTime: formatTime(entry.CreatedAt)},
+		ValueDate:       camtDateTime{Date: entry.CreatedAt.UTC().Format("2006-01-02")},
+		ServicerRef:     reference,
+		TransactionCode: entry.EntryType,
+		CodeIssuer:      camtCodeIssuer,
+		Details: camtDetails{
+			ServicerRef:   reference,
+			TransactionID: "LEDGER-" + reference,
+			Amount:        amount,
+			CreditDebit:   indicator,
+		},
+	}, xml.StartElement{Name: xml.Name{Local: "Ntry"}})
+}
+
+func (c *camtWriter) End(statement models.Statement) error {
+	for _, name := range []string{"Stmt", "BkToCstmrStmt", "Document"} {
+		if err := c.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return c.enc.Flush()
+}
+
+func newCamtBalance(code string, balance utils.Money, at time.Time) camtBalance {
+	return camtBalance{
+		Code:        code,
+		Amount:      camtAmount{Currency: camtCurrency, Value: balance.Abs().String()},
+		CreditDebit: camtIndicator(balance),
+		Date:        camtDateTime{DateTime: formatTime(at)},
+	}
+}
+
+// camtIndicator - в camt суммы всегда неотрицательные, знак передается индикатором CRDT/DBIT
+func camtIndicator(amount utils.Money) string {
+	if amount.IsNegative() {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// camtAccountID - UUID кошелька без дефисов: Othr/Id ограничен 34 символами
+func camtAccountID(walletID uuid.UUID) string {
+	return strings.ReplaceAll(walletID.String(), "-", "")
+}
+
+// camtStatementID детерминирован для кошелька и периода, чтобы повторная выгрузка распознавалась как дубликат.
+// Укладывается в Max35Text: 8 символов кошелька и границы периода с точностью до секунды
+func camtStatementID(statement models.Statement) string {
+	return fmt.Sprintf("%s-%s-%s",
+		camtAccountID(statement.WalletID)[:8],
+		statement.From.UTC().Format("060102150405"),
+		statement.To.UTC().Format("060102150405"),
+	)
+}
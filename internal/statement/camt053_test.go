@@ -0,0 +1,135 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/utils"
+
+	"github.com/google/uuid"
+)
+
+type sliceCursor struct {
+	statement models.Statement
+	entries   []models.LedgerEntry
+}
+
+func (c *sliceCursor) Statement() models.Statement { return c.statement }
+
+func (c *sliceCursor) Next() (*models.LedgerEntry, error) {
+	if len(c.entries) == 0 {
+		return nil, nil
+	}
+	entry := c.entries[0]
+	c.entries = c.entries[1:]
+	return &entry, nil
+}
+
+func (c *sliceCursor) Close() error { return nil }
+
+func writeCamtStatement(t *testing.T) []byte {
+	t.Helper()
+
+	from := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &sliceCursor{
+		statement: models.Statement{
+			WalletID:       uuid.MustParse("6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f"),
+			From:           from,
+			To:             to,
+			OpeningBalance: utils.Money{Raw: 1000},
+			ClosingBalance: utils.Money{Raw: -250},
+		},
+		entries: []models.LedgerEntry{
+			{ID: 41, EntryType: models.OperationTypeDeposit, Amount: utils.Money{Raw: 500}, BalanceAfter: utils.Money{Raw: 1500}, CreatedAt: from.Add(time.Hour)},
+			{ID: 42, EntryType: models.OperationTypeWithdraw, Amount: utils.Money{Raw: 1750}, BalanceAfter: utils.Money{Raw: -250}, CreatedAt: from.Add(2 * time.Hour)},
+		},
+	}
+
+	var buf bytes.Buffer
+	writer, err := NewWriter(models.StatementFormatCAMT053, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := Write(writer, cursor); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCamtWriter_Structure(t *testing.T) {
+	var doc struct {
+		XMLName xml.Name
+		Stmt    struct {
+			ID   string `xml:"Id"`
+			Acct string `xml:"Acct>Id>Othr>Id"`
+			Bal  []struct {
+				Code string `xml:"Tp>CdOrPrtry>Cd"`
+				Amt  string `xml:"Amt"`
+				Ind  string `xml:"CdtDbtInd"`
+			} `xml:"Bal"`
+			Ntry []struct {
+				Ref  string     `xml:"NtryRef"`
+				Amt  camtAmount `xml:"Amt"`
+				Ind  string     `xml:"CdtDbtInd"`
+				Code string     `xml:"BkTxCd>Prtry>Cd"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	if err := xml.Unmarshal(writeCamtStatement(t), &doc); err != nil {
+		t.Fatalf("xml.Unmarshal() error = %v", err)
+	}
+
+	if doc.XMLName.Space != camt053Namespace {
+		t.Errorf("namespace = %q, want %q", doc.XMLName.Space, camt053Namespace)
+	}
+	if doc.Stmt.Acct != "6f1c2d3e4b5a4c7d8e9f0a1b2c3d4e5f" {
+		t.Errorf("account id = %q", doc.Stmt.Acct)
+	}
+	if doc.Stmt.ID != "6f1c2d3e-250901000000-251001000000" {
+		t.Errorf("statement id = %q", doc.Stmt.ID)
+	}
+
+	if len(doc.Stmt.Bal) != 2 {
+		t.Fatalf("got %d balances, want 2", len(doc.Stmt.Bal))
+	}
+	if b := doc.Stmt.Bal[0]; b.Code != "OPBD" || b.Amt != "10.00" || b.Ind != "CRDT" {
+		t.Errorf("opening balance = %+v", b)
+	}
+	if b := doc.Stmt.Bal[1]; b.Code != "CLBD" || b.Amt != "2.50" || b.Ind != "DBIT" {
+		t.Errorf("closing balance = %+v", b)
+	}
+
+	if len(doc.Stmt.Ntry) != 2 {
+		t.Fatalf("got %d entries, want 2", len(doc.Stmt.Ntry))
+	}
+	if e := doc.Stmt.Ntry[0]; e.Ref != "41" || e.Amt.Value != "5.00" || e.Amt.Currency != camtCurrency || e.Ind != "CRDT" || e.Code != models.OperationTypeDeposit {
+		t.Errorf("deposit entry = %+v", e)
+	}
+	if e := doc.Stmt.Ntry[1]; e.Ref != "42" || e.Amt.Value != "17.50" || e.Ind != "DBIT" || e.Code != models.OperationTypeWithdraw {
+		t.Errorf("withdraw entry = %+v", e)
+	}
+}
+
+// TestCamtWriter_ValidatesAgainstSchema проверяет выписку официальной схемой camt.053.001.08 через xmllint
+func TestCamtWriter_ValidatesAgainstSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint не установлен")
+	}
+
+	path := filepath.Join(t.TempDir(), "statement.xml")
+	if err := os.WriteFile(path, writeCamtStatement(t), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(xmllint, "--noout", "--schema", filepath.Join("testdata", "camt.053.001.08.xsd"), path).CombinedOutput()
+	if err != nil {
+		t.Fatalf("xmllint rejected statement: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,155 @@
+package statement
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+)
+
+// Writer выводит выписку построчно: заголовок с входящим остатком, проводки, исходящий остаток.
+// Реализации не накапливают проводки, каждая строка пишется сразу в выходной поток
+type Writer interface {
+	Begin(statement models.Statement) error
+	Entry(entry models.LedgerEntry) error
+	End(statement models.Statement) error
+}
+
+// NewWriter возвращает Writer для формата выписки
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case models.StatementFormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case models.StatementFormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case models.StatementFormatCAMT053:
+		return newCamtWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown statement format: %s", format)
+}
+
+// ContentType возвращает MIME тип формата выписки
+func ContentType(format string) string {
+	switch format {
+	case models.StatementFormatJSONL:
+		return "application/x-ndjson"
+	case models.StatementFormatCAMT053:
+		return "application/xml"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileExtension возвращает расширение файла выписки для Content-Disposition
+func FileExtension(format string) string {
+	if format == models.StatementFormatCAMT053 {
+		return "xml"
+	}
+	return format
+}
+
+// Write читает проводки из курсора и передает их writer'у. Курсор не закрывается
+func Write(w Writer, cursor repository.StatementCursor) error {
+	statement := cursor.Statement()
+	if err := w.Begin(statement); err != nil {
+		return err
+	}
+
+	for {
+		entry, err := cursor.Next()
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			break
+		}
+		if err := w.Entry(*entry); err != nil {
+			return err
+		}
+	}
+
+	return w.End(statement)
+}
+
+// csvWriter пишет строки record,date,entryId,entryType,amount,balance.
+// Строки opening и closing содержат только дату границы периода и остаток
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Begin(statement models.Statement) error {
+	if err := c.w.Write([]string{"record", "date", "entryId", "entryType", "amount", "balance"}); err != nil {
+		return err
+	}
+	return c.w.Write([]string{"opening", formatTime(statement.From), "", "", "", statement.OpeningBalance.String()})
+}
+
+func (c *csvWriter) Entry(entry models.LedgerEntry) error {
+	return c.w.Write([]string{
+		"entry",
+		formatTime(entry.CreatedAt),
+		strconv.FormatInt(entry.ID, 10),
+		entry.EntryType,
+		entry.SignedAmount().String(),
+		entry.BalanceAfter.String(),
+	})
+}
+
+func (c *csvWriter) End(statement models.Statement) error {
+	if err := c.w.Write([]string{"closing", formatTime(statement.To), "", "", "", statement.ClosingBalance.String()}); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlRecord struct {
+	Record    string `json:"record"`
+	WalletID  string `json:"walletId,omitempty"`
+	Date      string `json:"date"`
+	EntryID   int64  `json:"entryId,omitempty"`
+	EntryType string `json:"entryType,omitempty"`
+	Amount    string `json:"amount,omitempty"`
+	Balance   string `json:"balance"`
+}
+
+// jsonlWriter пишет по одному JSON объекту на строку с теми же полями, что и CSV
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Begin(statement models.Statement) error {
+	return j.enc.Encode(jsonlRecord{
+		Record:   "opening",
+		WalletID: statement.WalletID.String(),
+		Date:     formatTime(statement.From),
+		Balance:  statement.OpeningBalance.String(),
+	})
+}
+
+func (j *jsonlWriter) Entry(entry models.LedgerEntry) error {
+	return j.enc.Encode(jsonlRecord{
+		Record:    "entry",
+		Date:      formatTime(entry.CreatedAt),
+		EntryID:   entry.ID,
+		EntryType: entry.EntryType,
+		Amount:    entry.SignedAmount().String(),
+		Balance:   entry.BalanceAfter.String(),
+	})
+}
+
+func (j *jsonlWriter) End(statement models.Statement) error {
+	return j.enc.Encode(jsonlRecord{
+		Record:   "closing",
+		WalletID: statement.WalletID.String(),
+		Date:     formatTime(statement.To),
+		Balance:  statement.ClosingBalance.String(),
+	})
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
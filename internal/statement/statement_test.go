@@ -0,0 +1,59 @@
+package statement
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils"
+)
+
+type failingCursor struct {
+	entries []models.LedgerEntry
+	err     error
+}
+
+func (c *failingCursor) Statement() models.Statement { return models.Statement{} }
+
+func (c *failingCursor) Next() (*models.LedgerEntry, error) {
+	if len(c.entries) == 0 {
+		return nil, c.err
+	}
+	entry := c.entries[0]
+	c.entries = c.entries[1:]
+	return &entry, nil
+}
+
+func (c *failingCursor) Close() error { return nil }
+
+func TestWrite_StopsOnCursorError(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(models.StatementFormatJSONL, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+
+	cursor := &failingCursor{
+		entries: []models.LedgerEntry{{ID: 1, EntryType: models.OperationTypeDeposit, Amount: utils.Money{Raw: 100}, BalanceAfter: utils.Money{Raw: 100}}},
+		err:     repository.ErrDatabaseError,
+	}
+	if err := Write(writer, cursor); !errors.Is(err, repository.ErrDatabaseError) {
+		t.Fatalf("Write() error = %v, want %v", err, repository.ErrDatabaseError)
+	}
+
+	// Оборванная выписка не должна выглядеть завершенной
+	if strings.Contains(buf.String(), `"record":"closing"`) {
+		t.Errorf("interrupted statement contains closing record: %s", buf.String())
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("Write() wrote %d lines before error, want opening and one entry", lines)
+	}
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	if _, err := NewWriter("xlsx", &bytes.Buffer{}); err == nil {
+		t.Error("NewWriter() accepted unknown format")
+	}
+}
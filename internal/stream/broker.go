@@ -0,0 +1,141 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Subscription получает сигнал о новых проводках кошелька.
+// Сигналы схлопываются: подписчик сам дочитывает журнал от последнего отправленного ID,
+// поэтому медленный клиент не блокирует рассылку и не теряет события
+type Subscription struct {
+	WalletID uuid.UUID
+	notify   chan struct{}
+	broker   *Broker
+}
+
+// Notify возвращает канал, в который приходит сигнал, когда в журнале кошелька появились новые записи
+func (s *Subscription) Notify() <-chan struct{} {
+	return s.notify
+}
+
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+func (s *Subscription) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Broker раздает уведомления о проводках подписчикам текущей реплики
+type Broker struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[uuid.UUID]map[*Subscription]struct{})}
+}
+
+func (b *Broker) Subscribe(walletID uuid.UUID) *Subscription {
+	sub := &Subscription{WalletID: walletID, notify: make(chan struct{}, 1), broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs[walletID] == nil {
+		b.subs[walletID] = make(map[*Subscription]struct{})
+	}
+	b.subs[walletID][sub] = struct{}{}
+	metrics.StreamSubscribers.Add(1)
+
+	return sub
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs, ok := b.subs[sub.WalletID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.WalletID)
+	}
+	metrics.StreamSubscribers.Add(-1)
+}
+
+// Publish будит подписчиков кошелька
+func (b *Broker) Publish(walletID uuid.UUID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[walletID] {
+		sub.signal()
+	}
+}
+
+// PublishAll будит всех подписчиков; используется после переподключения к Postgres,
+// когда часть уведомлений могла быть потеряна
+func (b *Broker) PublishAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			sub.signal()
+		}
+	}
+}
+
+// Listen подписывается на канал Postgres LISTEN/NOTIFY и пересылает уведомления подписчикам до отмены ctx.
+// Каждая реплика держит свое соединение, поэтому клиент получает события независимо от того,
+// на какой реплике прошла операция
+func (b *Broker) Listen(ctx context.Context, connStr, channel string) error {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.GlobalLogger.Error("Ошибка соединения LISTEN %s: %v", channel, err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// pq присылает nil после переподключения
+			if n == nil {
+				b.PublishAll()
+				continue
+			}
+			walletID, err := uuid.Parse(n.Extra)
+			if err != nil {
+				logger.GlobalLogger.Warning("Некорректное уведомление %s: %q", channel, n.Extra)
+				continue
+			}
+			b.Publish(walletID)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
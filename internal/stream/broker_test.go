@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	broker := NewBroker()
+	walletID := uuid.New()
+
+	sub := broker.Subscribe(walletID)
+	other := broker.Subscribe(uuid.New())
+	defer other.Close()
+
+	// Повторные сигналы схлопываются в один
+	broker.Publish(walletID)
+	broker.Publish(walletID)
+
+	select {
+	case <-sub.Notify():
+	default:
+		t.Fatal("subscriber must be notified")
+	}
+	select {
+	case <-sub.Notify():
+		t.Fatal("pending signals must be coalesced")
+	default:
+	}
+	select {
+	case <-other.Notify():
+		t.Fatal("subscriber of another wallet must not be notified")
+	default:
+	}
+
+	sub.Close()
+	sub.Close()
+	broker.Publish(walletID)
+	select {
+	case <-sub.Notify():
+		t.Fatal("closed subscription must not be notified")
+	default:
+	}
+	if _, ok := broker.subs[walletID]; ok {
+		t.Error("empty wallet entry must be removed")
+	}
+}
+
+func TestBroker_PublishAll(t *testing.T) {
+	broker := NewBroker()
+	first := broker.Subscribe(uuid.New())
+	second := broker.Subscribe(uuid.New())
+	defer first.Close()
+	defer second.Close()
+
+	broker.PublishAll()
+
+	for _, sub := range []*Subscription{first, second} {
+		select {
+		case <-sub.Notify():
+		default:
+			t.Errorf("subscriber of wallet %s must be notified", sub.WalletID)
+		}
+	}
+}
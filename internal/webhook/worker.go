@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"wallet-api/internal/metrics"
+	"wallet-api/internal/models"
+	"wallet-api/internal/netguard"
+	"wallet-api/internal/repository"
+	"wallet-api/internal/service"
+	"wallet-api/utils/logger"
+)
+
+type WorkerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration
+	// ClaimLease - на сколько пачка доставок закрепляется за worker'ом; должна превышать Timeout
+	ClaimLease time.Duration
+}
+
+// Worker отправляет подписанные доставки и повторяет неудачные с экспоненциальной задержкой
+type Worker struct {
+	repo   repository.WebhookRepositoryInterface
+	client *http.Client
+	cfg    WorkerConfig
+	now    func() time.Time
+}
+
+// NewWorker создает worker, который соединяется только с публичными адресами и не следует редиректам:
+// адрес подписки задает клиент
+func NewWorker(repo repository.WebhookRepositoryInterface, cfg WorkerConfig) *Worker {
+	return &Worker{
+		repo:   repo,
+		client: netguard.NewHTTPClient(cfg.Timeout),
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := w.DeliverOnce(ctx)
+			if err != nil {
+				logger.GlobalLogger.Error("Ошибка отправки webhook: %v", err)
+				break
+			}
+			if processed == 0 || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverOnce отправляет одну пачку доставок и возвращает количество отправленных.
+// Аренда пачки фиксируется до отправки, а каждая попытка записывается своей короткой транзакцией,
+// поэтому медленный подписчик не держит блокировки и соединение из пула, а сбой не теряет уже сделанные попытки
+func (w *Worker) DeliverOnce(ctx context.Context) (int, error) {
+	deliveries, err := w.repo.ClaimDeliveries(w.cfg.BatchSize, w.cfg.ClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	leaseEnd := w.now().Add(w.cfg.ClaimLease)
+	for i, delivery := range deliveries {
+		// Отправка, которая может не уложиться в аренду, не начинается: доставку заберет другая реплика
+		if w.now().Add(w.cfg.Timeout).After(leaseEnd) {
+			logger.GlobalLogger.Warning("Аренда пачки webhook истекает, не отправлено доставок: %d", len(deliveries)-i)
+			return i, nil
+		}
+
+		attempt := w.send(ctx, delivery)
+
+		status := models.WebhookDeliverySucceeded
+		nextAttemptAt := attempt.AttemptedAt
+		switch {
+		case attempt.Error == "":
+			metrics.WebhookDeliveredTotal.Add(1)
+		case delivery.Attempts+1 >= w.cfg.MaxAttempts:
+			status = models.WebhookDeliveryFailed
+			metrics.WebhookFailedTotal.Add(1)
+			logger.GlobalLogger.Error("Webhook %s не доставлен после %d попыток: %s", delivery.ID, delivery.Attempts+1, attempt.Error)
+		default:
+			status = models.WebhookDeliveryPending
+			nextAttemptAt = attempt.AttemptedAt.Add(w.backoff(delivery.Attempts))
+			metrics.WebhookRetriedTotal.Add(1)
+		}
+
+		if err := w.repo.RecordAttempt(attempt, status, nextAttemptAt); err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+func (w *Worker) send(ctx context.Context, delivery models.WebhookDelivery) models.WebhookDeliveryAttempt {
+	started := w.now()
+	attempt := models.WebhookDeliveryAttempt{DeliveryID: delivery.ID, AttemptedAt: started}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("build request: %v", err)
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set(SignatureHeader, Sign([]byte(delivery.Secret), started, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	attempt.Duration = w.now().Sub(started)
+	if err != nil {
+		attempt.Error = fmt.Sprintf("send request: %v", err)
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.ResponseStatus = sql.NullInt64{Int64: int64(resp.StatusCode), Valid: true}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.cfg.BaseBackoff
+	for i := 0; i < attempts && delay < w.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.cfg.MaxBackoff {
+		delay = w.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// FanoutPublisher подключает подписки к outbox: каждое событие превращается в доставки по подпискам
+type FanoutPublisher struct {
+	service service.WebhookServiceInterface
+}
+
+func NewFanoutPublisher(service service.WebhookServiceInterface) *FanoutPublisher {
+	return &FanoutPublisher{service: service}
+}
+
+func (p *FanoutPublisher) Publish(ctx context.Context, msg models.OutboxMessage) error {
+	return p.service.FanOut(msg)
+}
@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wallet-api/internal/models"
+	"wallet-api/internal/repository"
+	"wallet-api/utils/logger"
+
+	"github.com/google/uuid"
+)
+
+func init() {
+	logger.Init()
+}
+
+type recordedAttempt struct {
+	attempt       models.WebhookDeliveryAttempt
+	status        string
+	nextAttemptAt time.Time
+}
+
+type MockWebhookRepository struct {
+	repository.WebhookRepositoryInterface
+	deliveries []models.WebhookDelivery
+	attempts   []recordedAttempt
+	lease      time.Duration
+}
+
+func (m *MockWebhookRepository) ClaimDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	m.lease = lease
+	return m.deliveries, nil
+}
+
+func (m *MockWebhookRepository) RecordAttempt(attempt models.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	m.attempts = append(m.attempts, recordedAttempt{attempt: attempt, status: status, nextAttemptAt: nextAttemptAt})
+	return nil
+}
+
+// roundTripFunc подменяет транспорт клиента worker'а
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	secret := []byte("whsec")
+	body := []byte(`{"type":"deposit"}`)
+	now := time.Now()
+
+	header := Sign(secret, now, body)
+
+	if !Verify(secret, header, body, now, time.Minute) {
+		t.Errorf("Verify() must accept own signature")
+	}
+	if Verify([]byte("other"), header, body, now, time.Minute) {
+		t.Errorf("Verify() must reject wrong secret")
+	}
+	if Verify(secret, header, []byte(`{"type":"withdraw"}`), now, time.Minute) {
+		t.Errorf("Verify() must reject tampered body")
+	}
+	if Verify(secret, header, body, now.Add(10*time.Minute), time.Minute) {
+		t.Errorf("Verify() must reject stale signature")
+	}
+}
+
+func TestWorker_DeliverOnce(t *testing.T) {
+	secret := "whsec"
+	var gotSignature, gotBody string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(SignatureHeader)
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	repo := &MockWebhookRepository{
+		deliveries: []models.WebhookDelivery{
+			{ID: uuid.New(), URL: ok.URL, Secret: secret, Payload: []byte(`{"type":"deposit"}`)},
+			{ID: uuid.New(), URL: failing.URL, Secret: secret, Payload: []byte(`{}`), Attempts: 1},
+			{ID: uuid.New(), URL: failing.URL, Secret: secret, Payload: []byte(`{}`), Attempts: 2},
+		},
+	}
+	worker := NewWorker(repo, WorkerConfig{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Hour,
+		Timeout:     time.Second,
+		ClaimLease:  time.Minute,
+	})
+	// Получатели слушают loopback: проверку адреса здесь заменяет обычный клиент, ее покрывают тесты netguard
+	worker.client = &http.Client{Timeout: time.Second}
+
+	processed, err := worker.DeliverOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverOnce() unexpected error = %v", err)
+	}
+	if processed != 3 || len(repo.attempts) != 3 || repo.lease != time.Minute {
+		t.Fatalf("DeliverOnce() processed = %d, recorded %d attempts, lease %v", processed, len(repo.attempts), repo.lease)
+	}
+
+	if !Verify([]byte(secret), gotSignature, []byte(gotBody), time.Now(), time.Minute) {
+		t.Errorf("receiver must be able to verify the signature")
+	}
+
+	expected := []string{models.WebhookDeliverySucceeded, models.WebhookDeliveryPending, models.WebhookDeliveryFailed}
+	for i, want := range expected {
+		if repo.attempts[i].status != want {
+			t.Errorf("delivery %d status = %s, want %s", i, repo.attempts[i].status, want)
+		}
+	}
+
+	retry := repo.attempts[1]
+	if got := retry.nextAttemptAt.Sub(retry.attempt.AttemptedAt); got != 2*time.Second {
+		t.Errorf("retry backoff = %v, want %v", got, 2*time.Second)
+	}
+	if !retry.attempt.ResponseStatus.Valid || retry.attempt.ResponseStatus.Int64 != http.StatusInternalServerError {
+		t.Errorf("attempt must record response status, got %+v", retry.attempt.ResponseStatus)
+	}
+}
+
+func TestWorker_RefusesPrivateAddress(t *testing.T) {
+	var called bool
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer internal.Close()
+
+	repo := &MockWebhookRepository{
+		deliveries: []models.WebhookDelivery{{ID: uuid.New(), URL: internal.URL, Secret: "whsec", Payload: []byte(`{}`)}},
+	}
+	worker := NewWorker(repo, WorkerConfig{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Hour,
+		Timeout:     time.Second,
+		ClaimLease:  time.Minute,
+	})
+
+	if _, err := worker.DeliverOnce(context.Background()); err != nil {
+		t.Fatalf("DeliverOnce() unexpected error = %v", err)
+	}
+	if called {
+		t.Errorf("delivery must not reach a loopback address")
+	}
+	if attempt := repo.attempts[0]; attempt.status != models.WebhookDeliveryPending || !strings.Contains(attempt.attempt.Error, "not publicly routable") {
+		t.Errorf("attempt = %+v, want pending with forbidden address error", attempt)
+	}
+}
+
+func TestWorker_StopsBeforeLeaseExpires(t *testing.T) {
+	repo := &MockWebhookRepository{}
+	for i := 0; i < 3; i++ {
+		repo.deliveries = append(repo.deliveries, models.WebhookDelivery{ID: uuid.New(), URL: "https://example.com/hook", Secret: "whsec", Payload: []byte(`{}`)})
+	}
+	worker := NewWorker(repo, WorkerConfig{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Hour,
+		Timeout:     time.Second,
+		ClaimLease:  3 * time.Second,
+	})
+
+	// Каждая отправка занимает 1.5s: третья могла бы закончиться после аренды и не начинается
+	clock := time.Date(2025, 11, 20, 12, 0, 0, 0, time.UTC)
+	worker.now = func() time.Time { return clock }
+	worker.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		clock = clock.Add(1500 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})}
+
+	processed, err := worker.DeliverOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverOnce() unexpected error = %v", err)
+	}
+	if processed != 2 || len(repo.attempts) != 2 {
+		t.Errorf("DeliverOnce() processed = %d, recorded %d attempts, want 2", processed, len(repo.attempts))
+	}
+}
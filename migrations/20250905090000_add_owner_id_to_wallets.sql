@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets ADD COLUMN owner_id TEXT;
+
+CREATE INDEX idx_wallets_owner_id ON wallets(owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_wallets_owner_id;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS owner_id;
+-- +goose StatementEnd
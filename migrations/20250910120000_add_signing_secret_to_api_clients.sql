@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE api_clients ADD COLUMN signing_secret TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_clients DROP COLUMN IF EXISTS signing_secret;
+-- +goose StatementEnd
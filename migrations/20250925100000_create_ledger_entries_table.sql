@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    entry_type TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ledger_entries_wallet ON ledger_entries(wallet_id, id);
+
+-- Текущие балансы переносятся в журнал одной проводкой, чтобы история сходилась с wallets.balance
+INSERT INTO ledger_entries (wallet_id, entry_type, amount, balance_after, created_at)
+SELECT id, 'OPENING_BALANCE', balance, balance, updated_at
+FROM wallets
+WHERE balance <> 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_entries;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets ADD COLUMN status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'FROZEN'));
+ALTER TABLE wallets ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- Индексы под keyset пагинацию GET /api/v1/wallets: сортировка всегда идет по паре (поле, id)
+CREATE INDEX idx_wallets_created_at_id ON wallets(created_at, id);
+CREATE INDEX idx_wallets_updated_at_id ON wallets(updated_at, id);
+CREATE INDEX idx_wallets_balance_id ON wallets(balance, id);
+CREATE INDEX idx_wallets_status ON wallets(status);
+CREATE INDEX idx_wallets_metadata ON wallets USING GIN (metadata jsonb_path_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_wallets_metadata;
+DROP INDEX IF EXISTS idx_wallets_status;
+DROP INDEX IF EXISTS idx_wallets_balance_id;
+DROP INDEX IF EXISTS idx_wallets_updated_at_id;
+DROP INDEX IF EXISTS idx_wallets_created_at_id;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS metadata;
+ALTER TABLE wallets DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
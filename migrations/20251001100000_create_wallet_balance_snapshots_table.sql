@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Снимок фиксирует баланс кошелька после проводки ledger_entry_id; taken_at совпадает с временем этой проводки.
+-- Баланс на момент времени считается от ближайшего снимка, поэтому журнал не нужно проигрывать с начала
+CREATE TABLE wallet_balance_snapshots (
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    ledger_entry_id BIGINT NOT NULL REFERENCES ledger_entries(id),
+    balance BIGINT NOT NULL,
+    taken_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (wallet_id, ledger_entry_id)
+);
+
+CREATE INDEX idx_wallet_balance_snapshots_taken_at ON wallet_balance_snapshots(wallet_id, taken_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS wallet_balance_snapshots;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE import_jobs (
+    id UUID PRIMARY KEY,
+    client_id UUID REFERENCES api_clients(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed')),
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    total_rows INT NOT NULL,
+    processed_rows INT NOT NULL DEFAULT 0,
+    succeeded_rows INT NOT NULL DEFAULT 0,
+    failed_rows INT NOT NULL DEFAULT 0,
+    duplicate_rows INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    -- heartbeat_at обновляется при обработке строк; задание с устаревшим heartbeat забирает другой обработчик
+    heartbeat_at TIMESTAMP
+);
+
+CREATE INDEX idx_import_jobs_queue ON import_jobs(created_at) WHERE status <> 'completed';
+
+CREATE TABLE import_rows (
+    job_id UUID NOT NULL REFERENCES import_jobs(id) ON DELETE CASCADE,
+    row_number INT NOT NULL,
+    wallet_id UUID NOT NULL,
+    operation_type TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    external_ref TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    balance_after BIGINT,
+    error TEXT,
+    PRIMARY KEY (job_id, row_number)
+);
+
+CREATE INDEX idx_import_rows_pending ON import_rows(job_id, row_number) WHERE status = 'pending';
+
+-- Внешний референс занимается в транзакции изменения баланса, поэтому операция с ним проводится не больше одного раза,
+-- в каком бы файле и сколько бы раз он ни встретился
+CREATE TABLE import_external_refs (
+    external_ref TEXT PRIMARY KEY,
+    job_id UUID NOT NULL,
+    row_number INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS import_external_refs;
+DROP TABLE IF EXISTS import_rows;
+DROP TABLE IF EXISTS import_jobs;
+-- +goose StatementEnd
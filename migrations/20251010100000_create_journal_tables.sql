@@ -0,0 +1,72 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE system_accounts (
+    code TEXT PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+INSERT INTO system_accounts (code, description) VALUES
+    ('cash_in', 'Поступления извне: пополнения кошельков'),
+    ('cash_out', 'Выплаты вовне: списания с кошельков'),
+    ('fees', 'Комиссии'),
+    ('suspense', 'Остатки без известного источника: начальные балансы кошельков');
+
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY,
+    operation_type TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Проводка меняет ровно один счет: кошелек или системный счет. Сумма со знаком, плюс увеличивает остаток
+CREATE TABLE postings (
+    id BIGSERIAL PRIMARY KEY,
+    journal_id UUID NOT NULL REFERENCES journal_entries(id),
+    wallet_id UUID REFERENCES wallets(id),
+    system_account TEXT REFERENCES system_accounts(code),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    CHECK ((wallet_id IS NULL) <> (system_account IS NULL))
+);
+
+CREATE INDEX idx_postings_journal ON postings(journal_id);
+CREATE INDEX idx_postings_wallet ON postings(wallet_id) WHERE wallet_id IS NOT NULL;
+CREATE INDEX idx_postings_system_account ON postings(system_account) WHERE system_account IS NOT NULL;
+
+-- Проверка отложена до коммита: проводки одной записи журнала вставляются по одной,
+-- но транзакция зафиксируется, только если их сумма равна нулю
+CREATE FUNCTION check_journal_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM postings WHERE journal_id = NEW.journal_id) <> 0 THEN
+        RAISE EXCEPTION 'journal % is not balanced', NEW.journal_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_balanced();
+
+-- Текущие балансы переносятся в журнал против suspense, чтобы оборотная ведомость сошлась с wallets.balance
+CREATE TEMP TABLE opening_journals ON COMMIT DROP AS
+SELECT gen_random_uuid() AS journal_id, id AS wallet_id, balance
+FROM wallets
+WHERE balance <> 0;
+
+INSERT INTO journal_entries (id, operation_type)
+SELECT journal_id, 'OPENING_BALANCE' FROM opening_journals;
+
+INSERT INTO postings (journal_id, wallet_id, amount)
+SELECT journal_id, wallet_id, balance FROM opening_journals;
+
+INSERT INTO postings (journal_id, system_account, amount)
+SELECT journal_id, 'suspense', -balance FROM opening_journals;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS postings;
+DROP FUNCTION IF EXISTS check_journal_balanced();
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS system_accounts;
+-- +goose StatementEnd
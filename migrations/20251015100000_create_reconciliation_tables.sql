@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reconciliation_runs (
+    id UUID PRIMARY KEY,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed', 'failed')),
+    wallets_checked INT NOT NULL DEFAULT 0,
+    mismatches INT NOT NULL DEFAULT 0,
+    frozen_wallets INT NOT NULL DEFAULT 0,
+    -- last_wallet_id - курсор по кошелькам: сверка идет порциями в порядке id
+    last_wallet_id UUID,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP,
+    heartbeat_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Одновременно выполняется не больше одной сверки, даже если обработчик запущен на нескольких репликах
+CREATE UNIQUE INDEX idx_reconciliation_runs_running ON reconciliation_runs((TRUE)) WHERE status = 'running';
+CREATE INDEX idx_reconciliation_runs_started ON reconciliation_runs(started_at);
+
+CREATE TABLE reconciliation_mismatches (
+    run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    reason TEXT NOT NULL,
+    wallet_balance BIGINT NOT NULL,
+    ledger_balance BIGINT NOT NULL,
+    ledger_entries INT NOT NULL,
+    last_entry_id BIGINT,
+    last_balance_after BIGINT,
+    frozen BOOLEAN NOT NULL DEFAULT FALSE,
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (run_id, wallet_id, reason)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reconciliation_mismatches;
+DROP TABLE IF EXISTS reconciliation_runs;
+-- +goose StatementEnd
@@ -0,0 +1,94 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD COLUMN prev_hash BYTEA, ADD COLUMN hash BYTEA;
+
+-- Хэш проводки: SHA-256 от полей через "|" и hex хэша предыдущей проводки того же кошелька
+-- (пустая строка для первой). Формат должен совпадать с models.LedgerEntry.ComputeHash
+CREATE FUNCTION ledger_entry_hash(
+    entry_id BIGINT,
+    entry_wallet_id UUID,
+    entry_type TEXT,
+    entry_amount BIGINT,
+    entry_balance_after BIGINT,
+    entry_created_at TIMESTAMP,
+    entry_prev_hash BYTEA
+) RETURNS BYTEA AS $$
+    SELECT sha256(convert_to(concat_ws('|',
+        entry_wallet_id::text,
+        entry_id::text,
+        entry_type,
+        entry_amount::text,
+        entry_balance_after::text,
+        (EXTRACT(EPOCH FROM entry_created_at) * 1000000)::bigint::text,
+        COALESCE(encode(entry_prev_hash, 'hex'), '')
+    ), 'UTF8'))
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Существующие проводки связываются в цепочки в порядке id
+DO $$
+DECLARE
+    entry RECORD;
+    prev BYTEA;
+    prev_wallet UUID;
+BEGIN
+    FOR entry IN SELECT * FROM ledger_entries ORDER BY wallet_id, id LOOP
+        IF prev_wallet IS DISTINCT FROM entry.wallet_id THEN
+            prev := NULL;
+            prev_wallet := entry.wallet_id;
+        END IF;
+
+        UPDATE ledger_entries
+        SET prev_hash = prev,
+            hash = ledger_entry_hash(entry.id, entry.wallet_id, entry.entry_type, entry.amount, entry.balance_after, entry.created_at, prev)
+        WHERE id = entry.id
+        RETURNING hash INTO prev;
+    END LOOP;
+END;
+$$;
+
+ALTER TABLE ledger_entries ALTER COLUMN hash SET NOT NULL;
+
+-- Новые проводки кошелька вставляются под блокировкой его строки в wallets,
+-- поэтому последняя проводка кошелька не меняется до конца транзакции
+CREATE FUNCTION ledger_entries_chain() RETURNS TRIGGER AS $$
+BEGIN
+    SELECT hash INTO NEW.prev_hash
+    FROM ledger_entries
+    WHERE wallet_id = NEW.wallet_id
+    ORDER BY id DESC
+    LIMIT 1;
+
+    NEW.hash := ledger_entry_hash(NEW.id, NEW.wallet_id, NEW.entry_type, NEW.amount, NEW.balance_after, NEW.created_at, NEW.prev_hash);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_chain
+    BEFORE INSERT ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_chain();
+
+CREATE FUNCTION ledger_entries_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries is append-only: % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_immutable
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_immutable();
+
+CREATE TRIGGER ledger_entries_no_truncate
+    BEFORE TRUNCATE ON ledger_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_entries_immutable();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS ledger_entries_no_truncate ON ledger_entries;
+DROP TRIGGER IF EXISTS ledger_entries_immutable ON ledger_entries;
+DROP TRIGGER IF EXISTS ledger_entries_chain ON ledger_entries;
+DROP FUNCTION IF EXISTS ledger_entries_immutable();
+DROP FUNCTION IF EXISTS ledger_entries_chain();
+DROP FUNCTION IF EXISTS ledger_entry_hash(BIGINT, UUID, TEXT, BIGINT, BIGINT, TIMESTAMP, BYTEA);
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS hash, DROP COLUMN IF EXISTS prev_hash;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Лимиты в копейках, NULL - лимита нет. Суточные и месячные лимиты считаются по скользящему окну
+-- 24 часа и 30 дней по проводкам ledger_entries
+CREATE TABLE wallet_tiers (
+    code TEXT PRIMARY KEY,
+    description TEXT NOT NULL,
+    max_balance BIGINT CHECK (max_balance >= 0),
+    min_operation BIGINT CHECK (min_operation > 0),
+    max_operation BIGINT CHECK (max_operation > 0),
+    daily_deposit BIGINT CHECK (daily_deposit >= 0),
+    daily_withdrawal BIGINT CHECK (daily_withdrawal >= 0),
+    monthly_deposit BIGINT CHECK (monthly_deposit >= 0),
+    monthly_withdrawal BIGINT CHECK (monthly_withdrawal >= 0)
+);
+
+-- Значения anonymous и simplified соответствуют ограничениям 161-ФЗ для неидентифицированных
+-- и упрощенно идентифицированных клиентов; standard лимитов не имеет
+INSERT INTO wallet_tiers (code, description, max_balance, monthly_withdrawal) VALUES
+    ('standard', 'Без ограничений', NULL, NULL),
+    ('anonymous', 'Неидентифицированный клиент', 1500000, 4000000),
+    ('simplified', 'Упрощенная идентификация', 6000000, 20000000);
+
+ALTER TABLE wallets ADD COLUMN tier TEXT NOT NULL DEFAULT 'standard' REFERENCES wallet_tiers(code);
+
+-- Лимиты конкретного кошелька: заполненные колонки заменяют значения его уровня
+CREATE TABLE wallet_limits (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets(id) ON DELETE CASCADE,
+    max_balance BIGINT CHECK (max_balance >= 0),
+    min_operation BIGINT CHECK (min_operation > 0),
+    max_operation BIGINT CHECK (max_operation > 0),
+    daily_deposit BIGINT CHECK (daily_deposit >= 0),
+    daily_withdrawal BIGINT CHECK (daily_withdrawal >= 0),
+    monthly_deposit BIGINT CHECK (monthly_deposit >= 0),
+    monthly_withdrawal BIGINT CHECK (monthly_withdrawal >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Обороты за окно лимита читаются по кошельку и времени проводки
+CREATE INDEX idx_ledger_entries_wallet_created ON ledger_entries(wallet_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_ledger_entries_wallet_created;
+DROP TABLE IF EXISTS wallet_limits;
+ALTER TABLE wallets DROP COLUMN IF EXISTS tier;
+DROP TABLE IF EXISTS wallet_tiers;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Правила комиссий. tier NULL - правило для всех уровней, правило уровня кошелька важнее.
+-- Суммы в копейках, ставки в базисных пунктах (1 б.п. = 0,01%)
+CREATE TABLE fee_rules (
+    id BIGSERIAL PRIMARY KEY,
+    operation_type TEXT NOT NULL CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER')),
+    tier TEXT REFERENCES wallet_tiers(code),
+    kind TEXT NOT NULL CHECK (kind IN ('flat', 'percent', 'tiered')),
+    flat_amount BIGINT NOT NULL DEFAULT 0 CHECK (flat_amount >= 0),
+    rate_bp BIGINT NOT NULL DEFAULT 0 CHECK (rate_bp BETWEEN 0 AND 10000),
+    -- Ступени tiered: [{"from": копейки, "flat": копейки, "rateBp": б.п.}] в порядке возрастания from
+    brackets JSONB NOT NULL DEFAULT '[]',
+    min_fee BIGINT CHECK (min_fee >= 0),
+    max_fee BIGINT CHECK (max_fee >= 0),
+    rounding TEXT NOT NULL DEFAULT 'half_up' CHECK (rounding IN ('half_up', 'up', 'down')),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_fee_rules_operation_tier ON fee_rules(operation_type, COALESCE(tier, ''));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS fee_rules;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Кредитная линия кошелька в копейках: баланс может уйти в минус не больше чем на overdraft_limit.
+-- Граница проверяется в условии UPDATE списаний, а не CHECK на таблице: кошелек, ушедший в минус
+-- до появления линии, должен оставаться доступным для пополнения
+ALTER TABLE wallets ADD COLUMN overdraft_limit BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallets DROP COLUMN IF EXISTS overdraft_limit;
+-- +goose StatementEnd
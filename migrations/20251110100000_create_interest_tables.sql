@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO system_accounts (code, description) VALUES
+    ('interest', 'Проценты, выплаченные на остатки кошельков');
+
+-- День попадает сюда в той же транзакции, что и его начисления: после простоя планировщик
+-- досчитывает дни, начиная со следующего после последнего завершенного
+CREATE TABLE interest_accrual_days (
+    accrual_date DATE PRIMARY KEY,
+    rate_bp BIGINT NOT NULL CHECK (rate_bp >= 0),
+    wallets INT NOT NULL,
+    completed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Проценты за день на положительный остаток кошелька на конец дня (UTC). Сумма за день не округляется:
+-- при выплате точные доли за месяц складываются и округляются один раз
+CREATE TABLE interest_accruals (
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    accrual_date DATE NOT NULL,
+    balance BIGINT NOT NULL CHECK (balance > 0),
+    rate_bp BIGINT NOT NULL CHECK (rate_bp > 0),
+    days_in_year INT NOT NULL CHECK (days_in_year IN (365, 366)),
+    posted_at TIMESTAMP,
+    PRIMARY KEY (wallet_id, accrual_date)
+);
+
+CREATE INDEX idx_interest_accruals_unposted ON interest_accruals(accrual_date, wallet_id) WHERE posted_at IS NULL;
+
+-- Выплата за месяц: первичный ключ не дает выплатить один месяц дважды.
+-- ledger_entry_id пуст, если проценты за месяц округлились до нуля
+CREATE TABLE interest_postings (
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    period DATE NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    days INT NOT NULL,
+    ledger_entry_id BIGINT REFERENCES ledger_entries(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wallet_id, period)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS interest_postings;
+DROP TABLE IF EXISTS interest_accruals;
+DROP TABLE IF EXISTS interest_accrual_days;
+DELETE FROM system_accounts WHERE code = 'interest';
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Расписание перевода: cron (UTC) или interval_seconds, без них - однократный запуск в start_at.
+-- next_run_at - плановое время текущего запуска, retry_at - время повтора его неудавшейся попытки
+CREATE TABLE scheduled_transfers (
+    id UUID PRIMARY KEY,
+    client_id UUID REFERENCES api_clients(id) ON DELETE SET NULL,
+    from_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    to_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    cron TEXT,
+    interval_seconds BIGINT CHECK (interval_seconds >= 60),
+    start_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP,
+    max_retries INT NOT NULL DEFAULT 0 CHECK (max_retries >= 0),
+    retry_interval_seconds BIGINT NOT NULL DEFAULT 0 CHECK (retry_interval_seconds >= 0),
+    status TEXT NOT NULL CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+    next_run_at TIMESTAMP,
+    retry_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (from_wallet_id <> to_wallet_id),
+    CHECK (cron IS NULL OR interval_seconds IS NULL)
+);
+
+CREATE INDEX idx_scheduled_transfers_due ON scheduled_transfers ((COALESCE(retry_at, next_run_at))) WHERE status = 'active';
+CREATE INDEX idx_scheduled_transfers_client ON scheduled_transfers(client_id);
+
+-- Итог запуска: первичный ключ (расписание, плановое время) - ключ идемпотентности,
+-- успешный запуск не проводится повторно
+CREATE TABLE scheduled_transfer_runs (
+    schedule_id UUID NOT NULL REFERENCES scheduled_transfers(id) ON DELETE CASCADE,
+    occurrence_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('succeeded', 'retrying', 'failed')),
+    attempts INT NOT NULL,
+    failure_reason TEXT,
+    executed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (schedule_id, occurrence_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scheduled_transfer_runs;
+DROP TABLE IF EXISTS scheduled_transfers;
+-- +goose StatementEnd
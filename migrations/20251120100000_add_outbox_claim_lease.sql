@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Аренда события dispatcher'ом: строка помечается claimed_until и транзакция фиксируется до отправки,
+-- поэтому медленный получатель не держит блокировки и соединение. После истечения аренды событие
+-- забирает другой dispatcher
+ALTER TABLE outbox ADD COLUMN claimed_until TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox DROP COLUMN IF EXISTS claimed_until;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Новый кошелек относится к неидентифицированному клиенту и сразу получает лимиты anonymous.
+-- Уровень существующих кошельков не меняется: их пересмотр - решение администратора
+ALTER TABLE wallets ALTER COLUMN tier SET DEFAULT 'anonymous';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallets ALTER COLUMN tier SET DEFAULT 'standard';
+-- +goose StatementEnd
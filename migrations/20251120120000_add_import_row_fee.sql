@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Комиссия, удержанная (или для пробного прогона - ожидаемая) по строке импорта
+ALTER TABLE import_rows ADD COLUMN fee BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE import_rows DROP COLUMN IF EXISTS fee;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Аренда доставки worker'ом: строка помечается claimed_until и транзакция фиксируется до отправки,
+-- поэтому медленный подписчик не держит блокировки и соединение. После истечения аренды доставку
+-- забирает другой worker
+ALTER TABLE webhook_deliveries ADD COLUMN claimed_until TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS claimed_until;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Владелец (subject из JWT), создавший расписание в режиме AUTH_MODE=jwt. Перед каждым запуском
+-- проверяется, что кошелек-источник по-прежнему принадлежит ему
+ALTER TABLE scheduled_transfers ADD COLUMN owner_id TEXT;
+
+CREATE INDEX idx_scheduled_transfers_owner ON scheduled_transfers(owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_scheduled_transfers_owner;
+ALTER TABLE scheduled_transfers DROP COLUMN IF EXISTS owner_id;
+-- +goose StatementEnd
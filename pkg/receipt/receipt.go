@@ -0,0 +1,270 @@
+// Package receipt подписывает и проверяет квитанции операций кошелька.
+//
+// Квитанция подписывается Ed25519, публичные ключи публикуются в формате JWKS
+// по адресу /.well-known/wallet-receipt-keys. Получатель платежа проверяет квитанцию
+// без обращения к API:
+//
+//	keys, err := receipt.ParseKeySet(jwks)
+//	...
+//	if err := keys.Verify(&r); err != nil {
+//		// квитанция подделана или подписана неизвестным ключом
+//	}
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Version - версия формата подписываемой строки, входит в подпись
+const Version = "wallet-receipt-v2"
+
+// versionV1 - прежний формат без комиссии. Квитанции без поля version подписаны им и проверяются по-прежнему
+const versionV1 = "wallet-receipt-v1"
+
+const (
+	jwkKeyType   = "OKP"
+	jwkCurve     = "Ed25519"
+	jwkAlgorithm = "EdDSA"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown receipt key")
+	ErrInvalidSignature = errors.New("invalid receipt signature")
+)
+
+// Receipt - подписанная квитанция об операции над кошельком.
+// Суммы передаются строками в рублях, как в ответах API
+type Receipt struct {
+	// Version - формат подписываемой строки; заполняется при подписи, пустое значение означает wallet-receipt-v1
+	Version       string    `json:"version,omitempty"`
+	TransactionID uuid.UUID `json:"transactionId"`
+	WalletID      uuid.UUID `json:"walletId"`
+	OperationType string    `json:"operationType"`
+	Amount        string    `json:"amount"`
+	// Fee - комиссия операции, Net - сумма после ее вычета (см. models.Fee)
+	Fee string `json:"fee,omitempty"`
+	Net string `json:"net,omitempty"`
+	// BalanceAfter - баланс кошелька после операции и комиссии
+	BalanceAfter string    `json:"balanceAfter"`
+	Timestamp    time.Time `json:"timestamp"`
+	KeyID        string    `json:"keyId"`
+	// Signature - подпись Ed25519 строки Payload в base64url без выравнивания
+	Signature string `json:"signature"`
+}
+
+// Payload возвращает подписываемую строку: версия формата и поля квитанции через "|",
+// время - в UTC в формате RFC 3339 с долями секунды
+func (r *Receipt) Payload() []byte {
+	timestamp := r.Timestamp.UTC().Format(time.RFC3339Nano)
+	if r.Version == "" || r.Version == versionV1 {
+		return []byte(strings.Join([]string{
+			versionV1,
+			r.KeyID,
+			r.TransactionID.String(),
+			r.WalletID.String(),
+			r.OperationType,
+			r.Amount,
+			r.BalanceAfter,
+			timestamp,
+		}, "|"))
+	}
+
+	return []byte(strings.Join([]string{
+		r.Version,
+		r.KeyID,
+		r.TransactionID.String(),
+		r.WalletID.String(),
+		r.OperationType,
+		r.Amount,
+		r.Fee,
+		r.Net,
+		r.BalanceAfter,
+		timestamp,
+	}, "|"))
+}
+
+// KeyID возвращает идентификатор ключа: первые 8 байт SHA-256 публичного ключа в hex
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Signer подписывает квитанции закрытым ключом
+type Signer struct {
+	keyID string
+	key   ed25519.PrivateKey
+}
+
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{keyID: KeyID(key.Public().(ed25519.PublicKey)), key: key}
+}
+
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign заполняет Version, KeyID и Signature квитанции
+func (s *Signer) Sign(r *Receipt) {
+	r.Version = Version
+	r.KeyID = s.keyID
+	r.Signature = base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.key, r.Payload()))
+}
+
+// JWK - публичный ключ Ed25519 в формате RFC 8037
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	X   string `json:"x"`
+}
+
+// KeySet хранит публичные ключи проверки квитанций, индексированные по kid
+type KeySet struct {
+	keys map[string]ed25519.PublicKey
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{keys: make(map[string]ed25519.PublicKey)}
+}
+
+func (ks *KeySet) Len() int {
+	return len(ks.keys)
+}
+
+// Add добавляет ключ под идентификатором KeyID(key)
+func (ks *KeySet) Add(key ed25519.PublicKey) {
+	ks.keys[KeyID(key)] = key
+}
+
+// ParseKeySet читает JWKS документ, опубликованный сервисом; ключи других типов пропускаются
+func ParseKeySet(data []byte) (*KeySet, error) {
+	var doc struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse receipt keys: %w", err)
+	}
+
+	ks := NewKeySet()
+	for _, k := range doc.Keys {
+		if k.Kty != jwkKeyType || k.Crv != jwkCurve {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("parse receipt key %s: invalid public key", k.Kid)
+		}
+		ks.keys[k.Kid] = ed25519.PublicKey(x)
+	}
+
+	return ks, nil
+}
+
+// MarshalJSON возвращает ключи в формате JWKS
+func (ks *KeySet) MarshalJSON() ([]byte, error) {
+	doc := struct {
+		Keys []JWK `json:"keys"`
+	}{Keys: make([]JWK, 0, len(ks.keys))}
+
+	for kid, key := range ks.keys {
+		doc.Keys = append(doc.Keys, JWK{
+			Kty: jwkKeyType,
+			Crv: jwkCurve,
+			Kid: kid,
+			Alg: jwkAlgorithm,
+			Use: "sig",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		})
+	}
+	// Порядок ключей не зависит от обхода map, чтобы ответ можно было кэшировать
+	sort.Slice(doc.Keys, func(i, j int) bool { return doc.Keys[i].Kid < doc.Keys[j].Kid })
+
+	return json.Marshal(doc)
+}
+
+// Verify проверяет подпись квитанции ключом с ее KeyID
+func (ks *KeySet) Verify(r *Receipt) error {
+	key, ok := ks.keys[r.KeyID]
+	if !ok {
+		return fmt.Errorf("verify receipt %s: %w", r.KeyID, ErrUnknownKey)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(r.Signature)
+	if err != nil || !ed25519.Verify(key, r.Payload(), signature) {
+		return fmt.Errorf("verify receipt %s: %w", r.TransactionID, ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// LoadPrivateKey читает закрытый ключ Ed25519 в формате PEM (PKCS #8), например из
+// openssl genpkey -algorithm ed25519
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse receipt private key: %w", err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("parse receipt private key: unsupported key type %T", key)
+	}
+
+	return private, nil
+}
+
+// LoadPublicKey читает публичный ключ Ed25519 в формате PEM (PKIX)
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse receipt public key: %w", err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("parse receipt public key: unsupported key type %T", key)
+	}
+
+	return public, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read receipt key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("read receipt key %s: no pem block", path)
+	}
+	return block, nil
+}
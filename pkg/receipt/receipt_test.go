@@ -0,0 +1,172 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestReceipt() *Receipt {
+	return &Receipt{
+		TransactionID: uuid.New(),
+		WalletID:      uuid.New(),
+		OperationType: "DEPOSIT",
+		Amount:        "10.50",
+		Fee:           "0.50",
+		Net:           "10.00",
+		BalanceAfter:  "110.00",
+		Timestamp:     time.Date(2025, 10, 25, 12, 30, 0, 123456000, time.UTC),
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewSigner(private)
+	if signer.KeyID() != KeyID(public) {
+		t.Fatalf("KeyID() = %s, want %s", signer.KeyID(), KeyID(public))
+	}
+
+	r := newTestReceipt()
+	signer.Sign(r)
+
+	// Ключи и квитанция проходят через JSON, как у получателя платежа
+	published := NewKeySet()
+	published.Add(public)
+	jwks, err := json.Marshal(published)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseKeySet(jwks)
+	if err != nil {
+		t.Fatalf("ParseKeySet() error = %v", err)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received Receipt
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keys.Verify(&received); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(r *Receipt)
+		want   error
+	}{
+		{"amount", func(r *Receipt) { r.Amount = "1000.50" }, ErrInvalidSignature},
+		{"fee", func(r *Receipt) { r.Fee = "0.00" }, ErrInvalidSignature},
+		{"net", func(r *Receipt) { r.Net = "10.50" }, ErrInvalidSignature},
+		{"balance", func(r *Receipt) { r.BalanceAfter = "110.50" }, ErrInvalidSignature},
+		{"version", func(r *Receipt) { r.Version = "" }, ErrInvalidSignature},
+		{"wallet", func(r *Receipt) { r.WalletID = uuid.New() }, ErrInvalidSignature},
+		{"timestamp", func(r *Receipt) { r.Timestamp = r.Timestamp.Add(time.Microsecond) }, ErrInvalidSignature},
+		{"signature", func(r *Receipt) { r.Signature = "AAAA" }, ErrInvalidSignature},
+		{"unknown key", func(r *Receipt) { r.KeyID = "0000000000000000" }, ErrUnknownKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := received
+			tt.tamper(&tampered)
+			if err := keys.Verify(&tampered); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify_V1(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(nil)
+	signer := NewSigner(private)
+	keys := NewKeySet()
+	keys.Add(signer.PublicKey())
+
+	// Квитанция, выданная до появления комиссии: без version, fee и net
+	data := `{"transactionId":"` + uuid.NewString() + `","walletId":"` + uuid.NewString() + `",` +
+		`"operationType":"DEPOSIT","amount":"10.50","balanceAfter":"110.50",` +
+		`"timestamp":"2025-10-25T12:30:00.123456Z","keyId":"` + signer.KeyID() + `"}`
+	var r Receipt
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.Join([]string{
+		"wallet-receipt-v1", r.KeyID, r.TransactionID.String(), r.WalletID.String(),
+		"DEPOSIT", "10.50", "110.50", "2025-10-25T12:30:00.123456Z",
+	}, "|")
+	r.Signature = base64.RawURLEncoding.EncodeToString(ed25519.Sign(private, []byte(payload)))
+
+	if err := keys.Verify(&r); err != nil {
+		t.Errorf("Verify() of v1 receipt error = %v", err)
+	}
+}
+
+func TestVerify_TimestampZone(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(nil)
+	signer := NewSigner(private)
+	keys := NewKeySet()
+	keys.Add(signer.PublicKey())
+
+	r := newTestReceipt()
+	signer.Sign(r)
+
+	// Тот же момент в другой зоне - та же квитанция
+	r.Timestamp = r.Timestamp.In(time.FixedZone("MSK", 3*60*60))
+	if err := keys.Verify(r); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	dir := t.TempDir()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePath := filepath.Join(dir, "receipt.pem")
+	publicPath := filepath.Join(dir, "receipt.pub.pem")
+	os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600)
+	os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o644)
+
+	loaded, err := LoadPrivateKey(privatePath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() error = %v", err)
+	}
+	if !loaded.Equal(private) {
+		t.Error("LoadPrivateKey() returned a different key")
+	}
+
+	loadedPublic, err := LoadPublicKey(publicPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey() error = %v", err)
+	}
+	if !loadedPublic.Equal(public) {
+		t.Error("LoadPublicKey() returned a different key")
+	}
+
+	if _, err := LoadPublicKey(privatePath); err == nil {
+		t.Error("LoadPublicKey() of a private key succeeded")
+	}
+}
@@ -10,8 +10,25 @@ import (
 
 const (
 	roundHalfUpDivisor = 2
+	basisPointsScale   = 10000
 )
 
+// Rounding - способ округления до копейки при умножении суммы на ставку
+type Rounding string
+
+const (
+	// RoundHalfUp округляет половину копейки от нуля
+	RoundHalfUp Rounding = "half_up"
+	// RoundUp округляет любую долю копейки от нуля
+	RoundUp Rounding = "up"
+	// RoundDown отбрасывает доли копейки
+	RoundDown Rounding = "down"
+)
+
+func IsValidRounding(rounding Rounding) bool {
+	return rounding == RoundHalfUp || rounding == RoundUp || rounding == RoundDown
+}
+
 type Money struct {
 	Raw int64
 }
@@ -48,6 +65,48 @@ func (m Money) Sub(other Money) Money {
 	return Money{Raw: m.Raw - other.Raw}
 }
 
+// Abs возвращает сумму без знака
+func (m Money) Abs() Money {
+	if m.Raw < 0 {
+		return Money{Raw: -m.Raw}
+	}
+	return m
+}
+
+// MulBasisPoints возвращает m * basisPoints / 10000 (1 б.п. = 0,01%), округленную до копейки по rounding
+func (m Money) MulBasisPoints(basisPoints int64, rounding Rounding) Money {
+	return m.MulFraction(basisPoints, basisPointsScale, rounding)
+}
+
+// MulFraction возвращает m * num / den, округленную до копейки по rounding.
+// Вычисление идет в big.Rat, поэтому большие суммы не переполняются до округления
+func (m Money) MulFraction(num, den int64, rounding Rounding) Money {
+	r := new(big.Rat).SetFrac(big.NewInt(num), big.NewInt(den))
+	return NewMoneyFromRat(r.Mul(r, new(big.Rat).SetInt64(m.Raw)), rounding)
+}
+
+// NewMoneyFromRat округляет дробное число копеек до целого по rounding.
+// Позволяет сложить несколько точных долей и округлить результат один раз
+func NewMoneyFromRat(kopecks *big.Rat, rounding Rounding) Money {
+	quo, rem := new(big.Int).QuoRem(kopecks.Num(), kopecks.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return Money{Raw: quo.Int64()}
+	}
+
+	awayFromZero := false
+	switch rounding {
+	case RoundUp:
+		awayFromZero = true
+	case RoundHalfUp:
+		twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(roundHalfUpDivisor))
+		awayFromZero = twice.Cmp(kopecks.Denom()) >= 0
+	}
+	if awayFromZero {
+		quo.Add(quo, big.NewInt(int64(kopecks.Sign())))
+	}
+	return Money{Raw: quo.Int64()}
+}
+
 func (m Money) IsNegative() bool {
 	return m.Raw < 0
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"math/big"
 	"testing"
 )
 
@@ -104,6 +105,26 @@ func TestMoney_Sub(t *testing.T) {
 	}
 }
 
+func TestMoney_Abs(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Money
+		want Money
+	}{
+		{"positive", Money{Raw: 1050}, Money{Raw: 1050}},
+		{"zero", Money{Raw: 0}, Money{Raw: 0}},
+		{"negative", Money{Raw: -1050}, Money{Raw: 1050}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Abs(); got.Raw != tt.want.Raw {
+				t.Errorf("Money.Abs() = %v, want %v", got.Raw, tt.want.Raw)
+			}
+		})
+	}
+}
+
 func TestMoney_IsNegative(t *testing.T) {
 	tests := []struct {
 		name string
@@ -182,3 +203,65 @@ func TestMoney_Value(t *testing.T) {
 		t.Errorf("Money.Value() = %v, want %v", value, int64(12345))
 	}
 }
+
+func TestMoney_MulBasisPoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		m           Money
+		basisPoints int64
+		rounding    Rounding
+		want        int64
+	}{
+		{"exact", Money{Raw: 10000}, 150, RoundHalfUp, 150},
+		{"half up rounds half away", Money{Raw: 50}, 100, RoundHalfUp, 1},
+		{"half up rounds below half down", Money{Raw: 49}, 100, RoundHalfUp, 0},
+		{"up rounds any fraction", Money{Raw: 1001}, 1, RoundUp, 1},
+		{"down drops fraction", Money{Raw: 19999}, 50, RoundDown, 99},
+		{"negative half up", Money{Raw: -50}, 100, RoundHalfUp, -1},
+		{"negative down", Money{Raw: -199}, 50, RoundDown, 0},
+		{"large amount", Money{Raw: 9000000000000000}, 25, RoundHalfUp, 22500000000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.MulBasisPoints(tt.basisPoints, tt.rounding); got.Raw != tt.want {
+				t.Errorf("Money.MulBasisPoints() = %v, want %v", got.Raw, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_MulFraction(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		num, den int64
+		rounding Rounding
+		want     int64
+	}{
+		{"exact", Money{Raw: 36500}, 1, 365, RoundHalfUp, 100},
+		{"half up", Money{Raw: 100000}, 1000, 10000 * 365, RoundHalfUp, 27},
+		{"down", Money{Raw: 100000}, 1000, 10000 * 365, RoundDown, 27},
+		{"up", Money{Raw: 100000}, 1000, 10000 * 365, RoundUp, 28},
+		{"negative half up", Money{Raw: -3}, 1, 2, RoundHalfUp, -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.MulFraction(tt.num, tt.den, tt.rounding); got.Raw != tt.want {
+				t.Errorf("Money.MulFraction() = %v, want %v", got.Raw, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMoneyFromRat_RoundsSumOnce(t *testing.T) {
+	// 30 дней по 0,4 копейки: по отдельности каждый день округлился бы до нуля
+	sum := new(big.Rat)
+	for i := 0; i < 30; i++ {
+		sum.Add(sum, big.NewRat(2, 5))
+	}
+	if got := NewMoneyFromRat(sum, RoundHalfUp); got.Raw != 12 {
+		t.Errorf("NewMoneyFromRat() = %v, want 12", got.Raw)
+	}
+}
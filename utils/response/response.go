@@ -0,0 +1,58 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"wallet-api/utils/requestid"
+)
+
+const (
+	CodeInternalError = "INTERNAL_ERROR"
+	CodeUnauthorized  = "UNAUTHORIZED"
+	CodeForbidden     = "FORBIDDEN"
+	CodeBadRequest    = "BAD_REQUEST"
+	CodeNotFound      = "NOT_FOUND"
+	CodeRateLimited   = "RATE_LIMITED"
+	CodeLimitExceeded = "LIMIT_EXCEEDED"
+)
+
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	// Details - подробности ошибки, формат зависит от кода
+	Details interface{} `json:"details,omitempty"`
+}
+
+type errorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError отдает ошибку в едином JSON формате, подставляя ID запроса из контекста
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	WriteJSON(w, status, errorResponse{
+		Error: ErrorBody{
+			Code:      code,
+			Message:   message,
+			RequestID: requestid.FromContext(r.Context()),
+		},
+	})
+}
+
+// WriteErrorDetails отдает ошибку как WriteError с подробностями в поле details
+func WriteErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details interface{}) {
+	WriteJSON(w, status, errorResponse{
+		Error: ErrorBody{
+			Code:      code,
+			Message:   message,
+			RequestID: requestid.FromContext(r.Context()),
+			Details:   details,
+		},
+	})
+}